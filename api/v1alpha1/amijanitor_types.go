@@ -0,0 +1,89 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AmiJanitorSpec defines the desired state of AmiJanitor
+type AmiJanitorSpec struct {
+	// Region of the amis, defaults to the region configured for the controller.
+	Region string `json:"region,omitempty"`
+	// Tags the amis must have to be cleaned up, e.g. built-by: packer.
+	Tags map[string]string `json:"tags,omitempty"`
+	// MinAge is the age an ami must have reached to be cleaned up, defaults to 90 days.
+	MinAge *metav1.Duration `json:"min_age,omitempty"`
+	// GracePeriod during which an ami is tagged as pending deletion before it is deregistered,
+	// amis which get used in the meantime are kept. Defaults to 7 days.
+	GracePeriod *metav1.Duration `json:"grace_period,omitempty"`
+	// DryRun only reports the amis which would be cleaned up.
+	DryRun bool `json:"dry_run,omitempty"`
+	// ScanInterval is the interval at which the amis are scanned, defaults to 24h.
+	ScanInterval *metav1.Duration `json:"scan_interval,omitempty"`
+}
+
+// AmiJanitorStatus defines the observed state of AmiJanitor
+type AmiJanitorStatus struct {
+	// State of the last scan, Completed or Failed.
+	State string `json:"state,omitempty"`
+	// Message describes why the last scan failed.
+	Message string `json:"message,omitempty"`
+	// ObservedGeneration is the generation of the spec the last scan is done for.
+	ObservedGeneration int64 `json:"observed_generation,omitempty"`
+	// LastScanTime is the time the amis were last scanned.
+	LastScanTime *metav1.Time `json:"last_scan_time,omitempty"`
+	// DeregisteredImages is the number of amis deregistered so far.
+	DeregisteredImages int32 `json:"deregistered_images,omitempty"`
+	// ReclaimedSizeGiB is the size of the snapshots deleted so far.
+	ReclaimedSizeGiB int64 `json:"reclaimed_size_gib,omitempty"`
+	// EstimatedMonthlySavings of the snapshots deleted so far in USD. Snapshots are incremental,
+	// so this is an upper bound.
+	EstimatedMonthlySavings string `json:"estimated_monthly_savings,omitempty"`
+	// Images are the unused amis found by the last scan and the amis it deregistered.
+	Images []StaleImage `json:"images,omitempty"`
+}
+
+// StaleImage is an unused ami and the action taken on it.
+type StaleImage struct {
+	// ImageID of the ami, e.g. ami-0123456789abcdef0.
+	ImageID string `json:"image_id"`
+	// Name of the ami.
+	Name string `json:"name,omitempty"`
+	// CreationDate of the ami.
+	CreationDate metav1.Time `json:"creation_date"`
+	// SizeGiB is the size of the ebs volumes of the ami.
+	SizeGiB int32 `json:"size_gib,omitempty"`
+	// SnapshotIDs of the snapshots backing the ami, deleted along with it.
+	SnapshotIDs []string `json:"snapshot_ids,omitempty"`
+	// Action taken in the last scan, None, MarkedForDeletion, PendingDeletion, Deleted or Kept.
+	// Dry runs report WouldDelete for the amis which would be deregistered.
+	Action string `json:"action"`
+	// DeletionTime is the time after which the ami is deregistered.
+	DeletionTime *metav1.Time `json:"deletion_time,omitempty"`
+	// Message is the error of the last action, if any.
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// AmiJanitor is the Schema for the amijanitors API
+type AmiJanitor struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AmiJanitorSpec   `json:"spec,omitempty"`
+	Status AmiJanitorStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// AmiJanitorList contains a list of AmiJanitor
+type AmiJanitorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AmiJanitor `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AmiJanitor{}, &AmiJanitorList{})
+}
@@ -0,0 +1,87 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScalingProcess is a scaling process of an auto scaling group.
+// +kubebuilder:validation:Enum=Launch;Terminate;AddToLoadBalancer;AlarmNotification;AZRebalance;HealthCheck;InstanceRefresh;ReplaceUnhealthy;ScheduledActions
+type ScalingProcess string
+
+// AsgCostOptimizerSpec defines the desired state of AsgCostOptimizer
+type AsgCostOptimizerSpec struct {
+	// AutoScalingGroupNames of the groups scaled in the time window.
+	// +kubebuilder:validation:MinItems=1
+	AutoScalingGroupNames []string `json:"auto_scaling_group_names"`
+	// Scheduled start time window, should be valid  start time, supported timezone is IST
+	StartTimeWindow string `json:"start_time_window"`
+	// Scheduled end time window, should be valid  end time, supported timezone is IST
+	EndTimeWindow string `json:"end_time_window"`
+	// Region of the groups, defaults to the region configured for the controller.
+	Region string `json:"region,omitempty"`
+	// Capacity of the groups within the time window, defaults to zero.
+	Capacity AutoScalingCapacity `json:"capacity,omitempty"`
+	// SuspendProcesses are the scaling processes suspended within the time window, e.g. HealthCheck.
+	SuspendProcesses []ScalingProcess `json:"suspend_processes,omitempty"`
+}
+
+// AutoScalingCapacity is the capacity of an auto scaling group.
+type AutoScalingCapacity struct {
+	// +kubebuilder:validation:Minimum=0
+	MinSize int32 `json:"min_size"`
+	// +kubebuilder:validation:Minimum=0
+	MaxSize int32 `json:"max_size"`
+	// +kubebuilder:validation:Minimum=0
+	DesiredCapacity int32 `json:"desired_capacity"`
+}
+
+// AsgCostOptimizerStatus defines the observed state of AsgCostOptimizer
+type AsgCostOptimizerStatus struct {
+	// State represents current state of operation, InTimeWindow or OutOfTimeWindow.
+	State string `json:"state,omitempty"`
+	// Groups is the status of the auto scaling groups.
+	Groups []AutoScalingGroupStatus `json:"groups,omitempty"`
+}
+
+// AutoScalingGroupStatus is the status of an auto scaling group.
+type AutoScalingGroupStatus struct {
+	// Name of the auto scaling group.
+	Name string `json:"name"`
+	// State of the group, ScaledDown or Restored.
+	State string `json:"state,omitempty"`
+	// SavedCapacity is the capacity of the group before it got scaled down, restored at the end
+	// of the time window.
+	SavedCapacity *AutoScalingCapacity `json:"saved_capacity,omitempty"`
+	// SuspendedProcesses are the processes suspended by the controller, processes suspended
+	// before the time window are left suspended.
+	SuspendedProcesses []string `json:"suspended_processes,omitempty"`
+	// Message is the error of the last operation, if any.
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the state last changed.
+	LastTransitionTime *metav1.Time `json:"last_transition_time,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// AsgCostOptimizer is the Schema for the asgcostoptimizers API
+type AsgCostOptimizer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AsgCostOptimizerSpec   `json:"spec,omitempty"`
+	Status AsgCostOptimizerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// AsgCostOptimizerList contains a list of AsgCostOptimizer
+type AsgCostOptimizerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AsgCostOptimizer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AsgCostOptimizer{}, &AsgCostOptimizerList{})
+}
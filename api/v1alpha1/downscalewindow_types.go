@@ -0,0 +1,101 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DownscaleWindowSpec defines the desired state of DownscaleWindow
+type DownscaleWindowSpec struct {
+	// ElastiCacheReplicationGroups are the replication groups whose replicas are reduced in the time window.
+	ElastiCacheReplicationGroups []ElastiCacheDownscale `json:"elasticache_replication_groups,omitempty"`
+	// OpenSearchDomains are the domains whose data nodes are reduced in the time window.
+	OpenSearchDomains []OpenSearchDownscale `json:"opensearch_domains,omitempty"`
+	// Scheduled start time window, should be valid  start time, supported timezone is IST
+	StartTimeWindow string `json:"start_time_window"`
+	// Scheduled end time window, should be valid  end time, supported timezone is IST
+	EndTimeWindow string `json:"end_time_window"`
+	// Region of the resources, defaults to the region configured for the controller.
+	Region string `json:"region,omitempty"`
+}
+
+// ElastiCacheDownscale is the reduced configuration of an elasticache replication group.
+type ElastiCacheDownscale struct {
+	// ReplicationGroupID of the replication group.
+	ReplicationGroupID string `json:"replication_group_id"`
+	// ReplicasPerNodeGroup within the time window, defaults to zero. Automatic failover
+	// requires at least one replica.
+	// +kubebuilder:validation:Minimum=0
+	ReplicasPerNodeGroup int32 `json:"replicas_per_node_group,omitempty"`
+}
+
+// OpenSearchDownscale is the reduced configuration of an opensearch domain, at least one of
+// the instance count and the instance type has to be set.
+type OpenSearchDownscale struct {
+	// DomainName of the domain.
+	DomainName string `json:"domain_name"`
+	// InstanceCount of data nodes within the time window.
+	// +kubebuilder:validation:Minimum=1
+	InstanceCount *int32 `json:"instance_count,omitempty"`
+	// InstanceType of the data nodes within the time window, e.g. t3.small.search.
+	InstanceType string `json:"instance_type,omitempty"`
+}
+
+// DownscaleWindowStatus defines the observed state of DownscaleWindow
+type DownscaleWindowStatus struct {
+	// State represents current state of operation, InTimeWindow or OutOfTimeWindow.
+	State string `json:"state,omitempty"`
+	// Resources is the status of the downscaled resources.
+	Resources []DownscaledResource `json:"resources,omitempty"`
+}
+
+// DownscaledResource is the status of a downscaled resource.
+type DownscaledResource struct {
+	// Kind of the resource, ElastiCacheReplicationGroup or OpenSearchDomain.
+	Kind string `json:"kind"`
+	// Name of the resource.
+	Name string `json:"name"`
+	// State of the resource, Modifying, ScaledDown, Restoring or Restored.
+	State string `json:"state,omitempty"`
+	// SavedConfig is the configuration of the resource before it got downscaled, restored at the
+	// end of the time window. Only the settings changed within the window are saved.
+	SavedConfig *DownscaleConfig `json:"saved_config,omitempty"`
+	// Message is the error of the last operation, if any.
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the state last changed.
+	LastTransitionTime *metav1.Time `json:"last_transition_time,omitempty"`
+}
+
+// DownscaleConfig is the scaling configuration of a resource, unset settings are not managed.
+type DownscaleConfig struct {
+	// ReplicasPerNodeGroup of an elasticache replication group.
+	ReplicasPerNodeGroup *int32 `json:"replicas_per_node_group,omitempty"`
+	// InstanceCount of data nodes of an opensearch domain.
+	InstanceCount *int32 `json:"instance_count,omitempty"`
+	// InstanceType of the data nodes of an opensearch domain.
+	InstanceType string `json:"instance_type,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// DownscaleWindow is the Schema for the downscalewindows API
+type DownscaleWindow struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DownscaleWindowSpec   `json:"spec,omitempty"`
+	Status DownscaleWindowStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DownscaleWindowList contains a list of DownscaleWindow
+type DownscaleWindowList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DownscaleWindow `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DownscaleWindow{}, &DownscaleWindowList{})
+}
@@ -0,0 +1,105 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DynamoDbBillingMode is the billing mode of a dynamodb table.
+// +kubebuilder:validation:Enum=PROVISIONED;PAY_PER_REQUEST
+type DynamoDbBillingMode string
+
+// DynamoDbCostOptimizerSpec defines the desired state of DynamoDbCostOptimizer
+type DynamoDbCostOptimizerSpec struct {
+	// Tables whose capacity is changed in the time window.
+	// +kubebuilder:validation:MinItems=1
+	Tables []DynamoDbTableSchedule `json:"tables"`
+	// Scheduled start time window, should be valid  start time, supported timezone is IST
+	StartTimeWindow string `json:"start_time_window"`
+	// Scheduled end time window, should be valid  end time, supported timezone is IST
+	EndTimeWindow string `json:"end_time_window"`
+	// Region of the tables, defaults to the region configured for the controller.
+	Region string `json:"region,omitempty"`
+}
+
+// DynamoDbTableSchedule is the capacity of a table within the time window.
+type DynamoDbTableSchedule struct {
+	// TableName of the table.
+	TableName string `json:"table_name"`
+	// Capacity of the table within the time window. The billing mode defaults to the current
+	// billing mode of the table. Switching to PAY_PER_REQUEST is allowed once per 24 hours by
+	// aws, the switch is delayed until it is allowed again. Indexes without a capacity keep their
+	// capacity, or get the capacity of the table when switching to PROVISIONED.
+	DynamoDbTableCapacity `json:",inline"`
+}
+
+// DynamoDbTableCapacity is the capacity configuration of a table and its global secondary indexes.
+type DynamoDbTableCapacity struct {
+	// BillingMode of the table, PROVISIONED or PAY_PER_REQUEST.
+	BillingMode DynamoDbBillingMode `json:"billing_mode,omitempty"`
+	// ReadCapacityUnits of the table in PROVISIONED mode.
+	// +kubebuilder:validation:Minimum=1
+	ReadCapacityUnits int64 `json:"read_capacity_units,omitempty"`
+	// WriteCapacityUnits of the table in PROVISIONED mode.
+	// +kubebuilder:validation:Minimum=1
+	WriteCapacityUnits int64 `json:"write_capacity_units,omitempty"`
+	// GlobalSecondaryIndexes are the capacities of the global secondary indexes in PROVISIONED mode.
+	GlobalSecondaryIndexes []DynamoDbIndexCapacity `json:"global_secondary_indexes,omitempty"`
+}
+
+// DynamoDbIndexCapacity is the provisioned capacity of a global secondary index.
+type DynamoDbIndexCapacity struct {
+	// IndexName of the index.
+	IndexName string `json:"index_name"`
+	// +kubebuilder:validation:Minimum=1
+	ReadCapacityUnits int64 `json:"read_capacity_units"`
+	// +kubebuilder:validation:Minimum=1
+	WriteCapacityUnits int64 `json:"write_capacity_units"`
+}
+
+// DynamoDbCostOptimizerStatus defines the observed state of DynamoDbCostOptimizer
+type DynamoDbCostOptimizerStatus struct {
+	// State represents current state of operation, InTimeWindow or OutOfTimeWindow.
+	State string `json:"state,omitempty"`
+	// Tables is the status of the tables.
+	Tables []DynamoDbTableStatus `json:"tables,omitempty"`
+}
+
+// DynamoDbTableStatus is the status of a dynamodb table.
+type DynamoDbTableStatus struct {
+	// TableName of the table.
+	TableName string `json:"table_name"`
+	// State of the table, Updating, ScaledDown, Restoring or Restored.
+	State string `json:"state,omitempty"`
+	// SavedCapacity is the capacity of the table before the time window, restored at the end of
+	// the time window.
+	SavedCapacity *DynamoDbTableCapacity `json:"saved_capacity,omitempty"`
+	// Message is the error of the last operation, or the reason the table is not updated yet.
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the state last changed.
+	LastTransitionTime *metav1.Time `json:"last_transition_time,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// DynamoDbCostOptimizer is the Schema for the dynamodbcostoptimizers API
+type DynamoDbCostOptimizer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DynamoDbCostOptimizerSpec   `json:"spec,omitempty"`
+	Status DynamoDbCostOptimizerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DynamoDbCostOptimizerList contains a list of DynamoDbCostOptimizer
+type DynamoDbCostOptimizerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DynamoDbCostOptimizer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DynamoDbCostOptimizer{}, &DynamoDbCostOptimizerList{})
+}
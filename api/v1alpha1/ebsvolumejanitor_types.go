@@ -0,0 +1,95 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EbsVolumeJanitorSpec defines the desired state of EbsVolumeJanitor
+type EbsVolumeJanitorSpec struct {
+	// Region of the volumes, defaults to the region configured for the controller.
+	Region string `json:"region,omitempty"`
+	// Tags the unattached volumes must have to be cleaned up, e.g. environment: dev.
+	Tags map[string]string `json:"tags,omitempty"`
+	// MinAge is the age a volume must have reached to be cleaned up, defaults to 7 days.
+	MinAge *metav1.Duration `json:"min_age,omitempty"`
+	// GracePeriod during which a volume is tagged as pending deletion before it is deleted,
+	// attaching the volume in the meantime keeps it. The grace period starts over if a scan
+	// was missed, as the volume may have been attached in between. Defaults to 7 days.
+	GracePeriod *metav1.Duration `json:"grace_period,omitempty"`
+	// Snapshot the volumes before deleting them.
+	Snapshot bool `json:"snapshot,omitempty"`
+	// DryRun only reports the volumes which would be cleaned up.
+	DryRun bool `json:"dry_run,omitempty"`
+	// ScanInterval is the interval at which the volumes are scanned, defaults to 1h.
+	ScanInterval *metav1.Duration `json:"scan_interval,omitempty"`
+}
+
+// EbsVolumeJanitorStatus defines the observed state of EbsVolumeJanitor
+type EbsVolumeJanitorStatus struct {
+	// State of the last scan, Completed or Failed.
+	State string `json:"state,omitempty"`
+	// Message describes why the last scan failed.
+	Message string `json:"message,omitempty"`
+	// ObservedGeneration is the generation of the spec the last scan is done for.
+	ObservedGeneration int64 `json:"observed_generation,omitempty"`
+	// LastScanTime is the time the volumes were last scanned.
+	LastScanTime *metav1.Time `json:"last_scan_time,omitempty"`
+	// TotalSizeGiB is the size of the unattached volumes found by the last scan.
+	TotalSizeGiB int64 `json:"total_size_gib,omitempty"`
+	// EstimatedMonthlyCost of the unattached volumes found by the last scan in USD.
+	EstimatedMonthlyCost string `json:"estimated_monthly_cost,omitempty"`
+	// DeletedVolumes is the number of volumes deleted so far.
+	DeletedVolumes int32 `json:"deleted_volumes,omitempty"`
+	// ReclaimedSizeGiB is the size of the volumes deleted so far.
+	ReclaimedSizeGiB int64 `json:"reclaimed_size_gib,omitempty"`
+	// Volumes are the unattached volumes found by the last scan and the volumes it deleted.
+	Volumes []UnattachedVolume `json:"volumes,omitempty"`
+}
+
+// UnattachedVolume is an unattached ebs volume and the action taken on it.
+type UnattachedVolume struct {
+	// VolumeID of the volume, e.g. vol-0123456789abcdef0.
+	VolumeID string `json:"volume_id"`
+	// SizeGiB of the volume.
+	SizeGiB int32 `json:"size_gib"`
+	// VolumeType of the volume, e.g. gp3.
+	VolumeType string `json:"volume_type,omitempty"`
+	// CreateTime of the volume.
+	CreateTime metav1.Time `json:"create_time"`
+	// EstimatedMonthlyCost of the volume in USD, empty if the price of the type is unknown.
+	EstimatedMonthlyCost string `json:"estimated_monthly_cost,omitempty"`
+	// Action taken in the last scan, None, MarkedForDeletion, PendingDeletion, Snapshotting,
+	// Deleted or Kept. Dry runs report WouldDelete for the volumes which would be deleted.
+	Action string `json:"action"`
+	// DeletionTime is the time after which the volume is deleted.
+	DeletionTime *metav1.Time `json:"deletion_time,omitempty"`
+	// SnapshotID of the snapshot taken before the deletion.
+	SnapshotID string `json:"snapshot_id,omitempty"`
+	// Message is the error of the last action, if any.
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// EbsVolumeJanitor is the Schema for the ebsvolumejanitors API
+type EbsVolumeJanitor struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EbsVolumeJanitorSpec   `json:"spec,omitempty"`
+	Status EbsVolumeJanitorStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EbsVolumeJanitorList contains a list of EbsVolumeJanitor
+type EbsVolumeJanitorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EbsVolumeJanitor `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EbsVolumeJanitor{}, &EbsVolumeJanitorList{})
+}
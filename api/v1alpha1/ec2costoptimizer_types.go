@@ -14,7 +14,7 @@ const (
 )
 
 // Ec2OperationWindowType allows controller to perform operations in the given window.
-// +kubebuilder:validation:Enum=OnDemand;Scheduled
+// +kubebuilder:validation:Enum=OnDemand;Scheduled;Idle
 type Ec2OperationWindowType string
 
 const (
@@ -22,8 +22,15 @@ const (
 	OnDemand Ec2OperationWindowType = "OnDemand"
 	// Scheduled indicates that the operation has to be performed in given scheduled time.
 	Scheduled Ec2OperationWindowType = "Scheduled"
+	// Idle indicates that the instances have to be stopped once their cloudwatch metrics
+	// have been below the thresholds of the idle policy for its lookback period.
+	Idle Ec2OperationWindowType = "Idle"
 )
 
+// ConditionOperationSucceeded reports whether the last ec2 operation succeeded, the reason
+// of a failed operation is derived from the aws error code.
+const ConditionOperationSucceeded = "OperationSucceeded"
+
 // Ec2CostOptimizerSpec defines the desired state of Ec2CostOptimizer
 type Ec2CostOptimizerSpec struct {
 	// StopInstanceID on which start/stop operations has to be performed
@@ -31,20 +38,172 @@ type Ec2CostOptimizerSpec struct {
 	InstanceIDs []string `json:"instance_ids"`
 	// START/STOP operation
 	Operation Ec2OperationType `json:"operation"`
-	// OnDemand/Scheduled window
+	// OnDemand/Scheduled/Idle window
 	WindowType Ec2OperationWindowType `json:"window_type"`
 	// Scheduled start time window, should be valid  start time, supported timezone is IST
 	StartTimeWindow string `json:"start_time_window,omitempty"`
 	// Scheduled end time window, should be valid  end time, supported timezone is IST
 	EndTimeWindow string `json:"end_time_window,omitempty"`
+	// Region of the instances, defaults to the region configured for the controller.
+	Region string `json:"region,omitempty"`
+	// RetryPolicy for failed OnDemand operations, defaults are used if not specified.
+	RetryPolicy *RetryPolicy `json:"retry_policy,omitempty"`
+	// IdlePolicy configures when instances are considered idle for the Idle window, the
+	// operation has to be Stop. Defaults are used if not specified.
+	IdlePolicy *IdlePolicy `json:"idle_policy,omitempty"`
+	// Resize opts in to change the instance type of the instances while they are kept stopped
+	// by a Scheduled Stop. The resized instances which were running before the time window are
+	// started at its end, instances which were already stopped are kept stopped.
+	Resize *ResizePolicy `json:"resize,omitempty"`
+}
+
+// RetryPolicy configures how failed operations are retried, the delay between two attempts
+// starts with InitialBackoff and is doubled after every failed attempt up to MaxBackoff.
+type RetryPolicy struct {
+	// MaxAttempts after which the operation is marked as Failed, defaults to 5.
+	// +kubebuilder:validation:Minimum=1
+	MaxAttempts int32 `json:"max_attempts,omitempty"`
+	// InitialBackoff is the delay before the first retry, defaults to 30s.
+	InitialBackoff *metav1.Duration `json:"initial_backoff,omitempty"`
+	// MaxBackoff is the maximum delay between two attempts, defaults to 10m.
+	MaxBackoff *metav1.Duration `json:"max_backoff,omitempty"`
+}
+
+// OperationStatus is the status of the start/stop operation of an object, it is shared by the
+// objects operating their resources through the same reconciliation, e.g. Ec2CostOptimizer,
+// ResourceCostOptimizer and RdsCostOptimizer.
+type OperationStatus struct {
+	// State represents current state of operation, InProgress, Retrying, Failed, Completed,
+	// InTimeWindow, OutOfTimeWindow.
+	State string `json:"state,omitempty"`
+	// ObservedGeneration is the generation of the spec the status is computed for.
+	ObservedGeneration int64 `json:"observed_generation,omitempty"`
+	// Attempts is the number of failed attempts of the current operation.
+	Attempts int32 `json:"attempts,omitempty"`
+	// NextRetryTime is the time at which the failed operation is retried.
+	NextRetryTime *metav1.Time `json:"next_retry_time,omitempty"`
+}
+
+// IdlePolicy configures the thresholds below which an instance is considered idle, an instance
+// is idle if all its datapoints of the lookback period are below the thresholds.
+type IdlePolicy struct {
+	// MaxCPUUtilization is the cpu utilization in percent below which an instance is idle,
+	// defaults to 5.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	MaxCPUUtilization int32 `json:"max_cpu_utilization,omitempty"`
+	// MaxNetworkBytesPerHour is the network traffic, in and out, below which an instance is
+	// idle. The network traffic is not considered if not specified.
+	// +kubebuilder:validation:Minimum=0
+	MaxNetworkBytesPerHour int64 `json:"max_network_bytes_per_hour,omitempty"`
+	// LookbackPeriod for which the metrics have to be below the thresholds, defaults to 6h.
+	LookbackPeriod *metav1.Duration `json:"lookback_period,omitempty"`
+}
+
+// ResizePolicy configures the instance type the instances are resized to, either TargetInstanceType
+// or FromReport has to be specified.
+type ResizePolicy struct {
+	// TargetInstanceType all the instances are resized to, e.g. m5.large.
+	TargetInstanceType string `json:"target_instance_type,omitempty"`
+	// FromReport is the name of an Ec2RightsizingReport in the namespace of the object, the
+	// instances are resized to their first recommendation.
+	FromReport string `json:"from_report,omitempty"`
 }
 
 // Ec2CostOptimizerStatus defines the observed state of Ec2CostOptimizer
 type Ec2CostOptimizerStatus struct {
 	// InstanceID is unique identifier for aws-ec2 instance.
 	InstanceID string `json:"instance_id,omitempty"`
-	// Status represents current state of operation, InProgress, Failed, Completed.
-	State string `json:"state,omitempty"`
+	// OperationStatus is the status of the start/stop operation of the object.
+	OperationStatus `json:",inline"`
+	// SkippedInstances are the instances left untouched by the last operation because of
+	// instance specific errors.
+	SkippedInstances []SkippedInstance `json:"skipped_instances,omitempty"`
+	// InstanceUsage is the metric evidence of the last idle evaluation of the running instances.
+	InstanceUsage []InstanceUsage `json:"instance_usage,omitempty"`
+	// ResizedInstances are the instances resized during the time window.
+	ResizedInstances []ResizedInstance `json:"resized_instances,omitempty"`
+	// Savings are the estimated savings of the instances kept stopped by the controller.
+	Savings *Savings `json:"savings,omitempty"`
+	// Conditions represent the latest available observations of the operation.
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// SkippedInstance is an instance skipped by an operation.
+type SkippedInstance struct {
+	// InstanceID is unique identifier for aws-ec2 instance.
+	InstanceID string `json:"instance_id"`
+	// Reason why the instance was skipped, e.g. InstanceNotFound, IncorrectInstanceState.
+	Reason string `json:"reason"`
+	// Message is the error returned by aws.
+	Message string `json:"message,omitempty"`
+}
+
+// InstanceUsage is the usage of an instance over the lookback period of the idle policy.
+type InstanceUsage struct {
+	// InstanceID is unique identifier for aws-ec2 instance.
+	InstanceID string `json:"instance_id"`
+	// MaxCPUUtilization is the highest average cpu utilization in percent of the datapoints.
+	MaxCPUUtilization string `json:"max_cpu_utilization"`
+	// MaxNetworkBytesPerHour is the highest network traffic, in and out, of the datapoints.
+	MaxNetworkBytesPerHour int64 `json:"max_network_bytes_per_hour"`
+	// Datapoints is the number of cpu utilization datapoints found for the lookback period.
+	Datapoints int32 `json:"datapoints"`
+	// Idle reports whether the instance was found idle.
+	Idle bool `json:"idle"`
+	// EvaluationTime is the time the usage was evaluated.
+	EvaluationTime metav1.Time `json:"evaluation_time"`
+}
+
+// ResizedInstance is an instance resized during the time window.
+type ResizedInstance struct {
+	// InstanceID is unique identifier for aws-ec2 instance.
+	InstanceID string `json:"instance_id"`
+	// PreviousInstanceType is the instance type before the resize, used for the rollback.
+	PreviousInstanceType string `json:"previous_instance_type"`
+	// InstanceType the instance got resized to.
+	InstanceType string `json:"instance_type"`
+	// State of the resize, Resized, Starting, Started, RolledBack, Incompatible or Failed.
+	State string `json:"state"`
+	// Restart reports whether the instance was running before the object stopped it, only
+	// those instances are started at the end of the time window.
+	Restart bool `json:"restart,omitempty"`
+	// Message describes why the resize was rolled back or not done.
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the state last changed.
+	LastTransitionTime metav1.Time `json:"last_transition_time"`
+}
+
+// Savings are estimated from the time the instances were kept stopped, while they would
+// have been running otherwise, and their on-demand price.
+type Savings struct {
+	// EstimatedSavings is the cumulative estimated savings of all the instances in USD.
+	EstimatedSavings string `json:"estimated_savings"`
+	// LastUpdateTime is the time the savings were last accounted.
+	LastUpdateTime metav1.Time `json:"last_update_time"`
+	// Instances are the estimated savings per instance.
+	Instances []InstanceSavings `json:"instances,omitempty"`
+}
+
+// InstanceSavings are the estimated savings of a single instance.
+type InstanceSavings struct {
+	// InstanceID is unique identifier for aws-ec2 instance.
+	InstanceID string `json:"instance_id"`
+	// InstanceType of the instance, e.g. t3.medium.
+	InstanceType string `json:"instance_type,omitempty"`
+	// StoppedSeconds is the time the instance was kept stopped by the controller.
+	StoppedSeconds int64 `json:"stopped_seconds"`
+	// StoppedTime is the time the object stopped the instance. Only the instances stopped by
+	// the object are accounted, as long as they stay stopped.
+	StoppedTime *metav1.Time `json:"stopped_time,omitempty"`
+	// HourlyPrice is the on-demand price of the instance in USD, empty if the price is unknown.
+	HourlyPrice string `json:"hourly_price,omitempty"`
+	// EstimatedSavings of the instance in USD.
+	EstimatedSavings string `json:"estimated_savings"`
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,110 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Ec2InstanceSelector selects ec2 instances by their ids and/or tags, at least one of them has
+// to be specified. Instances have to match all the given tags.
+type Ec2InstanceSelector struct {
+	// InstanceIDs of the selected instances.
+	InstanceIDs []string `json:"instance_ids,omitempty"`
+	// Tags the selected instances have, e.g. team: payments.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// Ec2RightsizingReportSpec defines the desired state of Ec2RightsizingReport
+type Ec2RightsizingReportSpec struct {
+	// Selector of the instances to report on.
+	Selector Ec2InstanceSelector `json:"selector"`
+	// Region of the instances, defaults to the region configured for the controller.
+	Region string `json:"region,omitempty"`
+	// LookbackPeriod over which the usage of the instances is gathered, defaults to 14 days.
+	LookbackPeriod *metav1.Duration `json:"lookback_period,omitempty"`
+	// TargetCPUUtilization is the highest cpu utilization in percent the peak usage of an
+	// instance may reach on a recommended type, defaults to 60.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	TargetCPUUtilization int32 `json:"target_cpu_utilization,omitempty"`
+	// TargetMemoryUtilization is the highest memory utilization in percent the peak usage of an
+	// instance may reach on a recommended type, defaults to 80. Memory is only considered for
+	// instances publishing the mem_used_percent metric of the cloudwatch agent.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	TargetMemoryUtilization int32 `json:"target_memory_utilization,omitempty"`
+	// ReportInterval is the interval at which the report is refreshed, defaults to 24h.
+	ReportInterval *metav1.Duration `json:"report_interval,omitempty"`
+}
+
+// Ec2RightsizingReportStatus defines the observed state of Ec2RightsizingReport
+type Ec2RightsizingReportStatus struct {
+	// State of the report, Completed or Failed.
+	State string `json:"state,omitempty"`
+	// Message describes why the report failed.
+	Message string `json:"message,omitempty"`
+	// ObservedGeneration is the generation of the spec the report is computed for.
+	ObservedGeneration int64 `json:"observed_generation,omitempty"`
+	// LastReportTime is the time the report was last computed.
+	LastReportTime *metav1.Time `json:"last_report_time,omitempty"`
+	// EstimatedMonthlySavings is the sum of the estimated monthly savings of the first
+	// recommendation of every instance in USD.
+	EstimatedMonthlySavings string `json:"estimated_monthly_savings,omitempty"`
+	// Instances are the usage and the recommendations per instance.
+	Instances []InstanceRecommendations `json:"instances,omitempty"`
+}
+
+// InstanceRecommendations are the usage of an instance over the lookback period and the
+// instance types it could be resized to.
+type InstanceRecommendations struct {
+	// InstanceID is unique identifier for aws-ec2 instance.
+	InstanceID string `json:"instance_id"`
+	// InstanceType of the instance, e.g. m5.xlarge.
+	InstanceType string `json:"instance_type"`
+	// MaxCPUUtilization is the peak cpu utilization in percent.
+	MaxCPUUtilization string `json:"max_cpu_utilization,omitempty"`
+	// MaxMemoryUtilization is the peak memory utilization in percent, empty if the instance
+	// does not publish memory metrics.
+	MaxMemoryUtilization string `json:"max_memory_utilization,omitempty"`
+	// MaxNetworkBytesPerSecond is the peak network traffic, in and out.
+	MaxNetworkBytesPerSecond int64 `json:"max_network_bytes_per_second,omitempty"`
+	// Datapoints is the number of cpu utilization datapoints found for the lookback period.
+	Datapoints int32 `json:"datapoints"`
+	// Recommendations are the smaller instance types fitting the usage, the smallest first.
+	Recommendations []Recommendation `json:"recommendations,omitempty"`
+	// Message explains why there is no recommendation, if any.
+	Message string `json:"message,omitempty"`
+}
+
+// Recommendation is an instance type an instance could be resized to.
+type Recommendation struct {
+	// InstanceType recommended, e.g. m5.large.
+	InstanceType string `json:"instance_type"`
+	// EstimatedMonthlySavings in USD based on on-demand prices, empty if a price is unknown.
+	EstimatedMonthlySavings string `json:"estimated_monthly_savings,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// Ec2RightsizingReport is the Schema for the ec2rightsizingreports API, the controller only
+// recommends instance types and never resizes the instances.
+type Ec2RightsizingReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   Ec2RightsizingReportSpec   `json:"spec,omitempty"`
+	Status Ec2RightsizingReportStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// Ec2RightsizingReportList contains a list of Ec2RightsizingReport
+type Ec2RightsizingReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Ec2RightsizingReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Ec2RightsizingReport{}, &Ec2RightsizingReportList{})
+}
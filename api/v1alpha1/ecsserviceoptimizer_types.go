@@ -0,0 +1,70 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EcsServiceOptimizerSpec defines the desired state of EcsServiceOptimizer
+type EcsServiceOptimizerSpec struct {
+	// Clusters are the names or arns of the ecs clusters whose services are scaled in the time window.
+	// +kubebuilder:validation:MinItems=1
+	Clusters []string `json:"clusters"`
+	// Tags the services must have, all the services of the clusters are scaled if empty.
+	Tags map[string]string `json:"tags,omitempty"`
+	// Scheduled start time window, should be valid  start time, supported timezone is IST
+	StartTimeWindow string `json:"start_time_window"`
+	// Scheduled end time window, should be valid  end time, supported timezone is IST
+	EndTimeWindow string `json:"end_time_window"`
+	// Region of the clusters, defaults to the region configured for the controller.
+	Region string `json:"region,omitempty"`
+}
+
+// EcsServiceOptimizerStatus defines the observed state of EcsServiceOptimizer
+type EcsServiceOptimizerStatus struct {
+	// State represents current state of operation, InTimeWindow or OutOfTimeWindow.
+	State string `json:"state,omitempty"`
+	// Services is the status of the matched services.
+	Services []EcsServiceStatus `json:"services,omitempty"`
+}
+
+// EcsServiceStatus is the status of an ecs service.
+type EcsServiceStatus struct {
+	// Cluster of the service, as given in the spec.
+	Cluster string `json:"cluster"`
+	// Name of the service.
+	Name string `json:"name"`
+	// State of the service, ScaledDown or Restored.
+	State string `json:"state,omitempty"`
+	// SavedDesiredCount is the desired count of the service before it got scaled down, restored
+	// at the end of the time window.
+	SavedDesiredCount *int32 `json:"saved_desired_count,omitempty"`
+	// Message is the error of the last operation, if any.
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the state last changed.
+	LastTransitionTime *metav1.Time `json:"last_transition_time,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// EcsServiceOptimizer is the Schema for the ecsserviceoptimizers API
+type EcsServiceOptimizer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EcsServiceOptimizerSpec   `json:"spec,omitempty"`
+	Status EcsServiceOptimizerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EcsServiceOptimizerList contains a list of EcsServiceOptimizer
+type EcsServiceOptimizerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EcsServiceOptimizer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EcsServiceOptimizer{}, &EcsServiceOptimizerList{})
+}
@@ -0,0 +1,103 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EksCostOptimizerSpec defines the desired state of EksCostOptimizer
+type EksCostOptimizerSpec struct {
+	// ClusterName of the eks cluster the node groups belong to.
+	ClusterName string `json:"cluster_name"`
+	// NodeGroupNames of the managed node groups scaled in the time window.
+	// +kubebuilder:validation:MinItems=1
+	NodeGroupNames []string `json:"node_group_names"`
+	// Scheduled start time window, should be valid  start time, supported timezone is IST
+	StartTimeWindow string `json:"start_time_window"`
+	// Scheduled end time window, should be valid  end time, supported timezone is IST
+	EndTimeWindow string `json:"end_time_window"`
+	// Region of the cluster, defaults to the region configured for the controller.
+	Region string `json:"region,omitempty"`
+	// ScalingConfig of the node groups within the time window, defaults to zero nodes. The max
+	// size defaults to one, the lowest max size allowed by eks.
+	ScalingConfig EksScalingConfig `json:"scaling_config,omitempty"`
+	// Drain cordons and drains the nodes of a node group before it is scaled down to zero nodes.
+	Drain *DrainPolicy `json:"drain,omitempty"`
+}
+
+// EksScalingConfig is the scaling config of an eks managed node group.
+type EksScalingConfig struct {
+	// +kubebuilder:validation:Minimum=0
+	MinSize int32 `json:"min_size"`
+	// +kubebuilder:validation:Minimum=0
+	MaxSize int32 `json:"max_size,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	DesiredSize int32 `json:"desired_size"`
+}
+
+// DrainPolicy configures how the nodes are drained before the node group is scaled down.
+type DrainPolicy struct {
+	// KubeconfigSecretRef references the Secret, in the namespace of the object, holding the
+	// kubeconfig of the eks cluster.
+	KubeconfigSecretRef SecretKeyReference `json:"kubeconfig_secret_ref"`
+	// Timeout after which the node group is scaled down even if pods could not be evicted,
+	// e.g. because of a pod disruption budget, defaults to 10m.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// SecretKeyReference references a key of a Secret.
+type SecretKeyReference struct {
+	// Name of the Secret.
+	Name string `json:"name"`
+	// Key of the kubeconfig in the Secret, defaults to kubeconfig.
+	Key string `json:"key,omitempty"`
+}
+
+// EksCostOptimizerStatus defines the observed state of EksCostOptimizer
+type EksCostOptimizerStatus struct {
+	// State represents current state of operation, InTimeWindow or OutOfTimeWindow.
+	State string `json:"state,omitempty"`
+	// NodeGroups is the status of the managed node groups.
+	NodeGroups []EksNodeGroupStatus `json:"node_groups,omitempty"`
+}
+
+// EksNodeGroupStatus is the status of an eks managed node group.
+type EksNodeGroupStatus struct {
+	// Name of the node group.
+	Name string `json:"name"`
+	// State of the node group, Draining, ScaledDown or Restored.
+	State string `json:"state,omitempty"`
+	// SavedScalingConfig is the scaling config of the node group before it got scaled down,
+	// restored at the end of the time window.
+	SavedScalingConfig *EksScalingConfig `json:"saved_scaling_config,omitempty"`
+	// DrainStartTime is the time the nodes of the node group started to be drained.
+	DrainStartTime *metav1.Time `json:"drain_start_time,omitempty"`
+	// Message is the error of the last operation, if any.
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the state last changed.
+	LastTransitionTime *metav1.Time `json:"last_transition_time,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// EksCostOptimizer is the Schema for the ekscostoptimizers API
+type EksCostOptimizer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EksCostOptimizerSpec   `json:"spec,omitempty"`
+	Status EksCostOptimizerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EksCostOptimizerList contains a list of EksCostOptimizer
+type EksCostOptimizerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EksCostOptimizer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EksCostOptimizer{}, &EksCostOptimizerList{})
+}
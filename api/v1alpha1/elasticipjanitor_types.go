@@ -0,0 +1,86 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ElasticIpJanitorSpec defines the desired state of ElasticIpJanitor
+type ElasticIpJanitorSpec struct {
+	// Regions whose elastic ips are cleaned up, defaults to the region configured for the controller.
+	Regions []string `json:"regions,omitempty"`
+	// Tags the unassociated elastic ips must have to be released, e.g. environment: dev.
+	Tags map[string]string `json:"tags,omitempty"`
+	// IdleDuration is the time an elastic ip has to stay unassociated before it is released,
+	// counted from the first scan which found it unassociated. It starts over if a scan misses the
+	// elastic ip, which may have been associated in the meantime. Defaults to 7 days.
+	IdleDuration *metav1.Duration `json:"idle_duration,omitempty"`
+	// KeepTag is the key of the tag protecting an elastic ip from being released, defaults to
+	// kubeinbox.io/keep.
+	KeepTag string `json:"keep_tag,omitempty"`
+	// DryRun only reports the elastic ips which would be released.
+	DryRun bool `json:"dry_run,omitempty"`
+	// ScanInterval is the interval at which the elastic ips are scanned, defaults to 1h.
+	ScanInterval *metav1.Duration `json:"scan_interval,omitempty"`
+}
+
+// ElasticIpJanitorStatus defines the observed state of ElasticIpJanitor
+type ElasticIpJanitorStatus struct {
+	// State of the last scan, Completed or Failed.
+	State string `json:"state,omitempty"`
+	// Message describes why the last scan failed.
+	Message string `json:"message,omitempty"`
+	// ObservedGeneration is the generation of the spec the last scan is done for.
+	ObservedGeneration int64 `json:"observed_generation,omitempty"`
+	// LastScanTime is the time the elastic ips were last scanned.
+	LastScanTime *metav1.Time `json:"last_scan_time,omitempty"`
+	// EstimatedMonthlyCost of the unassociated elastic ips found by the last scan in USD.
+	EstimatedMonthlyCost string `json:"estimated_monthly_cost,omitempty"`
+	// ReleasedAddresses is the number of elastic ips released so far.
+	ReleasedAddresses int32 `json:"released_addresses,omitempty"`
+	// Addresses are the unassociated elastic ips found by the last scan and the ones it released.
+	Addresses []UnassociatedAddress `json:"addresses,omitempty"`
+}
+
+// UnassociatedAddress is an unassociated elastic ip and the action taken on it.
+type UnassociatedAddress struct {
+	// AllocationID of the elastic ip, e.g. eipalloc-0123456789abcdef0.
+	AllocationID string `json:"allocation_id"`
+	// PublicIP of the elastic ip.
+	PublicIP string `json:"public_ip"`
+	// Region of the elastic ip.
+	Region string `json:"region"`
+	// FirstSeenTime is the time the elastic ip was first found unassociated.
+	FirstSeenTime *metav1.Time `json:"first_seen_time,omitempty"`
+	// ReleaseTime is the time after which the elastic ip is released.
+	ReleaseTime *metav1.Time `json:"release_time,omitempty"`
+	// Action taken in the last scan, FirstSeen, Idle, Released or Kept. Dry runs report
+	// WouldRelease for the elastic ips which would be released.
+	Action string `json:"action"`
+	// Message is the error of the last action, if any.
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// ElasticIpJanitor is the Schema for the elasticipjanitors API
+type ElasticIpJanitor struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ElasticIpJanitorSpec   `json:"spec,omitempty"`
+	Status ElasticIpJanitorStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ElasticIpJanitorList contains a list of ElasticIpJanitor
+type ElasticIpJanitorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElasticIpJanitor `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ElasticIpJanitor{}, &ElasticIpJanitorList{})
+}
@@ -0,0 +1,90 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NatGatewayCostOptimizerSpec defines the desired state of NatGatewayCostOptimizer
+type NatGatewayCostOptimizerSpec struct {
+	// NatGatewayIDs of the nat gateways deleted in the time window. The gateways are recreated
+	// with new ids, the status maps them to the ids given here.
+	// +kubebuilder:validation:MinItems=1
+	NatGatewayIDs []string `json:"nat_gateway_ids"`
+	// Scheduled start time window, should be valid  start time, supported timezone is IST
+	StartTimeWindow string `json:"start_time_window"`
+	// Scheduled end time window, should be valid  end time, supported timezone is IST
+	EndTimeWindow string `json:"end_time_window"`
+	// Region of the nat gateways, defaults to the region configured for the controller.
+	Region string `json:"region,omitempty"`
+}
+
+// NatGatewayCostOptimizerStatus defines the observed state of NatGatewayCostOptimizer
+type NatGatewayCostOptimizerStatus struct {
+	// State represents current state of operation, InTimeWindow or OutOfTimeWindow.
+	State string `json:"state,omitempty"`
+	// NatGateways is the status of the nat gateways.
+	NatGateways []NatGatewayStatus `json:"nat_gateways,omitempty"`
+}
+
+// NatGatewayStatus is the status of a nat gateway.
+type NatGatewayStatus struct {
+	// Name is the id of the nat gateway given in the spec.
+	Name string `json:"name"`
+	// NatGatewayID is the id of the current nat gateway, it differs from the name once the
+	// gateway got recreated.
+	NatGatewayID string `json:"nat_gateway_id,omitempty"`
+	// State of the gateway, Deleting, Deleted, Creating or Restored.
+	State string `json:"state,omitempty"`
+	// SavedConfig is the configuration of the gateway before it got deleted, used to recreate
+	// it at the end of the time window.
+	SavedConfig *SavedNatGateway `json:"saved_config,omitempty"`
+	// Message is the error of the last operation, if any.
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the state last changed.
+	LastTransitionTime *metav1.Time `json:"last_transition_time,omitempty"`
+}
+
+// SavedNatGateway is the configuration of a deleted nat gateway.
+type SavedNatGateway struct {
+	// SubnetID of the subnet the gateway is created in.
+	SubnetID string `json:"subnet_id"`
+	// AllocationID of the elastic ip of the gateway, empty for private gateways.
+	AllocationID string `json:"allocation_id,omitempty"`
+	// Tags of the gateway.
+	Tags map[string]string `json:"tags,omitempty"`
+	// Routes targeting the gateway.
+	Routes []NatGatewayRoute `json:"routes,omitempty"`
+}
+
+// NatGatewayRoute is a route of a route table targeting a nat gateway.
+type NatGatewayRoute struct {
+	RouteTableID             string `json:"route_table_id"`
+	DestinationCidrBlock     string `json:"destination_cidr_block,omitempty"`
+	DestinationIpv6CidrBlock string `json:"destination_ipv6_cidr_block,omitempty"`
+	DestinationPrefixListID  string `json:"destination_prefix_list_id,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// NatGatewayCostOptimizer is the Schema for the natgatewaycostoptimizers API
+type NatGatewayCostOptimizer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NatGatewayCostOptimizerSpec   `json:"spec,omitempty"`
+	Status NatGatewayCostOptimizerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// NatGatewayCostOptimizerList contains a list of NatGatewayCostOptimizer
+type NatGatewayCostOptimizerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NatGatewayCostOptimizer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NatGatewayCostOptimizer{}, &NatGatewayCostOptimizerList{})
+}
@@ -0,0 +1,80 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RdsCostOptimizerSpec defines the desired state of RdsCostOptimizer
+type RdsCostOptimizerSpec struct {
+	// DBInstanceIdentifiers of the DB instances on which start/stop operations have to be performed,
+	// instances of Aurora clusters have to be selected through their cluster.
+	DBInstanceIdentifiers []string `json:"db_instance_identifiers,omitempty"`
+	// DBClusterIdentifiers of the Aurora clusters on which start/stop operations have to be performed.
+	DBClusterIdentifiers []string `json:"db_cluster_identifiers,omitempty"`
+	// START/STOP operation
+	Operation Ec2OperationType `json:"operation"`
+	// OnDemand/Scheduled window
+	// +kubebuilder:validation:Enum=OnDemand;Scheduled
+	WindowType Ec2OperationWindowType `json:"window_type"`
+	// Scheduled start time window, should be valid  start time, supported timezone is IST
+	StartTimeWindow string `json:"start_time_window,omitempty"`
+	// Scheduled end time window, should be valid  end time, supported timezone is IST
+	EndTimeWindow string `json:"end_time_window,omitempty"`
+	// Region of the databases, defaults to the region configured for the controller.
+	Region string `json:"region,omitempty"`
+	// RetryPolicy for failed OnDemand operations, defaults are used if not specified.
+	RetryPolicy *RetryPolicy `json:"retry_policy,omitempty"`
+}
+
+// RdsCostOptimizerStatus defines the observed state of RdsCostOptimizer
+type RdsCostOptimizerStatus struct {
+	// OperationStatus is the status of the start/stop operation of the object.
+	OperationStatus `json:",inline"`
+	// Databases is the status of the selected DB instances and clusters.
+	Databases []DatabaseStatus `json:"databases,omitempty"`
+}
+
+// DatabaseStatus is the status of a DB instance or an Aurora cluster.
+type DatabaseStatus struct {
+	// Identifier of the DB instance or cluster.
+	Identifier string `json:"identifier"`
+	// Kind is DBInstance or DBCluster.
+	Kind string `json:"kind"`
+	// Status reported by RDS, e.g. available, stopping, stopped.
+	Status string `json:"status,omitempty"`
+	// LastAction performed on the database, Start or Stop. The action of a Scheduled object is
+	// reset once its time window exited.
+	LastAction Ec2OperationType `json:"last_action,omitempty"`
+	// LastActionTime is the time the last action was performed.
+	LastActionTime *metav1.Time `json:"last_action_time,omitempty"`
+	// Restops is the number of times the database was stopped again within the time window of
+	// a Scheduled object after RDS restarted it automatically, which RDS does after seven days.
+	Restops int32 `json:"restops,omitempty"`
+	// Message is the error of the last action, if any.
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// RdsCostOptimizer is the Schema for the rdscostoptimizers API
+type RdsCostOptimizer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RdsCostOptimizerSpec   `json:"spec,omitempty"`
+	Status RdsCostOptimizerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// RdsCostOptimizerList contains a list of RdsCostOptimizer
+type RdsCostOptimizerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RdsCostOptimizer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RdsCostOptimizer{}, &RdsCostOptimizerList{})
+}
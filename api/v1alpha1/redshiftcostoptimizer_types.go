@@ -0,0 +1,87 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RedshiftCostOptimizerSpec defines the desired state of RedshiftCostOptimizer
+type RedshiftCostOptimizerSpec struct {
+	// ClusterIdentifiers of the provisioned clusters which have to be paused/resumed.
+	// +kubebuilder:validation:MinItems=1
+	ClusterIdentifiers []string `json:"cluster_identifiers"`
+	// START/STOP operation, Stop pauses and Start resumes the clusters.
+	Operation Ec2OperationType `json:"operation"`
+	// OnDemand/Scheduled window
+	// +kubebuilder:validation:Enum=OnDemand;Scheduled
+	WindowType Ec2OperationWindowType `json:"window_type"`
+	// Scheduled start time window, should be valid  start time, supported timezone is IST
+	StartTimeWindow string `json:"start_time_window,omitempty"`
+	// Scheduled end time window, should be valid  end time, supported timezone is IST
+	EndTimeWindow string `json:"end_time_window,omitempty"`
+	// Region of the clusters, defaults to the region configured for the controller.
+	Region string `json:"region,omitempty"`
+	// RetryPolicy for failed OnDemand operations, defaults are used if not specified. Operations
+	// on clusters which do not exist or are stuck in transition are not retried.
+	RetryPolicy *RetryPolicy `json:"retry_policy,omitempty"`
+}
+
+// RedshiftCostOptimizerStatus defines the observed state of RedshiftCostOptimizer
+type RedshiftCostOptimizerStatus struct {
+	// OperationStatus is the status of the pause/resume operation of the object.
+	OperationStatus `json:",inline"`
+	// Clusters is the status of the selected clusters.
+	Clusters []RedshiftClusterStatus `json:"clusters,omitempty"`
+}
+
+// RedshiftClusterStatus is the status of a redshift cluster.
+type RedshiftClusterStatus struct {
+	// Identifier of the cluster.
+	Identifier string `json:"identifier"`
+	// Status reported by redshift, e.g. available, pausing, paused or resuming.
+	Status string `json:"status,omitempty"`
+	// LastAction performed on the cluster, Start or Stop.
+	LastAction Ec2OperationType `json:"last_action,omitempty"`
+	// LastActionTime is the time the last action was performed.
+	LastActionTime *metav1.Time `json:"last_action_time,omitempty"`
+	// Transitions are the latest status changes of the cluster observed by the controller,
+	// oldest first.
+	Transitions []StatusTransition `json:"transitions,omitempty"`
+	// Message is the error of the last action, or the reason the cluster failed to reach the
+	// status of the operation, e.g. it does not exist or is stuck in transition.
+	Message string `json:"message,omitempty"`
+}
+
+// StatusTransition is a change of the status of a resource.
+type StatusTransition struct {
+	// From is the previous status, empty if the resource was not observed before.
+	From string `json:"from,omitempty"`
+	// To is the new status.
+	To string `json:"to"`
+	// Time the change was observed.
+	Time metav1.Time `json:"time"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// RedshiftCostOptimizer is the Schema for the redshiftcostoptimizers API
+type RedshiftCostOptimizer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RedshiftCostOptimizerSpec   `json:"spec,omitempty"`
+	Status RedshiftCostOptimizerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// RedshiftCostOptimizerList contains a list of RedshiftCostOptimizer
+type RedshiftCostOptimizerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RedshiftCostOptimizer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RedshiftCostOptimizer{}, &RedshiftCostOptimizerList{})
+}
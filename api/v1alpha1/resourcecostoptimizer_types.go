@@ -0,0 +1,92 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SchedulableResourceType is the type of the aws resources started/stopped by a ResourceCostOptimizer.
+// +kubebuilder:validation:Enum=EC2;Lightsail;WorkSpaces
+type SchedulableResourceType string
+
+const (
+	// EC2 instances, selected by their id.
+	EC2 SchedulableResourceType = "EC2"
+	// Lightsail instances, selected by their name.
+	Lightsail SchedulableResourceType = "Lightsail"
+	// WorkSpaces selected by their id, only workspaces in AutoStop running mode can be started/stopped.
+	WorkSpaces SchedulableResourceType = "WorkSpaces"
+)
+
+// ResourceCostOptimizerSpec defines the desired state of ResourceCostOptimizer
+type ResourceCostOptimizerSpec struct {
+	// ResourceType of the selected resources, EC2, Lightsail or WorkSpaces.
+	ResourceType SchedulableResourceType `json:"resource_type"`
+	// ResourceIDs of the resources on which start/stop operations have to be performed, i.e. the
+	// ids of the EC2 instances, the names of the Lightsail instances or the ids of the WorkSpaces.
+	// All the resources having the tags are selected if empty, either ids or tags have to be
+	// specified.
+	ResourceIDs []string `json:"resource_ids,omitempty"`
+	// Tags the selected resources have to have.
+	Tags map[string]string `json:"tags,omitempty"`
+	// START/STOP operation
+	Operation Ec2OperationType `json:"operation"`
+	// OnDemand/Scheduled window
+	// +kubebuilder:validation:Enum=OnDemand;Scheduled
+	WindowType Ec2OperationWindowType `json:"window_type"`
+	// Scheduled start time window, should be valid  start time, supported timezone is IST
+	StartTimeWindow string `json:"start_time_window,omitempty"`
+	// Scheduled end time window, should be valid  end time, supported timezone is IST
+	EndTimeWindow string `json:"end_time_window,omitempty"`
+	// Region of the resources, defaults to the region configured for the controller.
+	Region string `json:"region,omitempty"`
+	// RetryPolicy for failed OnDemand operations, defaults are used if not specified.
+	RetryPolicy *RetryPolicy `json:"retry_policy,omitempty"`
+}
+
+// ResourceCostOptimizerStatus defines the observed state of ResourceCostOptimizer
+type ResourceCostOptimizerStatus struct {
+	// OperationStatus is the status of the start/stop operation of the object.
+	OperationStatus `json:",inline"`
+	// Resources is the status of the selected resources.
+	Resources []SchedulableResourceStatus `json:"resources,omitempty"`
+}
+
+// SchedulableResourceStatus is the status of a resource started/stopped by the controller.
+type SchedulableResourceStatus struct {
+	// ID of the resource, i.e. the EC2 instance id, the Lightsail instance name or the WorkSpace id.
+	ID string `json:"id"`
+	// Status reported by the service, e.g. running/stopped for EC2 and Lightsail or
+	// AVAILABLE/STOPPED for WorkSpaces.
+	Status string `json:"status,omitempty"`
+	// LastAction performed on the resource, Start or Stop.
+	LastAction Ec2OperationType `json:"last_action,omitempty"`
+	// LastActionTime is the time the last action was performed.
+	LastActionTime *metav1.Time `json:"last_action_time,omitempty"`
+	// Message is the error of the last action, if any.
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// ResourceCostOptimizer is the Schema for the resourcecostoptimizers API
+type ResourceCostOptimizer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ResourceCostOptimizerSpec   `json:"spec,omitempty"`
+	Status ResourceCostOptimizerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ResourceCostOptimizerList contains a list of ResourceCostOptimizer
+type ResourceCostOptimizerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ResourceCostOptimizer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ResourceCostOptimizer{}, &ResourceCostOptimizerList{})
+}
@@ -0,0 +1,98 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SageMakerCostOptimizerSpec defines the desired state of SageMakerCostOptimizer
+type SageMakerCostOptimizerSpec struct {
+	// NotebookInstanceNames of the notebook instances stopped in the time window.
+	NotebookInstanceNames []string `json:"notebook_instance_names,omitempty"`
+	// StudioApps deletes the idle studio KernelGateway apps in the time window.
+	StudioApps *StudioAppPolicy `json:"studio_apps,omitempty"`
+	// Scheduled start time window, should be valid  start time, supported timezone is IST
+	StartTimeWindow string `json:"start_time_window"`
+	// Scheduled end time window, should be valid  end time, supported timezone is IST
+	EndTimeWindow string `json:"end_time_window"`
+	// Region of the notebook instances and studio domains, defaults to the region configured for the controller.
+	Region string `json:"region,omitempty"`
+}
+
+// StudioAppPolicy selects the studio apps deleted once idle.
+type StudioAppPolicy struct {
+	// DomainID of the studio domain, all the domains of the region if empty.
+	DomainID string `json:"domain_id,omitempty"`
+	// IdleThreshold is the time since the last user activity after which an app is deleted,
+	// defaults to 2h. The last activity reported by SageMaker is also refreshed by the health
+	// checks of the app, apps left open in a browser are hence not found idle and not deleted.
+	IdleThreshold *metav1.Duration `json:"idle_threshold,omitempty"`
+}
+
+// SageMakerCostOptimizerStatus defines the observed state of SageMakerCostOptimizer
+type SageMakerCostOptimizerStatus struct {
+	// OperationStatus is the status of the stop operation of the notebook instances, its state is
+	// InTimeWindow or OutOfTimeWindow.
+	OperationStatus `json:",inline"`
+	// NotebookInstances is the status of the notebook instances.
+	NotebookInstances []NotebookInstanceStatus `json:"notebook_instances,omitempty"`
+	// DeletedApps are the studio apps deleted in the current or the last time window, newest last.
+	DeletedApps []DeletedStudioApp `json:"deleted_apps,omitempty"`
+	// EstimatedHourlySavings of the notebook instances stopped by the controller and the apps it
+	// deleted, in USD.
+	EstimatedHourlySavings string `json:"estimated_hourly_savings,omitempty"`
+	// Message is the error of the last studio apps cleanup, if any.
+	Message string `json:"message,omitempty"`
+}
+
+// NotebookInstanceStatus is the status of a notebook instance.
+type NotebookInstanceStatus struct {
+	// Name of the notebook instance.
+	Name string `json:"name"`
+	// Status reported by sagemaker, e.g. InService, Stopping or Stopped.
+	Status string `json:"status,omitempty"`
+	// InstanceType of the notebook instance.
+	InstanceType string `json:"instance_type,omitempty"`
+	// StoppedTime is the time the controller stopped the notebook instance, cleared once it
+	// runs again.
+	StoppedTime *metav1.Time `json:"stopped_time,omitempty"`
+	// Message is the error of the last operation, if any.
+	Message string `json:"message,omitempty"`
+}
+
+// DeletedStudioApp is a studio app deleted by the controller.
+type DeletedStudioApp struct {
+	DomainID        string `json:"domain_id"`
+	UserProfileName string `json:"user_profile_name,omitempty"`
+	SpaceName       string `json:"space_name,omitempty"`
+	AppName         string `json:"app_name"`
+	InstanceType    string `json:"instance_type,omitempty"`
+	// LastActivityTime is the last user activity, or the creation time if the app was never used.
+	LastActivityTime metav1.Time `json:"last_activity_time"`
+	// DeletionTime is the time the app got deleted.
+	DeletionTime metav1.Time `json:"deletion_time"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// SageMakerCostOptimizer is the Schema for the sagemakercostoptimizers API
+type SageMakerCostOptimizer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SageMakerCostOptimizerSpec   `json:"spec,omitempty"`
+	Status SageMakerCostOptimizerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SageMakerCostOptimizerList contains a list of SageMakerCostOptimizer
+type SageMakerCostOptimizerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SageMakerCostOptimizer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SageMakerCostOptimizer{}, &SageMakerCostOptimizerList{})
+}
@@ -0,0 +1,107 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SnapshotRetentionPolicySpec defines the desired state of SnapshotRetentionPolicy
+type SnapshotRetentionPolicySpec struct {
+	// Region of the snapshots, defaults to the region configured for the controller.
+	Region string `json:"region,omitempty"`
+	// Selector of the snapshots the retention rules apply to.
+	Selector SnapshotSelector `json:"selector,omitempty"`
+	// Retention rules, a snapshot is kept if any of the rules keeps it. The rules are applied to
+	// the snapshots of each volume separately.
+	Retention RetentionRules `json:"retention"`
+	// Enforce deletes the snapshots not kept by the retention rules, otherwise they are only
+	// listed in the status for review.
+	Enforce bool `json:"enforce,omitempty"`
+	// Interval at which the retention rules are applied, defaults to 24h.
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// SnapshotSelector selects ebs snapshots, snapshots have to match all the given fields.
+type SnapshotSelector struct {
+	// Tags the selected snapshots have, e.g. backup: nightly.
+	Tags map[string]string `json:"tags,omitempty"`
+	// VolumeIDs of the volumes the selected snapshots were taken of.
+	VolumeIDs []string `json:"volume_ids,omitempty"`
+	// OwnerIDs of the selected snapshots, defaults to self. Only snapshots owned by the account
+	// can be deleted.
+	OwnerIDs []string `json:"owner_ids,omitempty"`
+}
+
+// RetentionRules decide which snapshots are kept, at least one rule has to be set.
+type RetentionRules struct {
+	// KeepLast keeps the given number of latest snapshots.
+	// +kubebuilder:validation:Minimum=0
+	KeepLast int32 `json:"keep_last,omitempty"`
+	// KeepDailyDays keeps the latest snapshot of each day for the given number of days.
+	// +kubebuilder:validation:Minimum=0
+	KeepDailyDays int32 `json:"keep_daily_days,omitempty"`
+	// KeepWeeklyWeeks keeps the latest snapshot of each week for the given number of weeks.
+	// +kubebuilder:validation:Minimum=0
+	KeepWeeklyWeeks int32 `json:"keep_weekly_weeks,omitempty"`
+}
+
+// SnapshotRetentionPolicyStatus defines the observed state of SnapshotRetentionPolicy
+type SnapshotRetentionPolicyStatus struct {
+	// State of the last run, Completed or Failed.
+	State string `json:"state,omitempty"`
+	// Message describes why the last run failed.
+	Message string `json:"message,omitempty"`
+	// ObservedGeneration is the generation of the spec the last run is done for.
+	ObservedGeneration int64 `json:"observed_generation,omitempty"`
+	// LastRunTime is the time the retention rules were last applied.
+	LastRunTime *metav1.Time `json:"last_run_time,omitempty"`
+	// SelectedSnapshots is the number of snapshots selected in the last run.
+	SelectedSnapshots int32 `json:"selected_snapshots,omitempty"`
+	// RetainedSnapshots is the number of snapshots kept in the last run.
+	RetainedSnapshots int32 `json:"retained_snapshots,omitempty"`
+	// DeletedSnapshots is the number of snapshots deleted so far.
+	DeletedSnapshots int32 `json:"deleted_snapshots,omitempty"`
+	// ExpiredSnapshots are the snapshots not kept by the retention rules in the last run, they
+	// are deleted if the policy is enforced.
+	ExpiredSnapshots []ExpiredSnapshot `json:"expired_snapshots,omitempty"`
+}
+
+// ExpiredSnapshot is a snapshot not kept by the retention rules.
+type ExpiredSnapshot struct {
+	// SnapshotID of the snapshot, e.g. snap-0123456789abcdef0.
+	SnapshotID string `json:"snapshot_id"`
+	// VolumeID of the volume the snapshot was taken of.
+	VolumeID string `json:"volume_id,omitempty"`
+	// StartTime of the snapshot.
+	StartTime metav1.Time `json:"start_time"`
+	// VolumeSizeGiB is the size of the volume the snapshot was taken of.
+	VolumeSizeGiB int32 `json:"volume_size_gib,omitempty"`
+	// Action taken on the snapshot, WouldDelete, Deleted or Skipped if it is used by an ami.
+	Action string `json:"action"`
+	// Message is the error of the deletion, if any.
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// SnapshotRetentionPolicy is the Schema for the snapshotretentionpolicies API
+type SnapshotRetentionPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SnapshotRetentionPolicySpec   `json:"spec,omitempty"`
+	Status SnapshotRetentionPolicyStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SnapshotRetentionPolicyList contains a list of SnapshotRetentionPolicy
+type SnapshotRetentionPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SnapshotRetentionPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SnapshotRetentionPolicy{}, &SnapshotRetentionPolicyList{})
+}
@@ -0,0 +1,64 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkloadScheduleOptimizerSpec defines the desired state of WorkloadScheduleOptimizer
+type WorkloadScheduleOptimizerSpec struct {
+	// Selector of the Deployments and StatefulSets scaled to zero in the time window, only the
+	// workloads in the namespace of the object are selected.
+	Selector metav1.LabelSelector `json:"selector"`
+	// Scheduled start time window, should be valid  start time, supported timezone is IST
+	StartTimeWindow string `json:"start_time_window"`
+	// Scheduled end time window, should be valid  end time, supported timezone is IST
+	EndTimeWindow string `json:"end_time_window"`
+}
+
+// WorkloadScheduleOptimizerStatus defines the observed state of WorkloadScheduleOptimizer
+type WorkloadScheduleOptimizerStatus struct {
+	// State represents current state of operation, InTimeWindow or OutOfTimeWindow.
+	State string `json:"state,omitempty"`
+	// ScaledWorkloads are the workloads currently scaled to zero by the object.
+	ScaledWorkloads []ScaledWorkload `json:"scaled_workloads,omitempty"`
+	// Message is the error of the last reconciliation, if any.
+	Message string `json:"message,omitempty"`
+}
+
+// ScaledWorkload is a workload scaled to zero, its original replicas are also stored in an
+// annotation of the workload itself.
+type ScaledWorkload struct {
+	// Kind of the workload, Deployment or StatefulSet.
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// OriginalReplicas restored at the end of the time window.
+	OriginalReplicas int32 `json:"original_replicas"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// WorkloadScheduleOptimizer is the Schema for the workloadscheduleoptimizers API, scaling
+// workloads of the cluster the controller runs in to zero lets the node autoscaler remove the
+// ec2 capacity they used.
+type WorkloadScheduleOptimizer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WorkloadScheduleOptimizerSpec   `json:"spec,omitempty"`
+	Status WorkloadScheduleOptimizerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// WorkloadScheduleOptimizerList contains a list of WorkloadScheduleOptimizer
+type WorkloadScheduleOptimizerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WorkloadScheduleOptimizer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WorkloadScheduleOptimizer{}, &WorkloadScheduleOptimizerList{})
+}
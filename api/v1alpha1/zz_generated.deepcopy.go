@@ -22,30 +22,2478 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AmiJanitor) DeepCopyInto(out *AmiJanitor) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AmiJanitor.
+func (in *AmiJanitor) DeepCopy() *AmiJanitor {
+	if in == nil {
+		return nil
+	}
+	out := new(AmiJanitor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AmiJanitor) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AmiJanitorList) DeepCopyInto(out *AmiJanitorList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AmiJanitor, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AmiJanitorList.
+func (in *AmiJanitorList) DeepCopy() *AmiJanitorList {
+	if in == nil {
+		return nil
+	}
+	out := new(AmiJanitorList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AmiJanitorList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AmiJanitorSpec) DeepCopyInto(out *AmiJanitorSpec) {
+	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MinAge != nil {
+		in, out := &in.MinAge, &out.MinAge
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ScanInterval != nil {
+		in, out := &in.ScanInterval, &out.ScanInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AmiJanitorSpec.
+func (in *AmiJanitorSpec) DeepCopy() *AmiJanitorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AmiJanitorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AmiJanitorStatus) DeepCopyInto(out *AmiJanitorStatus) {
+	*out = *in
+	if in.LastScanTime != nil {
+		in, out := &in.LastScanTime, &out.LastScanTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]StaleImage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AmiJanitorStatus.
+func (in *AmiJanitorStatus) DeepCopy() *AmiJanitorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AmiJanitorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AsgCostOptimizer) DeepCopyInto(out *AsgCostOptimizer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AsgCostOptimizer.
+func (in *AsgCostOptimizer) DeepCopy() *AsgCostOptimizer {
+	if in == nil {
+		return nil
+	}
+	out := new(AsgCostOptimizer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AsgCostOptimizer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AsgCostOptimizerList) DeepCopyInto(out *AsgCostOptimizerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AsgCostOptimizer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AsgCostOptimizerList.
+func (in *AsgCostOptimizerList) DeepCopy() *AsgCostOptimizerList {
+	if in == nil {
+		return nil
+	}
+	out := new(AsgCostOptimizerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AsgCostOptimizerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AsgCostOptimizerSpec) DeepCopyInto(out *AsgCostOptimizerSpec) {
+	*out = *in
+	if in.AutoScalingGroupNames != nil {
+		in, out := &in.AutoScalingGroupNames, &out.AutoScalingGroupNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Capacity = in.Capacity
+	if in.SuspendProcesses != nil {
+		in, out := &in.SuspendProcesses, &out.SuspendProcesses
+		*out = make([]ScalingProcess, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AsgCostOptimizerSpec.
+func (in *AsgCostOptimizerSpec) DeepCopy() *AsgCostOptimizerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AsgCostOptimizerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AsgCostOptimizerStatus) DeepCopyInto(out *AsgCostOptimizerStatus) {
+	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]AutoScalingGroupStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AsgCostOptimizerStatus.
+func (in *AsgCostOptimizerStatus) DeepCopy() *AsgCostOptimizerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AsgCostOptimizerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoScalingCapacity) DeepCopyInto(out *AutoScalingCapacity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoScalingCapacity.
+func (in *AutoScalingCapacity) DeepCopy() *AutoScalingCapacity {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoScalingCapacity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoScalingGroupStatus) DeepCopyInto(out *AutoScalingGroupStatus) {
+	*out = *in
+	if in.SavedCapacity != nil {
+		in, out := &in.SavedCapacity, &out.SavedCapacity
+		*out = new(AutoScalingCapacity)
+		**out = **in
+	}
+	if in.SuspendedProcesses != nil {
+		in, out := &in.SuspendedProcesses, &out.SuspendedProcesses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoScalingGroupStatus.
+func (in *AutoScalingGroupStatus) DeepCopy() *AutoScalingGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoScalingGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseStatus) DeepCopyInto(out *DatabaseStatus) {
+	*out = *in
+	if in.LastActionTime != nil {
+		in, out := &in.LastActionTime, &out.LastActionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseStatus.
+func (in *DatabaseStatus) DeepCopy() *DatabaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeletedStudioApp) DeepCopyInto(out *DeletedStudioApp) {
+	*out = *in
+	in.LastActivityTime.DeepCopyInto(&out.LastActivityTime)
+	in.DeletionTime.DeepCopyInto(&out.DeletionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeletedStudioApp.
+func (in *DeletedStudioApp) DeepCopy() *DeletedStudioApp {
+	if in == nil {
+		return nil
+	}
+	out := new(DeletedStudioApp)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DownscaleConfig) DeepCopyInto(out *DownscaleConfig) {
+	*out = *in
+	if in.ReplicasPerNodeGroup != nil {
+		in, out := &in.ReplicasPerNodeGroup, &out.ReplicasPerNodeGroup
+		*out = new(int32)
+		**out = **in
+	}
+	if in.InstanceCount != nil {
+		in, out := &in.InstanceCount, &out.InstanceCount
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownscaleConfig.
+func (in *DownscaleConfig) DeepCopy() *DownscaleConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DownscaleConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DownscaleWindow) DeepCopyInto(out *DownscaleWindow) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownscaleWindow.
+func (in *DownscaleWindow) DeepCopy() *DownscaleWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(DownscaleWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DownscaleWindow) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DownscaleWindowList) DeepCopyInto(out *DownscaleWindowList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DownscaleWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownscaleWindowList.
+func (in *DownscaleWindowList) DeepCopy() *DownscaleWindowList {
+	if in == nil {
+		return nil
+	}
+	out := new(DownscaleWindowList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DownscaleWindowList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DownscaleWindowSpec) DeepCopyInto(out *DownscaleWindowSpec) {
+	*out = *in
+	if in.ElastiCacheReplicationGroups != nil {
+		in, out := &in.ElastiCacheReplicationGroups, &out.ElastiCacheReplicationGroups
+		*out = make([]ElastiCacheDownscale, len(*in))
+		copy(*out, *in)
+	}
+	if in.OpenSearchDomains != nil {
+		in, out := &in.OpenSearchDomains, &out.OpenSearchDomains
+		*out = make([]OpenSearchDownscale, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownscaleWindowSpec.
+func (in *DownscaleWindowSpec) DeepCopy() *DownscaleWindowSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DownscaleWindowSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DownscaleWindowStatus) DeepCopyInto(out *DownscaleWindowStatus) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]DownscaledResource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownscaleWindowStatus.
+func (in *DownscaleWindowStatus) DeepCopy() *DownscaleWindowStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DownscaleWindowStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DownscaledResource) DeepCopyInto(out *DownscaledResource) {
+	*out = *in
+	if in.SavedConfig != nil {
+		in, out := &in.SavedConfig, &out.SavedConfig
+		*out = new(DownscaleConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownscaledResource.
+func (in *DownscaledResource) DeepCopy() *DownscaledResource {
+	if in == nil {
+		return nil
+	}
+	out := new(DownscaledResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DrainPolicy) DeepCopyInto(out *DrainPolicy) {
+	*out = *in
+	out.KubeconfigSecretRef = in.KubeconfigSecretRef
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DrainPolicy.
+func (in *DrainPolicy) DeepCopy() *DrainPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DrainPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DynamoDbCostOptimizer) DeepCopyInto(out *DynamoDbCostOptimizer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DynamoDbCostOptimizer.
+func (in *DynamoDbCostOptimizer) DeepCopy() *DynamoDbCostOptimizer {
+	if in == nil {
+		return nil
+	}
+	out := new(DynamoDbCostOptimizer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DynamoDbCostOptimizer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DynamoDbCostOptimizerList) DeepCopyInto(out *DynamoDbCostOptimizerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DynamoDbCostOptimizer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DynamoDbCostOptimizerList.
+func (in *DynamoDbCostOptimizerList) DeepCopy() *DynamoDbCostOptimizerList {
+	if in == nil {
+		return nil
+	}
+	out := new(DynamoDbCostOptimizerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DynamoDbCostOptimizerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DynamoDbCostOptimizerSpec) DeepCopyInto(out *DynamoDbCostOptimizerSpec) {
+	*out = *in
+	if in.Tables != nil {
+		in, out := &in.Tables, &out.Tables
+		*out = make([]DynamoDbTableSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DynamoDbCostOptimizerSpec.
+func (in *DynamoDbCostOptimizerSpec) DeepCopy() *DynamoDbCostOptimizerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DynamoDbCostOptimizerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DynamoDbCostOptimizerStatus) DeepCopyInto(out *DynamoDbCostOptimizerStatus) {
+	*out = *in
+	if in.Tables != nil {
+		in, out := &in.Tables, &out.Tables
+		*out = make([]DynamoDbTableStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DynamoDbCostOptimizerStatus.
+func (in *DynamoDbCostOptimizerStatus) DeepCopy() *DynamoDbCostOptimizerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DynamoDbCostOptimizerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DynamoDbIndexCapacity) DeepCopyInto(out *DynamoDbIndexCapacity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DynamoDbIndexCapacity.
+func (in *DynamoDbIndexCapacity) DeepCopy() *DynamoDbIndexCapacity {
+	if in == nil {
+		return nil
+	}
+	out := new(DynamoDbIndexCapacity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DynamoDbTableCapacity) DeepCopyInto(out *DynamoDbTableCapacity) {
+	*out = *in
+	if in.GlobalSecondaryIndexes != nil {
+		in, out := &in.GlobalSecondaryIndexes, &out.GlobalSecondaryIndexes
+		*out = make([]DynamoDbIndexCapacity, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DynamoDbTableCapacity.
+func (in *DynamoDbTableCapacity) DeepCopy() *DynamoDbTableCapacity {
+	if in == nil {
+		return nil
+	}
+	out := new(DynamoDbTableCapacity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DynamoDbTableSchedule) DeepCopyInto(out *DynamoDbTableSchedule) {
+	*out = *in
+	in.DynamoDbTableCapacity.DeepCopyInto(&out.DynamoDbTableCapacity)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DynamoDbTableSchedule.
+func (in *DynamoDbTableSchedule) DeepCopy() *DynamoDbTableSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(DynamoDbTableSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DynamoDbTableStatus) DeepCopyInto(out *DynamoDbTableStatus) {
+	*out = *in
+	if in.SavedCapacity != nil {
+		in, out := &in.SavedCapacity, &out.SavedCapacity
+		*out = new(DynamoDbTableCapacity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DynamoDbTableStatus.
+func (in *DynamoDbTableStatus) DeepCopy() *DynamoDbTableStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DynamoDbTableStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EbsVolumeJanitor) DeepCopyInto(out *EbsVolumeJanitor) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EbsVolumeJanitor.
+func (in *EbsVolumeJanitor) DeepCopy() *EbsVolumeJanitor {
+	if in == nil {
+		return nil
+	}
+	out := new(EbsVolumeJanitor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EbsVolumeJanitor) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EbsVolumeJanitorList) DeepCopyInto(out *EbsVolumeJanitorList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EbsVolumeJanitor, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EbsVolumeJanitorList.
+func (in *EbsVolumeJanitorList) DeepCopy() *EbsVolumeJanitorList {
+	if in == nil {
+		return nil
+	}
+	out := new(EbsVolumeJanitorList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EbsVolumeJanitorList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EbsVolumeJanitorSpec) DeepCopyInto(out *EbsVolumeJanitorSpec) {
+	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MinAge != nil {
+		in, out := &in.MinAge, &out.MinAge
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ScanInterval != nil {
+		in, out := &in.ScanInterval, &out.ScanInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EbsVolumeJanitorSpec.
+func (in *EbsVolumeJanitorSpec) DeepCopy() *EbsVolumeJanitorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EbsVolumeJanitorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EbsVolumeJanitorStatus) DeepCopyInto(out *EbsVolumeJanitorStatus) {
+	*out = *in
+	if in.LastScanTime != nil {
+		in, out := &in.LastScanTime, &out.LastScanTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]UnattachedVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EbsVolumeJanitorStatus.
+func (in *EbsVolumeJanitorStatus) DeepCopy() *EbsVolumeJanitorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EbsVolumeJanitorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Ec2CostOptimizer) DeepCopyInto(out *Ec2CostOptimizer) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2CostOptimizer.
+func (in *Ec2CostOptimizer) DeepCopy() *Ec2CostOptimizer {
+	if in == nil {
+		return nil
+	}
+	out := new(Ec2CostOptimizer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Ec2CostOptimizer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Ec2CostOptimizerList) DeepCopyInto(out *Ec2CostOptimizerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Ec2CostOptimizer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2CostOptimizerList.
+func (in *Ec2CostOptimizerList) DeepCopy() *Ec2CostOptimizerList {
+	if in == nil {
+		return nil
+	}
+	out := new(Ec2CostOptimizerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Ec2CostOptimizerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Ec2CostOptimizerSpec) DeepCopyInto(out *Ec2CostOptimizerSpec) {
+	*out = *in
+	if in.InstanceIDs != nil {
+		in, out := &in.InstanceIDs, &out.InstanceIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IdlePolicy != nil {
+		in, out := &in.IdlePolicy, &out.IdlePolicy
+		*out = new(IdlePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Resize != nil {
+		in, out := &in.Resize, &out.Resize
+		*out = new(ResizePolicy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2CostOptimizerSpec.
+func (in *Ec2CostOptimizerSpec) DeepCopy() *Ec2CostOptimizerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(Ec2CostOptimizerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Ec2CostOptimizerStatus) DeepCopyInto(out *Ec2CostOptimizerStatus) {
+	*out = *in
+	in.OperationStatus.DeepCopyInto(&out.OperationStatus)
+	if in.SkippedInstances != nil {
+		in, out := &in.SkippedInstances, &out.SkippedInstances
+		*out = make([]SkippedInstance, len(*in))
+		copy(*out, *in)
+	}
+	if in.InstanceUsage != nil {
+		in, out := &in.InstanceUsage, &out.InstanceUsage
+		*out = make([]InstanceUsage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ResizedInstances != nil {
+		in, out := &in.ResizedInstances, &out.ResizedInstances
+		*out = make([]ResizedInstance, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Savings != nil {
+		in, out := &in.Savings, &out.Savings
+		*out = new(Savings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2CostOptimizerStatus.
+func (in *Ec2CostOptimizerStatus) DeepCopy() *Ec2CostOptimizerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(Ec2CostOptimizerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Ec2InstanceSelector) DeepCopyInto(out *Ec2InstanceSelector) {
+	*out = *in
+	if in.InstanceIDs != nil {
+		in, out := &in.InstanceIDs, &out.InstanceIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2InstanceSelector.
+func (in *Ec2InstanceSelector) DeepCopy() *Ec2InstanceSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(Ec2InstanceSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Ec2RightsizingReport) DeepCopyInto(out *Ec2RightsizingReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2RightsizingReport.
+func (in *Ec2RightsizingReport) DeepCopy() *Ec2RightsizingReport {
+	if in == nil {
+		return nil
+	}
+	out := new(Ec2RightsizingReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Ec2RightsizingReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Ec2RightsizingReportList) DeepCopyInto(out *Ec2RightsizingReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Ec2RightsizingReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2RightsizingReportList.
+func (in *Ec2RightsizingReportList) DeepCopy() *Ec2RightsizingReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(Ec2RightsizingReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Ec2RightsizingReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Ec2RightsizingReportSpec) DeepCopyInto(out *Ec2RightsizingReportSpec) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+	if in.LookbackPeriod != nil {
+		in, out := &in.LookbackPeriod, &out.LookbackPeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ReportInterval != nil {
+		in, out := &in.ReportInterval, &out.ReportInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2RightsizingReportSpec.
+func (in *Ec2RightsizingReportSpec) DeepCopy() *Ec2RightsizingReportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(Ec2RightsizingReportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Ec2RightsizingReportStatus) DeepCopyInto(out *Ec2RightsizingReportStatus) {
+	*out = *in
+	if in.LastReportTime != nil {
+		in, out := &in.LastReportTime, &out.LastReportTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Instances != nil {
+		in, out := &in.Instances, &out.Instances
+		*out = make([]InstanceRecommendations, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2RightsizingReportStatus.
+func (in *Ec2RightsizingReportStatus) DeepCopy() *Ec2RightsizingReportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(Ec2RightsizingReportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EcsServiceOptimizer) DeepCopyInto(out *EcsServiceOptimizer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EcsServiceOptimizer.
+func (in *EcsServiceOptimizer) DeepCopy() *EcsServiceOptimizer {
+	if in == nil {
+		return nil
+	}
+	out := new(EcsServiceOptimizer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EcsServiceOptimizer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EcsServiceOptimizerList) DeepCopyInto(out *EcsServiceOptimizerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EcsServiceOptimizer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EcsServiceOptimizerList.
+func (in *EcsServiceOptimizerList) DeepCopy() *EcsServiceOptimizerList {
+	if in == nil {
+		return nil
+	}
+	out := new(EcsServiceOptimizerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EcsServiceOptimizerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EcsServiceOptimizerSpec) DeepCopyInto(out *EcsServiceOptimizerSpec) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EcsServiceOptimizerSpec.
+func (in *EcsServiceOptimizerSpec) DeepCopy() *EcsServiceOptimizerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EcsServiceOptimizerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EcsServiceOptimizerStatus) DeepCopyInto(out *EcsServiceOptimizerStatus) {
+	*out = *in
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]EcsServiceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EcsServiceOptimizerStatus.
+func (in *EcsServiceOptimizerStatus) DeepCopy() *EcsServiceOptimizerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EcsServiceOptimizerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EcsServiceStatus) DeepCopyInto(out *EcsServiceStatus) {
+	*out = *in
+	if in.SavedDesiredCount != nil {
+		in, out := &in.SavedDesiredCount, &out.SavedDesiredCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EcsServiceStatus.
+func (in *EcsServiceStatus) DeepCopy() *EcsServiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EcsServiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EksCostOptimizer) DeepCopyInto(out *EksCostOptimizer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EksCostOptimizer.
+func (in *EksCostOptimizer) DeepCopy() *EksCostOptimizer {
+	if in == nil {
+		return nil
+	}
+	out := new(EksCostOptimizer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EksCostOptimizer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EksCostOptimizerList) DeepCopyInto(out *EksCostOptimizerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EksCostOptimizer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EksCostOptimizerList.
+func (in *EksCostOptimizerList) DeepCopy() *EksCostOptimizerList {
+	if in == nil {
+		return nil
+	}
+	out := new(EksCostOptimizerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EksCostOptimizerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EksCostOptimizerSpec) DeepCopyInto(out *EksCostOptimizerSpec) {
+	*out = *in
+	if in.NodeGroupNames != nil {
+		in, out := &in.NodeGroupNames, &out.NodeGroupNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.ScalingConfig = in.ScalingConfig
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(DrainPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EksCostOptimizerSpec.
+func (in *EksCostOptimizerSpec) DeepCopy() *EksCostOptimizerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EksCostOptimizerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EksCostOptimizerStatus) DeepCopyInto(out *EksCostOptimizerStatus) {
+	*out = *in
+	if in.NodeGroups != nil {
+		in, out := &in.NodeGroups, &out.NodeGroups
+		*out = make([]EksNodeGroupStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EksCostOptimizerStatus.
+func (in *EksCostOptimizerStatus) DeepCopy() *EksCostOptimizerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EksCostOptimizerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EksNodeGroupStatus) DeepCopyInto(out *EksNodeGroupStatus) {
+	*out = *in
+	if in.SavedScalingConfig != nil {
+		in, out := &in.SavedScalingConfig, &out.SavedScalingConfig
+		*out = new(EksScalingConfig)
+		**out = **in
+	}
+	if in.DrainStartTime != nil {
+		in, out := &in.DrainStartTime, &out.DrainStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EksNodeGroupStatus.
+func (in *EksNodeGroupStatus) DeepCopy() *EksNodeGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EksNodeGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EksScalingConfig) DeepCopyInto(out *EksScalingConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EksScalingConfig.
+func (in *EksScalingConfig) DeepCopy() *EksScalingConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EksScalingConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElastiCacheDownscale) DeepCopyInto(out *ElastiCacheDownscale) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElastiCacheDownscale.
+func (in *ElastiCacheDownscale) DeepCopy() *ElastiCacheDownscale {
+	if in == nil {
+		return nil
+	}
+	out := new(ElastiCacheDownscale)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticIpJanitor) DeepCopyInto(out *ElasticIpJanitor) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticIpJanitor.
+func (in *ElasticIpJanitor) DeepCopy() *ElasticIpJanitor {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticIpJanitor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticIpJanitor) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticIpJanitorList) DeepCopyInto(out *ElasticIpJanitorList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElasticIpJanitor, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticIpJanitorList.
+func (in *ElasticIpJanitorList) DeepCopy() *ElasticIpJanitorList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticIpJanitorList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticIpJanitorList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticIpJanitorSpec) DeepCopyInto(out *ElasticIpJanitorSpec) {
+	*out = *in
+	if in.Regions != nil {
+		in, out := &in.Regions, &out.Regions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.IdleDuration != nil {
+		in, out := &in.IdleDuration, &out.IdleDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ScanInterval != nil {
+		in, out := &in.ScanInterval, &out.ScanInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticIpJanitorSpec.
+func (in *ElasticIpJanitorSpec) DeepCopy() *ElasticIpJanitorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticIpJanitorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticIpJanitorStatus) DeepCopyInto(out *ElasticIpJanitorStatus) {
+	*out = *in
+	if in.LastScanTime != nil {
+		in, out := &in.LastScanTime, &out.LastScanTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]UnassociatedAddress, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticIpJanitorStatus.
+func (in *ElasticIpJanitorStatus) DeepCopy() *ElasticIpJanitorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticIpJanitorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExpiredSnapshot) DeepCopyInto(out *ExpiredSnapshot) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExpiredSnapshot.
+func (in *ExpiredSnapshot) DeepCopy() *ExpiredSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(ExpiredSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdlePolicy) DeepCopyInto(out *IdlePolicy) {
+	*out = *in
+	if in.LookbackPeriod != nil {
+		in, out := &in.LookbackPeriod, &out.LookbackPeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdlePolicy.
+func (in *IdlePolicy) DeepCopy() *IdlePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(IdlePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceRecommendations) DeepCopyInto(out *InstanceRecommendations) {
+	*out = *in
+	if in.Recommendations != nil {
+		in, out := &in.Recommendations, &out.Recommendations
+		*out = make([]Recommendation, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceRecommendations.
+func (in *InstanceRecommendations) DeepCopy() *InstanceRecommendations {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceRecommendations)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceSavings) DeepCopyInto(out *InstanceSavings) {
+	*out = *in
+	if in.StoppedTime != nil {
+		in, out := &in.StoppedTime, &out.StoppedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceSavings.
+func (in *InstanceSavings) DeepCopy() *InstanceSavings {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceSavings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceUsage) DeepCopyInto(out *InstanceUsage) {
+	*out = *in
+	in.EvaluationTime.DeepCopyInto(&out.EvaluationTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceUsage.
+func (in *InstanceUsage) DeepCopy() *InstanceUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatGatewayCostOptimizer) DeepCopyInto(out *NatGatewayCostOptimizer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatGatewayCostOptimizer.
+func (in *NatGatewayCostOptimizer) DeepCopy() *NatGatewayCostOptimizer {
+	if in == nil {
+		return nil
+	}
+	out := new(NatGatewayCostOptimizer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NatGatewayCostOptimizer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatGatewayCostOptimizerList) DeepCopyInto(out *NatGatewayCostOptimizerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NatGatewayCostOptimizer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatGatewayCostOptimizerList.
+func (in *NatGatewayCostOptimizerList) DeepCopy() *NatGatewayCostOptimizerList {
+	if in == nil {
+		return nil
+	}
+	out := new(NatGatewayCostOptimizerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NatGatewayCostOptimizerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatGatewayCostOptimizerSpec) DeepCopyInto(out *NatGatewayCostOptimizerSpec) {
+	*out = *in
+	if in.NatGatewayIDs != nil {
+		in, out := &in.NatGatewayIDs, &out.NatGatewayIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatGatewayCostOptimizerSpec.
+func (in *NatGatewayCostOptimizerSpec) DeepCopy() *NatGatewayCostOptimizerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NatGatewayCostOptimizerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatGatewayCostOptimizerStatus) DeepCopyInto(out *NatGatewayCostOptimizerStatus) {
+	*out = *in
+	if in.NatGateways != nil {
+		in, out := &in.NatGateways, &out.NatGateways
+		*out = make([]NatGatewayStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatGatewayCostOptimizerStatus.
+func (in *NatGatewayCostOptimizerStatus) DeepCopy() *NatGatewayCostOptimizerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NatGatewayCostOptimizerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatGatewayRoute) DeepCopyInto(out *NatGatewayRoute) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatGatewayRoute.
+func (in *NatGatewayRoute) DeepCopy() *NatGatewayRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(NatGatewayRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatGatewayStatus) DeepCopyInto(out *NatGatewayStatus) {
+	*out = *in
+	if in.SavedConfig != nil {
+		in, out := &in.SavedConfig, &out.SavedConfig
+		*out = new(SavedNatGateway)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatGatewayStatus.
+func (in *NatGatewayStatus) DeepCopy() *NatGatewayStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NatGatewayStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotebookInstanceStatus) DeepCopyInto(out *NotebookInstanceStatus) {
+	*out = *in
+	if in.StoppedTime != nil {
+		in, out := &in.StoppedTime, &out.StoppedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotebookInstanceStatus.
+func (in *NotebookInstanceStatus) DeepCopy() *NotebookInstanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NotebookInstanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenSearchDownscale) DeepCopyInto(out *OpenSearchDownscale) {
+	*out = *in
+	if in.InstanceCount != nil {
+		in, out := &in.InstanceCount, &out.InstanceCount
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenSearchDownscale.
+func (in *OpenSearchDownscale) DeepCopy() *OpenSearchDownscale {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenSearchDownscale)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationStatus) DeepCopyInto(out *OperationStatus) {
+	*out = *in
+	if in.NextRetryTime != nil {
+		in, out := &in.NextRetryTime, &out.NextRetryTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationStatus.
+func (in *OperationStatus) DeepCopy() *OperationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RdsCostOptimizer) DeepCopyInto(out *RdsCostOptimizer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RdsCostOptimizer.
+func (in *RdsCostOptimizer) DeepCopy() *RdsCostOptimizer {
+	if in == nil {
+		return nil
+	}
+	out := new(RdsCostOptimizer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RdsCostOptimizer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RdsCostOptimizerList) DeepCopyInto(out *RdsCostOptimizerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RdsCostOptimizer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RdsCostOptimizerList.
+func (in *RdsCostOptimizerList) DeepCopy() *RdsCostOptimizerList {
+	if in == nil {
+		return nil
+	}
+	out := new(RdsCostOptimizerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RdsCostOptimizerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RdsCostOptimizerSpec) DeepCopyInto(out *RdsCostOptimizerSpec) {
+	*out = *in
+	if in.DBInstanceIdentifiers != nil {
+		in, out := &in.DBInstanceIdentifiers, &out.DBInstanceIdentifiers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DBClusterIdentifiers != nil {
+		in, out := &in.DBClusterIdentifiers, &out.DBClusterIdentifiers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RdsCostOptimizerSpec.
+func (in *RdsCostOptimizerSpec) DeepCopy() *RdsCostOptimizerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RdsCostOptimizerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RdsCostOptimizerStatus) DeepCopyInto(out *RdsCostOptimizerStatus) {
+	*out = *in
+	in.OperationStatus.DeepCopyInto(&out.OperationStatus)
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]DatabaseStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RdsCostOptimizerStatus.
+func (in *RdsCostOptimizerStatus) DeepCopy() *RdsCostOptimizerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RdsCostOptimizerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Recommendation) DeepCopyInto(out *Recommendation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Recommendation.
+func (in *Recommendation) DeepCopy() *Recommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(Recommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedshiftClusterStatus) DeepCopyInto(out *RedshiftClusterStatus) {
+	*out = *in
+	if in.LastActionTime != nil {
+		in, out := &in.LastActionTime, &out.LastActionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Transitions != nil {
+		in, out := &in.Transitions, &out.Transitions
+		*out = make([]StatusTransition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedshiftClusterStatus.
+func (in *RedshiftClusterStatus) DeepCopy() *RedshiftClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RedshiftClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedshiftCostOptimizer) DeepCopyInto(out *RedshiftCostOptimizer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedshiftCostOptimizer.
+func (in *RedshiftCostOptimizer) DeepCopy() *RedshiftCostOptimizer {
+	if in == nil {
+		return nil
+	}
+	out := new(RedshiftCostOptimizer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RedshiftCostOptimizer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedshiftCostOptimizerList) DeepCopyInto(out *RedshiftCostOptimizerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RedshiftCostOptimizer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedshiftCostOptimizerList.
+func (in *RedshiftCostOptimizerList) DeepCopy() *RedshiftCostOptimizerList {
+	if in == nil {
+		return nil
+	}
+	out := new(RedshiftCostOptimizerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RedshiftCostOptimizerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedshiftCostOptimizerSpec) DeepCopyInto(out *RedshiftCostOptimizerSpec) {
+	*out = *in
+	if in.ClusterIdentifiers != nil {
+		in, out := &in.ClusterIdentifiers, &out.ClusterIdentifiers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedshiftCostOptimizerSpec.
+func (in *RedshiftCostOptimizerSpec) DeepCopy() *RedshiftCostOptimizerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RedshiftCostOptimizerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedshiftCostOptimizerStatus) DeepCopyInto(out *RedshiftCostOptimizerStatus) {
+	*out = *in
+	in.OperationStatus.DeepCopyInto(&out.OperationStatus)
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]RedshiftClusterStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedshiftCostOptimizerStatus.
+func (in *RedshiftCostOptimizerStatus) DeepCopy() *RedshiftCostOptimizerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RedshiftCostOptimizerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResizePolicy) DeepCopyInto(out *ResizePolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResizePolicy.
+func (in *ResizePolicy) DeepCopy() *ResizePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ResizePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResizedInstance) DeepCopyInto(out *ResizedInstance) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResizedInstance.
+func (in *ResizedInstance) DeepCopy() *ResizedInstance {
+	if in == nil {
+		return nil
+	}
+	out := new(ResizedInstance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceCostOptimizer) DeepCopyInto(out *ResourceCostOptimizer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceCostOptimizer.
+func (in *ResourceCostOptimizer) DeepCopy() *ResourceCostOptimizer {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceCostOptimizer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ResourceCostOptimizer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceCostOptimizerList) DeepCopyInto(out *ResourceCostOptimizerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ResourceCostOptimizer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceCostOptimizerList.
+func (in *ResourceCostOptimizerList) DeepCopy() *ResourceCostOptimizerList {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceCostOptimizerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ResourceCostOptimizerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceCostOptimizerSpec) DeepCopyInto(out *ResourceCostOptimizerSpec) {
+	*out = *in
+	if in.ResourceIDs != nil {
+		in, out := &in.ResourceIDs, &out.ResourceIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceCostOptimizerSpec.
+func (in *ResourceCostOptimizerSpec) DeepCopy() *ResourceCostOptimizerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceCostOptimizerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceCostOptimizerStatus) DeepCopyInto(out *ResourceCostOptimizerStatus) {
+	*out = *in
+	in.OperationStatus.DeepCopyInto(&out.OperationStatus)
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]SchedulableResourceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceCostOptimizerStatus.
+func (in *ResourceCostOptimizerStatus) DeepCopy() *ResourceCostOptimizerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceCostOptimizerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetentionRules) DeepCopyInto(out *RetentionRules) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetentionRules.
+func (in *RetentionRules) DeepCopy() *RetentionRules {
+	if in == nil {
+		return nil
+	}
+	out := new(RetentionRules)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
+	*out = *in
+	if in.InitialBackoff != nil {
+		in, out := &in.InitialBackoff, &out.InitialBackoff
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxBackoff != nil {
+		in, out := &in.MaxBackoff, &out.MaxBackoff
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryPolicy.
+func (in *RetryPolicy) DeepCopy() *RetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SageMakerCostOptimizer) DeepCopyInto(out *SageMakerCostOptimizer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SageMakerCostOptimizer.
+func (in *SageMakerCostOptimizer) DeepCopy() *SageMakerCostOptimizer {
+	if in == nil {
+		return nil
+	}
+	out := new(SageMakerCostOptimizer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SageMakerCostOptimizer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SageMakerCostOptimizerList) DeepCopyInto(out *SageMakerCostOptimizerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SageMakerCostOptimizer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SageMakerCostOptimizerList.
+func (in *SageMakerCostOptimizerList) DeepCopy() *SageMakerCostOptimizerList {
+	if in == nil {
+		return nil
+	}
+	out := new(SageMakerCostOptimizerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SageMakerCostOptimizerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SageMakerCostOptimizerSpec) DeepCopyInto(out *SageMakerCostOptimizerSpec) {
+	*out = *in
+	if in.NotebookInstanceNames != nil {
+		in, out := &in.NotebookInstanceNames, &out.NotebookInstanceNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StudioApps != nil {
+		in, out := &in.StudioApps, &out.StudioApps
+		*out = new(StudioAppPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SageMakerCostOptimizerSpec.
+func (in *SageMakerCostOptimizerSpec) DeepCopy() *SageMakerCostOptimizerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SageMakerCostOptimizerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SageMakerCostOptimizerStatus) DeepCopyInto(out *SageMakerCostOptimizerStatus) {
+	*out = *in
+	in.OperationStatus.DeepCopyInto(&out.OperationStatus)
+	if in.NotebookInstances != nil {
+		in, out := &in.NotebookInstances, &out.NotebookInstances
+		*out = make([]NotebookInstanceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DeletedApps != nil {
+		in, out := &in.DeletedApps, &out.DeletedApps
+		*out = make([]DeletedStudioApp, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SageMakerCostOptimizerStatus.
+func (in *SageMakerCostOptimizerStatus) DeepCopy() *SageMakerCostOptimizerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SageMakerCostOptimizerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SavedNatGateway) DeepCopyInto(out *SavedNatGateway) {
+	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]NatGatewayRoute, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SavedNatGateway.
+func (in *SavedNatGateway) DeepCopy() *SavedNatGateway {
+	if in == nil {
+		return nil
+	}
+	out := new(SavedNatGateway)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Savings) DeepCopyInto(out *Savings) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	if in.Instances != nil {
+		in, out := &in.Instances, &out.Instances
+		*out = make([]InstanceSavings, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Savings.
+func (in *Savings) DeepCopy() *Savings {
+	if in == nil {
+		return nil
+	}
+	out := new(Savings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaledWorkload) DeepCopyInto(out *ScaledWorkload) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaledWorkload.
+func (in *ScaledWorkload) DeepCopy() *ScaledWorkload {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaledWorkload)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchedulableResourceStatus) DeepCopyInto(out *SchedulableResourceStatus) {
+	*out = *in
+	if in.LastActionTime != nil {
+		in, out := &in.LastActionTime, &out.LastActionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchedulableResourceStatus.
+func (in *SchedulableResourceStatus) DeepCopy() *SchedulableResourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SchedulableResourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyReference.
+func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SkippedInstance) DeepCopyInto(out *SkippedInstance) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SkippedInstance.
+func (in *SkippedInstance) DeepCopy() *SkippedInstance {
+	if in == nil {
+		return nil
+	}
+	out := new(SkippedInstance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotRetentionPolicy) DeepCopyInto(out *SnapshotRetentionPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2CostOptimizer.
-func (in *Ec2CostOptimizer) DeepCopy() *Ec2CostOptimizer {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotRetentionPolicy.
+func (in *SnapshotRetentionPolicy) DeepCopy() *SnapshotRetentionPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(Ec2CostOptimizer)
+	out := new(SnapshotRetentionPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Ec2CostOptimizer) DeepCopyObject() runtime.Object {
+func (in *SnapshotRetentionPolicy) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -53,31 +2501,31 @@ func (in *Ec2CostOptimizer) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Ec2CostOptimizerList) DeepCopyInto(out *Ec2CostOptimizerList) {
+func (in *SnapshotRetentionPolicyList) DeepCopyInto(out *SnapshotRetentionPolicyList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]Ec2CostOptimizer, len(*in))
+		*out = make([]SnapshotRetentionPolicy, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2CostOptimizerList.
-func (in *Ec2CostOptimizerList) DeepCopy() *Ec2CostOptimizerList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotRetentionPolicyList.
+func (in *SnapshotRetentionPolicyList) DeepCopy() *SnapshotRetentionPolicyList {
 	if in == nil {
 		return nil
 	}
-	out := new(Ec2CostOptimizerList)
+	out := new(SnapshotRetentionPolicyList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Ec2CostOptimizerList) DeepCopyObject() runtime.Object {
+func (in *SnapshotRetentionPolicyList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -85,36 +2533,280 @@ func (in *Ec2CostOptimizerList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Ec2CostOptimizerSpec) DeepCopyInto(out *Ec2CostOptimizerSpec) {
+func (in *SnapshotRetentionPolicySpec) DeepCopyInto(out *SnapshotRetentionPolicySpec) {
 	*out = *in
-	if in.InstanceIDs != nil {
-		in, out := &in.InstanceIDs, &out.InstanceIDs
+	in.Selector.DeepCopyInto(&out.Selector)
+	out.Retention = in.Retention
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotRetentionPolicySpec.
+func (in *SnapshotRetentionPolicySpec) DeepCopy() *SnapshotRetentionPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotRetentionPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotRetentionPolicyStatus) DeepCopyInto(out *SnapshotRetentionPolicyStatus) {
+	*out = *in
+	if in.LastRunTime != nil {
+		in, out := &in.LastRunTime, &out.LastRunTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ExpiredSnapshots != nil {
+		in, out := &in.ExpiredSnapshots, &out.ExpiredSnapshots
+		*out = make([]ExpiredSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotRetentionPolicyStatus.
+func (in *SnapshotRetentionPolicyStatus) DeepCopy() *SnapshotRetentionPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotRetentionPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotSelector) DeepCopyInto(out *SnapshotSelector) {
+	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.VolumeIDs != nil {
+		in, out := &in.VolumeIDs, &out.VolumeIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OwnerIDs != nil {
+		in, out := &in.OwnerIDs, &out.OwnerIDs
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2CostOptimizerSpec.
-func (in *Ec2CostOptimizerSpec) DeepCopy() *Ec2CostOptimizerSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotSelector.
+func (in *SnapshotSelector) DeepCopy() *SnapshotSelector {
 	if in == nil {
 		return nil
 	}
-	out := new(Ec2CostOptimizerSpec)
+	out := new(SnapshotSelector)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Ec2CostOptimizerStatus) DeepCopyInto(out *Ec2CostOptimizerStatus) {
+func (in *StaleImage) DeepCopyInto(out *StaleImage) {
 	*out = *in
+	in.CreationDate.DeepCopyInto(&out.CreationDate)
+	if in.SnapshotIDs != nil {
+		in, out := &in.SnapshotIDs, &out.SnapshotIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeletionTime != nil {
+		in, out := &in.DeletionTime, &out.DeletionTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2CostOptimizerStatus.
-func (in *Ec2CostOptimizerStatus) DeepCopy() *Ec2CostOptimizerStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaleImage.
+func (in *StaleImage) DeepCopy() *StaleImage {
 	if in == nil {
 		return nil
 	}
-	out := new(Ec2CostOptimizerStatus)
+	out := new(StaleImage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatusTransition) DeepCopyInto(out *StatusTransition) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatusTransition.
+func (in *StatusTransition) DeepCopy() *StatusTransition {
+	if in == nil {
+		return nil
+	}
+	out := new(StatusTransition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StudioAppPolicy) DeepCopyInto(out *StudioAppPolicy) {
+	*out = *in
+	if in.IdleThreshold != nil {
+		in, out := &in.IdleThreshold, &out.IdleThreshold
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StudioAppPolicy.
+func (in *StudioAppPolicy) DeepCopy() *StudioAppPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(StudioAppPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnassociatedAddress) DeepCopyInto(out *UnassociatedAddress) {
+	*out = *in
+	if in.FirstSeenTime != nil {
+		in, out := &in.FirstSeenTime, &out.FirstSeenTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ReleaseTime != nil {
+		in, out := &in.ReleaseTime, &out.ReleaseTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnassociatedAddress.
+func (in *UnassociatedAddress) DeepCopy() *UnassociatedAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(UnassociatedAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnattachedVolume) DeepCopyInto(out *UnattachedVolume) {
+	*out = *in
+	in.CreateTime.DeepCopyInto(&out.CreateTime)
+	if in.DeletionTime != nil {
+		in, out := &in.DeletionTime, &out.DeletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnattachedVolume.
+func (in *UnattachedVolume) DeepCopy() *UnattachedVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(UnattachedVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadScheduleOptimizer) DeepCopyInto(out *WorkloadScheduleOptimizer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadScheduleOptimizer.
+func (in *WorkloadScheduleOptimizer) DeepCopy() *WorkloadScheduleOptimizer {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadScheduleOptimizer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkloadScheduleOptimizer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadScheduleOptimizerList) DeepCopyInto(out *WorkloadScheduleOptimizerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WorkloadScheduleOptimizer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadScheduleOptimizerList.
+func (in *WorkloadScheduleOptimizerList) DeepCopy() *WorkloadScheduleOptimizerList {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadScheduleOptimizerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkloadScheduleOptimizerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadScheduleOptimizerSpec) DeepCopyInto(out *WorkloadScheduleOptimizerSpec) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadScheduleOptimizerSpec.
+func (in *WorkloadScheduleOptimizerSpec) DeepCopy() *WorkloadScheduleOptimizerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadScheduleOptimizerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadScheduleOptimizerStatus) DeepCopyInto(out *WorkloadScheduleOptimizerStatus) {
+	*out = *in
+	if in.ScaledWorkloads != nil {
+		in, out := &in.ScaledWorkloads, &out.ScaledWorkloads
+		*out = make([]ScaledWorkload, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadScheduleOptimizerStatus.
+func (in *WorkloadScheduleOptimizerStatus) DeepCopy() *WorkloadScheduleOptimizerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadScheduleOptimizerStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -0,0 +1,313 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/pricing"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	defaultAmiMinAge       = 90 * 24 * time.Hour
+	defaultAmiGracePeriod  = 7 * 24 * time.Hour
+	defaultAmiScanInterval = 24 * time.Hour
+)
+
+// amiOperations are the ec2 operations performed on the amis and their snapshots.
+type amiOperations interface {
+	describeOwnedAmis(logger logr.Logger, region string, tags map[string]string) ([]utils.Ami, error)
+	describeUsedAmiIDs(logger logr.Logger, region string) (map[string]bool, error)
+	createTags(logger logr.Logger, region string, resourceIDs []string, tags map[string]string) error
+	deleteTags(logger logr.Logger, region string, resourceIDs []string, keys ...string) error
+	deregisterAmi(logger logr.Logger, region, imageID string) error
+	deleteSnapshot(logger logr.Logger, region, snapshotID string) error
+}
+
+// awsAmiOperations performs the ec2 operations through the aws cli.
+type awsAmiOperations struct{}
+
+func (awsAmiOperations) describeOwnedAmis(logger logr.Logger, region string, tags map[string]string) ([]utils.Ami, error) {
+	return utils.DescribeOwnedAmis(logger, region, tags)
+}
+
+func (awsAmiOperations) describeUsedAmiIDs(logger logr.Logger, region string) (map[string]bool, error) {
+	return utils.DescribeUsedAmiIDs(logger, region)
+}
+
+func (awsAmiOperations) createTags(logger logr.Logger, region string, resourceIDs []string, tags map[string]string) error {
+	return utils.CreateEc2Tags(logger, region, resourceIDs, tags)
+}
+
+func (awsAmiOperations) deleteTags(logger logr.Logger, region string, resourceIDs []string, keys ...string) error {
+	return utils.DeleteEc2Tags(logger, region, resourceIDs, keys...)
+}
+
+func (awsAmiOperations) deregisterAmi(logger logr.Logger, region, imageID string) error {
+	return utils.DeregisterAmi(logger, region, imageID)
+}
+
+func (awsAmiOperations) deleteSnapshot(logger logr.Logger, region, snapshotID string) error {
+	return utils.DeleteEbsSnapshot(logger, region, snapshotID)
+}
+
+// AmiJanitorReconciler reconciles a AmiJanitor object
+type AmiJanitorReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Prices is the catalog of prices used to estimate the savings, savings are not estimated
+	// if nil.
+	Prices pricing.Catalog
+	logger logr.Logger
+	// operations performed on the amis, the aws cli is used if not set.
+	operations amiOperations
+}
+
+// amis returns the operations performed on the amis.
+func (r *AmiJanitorReconciler) amis() amiOperations {
+	if r.operations == nil {
+		return awsAmiOperations{}
+	}
+	return r.operations
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AmiJanitorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.AmiJanitor{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=amijanitors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=amijanitors/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=amijanitors/finalizers,verbs=update
+
+// Reconcile scans the amis owned by the account at the scan interval. Amis which reached the
+// min age and are not used by any instance, launch template or launch configuration are tagged
+// as pending deletion, and deregistered along with their snapshots once the grace period is
+// over. Amis which get used within the grace period are kept.
+func (r *AmiJanitorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling AmiJanitor ...")
+
+	janitor := &costoptimizerv1alpha1.AmiJanitor{}
+	if err := r.Get(ctx, req.NamespacedName, janitor); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	policy := newJanitorPolicy(janitor.Spec.MinAge, janitor.Spec.GracePeriod, janitor.Spec.ScanInterval, janitorPolicy{
+		minAge:       defaultAmiMinAge,
+		gracePeriod:  defaultAmiGracePeriod,
+		scanInterval: defaultAmiScanInterval,
+	})
+	if last := janitor.Status.LastScanTime; last != nil && janitor.Status.ObservedGeneration == janitor.Generation {
+		if wait := time.Until(last.Add(policy.scanInterval)); wait > 0 {
+			r.logger.V(1).Info("scan is up to date", "next scan after", wait.String())
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	amis, err := r.amis().describeOwnedAmis(r.logger, janitor.Spec.Region, janitor.Spec.Tags)
+	if err != nil {
+		return r.scanError(ctx, janitor, policy, err)
+	}
+	used, err := r.amis().describeUsedAmiIDs(r.logger, janitor.Spec.Region)
+	if err != nil {
+		return r.scanError(ctx, janitor, policy, err)
+	}
+	sort.Slice(amis, func(i, j int) bool { return amis[i].ImageID < amis[j].ImageID })
+
+	now := time.Now()
+	var images []costoptimizerv1alpha1.StaleImage
+	var deregistered int32
+	var reclaimedSize int64
+	for _, ami := range amis {
+		var entry costoptimizerv1alpha1.StaleImage
+		var reclaimed int32
+		if used[ami.ImageID] {
+			if _, marked := ami.Tags.Get(pendingDeletionTag); !marked {
+				continue
+			}
+			entry, err = r.keepImage(janitor, ami)
+		} else {
+			entry, reclaimed, err = r.handleImage(janitor, policy, ami, now)
+		}
+		if err != nil {
+			if _, action := utils.ClassifyError(err); action != utils.ActionSkip {
+				return r.scanError(ctx, janitor, policy, err)
+			}
+			entry.Message = err.Error()
+		}
+		if entry.Action == janitorActionDeleted {
+			deregistered++
+			reclaimedSize += int64(reclaimed)
+		}
+		images = append(images, entry)
+	}
+
+	if deregistered > 0 {
+		r.Recorder.Eventf(janitor, corev1.EventTypeNormal, eventReasonDeleted,
+			"Deregistered %d ami(s), reclaimed %d GiB of snapshots", deregistered, reclaimedSize)
+	}
+	scanTime := metav1.NewTime(now)
+	r.patchStatus(ctx, janitor, func(status *costoptimizerv1alpha1.AmiJanitorStatus) {
+		status.State = complete
+		status.Message = ""
+		status.ObservedGeneration = janitor.Generation
+		status.LastScanTime = &scanTime
+		status.DeregisteredImages += deregistered
+		status.ReclaimedSizeGiB += reclaimedSize
+		savings, _ := pricing.SnapshotMonthlyPrice(r.Prices, utils.ResolveRegion(janitor.Spec.Region), int32(status.ReclaimedSizeGiB))
+		status.EstimatedMonthlySavings = formatUSD(savings)
+		status.Images = images
+	})
+	return ctrl.Result{RequeueAfter: policy.scanInterval}, nil
+}
+
+// keepImage removes the pending deletion tag of the ami which got used within the grace period.
+func (r *AmiJanitorReconciler) keepImage(janitor *costoptimizerv1alpha1.AmiJanitor, ami utils.Ami) (costoptimizerv1alpha1.StaleImage, error) {
+	entry := newStaleImage(ami)
+	entry.Action = janitorActionKept
+	if janitor.Spec.DryRun {
+		return entry, nil
+	}
+	if err := r.amis().deleteTags(r.logger, janitor.Spec.Region, []string{ami.ImageID}, pendingDeletionTag); err != nil {
+		return entry, err
+	}
+	r.Recorder.Eventf(janitor, corev1.EventTypeNormal, eventReasonKept,
+		"Kept ami %s which got used within the grace period", ami.ImageID)
+	return entry, nil
+}
+
+// handleImage takes the next action on the unused ami, it returns its status entry and the size
+// of the snapshots deleted along with it.
+func (r *AmiJanitorReconciler) handleImage(janitor *costoptimizerv1alpha1.AmiJanitor, policy janitorPolicy, ami utils.Ami,
+	now time.Time) (costoptimizerv1alpha1.StaleImage, int32, error) {
+	entry := newStaleImage(ami)
+	action, deletionTime := policy.nextAction(ami.CreationDate, ami.Tags, now)
+	if action != janitorActionNone {
+		entry.DeletionTime = &metav1.Time{Time: deletionTime}
+	}
+	if janitor.Spec.DryRun {
+		entry.Action = janitorActionNone
+		if action != janitorActionNone {
+			entry.Action = janitorActionWouldDelete
+		}
+		return entry, 0, nil
+	}
+
+	entry.Action = action
+	switch action {
+	case janitorActionMarkedForDeletion:
+		err := r.amis().createTags(r.logger, janitor.Spec.Region, []string{ami.ImageID},
+			map[string]string{pendingDeletionTag: deletionTime.UTC().Format(time.RFC3339)})
+		if err != nil {
+			entry.Action, entry.DeletionTime = janitorActionNone, nil
+			return entry, 0, err
+		}
+		r.Recorder.Eventf(janitor, corev1.EventTypeNormal, eventReasonMarkedForDeletion,
+			"Ami %s (%s) is deregistered after %s unless it gets used", ami.ImageID, ami.Name, deletionTime.Format(time.RFC3339))
+	case janitorActionDeleted:
+		return r.deregisterImage(janitor, ami, entry)
+	}
+	return entry, 0, nil
+}
+
+// deregisterImage deregisters the ami and deletes its snapshots. Snapshots which cannot be
+// deleted, e.g. because they back another ami, are reported in the message of the entry.
+func (r *AmiJanitorReconciler) deregisterImage(janitor *costoptimizerv1alpha1.AmiJanitor, ami utils.Ami,
+	entry costoptimizerv1alpha1.StaleImage) (costoptimizerv1alpha1.StaleImage, int32, error) {
+	region := janitor.Spec.Region
+	if err := r.amis().deregisterAmi(r.logger, region, ami.ImageID); err != nil {
+		reason, action := utils.ClassifyError(err)
+		r.Recorder.Eventf(janitor, corev1.EventTypeWarning, eventReasonOperationFailed,
+			"Deregistering ami %s failed with reason %s (action: %s): %v", ami.ImageID, reason, action, err)
+		entry.Action = janitorActionPendingDeletion
+		return entry, 0, err
+	}
+
+	var reclaimed int32
+	var failures []string
+	for i, snapshotID := range ami.SnapshotIDs {
+		if err := r.amis().deleteSnapshot(r.logger, region, snapshotID); err != nil && !isNotFound(err) {
+			failures = append(failures, fmt.Sprintf("%s: %v", snapshotID, err))
+			continue
+		}
+		if i < len(ami.VolumeSizes) {
+			reclaimed += ami.VolumeSizes[i]
+		}
+	}
+	if len(failures) > 0 {
+		entry.Message = "snapshots left behind, " + strings.Join(failures, "; ")
+		r.Recorder.Eventf(janitor, corev1.EventTypeWarning, eventReasonOperationFailed,
+			"Deregistered ami %s but failed to delete its snapshots: %s", ami.ImageID, strings.Join(failures, "; "))
+	}
+	entry.Action = janitorActionDeleted
+	return entry, reclaimed, nil
+}
+
+func newStaleImage(ami utils.Ami) costoptimizerv1alpha1.StaleImage {
+	entry := costoptimizerv1alpha1.StaleImage{
+		ImageID:      ami.ImageID,
+		Name:         ami.Name,
+		CreationDate: metav1.NewTime(ami.CreationDate),
+		SnapshotIDs:  ami.SnapshotIDs,
+	}
+	for _, size := range ami.VolumeSizes {
+		entry.SizeGiB += size
+	}
+	return entry
+}
+
+// scanError marks the scan as failed, errors which cannot be resolved by retrying are retried
+// at the next scan interval.
+func (r *AmiJanitorReconciler) scanError(ctx context.Context, janitor *costoptimizerv1alpha1.AmiJanitor,
+	policy janitorPolicy, err error) (ctrl.Result, error) {
+	r.logger.Error(err, "unable to scan amis")
+	r.patchStatus(ctx, janitor, func(status *costoptimizerv1alpha1.AmiJanitorStatus) {
+		status.State = failed
+		status.Message = err.Error()
+		status.ObservedGeneration = janitor.Generation
+	})
+	if _, action := utils.ClassifyError(err); action == utils.ActionFail {
+		return ctrl.Result{RequeueAfter: policy.scanInterval}, nil
+	}
+	return ctrl.Result{}, err
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *AmiJanitorReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.AmiJanitor,
+	mutate func(status *costoptimizerv1alpha1.AmiJanitorStatus)) {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+	}
+}
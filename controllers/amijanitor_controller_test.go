@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	"k8s.io/client-go/tools/record"
+)
+
+// fakeAmiOperations performs the ec2 operations on the amis, the operations fail with the error
+// given for "<operation> <id>". The operations performed are recorded in order.
+type fakeAmiOperations struct {
+	errs       map[string]error
+	operations []string
+}
+
+func (f *fakeAmiOperations) operate(operation, id string) error {
+	f.operations = append(f.operations, operation+" "+id)
+	return f.errs[operation+" "+id]
+}
+
+func (f *fakeAmiOperations) describeOwnedAmis(logr.Logger, string, map[string]string) ([]utils.Ami, error) {
+	return nil, nil
+}
+
+func (f *fakeAmiOperations) describeUsedAmiIDs(logr.Logger, string) (map[string]bool, error) {
+	return nil, nil
+}
+
+func (f *fakeAmiOperations) createTags(_ logr.Logger, _ string, ids []string, _ map[string]string) error {
+	return f.operate("tag", strings.Join(ids, ","))
+}
+
+func (f *fakeAmiOperations) deleteTags(_ logr.Logger, _ string, ids []string, _ ...string) error {
+	return f.operate("untag", strings.Join(ids, ","))
+}
+
+func (f *fakeAmiOperations) deregisterAmi(_ logr.Logger, _, id string) error {
+	return f.operate("deregister", id)
+}
+
+func (f *fakeAmiOperations) deleteSnapshot(_ logr.Logger, _, id string) error {
+	return f.operate("delete", id)
+}
+
+func TestAmiJanitorHandleImage(t *testing.T) {
+	policy := janitorPolicy{minAge: 24 * time.Hour, gracePeriod: 48 * time.Hour}
+	now := time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-72 * time.Hour)
+	expired := utils.Tags{{Key: pendingDeletionTag, Value: "2023-03-10T00:00:00Z"}}
+	inUse := &utils.AWSError{Code: "InvalidSnapshot.InUse", Operation: "DeleteSnapshot", Message: "in use by ami-2"}
+	notFound := &utils.AWSError{Code: "InvalidSnapshot.NotFound", Operation: "DeleteSnapshot", Message: "not found"}
+	denied := &utils.AWSError{Code: "UnauthorizedOperation", Operation: "DeregisterImage", Message: "denied"}
+
+	tests := []struct {
+		name       string
+		dryRun     bool
+		ami        utils.Ami
+		errs       map[string]error
+		err        error
+		action     string
+		reclaimed  int32
+		message    bool
+		operations []string
+		events     []string
+	}{
+		{
+			name:   "dry run",
+			dryRun: true,
+			ami:    utils.Ami{ImageID: "ami-1", CreationDate: old, Tags: expired},
+			action: janitorActionWouldDelete,
+		},
+		{
+			name:   "too young",
+			ami:    utils.Ami{ImageID: "ami-1", CreationDate: now.Add(-time.Hour)},
+			action: janitorActionNone,
+		},
+		{
+			name:       "marked for deletion",
+			ami:        utils.Ami{ImageID: "ami-1", CreationDate: old},
+			action:     janitorActionMarkedForDeletion,
+			operations: []string{"tag ami-1"},
+			events:     []string{eventReasonMarkedForDeletion},
+		},
+		{
+			name:   "within grace period",
+			ami:    utils.Ami{ImageID: "ami-1", CreationDate: old, Tags: utils.Tags{{Key: pendingDeletionTag, Value: "2023-03-11T00:00:00Z"}}},
+			action: janitorActionPendingDeletion,
+		},
+		{
+			name:       "deregistered",
+			ami:        utils.Ami{ImageID: "ami-1", CreationDate: old, Tags: expired, SnapshotIDs: []string{"snap-1", "snap-2"}, VolumeSizes: []int32{8, 100}},
+			errs:       map[string]error{"delete snap-2": notFound},
+			action:     janitorActionDeleted,
+			reclaimed:  108,
+			operations: []string{"deregister ami-1", "delete snap-1", "delete snap-2"},
+		},
+		{
+			name:       "snapshot left behind",
+			ami:        utils.Ami{ImageID: "ami-1", CreationDate: old, Tags: expired, SnapshotIDs: []string{"snap-1", "snap-2"}, VolumeSizes: []int32{8, 100}},
+			errs:       map[string]error{"delete snap-1": inUse},
+			action:     janitorActionDeleted,
+			reclaimed:  100,
+			message:    true,
+			operations: []string{"deregister ami-1", "delete snap-1", "delete snap-2"},
+			events:     []string{eventReasonOperationFailed},
+		},
+		{
+			name:       "deregistration failed",
+			ami:        utils.Ami{ImageID: "ami-1", CreationDate: old, Tags: expired, SnapshotIDs: []string{"snap-1"}, VolumeSizes: []int32{8}},
+			errs:       map[string]error{"deregister ami-1": denied},
+			err:        denied,
+			action:     janitorActionPendingDeletion,
+			operations: []string{"deregister ami-1"},
+			events:     []string{eventReasonOperationFailed},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			amis := &fakeAmiOperations{errs: test.errs}
+			recorder := record.NewFakeRecorder(10)
+			r := &AmiJanitorReconciler{Recorder: recorder, logger: logr.Discard(), operations: amis}
+			janitor := &costoptimizerv1alpha1.AmiJanitor{Spec: costoptimizerv1alpha1.AmiJanitorSpec{DryRun: test.dryRun}}
+
+			entry, reclaimed, err := r.handleImage(janitor, policy, test.ami, now)
+			if !errors.Is(err, test.err) {
+				t.Errorf("expected error %v, got %v", test.err, err)
+			}
+			if entry.Action != test.action || reclaimed != test.reclaimed || (entry.Message != "") != test.message {
+				t.Errorf("expected %s reclaiming %d GiB (message: %v), got %s reclaiming %d GiB (message: %q)",
+					test.action, test.reclaimed, test.message, entry.Action, reclaimed, entry.Message)
+			}
+			if !reflect.DeepEqual(amis.operations, test.operations) {
+				t.Errorf("expected operations %v, got %v", test.operations, amis.operations)
+			}
+			if events := eventReasons(recorder); !reflect.DeepEqual(events, test.events) {
+				t.Errorf("expected events %v, got %v", test.events, events)
+			}
+		})
+	}
+}
+
+func TestAmiJanitorKeepImage(t *testing.T) {
+	ami := utils.Ami{ImageID: "ami-1", Tags: utils.Tags{{Key: pendingDeletionTag, Value: "2023-03-11T00:00:00Z"}}}
+	for _, dryRun := range []bool{true, false} {
+		amis := &fakeAmiOperations{}
+		recorder := record.NewFakeRecorder(10)
+		r := &AmiJanitorReconciler{Recorder: recorder, logger: logr.Discard(), operations: amis}
+		janitor := &costoptimizerv1alpha1.AmiJanitor{Spec: costoptimizerv1alpha1.AmiJanitorSpec{DryRun: dryRun}}
+
+		entry, err := r.keepImage(janitor, ami)
+		if err != nil || entry.Action != janitorActionKept {
+			t.Errorf("dry run %v: expected the ami to be kept, got %s (error: %v)", dryRun, entry.Action, err)
+		}
+		var expected, events []string
+		if !dryRun {
+			expected, events = []string{"untag ami-1"}, []string{eventReasonKept}
+		}
+		if !reflect.DeepEqual(amis.operations, expected) {
+			t.Errorf("dry run %v: expected operations %v, got %v", dryRun, expected, amis.operations)
+		}
+		if got := eventReasons(recorder); !reflect.DeepEqual(got, events) {
+			t.Errorf("dry run %v: expected events %v, got %v", dryRun, events, got)
+		}
+	}
+}
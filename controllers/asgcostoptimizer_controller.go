@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// states of a scaled resource.
+const (
+	scaledDown = "ScaledDown"
+	restored   = "Restored"
+)
+
+// autoScalingOperations are the auto scaling operations performed on the groups.
+type autoScalingOperations interface {
+	describeGroups(logger logr.Logger, region string, names []string) ([]utils.AutoScalingGroup, error)
+	updateCapacity(logger logr.Logger, region, name string, minSize, maxSize, desiredCapacity int32) error
+	suspendProcesses(logger logr.Logger, region, name string, processes []string) error
+	resumeProcesses(logger logr.Logger, region, name string, processes []string) error
+}
+
+// awsAutoScalingOperations performs the auto scaling operations through the aws cli.
+type awsAutoScalingOperations struct{}
+
+func (awsAutoScalingOperations) describeGroups(logger logr.Logger, region string, names []string) ([]utils.AutoScalingGroup, error) {
+	return utils.DescribeAutoScalingGroups(logger, region, names)
+}
+
+func (awsAutoScalingOperations) updateCapacity(logger logr.Logger, region, name string, minSize, maxSize, desiredCapacity int32) error {
+	return utils.UpdateAutoScalingGroupCapacity(logger, region, name, minSize, maxSize, desiredCapacity)
+}
+
+func (awsAutoScalingOperations) suspendProcesses(logger logr.Logger, region, name string, processes []string) error {
+	return utils.SuspendAutoScalingProcesses(logger, region, name, processes)
+}
+
+func (awsAutoScalingOperations) resumeProcesses(logger logr.Logger, region, name string, processes []string) error {
+	return utils.ResumeAutoScalingProcesses(logger, region, name, processes)
+}
+
+// AsgCostOptimizerReconciler reconciles a AsgCostOptimizer object
+type AsgCostOptimizerReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	logger   logr.Logger
+	// operations performed on the groups, the aws cli is used if not set.
+	operations autoScalingOperations
+}
+
+// autoScaling returns the operations performed on the groups.
+func (r *AsgCostOptimizerReconciler) autoScaling() autoScalingOperations {
+	if r.operations == nil {
+		return awsAutoScalingOperations{}
+	}
+	return r.operations
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AsgCostOptimizerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.AsgCostOptimizer{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=asgcostoptimizers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=asgcostoptimizers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=asgcostoptimizers/finalizers,verbs=update
+
+// Reconcile scales the auto scaling groups to the configured capacity within the time window,
+// after saving their capacity in the status, and restores the saved capacity once the window ends
+// or the object is deleted.
+func (r *AsgCostOptimizerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling AsgCostOptimizer ...")
+
+	asgCostOptimizer := &costoptimizerv1alpha1.AsgCostOptimizer{}
+	if err := r.Get(ctx, req.NamespacedName, asgCostOptimizer); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	deleted, err := handleRestoreFinalizer(ctx, r.Client, asgCostOptimizer, func() error {
+		return r.handleGroups(ctx, asgCostOptimizer, false)
+	})
+	if err != nil {
+		r.logger.Error(err, "error handling the finalizer")
+		return ctrl.Result{}, err
+	}
+	if deleted {
+		return ctrl.Result{}, nil
+	}
+
+	inWindow := isInTimeWindow(r.logger, asgCostOptimizer.Spec.StartTimeWindow, asgCostOptimizer.Spec.EndTimeWindow)
+	err = r.handleGroups(ctx, asgCostOptimizer, inWindow)
+	if err != nil {
+		r.logger.Error(err, "error processing auto scaling groups")
+		if _, action := utils.ClassifyError(err); action == utils.ActionFail {
+			// retrying will not help, check again in the next schedule run.
+			err = nil
+		}
+	}
+	return ctrl.Result{RequeueAfter: wait.Jitter(1*time.Minute, 0.5)}, err
+}
+
+// handleGroups scales down or restores the groups, the capacity of a group is saved in the
+// status before the group is scaled down so that it is never lost.
+func (r *AsgCostOptimizerReconciler) handleGroups(ctx context.Context, asgCostOptimizer *costoptimizerv1alpha1.AsgCostOptimizer, inWindow bool) error {
+	groups, err := r.autoScaling().describeGroups(r.logger, asgCostOptimizer.Spec.Region, asgCostOptimizer.Spec.AutoScalingGroupNames)
+	if err != nil {
+		return err
+	}
+	described := map[string]utils.AutoScalingGroup{}
+	for _, group := range groups {
+		described[group.Name] = group
+	}
+
+	state := outOfTimeWindow
+	if inWindow {
+		state = inTimeWindow
+	}
+	statuses := autoScalingGroupStatuses(asgCostOptimizer, described, inWindow)
+	var firstErr error
+	err = saveThenMutate(ctx, r.Client, asgCostOptimizer, func() {
+		asgCostOptimizer.Status.State = fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Scheduled, state)
+		// copy, the statuses are updated below.
+		asgCostOptimizer.Status.Groups = append([]costoptimizerv1alpha1.AutoScalingGroupStatus(nil), statuses...)
+	}, func() error {
+		for i := range statuses {
+			group, found := described[statuses[i].Name]
+			if !found {
+				statuses[i].Message = "auto scaling group not found"
+				continue
+			}
+			var err error
+			if inWindow {
+				err = r.scaleDownGroup(asgCostOptimizer, group, &statuses[i])
+			} else {
+				err = r.restoreGroup(asgCostOptimizer, &statuses[i])
+			}
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return r.patchStatus(ctx, asgCostOptimizer, func(status *costoptimizerv1alpha1.AsgCostOptimizerStatus) {
+			status.Groups = statuses
+		})
+	})
+	if firstErr != nil {
+		return firstErr
+	}
+	return err
+}
+
+// autoScalingGroupStatuses returns the status of the groups of the object, saving the capacity of
+// the groups and the processes to suspend within the time window. The saved capacity is kept
+// until the group is restored, so that the scaled down capacity never overwrites it. Processes
+// which were suspended before are not saved, so that they are not resumed by the restore.
+func autoScalingGroupStatuses(asgCostOptimizer *costoptimizerv1alpha1.AsgCostOptimizer, described map[string]utils.AutoScalingGroup,
+	inWindow bool) []costoptimizerv1alpha1.AutoScalingGroupStatus {
+	previous := map[string]costoptimizerv1alpha1.AutoScalingGroupStatus{}
+	for _, group := range asgCostOptimizer.Status.Groups {
+		previous[group.Name] = group
+	}
+
+	statuses := make([]costoptimizerv1alpha1.AutoScalingGroupStatus, 0, len(asgCostOptimizer.Spec.AutoScalingGroupNames))
+	for _, name := range asgCostOptimizer.Spec.AutoScalingGroupNames {
+		status, ok := previous[name]
+		if !ok {
+			status = costoptimizerv1alpha1.AutoScalingGroupStatus{Name: name}
+		}
+		if group, found := described[name]; found && inWindow && status.SavedCapacity == nil {
+			status.SavedCapacity = &costoptimizerv1alpha1.AutoScalingCapacity{
+				MinSize: group.MinSize, MaxSize: group.MaxSize, DesiredCapacity: group.DesiredCapacity,
+			}
+			status.SuspendedProcesses = nil
+			for _, process := range asgCostOptimizer.Spec.SuspendProcesses {
+				if !containsString(group.SuspendedProcesses, string(process)) {
+					status.SuspendedProcesses = append(status.SuspendedProcesses, string(process))
+				}
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (r *AsgCostOptimizerReconciler) scaleDownGroup(asgCostOptimizer *costoptimizerv1alpha1.AsgCostOptimizer, group utils.AutoScalingGroup,
+	status *costoptimizerv1alpha1.AutoScalingGroupStatus) error {
+	capacity := asgCostOptimizer.Spec.Capacity
+	if group.MinSize != capacity.MinSize || group.MaxSize != capacity.MaxSize || group.DesiredCapacity != capacity.DesiredCapacity {
+		err := r.autoScaling().updateCapacity(r.logger, asgCostOptimizer.Spec.Region, group.Name,
+			capacity.MinSize, capacity.MaxSize, capacity.DesiredCapacity)
+		if err != nil {
+			return r.groupOperationFailed(asgCostOptimizer, status, "scale down", err)
+		}
+	}
+	var suspend []string
+	for _, process := range status.SuspendedProcesses {
+		if !containsString(group.SuspendedProcesses, process) {
+			suspend = append(suspend, process)
+		}
+	}
+	if err := r.autoScaling().suspendProcesses(r.logger, asgCostOptimizer.Spec.Region, group.Name, suspend); err != nil {
+		return r.groupOperationFailed(asgCostOptimizer, status, "suspend processes of", err)
+	}
+
+	status.Message = ""
+	if status.State != scaledDown {
+		now := metav1.Now()
+		status.State, status.LastTransitionTime = scaledDown, &now
+		r.Recorder.Eventf(asgCostOptimizer, corev1.EventTypeNormal, eventReasonScaledDown,
+			"Scaled down auto scaling group %s to %d/%d/%d (min/max/desired)", group.Name,
+			capacity.MinSize, capacity.MaxSize, capacity.DesiredCapacity)
+	}
+	return nil
+}
+
+func (r *AsgCostOptimizerReconciler) restoreGroup(asgCostOptimizer *costoptimizerv1alpha1.AsgCostOptimizer,
+	status *costoptimizerv1alpha1.AutoScalingGroupStatus) error {
+	saved := status.SavedCapacity
+	if saved == nil {
+		return nil
+	}
+	err := r.autoScaling().updateCapacity(r.logger, asgCostOptimizer.Spec.Region, status.Name,
+		saved.MinSize, saved.MaxSize, saved.DesiredCapacity)
+	if err != nil {
+		return r.groupOperationFailed(asgCostOptimizer, status, "restore", err)
+	}
+	if err := r.autoScaling().resumeProcesses(r.logger, asgCostOptimizer.Spec.Region, status.Name, status.SuspendedProcesses); err != nil {
+		return r.groupOperationFailed(asgCostOptimizer, status, "resume processes of", err)
+	}
+
+	r.Recorder.Eventf(asgCostOptimizer, corev1.EventTypeNormal, eventReasonRestored,
+		"Restored auto scaling group %s to %d/%d/%d (min/max/desired)", status.Name,
+		saved.MinSize, saved.MaxSize, saved.DesiredCapacity)
+	now := metav1.Now()
+	status.State, status.LastTransitionTime = restored, &now
+	status.SavedCapacity, status.SuspendedProcesses, status.Message = nil, nil, ""
+	return nil
+}
+
+func (r *AsgCostOptimizerReconciler) groupOperationFailed(asgCostOptimizer *costoptimizerv1alpha1.AsgCostOptimizer,
+	status *costoptimizerv1alpha1.AutoScalingGroupStatus, operation string, err error) error {
+	reason, action := utils.ClassifyError(err)
+	status.Message = err.Error()
+	r.Recorder.Eventf(asgCostOptimizer, corev1.EventTypeWarning, eventReasonOperationFailed,
+		"Failed to %s auto scaling group %s with reason %s (action: %s): %v", operation, status.Name, reason, action, err)
+	return err
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *AsgCostOptimizerReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.AsgCostOptimizer,
+	mutate func(status *costoptimizerv1alpha1.AsgCostOptimizerStatus)) error {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return err
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+		return err
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
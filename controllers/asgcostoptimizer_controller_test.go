@@ -0,0 +1,283 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// fakeAutoScalingOperations performs the auto scaling operations on the groups, the capacity
+// updates fail with err. The operations performed are recorded in order.
+type fakeAutoScalingOperations struct {
+	groups     []utils.AutoScalingGroup
+	err        error
+	operations []string
+}
+
+func (f *fakeAutoScalingOperations) describeGroups(logr.Logger, string, []string) ([]utils.AutoScalingGroup, error) {
+	return f.groups, nil
+}
+
+func (f *fakeAutoScalingOperations) updateCapacity(_ logr.Logger, _, name string, minSize, maxSize, desiredCapacity int32) error {
+	f.operations = append(f.operations, fmt.Sprintf("update %s %d/%d/%d", name, minSize, maxSize, desiredCapacity))
+	return f.err
+}
+
+func (f *fakeAutoScalingOperations) suspendProcesses(_ logr.Logger, _, name string, processes []string) error {
+	f.operations = append(f.operations, fmt.Sprintf("suspend %s %v", name, processes))
+	return nil
+}
+
+func (f *fakeAutoScalingOperations) resumeProcesses(_ logr.Logger, _, name string, processes []string) error {
+	f.operations = append(f.operations, fmt.Sprintf("resume %s %v", name, processes))
+	return nil
+}
+
+func TestAutoScalingGroupStatuses(t *testing.T) {
+	described := map[string]utils.AutoScalingGroup{
+		"workers": {Name: "workers", MinSize: 2, MaxSize: 6, DesiredCapacity: 3, SuspendedProcesses: []string{"AZRebalance"}},
+		// scaled down already by the object.
+		"batch": {Name: "batch", MinSize: 0, MaxSize: 0, DesiredCapacity: 0},
+	}
+	saved := &costoptimizerv1alpha1.AutoScalingCapacity{MinSize: 1, MaxSize: 4, DesiredCapacity: 2}
+	previous := []costoptimizerv1alpha1.AutoScalingGroupStatus{
+		{Name: "batch", State: scaledDown, SavedCapacity: saved, SuspendedProcesses: []string{"HealthCheck"}},
+	}
+	tests := []struct {
+		name     string
+		previous []costoptimizerv1alpha1.AutoScalingGroupStatus
+		inWindow bool
+		expected []costoptimizerv1alpha1.AutoScalingGroupStatus
+	}{
+		{
+			name:     "window entered",
+			inWindow: true,
+			expected: []costoptimizerv1alpha1.AutoScalingGroupStatus{
+				{Name: "workers", SavedCapacity: &costoptimizerv1alpha1.AutoScalingCapacity{MinSize: 2, MaxSize: 6, DesiredCapacity: 3},
+					SuspendedProcesses: []string{"HealthCheck"}},
+				{Name: "batch", SavedCapacity: &costoptimizerv1alpha1.AutoScalingCapacity{}, SuspendedProcesses: []string{"AZRebalance", "HealthCheck"}},
+				{Name: "gone"},
+			},
+		},
+		{
+			name:     "saved capacity is kept",
+			previous: previous,
+			inWindow: true,
+			expected: []costoptimizerv1alpha1.AutoScalingGroupStatus{
+				{Name: "workers", SavedCapacity: &costoptimizerv1alpha1.AutoScalingCapacity{MinSize: 2, MaxSize: 6, DesiredCapacity: 3},
+					SuspendedProcesses: []string{"HealthCheck"}},
+				{Name: "batch", State: scaledDown, SavedCapacity: saved, SuspendedProcesses: []string{"HealthCheck"}},
+				{Name: "gone"},
+			},
+		},
+		{
+			name:     "out of window",
+			previous: previous,
+			expected: []costoptimizerv1alpha1.AutoScalingGroupStatus{
+				{Name: "workers"},
+				{Name: "batch", State: scaledDown, SavedCapacity: saved, SuspendedProcesses: []string{"HealthCheck"}},
+				{Name: "gone"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			obj := &costoptimizerv1alpha1.AsgCostOptimizer{
+				Spec: costoptimizerv1alpha1.AsgCostOptimizerSpec{
+					AutoScalingGroupNames: []string{"workers", "batch", "gone"},
+					SuspendProcesses:      []costoptimizerv1alpha1.ScalingProcess{"AZRebalance", "HealthCheck"},
+				},
+				Status: costoptimizerv1alpha1.AsgCostOptimizerStatus{Groups: test.previous},
+			}
+			if statuses := autoScalingGroupStatuses(obj, described, test.inWindow); !reflect.DeepEqual(statuses, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, statuses)
+			}
+		})
+	}
+}
+
+func TestScaleDownAndRestoreGroup(t *testing.T) {
+	throttled := &utils.AWSError{Code: "Throttling", Operation: "UpdateAutoScalingGroup", Message: "rate exceeded"}
+	saved := &costoptimizerv1alpha1.AutoScalingCapacity{MinSize: 2, MaxSize: 6, DesiredCapacity: 3}
+	tests := []struct {
+		name       string
+		inWindow   bool
+		group      utils.AutoScalingGroup
+		status     costoptimizerv1alpha1.AutoScalingGroupStatus
+		err        error
+		operations []string
+		expected   costoptimizerv1alpha1.AutoScalingGroupStatus
+		events     []string
+	}{
+		{
+			name:     "scale down",
+			inWindow: true,
+			group:    utils.AutoScalingGroup{Name: "workers", MinSize: 2, MaxSize: 6, DesiredCapacity: 3, SuspendedProcesses: []string{"AZRebalance"}},
+			status:   costoptimizerv1alpha1.AutoScalingGroupStatus{Name: "workers", SavedCapacity: saved, SuspendedProcesses: []string{"HealthCheck"}},
+			operations: []string{
+				"update workers 0/0/0",
+				"suspend workers [HealthCheck]",
+			},
+			expected: costoptimizerv1alpha1.AutoScalingGroupStatus{Name: "workers", State: scaledDown, SavedCapacity: saved,
+				SuspendedProcesses: []string{"HealthCheck"}},
+			events: []string{eventReasonScaledDown},
+		},
+		{
+			name:     "scaled down already",
+			inWindow: true,
+			group:    utils.AutoScalingGroup{Name: "workers", SuspendedProcesses: []string{"AZRebalance", "HealthCheck"}},
+			status: costoptimizerv1alpha1.AutoScalingGroupStatus{Name: "workers", State: scaledDown, SavedCapacity: saved,
+				SuspendedProcesses: []string{"HealthCheck"}},
+			operations: []string{"suspend workers []"},
+			expected: costoptimizerv1alpha1.AutoScalingGroupStatus{Name: "workers", State: scaledDown, SavedCapacity: saved,
+				SuspendedProcesses: []string{"HealthCheck"}},
+		},
+		{
+			name:     "scale down failed",
+			inWindow: true,
+			group:    utils.AutoScalingGroup{Name: "workers", MinSize: 2, MaxSize: 6, DesiredCapacity: 3},
+			status:   costoptimizerv1alpha1.AutoScalingGroupStatus{Name: "workers", SavedCapacity: saved},
+			err:      throttled,
+			operations: []string{
+				"update workers 0/0/0",
+			},
+			expected: costoptimizerv1alpha1.AutoScalingGroupStatus{Name: "workers", SavedCapacity: saved, Message: throttled.Error()},
+			events:   []string{eventReasonOperationFailed},
+		},
+		{
+			name:   "restore",
+			group:  utils.AutoScalingGroup{Name: "workers", SuspendedProcesses: []string{"AZRebalance", "HealthCheck"}},
+			status: costoptimizerv1alpha1.AutoScalingGroupStatus{Name: "workers", State: scaledDown, SavedCapacity: saved, SuspendedProcesses: []string{"HealthCheck"}},
+			operations: []string{
+				"update workers 2/6/3",
+				"resume workers [HealthCheck]",
+			},
+			expected: costoptimizerv1alpha1.AutoScalingGroupStatus{Name: "workers", State: restored},
+			events:   []string{eventReasonRestored},
+		},
+		{
+			name:     "nothing to restore",
+			group:    utils.AutoScalingGroup{Name: "workers", MinSize: 2, MaxSize: 6, DesiredCapacity: 3},
+			status:   costoptimizerv1alpha1.AutoScalingGroupStatus{Name: "workers", State: restored},
+			expected: costoptimizerv1alpha1.AutoScalingGroupStatus{Name: "workers", State: restored},
+		},
+		{
+			name:   "restore failed",
+			group:  utils.AutoScalingGroup{Name: "workers"},
+			status: costoptimizerv1alpha1.AutoScalingGroupStatus{Name: "workers", State: scaledDown, SavedCapacity: saved, SuspendedProcesses: []string{"HealthCheck"}},
+			err:    throttled,
+			operations: []string{
+				"update workers 2/6/3",
+			},
+			expected: costoptimizerv1alpha1.AutoScalingGroupStatus{Name: "workers", State: scaledDown, SavedCapacity: saved,
+				SuspendedProcesses: []string{"HealthCheck"}, Message: throttled.Error()},
+			events: []string{eventReasonOperationFailed},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			autoScaling := &fakeAutoScalingOperations{err: test.err}
+			recorder := record.NewFakeRecorder(10)
+			r := &AsgCostOptimizerReconciler{Recorder: recorder, logger: logr.Discard(), operations: autoScaling}
+			obj := &costoptimizerv1alpha1.AsgCostOptimizer{}
+
+			status := test.status
+			var err error
+			if test.inWindow {
+				err = r.scaleDownGroup(obj, test.group, &status)
+			} else {
+				err = r.restoreGroup(obj, &status)
+			}
+			if !errors.Is(err, test.err) {
+				t.Errorf("expected error %v, got %v", test.err, err)
+			}
+			status.LastTransitionTime = nil
+			if !reflect.DeepEqual(status, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, status)
+			}
+			if !reflect.DeepEqual(autoScaling.operations, test.operations) {
+				t.Errorf("expected operations %v, got %v", test.operations, autoScaling.operations)
+			}
+			if events := eventReasons(recorder); !reflect.DeepEqual(events, test.events) {
+				t.Errorf("expected events %v, got %v", test.events, events)
+			}
+		})
+	}
+}
+
+func TestHandleGroupsSavesCapacityFirst(t *testing.T) {
+	autoScaling := &fakeAutoScalingOperations{groups: []utils.AutoScalingGroup{{Name: "workers", MinSize: 2, MaxSize: 6, DesiredCapacity: 3}}}
+	obj := &costoptimizerv1alpha1.AsgCostOptimizer{Spec: costoptimizerv1alpha1.AsgCostOptimizerSpec{AutoScalingGroupNames: []string{"workers"}}}
+
+	conflict := errors.New("conflict")
+	c := &fakeClient{statusPatchErr: conflict}
+	r := &AsgCostOptimizerReconciler{Client: c, Recorder: record.NewFakeRecorder(10), logger: logr.Discard(), operations: autoScaling}
+	if err := r.handleGroups(context.Background(), obj, true); !errors.Is(err, conflict) {
+		t.Errorf("expected the patch error, got %v", err)
+	}
+	if len(autoScaling.operations) > 0 {
+		t.Errorf("expected the group to be left alone until its capacity is saved, got %v", autoScaling.operations)
+	}
+
+	c.statusPatchErr = nil
+	if err := r.handleGroups(context.Background(), obj, true); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if expected := []string{"update workers 0/0/0", "suspend workers []"}; !reflect.DeepEqual(autoScaling.operations, expected) {
+		t.Errorf("expected operations %v, got %v", expected, autoScaling.operations)
+	}
+	if saved := obj.Status.Groups[0].SavedCapacity; saved == nil || saved.DesiredCapacity != 3 {
+		t.Errorf("expected the capacity to be saved, got %+v", saved)
+	}
+}
+
+func TestAsgRestoreFinalizer(t *testing.T) {
+	saved := &costoptimizerv1alpha1.AutoScalingCapacity{MinSize: 2, MaxSize: 6, DesiredCapacity: 3}
+	autoScaling := &fakeAutoScalingOperations{groups: []utils.AutoScalingGroup{{Name: "workers"}}}
+	obj := &costoptimizerv1alpha1.AsgCostOptimizer{
+		ObjectMeta: metav1.ObjectMeta{Name: "asg"},
+		Spec: costoptimizerv1alpha1.AsgCostOptimizerSpec{AutoScalingGroupNames: []string{"workers"},
+			StartTimeWindow: "00:00:00", EndTimeWindow: "23:59:59"},
+		Status: costoptimizerv1alpha1.AsgCostOptimizerStatus{Groups: []costoptimizerv1alpha1.AutoScalingGroupStatus{
+			{Name: "workers", State: scaledDown, SavedCapacity: saved},
+		}},
+	}
+	c := &fakeClient{object: obj}
+	r := &AsgCostOptimizerReconciler{Client: c, Recorder: record.NewFakeRecorder(10), operations: autoScaling}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "asg"}}
+
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !controllerutil.ContainsFinalizer(c.object, restoreFinalizer) {
+		t.Fatalf("expected the restore finalizer to be added, got %v", c.object.GetFinalizers())
+	}
+
+	// the object is deleted within the window, the saved capacity is restored.
+	autoScaling.operations = nil
+	now := metav1.Now()
+	c.object.SetDeletionTimestamp(&now)
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"update workers 2/6/3", "resume workers []"}; !reflect.DeepEqual(autoScaling.operations, expected) {
+		t.Errorf("expected operations %v, got %v", expected, autoScaling.operations)
+	}
+	if finalizers := c.object.GetFinalizers(); len(finalizers) > 0 {
+		t.Errorf("expected the finalizer to be removed, got %v", finalizers)
+	}
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeClient serves a single object and counts the patches of the object and of its status, the
+// patches fail with the configured errors. A successful patch replaces the served object with the
+// patched one. Any other call panics, the tests only exercise the get and the patches.
+type fakeClient struct {
+	client.Client
+	object         client.Object
+	patchErr       error
+	statusPatchErr error
+	patches        int
+	statusPatches  int
+}
+
+func (c *fakeClient) Get(_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	if c.object == nil {
+		return apierrors.NewNotFound(schema.GroupResource{}, key.Name)
+	}
+	reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(c.object.DeepCopyObject()).Elem())
+	return nil
+}
+
+func (c *fakeClient) Patch(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+	c.patches++
+	if c.patchErr != nil {
+		return c.patchErr
+	}
+	c.object = obj.DeepCopyObject().(client.Object)
+	return nil
+}
+
+func (c *fakeClient) Status() client.StatusWriter {
+	return fakeStatusWriter{c}
+}
+
+type fakeStatusWriter struct {
+	c *fakeClient
+}
+
+func (w fakeStatusWriter) Update(context.Context, client.Object, ...client.UpdateOption) error {
+	return w.c.statusPatchErr
+}
+
+func (w fakeStatusWriter) Patch(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+	w.c.statusPatches++
+	if w.c.statusPatchErr != nil {
+		return w.c.statusPatchErr
+	}
+	w.c.object = obj.DeepCopyObject().(client.Object)
+	return nil
+}
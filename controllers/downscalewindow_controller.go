@@ -0,0 +1,450 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// states of a downscaled resource, next to ScaledDown and Restored.
+const (
+	downscaleModifying = "Modifying"
+	downscaleRestoring = "Restoring"
+)
+
+// kinds of downscaled resources.
+const (
+	elastiCacheReplicationGroupKind = "ElastiCacheReplicationGroup"
+	openSearchDomainKind            = "OpenSearchDomain"
+)
+
+// downscaleOperations are the aws operations performed on the downscaled resources.
+type downscaleOperations interface {
+	describeReplicationGroup(logger logr.Logger, region, replicationGroupID string) (utils.ElastiCacheReplicationGroup, error)
+	modifyReplicaCount(logger logr.Logger, region, replicationGroupID string, current, replicas int32) error
+	describeDomain(logger logr.Logger, region, domainName string) (utils.OpenSearchDomain, error)
+	updateDataNodes(logger logr.Logger, region, domainName, instanceType string, instanceCount int32) error
+}
+
+// awsDownscaleOperations performs the aws operations through the aws cli.
+type awsDownscaleOperations struct{}
+
+func (awsDownscaleOperations) describeReplicationGroup(logger logr.Logger, region, replicationGroupID string) (utils.ElastiCacheReplicationGroup, error) {
+	return utils.DescribeElastiCacheReplicationGroup(logger, region, replicationGroupID)
+}
+
+func (awsDownscaleOperations) modifyReplicaCount(logger logr.Logger, region, replicationGroupID string, current, replicas int32) error {
+	return utils.ModifyElastiCacheReplicaCount(logger, region, replicationGroupID, current, replicas)
+}
+
+func (awsDownscaleOperations) describeDomain(logger logr.Logger, region, domainName string) (utils.OpenSearchDomain, error) {
+	return utils.DescribeOpenSearchDomain(logger, region, domainName)
+}
+
+func (awsDownscaleOperations) updateDataNodes(logger logr.Logger, region, domainName, instanceType string, instanceCount int32) error {
+	return utils.UpdateOpenSearchDataNodes(logger, region, domainName, instanceType, instanceCount)
+}
+
+// downscaleTarget is a resource which cannot be stopped but downscaled.
+type downscaleTarget interface {
+	kind() string
+	name() string
+	// target returns the configuration of the resource within the time window.
+	target() costoptimizerv1alpha1.DownscaleConfig
+	// describe returns the current configuration of the resource, and whether a modification is
+	// in progress.
+	describe(logger logr.Logger, region string) (costoptimizerv1alpha1.DownscaleConfig, bool, error)
+	// apply starts the modification of the resource from the current to the given configuration.
+	apply(logger logr.Logger, region string, current, config costoptimizerv1alpha1.DownscaleConfig) error
+}
+
+type elastiCacheTarget struct {
+	costoptimizerv1alpha1.ElastiCacheDownscale
+	operations downscaleOperations
+}
+
+func (t elastiCacheTarget) kind() string { return elastiCacheReplicationGroupKind }
+
+func (t elastiCacheTarget) name() string { return t.ReplicationGroupID }
+
+func (t elastiCacheTarget) target() costoptimizerv1alpha1.DownscaleConfig {
+	replicas := t.ReplicasPerNodeGroup
+	return costoptimizerv1alpha1.DownscaleConfig{ReplicasPerNodeGroup: &replicas}
+}
+
+func (t elastiCacheTarget) describe(logger logr.Logger, region string) (costoptimizerv1alpha1.DownscaleConfig, bool, error) {
+	group, err := t.operations.describeReplicationGroup(logger, region, t.ReplicationGroupID)
+	if err != nil {
+		return costoptimizerv1alpha1.DownscaleConfig{}, false, err
+	}
+	replicas := group.ReplicasPerNodeGroup()
+	return costoptimizerv1alpha1.DownscaleConfig{ReplicasPerNodeGroup: &replicas}, group.Status != "available", nil
+}
+
+func (t elastiCacheTarget) apply(logger logr.Logger, region string, current, config costoptimizerv1alpha1.DownscaleConfig) error {
+	return t.operations.modifyReplicaCount(logger, region, t.ReplicationGroupID, *current.ReplicasPerNodeGroup, *config.ReplicasPerNodeGroup)
+}
+
+type openSearchTarget struct {
+	costoptimizerv1alpha1.OpenSearchDownscale
+	operations downscaleOperations
+}
+
+func (t openSearchTarget) kind() string { return openSearchDomainKind }
+
+func (t openSearchTarget) name() string { return t.DomainName }
+
+func (t openSearchTarget) target() costoptimizerv1alpha1.DownscaleConfig {
+	return costoptimizerv1alpha1.DownscaleConfig{InstanceCount: t.InstanceCount, InstanceType: t.InstanceType}
+}
+
+func (t openSearchTarget) describe(logger logr.Logger, region string) (costoptimizerv1alpha1.DownscaleConfig, bool, error) {
+	domain, err := t.operations.describeDomain(logger, region, t.DomainName)
+	if err != nil {
+		return costoptimizerv1alpha1.DownscaleConfig{}, false, err
+	}
+	instanceCount := domain.InstanceCount
+	return costoptimizerv1alpha1.DownscaleConfig{InstanceCount: &instanceCount, InstanceType: domain.InstanceType}, domain.Processing, nil
+}
+
+func (t openSearchTarget) apply(logger logr.Logger, region string, _, config costoptimizerv1alpha1.DownscaleConfig) error {
+	var instanceCount int32
+	if config.InstanceCount != nil {
+		instanceCount = *config.InstanceCount
+	}
+	return t.operations.updateDataNodes(logger, region, t.DomainName, config.InstanceType, instanceCount)
+}
+
+// DownscaleWindowReconciler reconciles a DownscaleWindow object
+type DownscaleWindowReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	logger   logr.Logger
+	// operations performed on the resources, the aws cli is used if not set.
+	operations downscaleOperations
+}
+
+// downscale returns the operations performed on the resources.
+func (r *DownscaleWindowReconciler) downscale() downscaleOperations {
+	if r.operations == nil {
+		return awsDownscaleOperations{}
+	}
+	return r.operations
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DownscaleWindowReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.DownscaleWindow{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=downscalewindows,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=downscalewindows/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=downscalewindows/finalizers,verbs=update
+
+// Reconcile applies the reduced configuration to the resources within the time window, after
+// saving their configuration in the status, and restores the saved configuration once the window
+// ends or the object is deleted. A resource is only modified once its previous modification
+// finished.
+func (r *DownscaleWindowReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling DownscaleWindow ...")
+
+	downscaleWindow := &costoptimizerv1alpha1.DownscaleWindow{}
+	if err := r.Get(ctx, req.NamespacedName, downscaleWindow); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	deleted, err := handleRestoreFinalizer(ctx, r.Client, downscaleWindow, func() error {
+		if err := r.handleResources(ctx, downscaleWindow, false); err != nil {
+			return err
+		}
+		// the object is removed once the modifications restoring the resources finished.
+		return resourcesRestored(downscaleWindow)
+	})
+	if err != nil {
+		r.logger.Error(err, "error handling the finalizer")
+		return ctrl.Result{}, err
+	}
+	if deleted {
+		return ctrl.Result{}, nil
+	}
+
+	inWindow := isInTimeWindow(r.logger, downscaleWindow.Spec.StartTimeWindow, downscaleWindow.Spec.EndTimeWindow)
+	err = r.handleResources(ctx, downscaleWindow, inWindow)
+	if err != nil {
+		r.logger.Error(err, "error processing downscaled resources")
+		if _, action := utils.ClassifyError(err); action == utils.ActionFail {
+			// retrying will not help, check again in the next schedule run.
+			err = nil
+		}
+	}
+	return ctrl.Result{RequeueAfter: wait.Jitter(1*time.Minute, 0.5)}, err
+}
+
+// downscaleTargets returns the resources selected by the spec.
+func downscaleTargets(spec costoptimizerv1alpha1.DownscaleWindowSpec, operations downscaleOperations) []downscaleTarget {
+	var targets []downscaleTarget
+	for _, group := range spec.ElastiCacheReplicationGroups {
+		targets = append(targets, elastiCacheTarget{group, operations})
+	}
+	for _, domain := range spec.OpenSearchDomains {
+		targets = append(targets, openSearchTarget{domain, operations})
+	}
+	return targets
+}
+
+// unselectedTarget returns the target restoring a resource which is not selected by the spec
+// anymore, nil if the kind is unknown.
+func unselectedTarget(resource costoptimizerv1alpha1.DownscaledResource, operations downscaleOperations) downscaleTarget {
+	switch resource.Kind {
+	case elastiCacheReplicationGroupKind:
+		return elastiCacheTarget{costoptimizerv1alpha1.ElastiCacheDownscale{ReplicationGroupID: resource.Name}, operations}
+	case openSearchDomainKind:
+		return openSearchTarget{costoptimizerv1alpha1.OpenSearchDownscale{DomainName: resource.Name}, operations}
+	}
+	return nil
+}
+
+// downscaleStatuses returns the status of the targets. Resources which are not selected anymore
+// are kept as long as they have a saved configuration, so that they are still restored, they are
+// returned after the selected ones along with a target restoring them.
+func downscaleStatuses(previous []costoptimizerv1alpha1.DownscaledResource, targets []downscaleTarget,
+	operations downscaleOperations) ([]costoptimizerv1alpha1.DownscaledResource, []downscaleTarget) {
+	previousByName := map[string]costoptimizerv1alpha1.DownscaledResource{}
+	for _, resource := range previous {
+		previousByName[resource.Kind+"/"+resource.Name] = resource
+	}
+
+	statuses := make([]costoptimizerv1alpha1.DownscaledResource, 0, len(targets))
+	for _, target := range targets {
+		key := target.kind() + "/" + target.name()
+		status, ok := previousByName[key]
+		if !ok {
+			status = costoptimizerv1alpha1.DownscaledResource{Kind: target.kind(), Name: target.name()}
+		}
+		delete(previousByName, key)
+		statuses = append(statuses, status)
+	}
+	for _, resource := range previous {
+		if _, ok := previousByName[resource.Kind+"/"+resource.Name]; !ok || resource.SavedConfig == nil {
+			continue
+		}
+		if target := unselectedTarget(resource, operations); target != nil {
+			resource.Message = downscaleNotSelected
+			statuses = append(statuses, resource)
+			targets = append(targets, target)
+		}
+	}
+	return statuses, targets
+}
+
+// downscaleNotSelected is the message of the resources which are not selected anymore.
+const downscaleNotSelected = "resource not selected anymore"
+
+// resourcesRestored returns an error while resources of the object are still to be restored.
+func resourcesRestored(downscaleWindow *costoptimizerv1alpha1.DownscaleWindow) error {
+	pending := 0
+	for _, resource := range downscaleWindow.Status.Resources {
+		if resource.SavedConfig != nil {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("waiting for %d resource(s) to be restored", pending)
+	}
+	return nil
+}
+
+// describedResource is the current configuration of a resource.
+type describedResource struct {
+	config costoptimizerv1alpha1.DownscaleConfig
+	busy   bool
+	err    error
+}
+
+// handleResources downscales or restores the resources, the configuration of a resource is saved
+// in the status before the resource is downscaled so that it is never lost. Resources which are
+// not selected anymore are restored.
+func (r *DownscaleWindowReconciler) handleResources(ctx context.Context, downscaleWindow *costoptimizerv1alpha1.DownscaleWindow, inWindow bool) error {
+	region := downscaleWindow.Spec.Region
+	selected := downscaleTargets(downscaleWindow.Spec, r.downscale())
+	statuses, targets := downscaleStatuses(downscaleWindow.Status.Resources, selected, r.downscale())
+	described := make([]describedResource, 0, len(targets))
+	for i, target := range targets {
+		config, busy, err := target.describe(r.logger, region)
+		if err == nil && inWindow && i < len(selected) && !busy && statuses[i].SavedConfig == nil {
+			saved := managedConfig(config, target.target())
+			statuses[i].SavedConfig = &saved
+		}
+		described = append(described, describedResource{config: config, busy: busy, err: err})
+	}
+
+	state := outOfTimeWindow
+	if inWindow {
+		state = inTimeWindow
+	}
+	var firstErr error
+	err := saveThenMutate(ctx, r.Client, downscaleWindow, func() {
+		downscaleWindow.Status.State = fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Scheduled, state)
+		// copy, the statuses are updated below.
+		downscaleWindow.Status.Resources = append([]costoptimizerv1alpha1.DownscaledResource(nil), statuses...)
+	}, func() error {
+		for i, target := range targets {
+			err := described[i].err
+			if err == nil {
+				// unselected resources are restored right away.
+				err = r.handleResource(downscaleWindow, target, described[i], &statuses[i], inWindow && i < len(selected))
+				if err == nil && i >= len(selected) && statuses[i].SavedConfig != nil {
+					statuses[i].Message = downscaleNotSelected
+				}
+			} else {
+				statuses[i].Message = err.Error()
+			}
+			if err != nil {
+				if _, action := utils.ClassifyError(err); action != utils.ActionSkip && firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+		return r.patchStatus(ctx, downscaleWindow, func(status *costoptimizerv1alpha1.DownscaleWindowStatus) {
+			status.Resources = statuses
+		})
+	})
+	if firstErr != nil {
+		return firstErr
+	}
+	return err
+}
+
+// handleResource moves the resource towards its configuration within or outside of the time
+// window, waiting for running modifications to finish.
+func (r *DownscaleWindowReconciler) handleResource(downscaleWindow *costoptimizerv1alpha1.DownscaleWindow, target downscaleTarget,
+	described describedResource, status *costoptimizerv1alpha1.DownscaledResource, inWindow bool) error {
+	if status.SavedConfig == nil {
+		if inWindow {
+			status.Message = "waiting for the running modification to finish"
+		}
+		return nil
+	}
+	desired, transitional := target.target(), downscaleModifying
+	if !inWindow {
+		desired, transitional = *status.SavedConfig, downscaleRestoring
+	}
+	status.Message = ""
+
+	switch {
+	case described.busy:
+		setDownscaleState(status, transitional)
+	case configReached(described.config, desired) && inWindow:
+		if status.State != scaledDown {
+			r.Recorder.Eventf(downscaleWindow, corev1.EventTypeNormal, eventReasonScaledDown,
+				"Downscaled %s %s to %s", target.kind(), target.name(), formatDownscaleConfig(desired))
+		}
+		setDownscaleState(status, scaledDown)
+	case configReached(described.config, desired):
+		r.Recorder.Eventf(downscaleWindow, corev1.EventTypeNormal, eventReasonRestored,
+			"Restored %s %s to %s", target.kind(), target.name(), formatDownscaleConfig(desired))
+		setDownscaleState(status, restored)
+		status.SavedConfig = nil
+	default:
+		if err := target.apply(r.logger, downscaleWindow.Spec.Region, described.config, desired); err != nil {
+			reason, action := utils.ClassifyError(err)
+			status.Message = err.Error()
+			r.Recorder.Eventf(downscaleWindow, corev1.EventTypeWarning, eventReasonOperationFailed,
+				"Failed to modify %s %s with reason %s (action: %s): %v", target.kind(), target.name(), reason, action, err)
+			return err
+		}
+		setDownscaleState(status, transitional)
+	}
+	return nil
+}
+
+func setDownscaleState(status *costoptimizerv1alpha1.DownscaledResource, state string) {
+	if status.State == state {
+		return
+	}
+	now := metav1.Now()
+	status.State, status.LastTransitionTime = state, &now
+}
+
+// managedConfig returns the settings of the current configuration which are set by the target.
+func managedConfig(current, target costoptimizerv1alpha1.DownscaleConfig) costoptimizerv1alpha1.DownscaleConfig {
+	var managed costoptimizerv1alpha1.DownscaleConfig
+	if target.ReplicasPerNodeGroup != nil {
+		managed.ReplicasPerNodeGroup = current.ReplicasPerNodeGroup
+	}
+	if target.InstanceCount != nil {
+		managed.InstanceCount = current.InstanceCount
+	}
+	if target.InstanceType != "" {
+		managed.InstanceType = current.InstanceType
+	}
+	return managed
+}
+
+// configReached returns true if the current configuration matches all the settings of the
+// desired configuration.
+func configReached(current, desired costoptimizerv1alpha1.DownscaleConfig) bool {
+	equal := func(a, b *int32) bool { return b == nil || (a != nil && *a == *b) }
+	return equal(current.ReplicasPerNodeGroup, desired.ReplicasPerNodeGroup) &&
+		equal(current.InstanceCount, desired.InstanceCount) &&
+		(desired.InstanceType == "" || current.InstanceType == desired.InstanceType)
+}
+
+func formatDownscaleConfig(config costoptimizerv1alpha1.DownscaleConfig) string {
+	var formatted string
+	if config.ReplicasPerNodeGroup != nil {
+		formatted = fmt.Sprintf("%d replica(s) per node group", *config.ReplicasPerNodeGroup)
+	}
+	if config.InstanceCount != nil {
+		formatted = fmt.Sprintf("%d data node(s)", *config.InstanceCount)
+	}
+	if config.InstanceType != "" {
+		if formatted != "" {
+			formatted += " of "
+		}
+		formatted += config.InstanceType
+	}
+	return formatted
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *DownscaleWindowReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.DownscaleWindow,
+	mutate func(status *costoptimizerv1alpha1.DownscaleWindowStatus)) error {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return err
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+		return err
+	}
+	return nil
+}
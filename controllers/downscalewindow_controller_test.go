@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// fakeDownscaleOperations performs the elasticache operations on the replication groups, the
+// modifications complete right away. The operations performed are recorded in order.
+type fakeDownscaleOperations struct {
+	groups     map[string]utils.ElastiCacheReplicationGroup
+	operations []string
+}
+
+func (f *fakeDownscaleOperations) describeReplicationGroup(_ logr.Logger, _, replicationGroupID string) (utils.ElastiCacheReplicationGroup, error) {
+	group, ok := f.groups[replicationGroupID]
+	if !ok {
+		return group, &utils.AWSError{Code: "ReplicationGroupNotFoundFault", Operation: "DescribeReplicationGroups"}
+	}
+	return group, nil
+}
+
+func (f *fakeDownscaleOperations) modifyReplicaCount(_ logr.Logger, _, replicationGroupID string, current, replicas int32) error {
+	f.operations = append(f.operations, fmt.Sprintf("modify %s %d->%d", replicationGroupID, current, replicas))
+	f.groups[replicationGroupID] = utils.ElastiCacheReplicationGroup{ReplicationGroupID: replicationGroupID, Status: "available",
+		NodeGroupMembers: []int32{replicas + 1}}
+	return nil
+}
+
+func (f *fakeDownscaleOperations) describeDomain(logr.Logger, string, string) (utils.OpenSearchDomain, error) {
+	return utils.OpenSearchDomain{}, errors.New("unexpected opensearch domain")
+}
+
+func (f *fakeDownscaleOperations) updateDataNodes(logr.Logger, string, string, string, int32) error {
+	return errors.New("unexpected opensearch domain")
+}
+
+// newDownscaleTest returns an object downscaling the replication group cache-1 from two to no
+// replicas within the time window, and the reconciler processing it.
+func newDownscaleTest() (*costoptimizerv1alpha1.DownscaleWindow, *DownscaleWindowReconciler, *fakeDownscaleOperations) {
+	obj := &costoptimizerv1alpha1.DownscaleWindow{
+		ObjectMeta: metav1.ObjectMeta{Name: "downscale"},
+		Spec: costoptimizerv1alpha1.DownscaleWindowSpec{
+			ElastiCacheReplicationGroups: []costoptimizerv1alpha1.ElastiCacheDownscale{{ReplicationGroupID: "cache-1"}},
+			StartTimeWindow:              "00:00:00", EndTimeWindow: "23:59:59",
+		},
+	}
+	downscale := &fakeDownscaleOperations{groups: map[string]utils.ElastiCacheReplicationGroup{
+		"cache-1": {ReplicationGroupID: "cache-1", Status: "available", NodeGroupMembers: []int32{3}},
+	}}
+	r := &DownscaleWindowReconciler{Client: &fakeClient{object: obj}, Recorder: record.NewFakeRecorder(10), logger: logr.Discard(),
+		operations: downscale}
+	return obj, r, downscale
+}
+
+func TestDownscaleConfig(t *testing.T) {
+	one, three := int32(1), int32(3)
+	current := costoptimizerv1alpha1.DownscaleConfig{InstanceCount: &three, InstanceType: "r6g.large.search"}
+	tests := []struct {
+		name    string
+		target  costoptimizerv1alpha1.DownscaleConfig
+		reached bool
+		managed string
+	}{
+		{name: "instance count", target: costoptimizerv1alpha1.DownscaleConfig{InstanceCount: &one}, managed: "3 data node(s)"},
+		{name: "instance type", target: costoptimizerv1alpha1.DownscaleConfig{InstanceType: "t3.small.search"}, managed: "r6g.large.search"},
+		{
+			name:    "both",
+			target:  costoptimizerv1alpha1.DownscaleConfig{InstanceCount: &one, InstanceType: "t3.small.search"},
+			managed: "3 data node(s) of r6g.large.search",
+		},
+		{
+			name:    "reached",
+			target:  costoptimizerv1alpha1.DownscaleConfig{InstanceCount: &three},
+			reached: true,
+			managed: "3 data node(s)",
+		},
+		{name: "nothing managed", reached: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if reached := configReached(current, test.target); reached != test.reached {
+				t.Errorf("expected reached %t, got %t", test.reached, reached)
+			}
+			managed := managedConfig(current, test.target)
+			if formatted := formatDownscaleConfig(managed); formatted != test.managed {
+				t.Errorf("expected managed config %q, got %q", test.managed, formatted)
+			}
+			if !configReached(current, managed) {
+				t.Errorf("expected the current config to reach the managed config %+v", managed)
+			}
+		})
+	}
+
+	replicas := int32(2)
+	if configReached(costoptimizerv1alpha1.DownscaleConfig{}, costoptimizerv1alpha1.DownscaleConfig{ReplicasPerNodeGroup: &replicas}) {
+		t.Error("expected an unknown replica count not to reach the desired config")
+	}
+}
+
+func TestDownscaleStatuses(t *testing.T) {
+	two := int32(2)
+	saved := &costoptimizerv1alpha1.DownscaleConfig{ReplicasPerNodeGroup: &two}
+	previous := []costoptimizerv1alpha1.DownscaledResource{
+		{Kind: elastiCacheReplicationGroupKind, Name: "cache-1", State: scaledDown, SavedConfig: saved},
+		{Kind: elastiCacheReplicationGroupKind, Name: "removed", State: scaledDown, SavedConfig: saved},
+		{Kind: openSearchDomainKind, Name: "restored", State: restored},
+	}
+	selected := []downscaleTarget{
+		elastiCacheTarget{ElastiCacheDownscale: costoptimizerv1alpha1.ElastiCacheDownscale{ReplicationGroupID: "cache-1"}},
+		openSearchTarget{OpenSearchDownscale: costoptimizerv1alpha1.OpenSearchDownscale{DomainName: "search-1"}},
+	}
+
+	statuses, targets := downscaleStatuses(previous, selected, nil)
+	expected := []costoptimizerv1alpha1.DownscaledResource{
+		{Kind: elastiCacheReplicationGroupKind, Name: "cache-1", State: scaledDown, SavedConfig: saved},
+		{Kind: openSearchDomainKind, Name: "search-1"},
+		{Kind: elastiCacheReplicationGroupKind, Name: "removed", State: scaledDown, SavedConfig: saved, Message: downscaleNotSelected},
+	}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("expected %+v, got %+v", expected, statuses)
+	}
+	if len(targets) != 3 || targets[2].kind() != elastiCacheReplicationGroupKind || targets[2].name() != "removed" {
+		t.Errorf("expected a target restoring the removed replication group, got %+v", targets)
+	}
+}
+
+func TestHandleResourcesSavesConfigFirst(t *testing.T) {
+	obj, r, downscale := newDownscaleTest()
+	conflict := errors.New("conflict")
+	r.Client.(*fakeClient).statusPatchErr = conflict
+
+	if err := r.handleResources(context.Background(), obj, true); !errors.Is(err, conflict) {
+		t.Errorf("expected the patch error, got %v", err)
+	}
+	if len(downscale.operations) > 0 {
+		t.Errorf("expected the resource to be left alone until its config is saved, got %v", downscale.operations)
+	}
+}
+
+func TestHandleResourcesRestoresUnselectedResources(t *testing.T) {
+	obj, r, downscale := newDownscaleTest()
+	if err := r.handleResources(context.Background(), obj, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// removed from the spec within the window, it is restored right away.
+	obj.Spec.ElastiCacheReplicationGroups = nil
+	if err := r.handleResources(context.Background(), obj, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"modify cache-1 2->0", "modify cache-1 0->2"}; !reflect.DeepEqual(downscale.operations, expected) {
+		t.Errorf("expected operations %v, got %v", expected, downscale.operations)
+	}
+	if resources := obj.Status.Resources; len(resources) != 1 || resources[0].State != downscaleRestoring ||
+		resources[0].Message != downscaleNotSelected {
+		t.Fatalf("expected the replication group to be kept until it is restored, got %+v", resources)
+	}
+
+	if err := r.handleResources(context.Background(), obj, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resources := obj.Status.Resources; len(resources) != 1 || resources[0].State != restored || resources[0].SavedConfig != nil {
+		t.Fatalf("expected the replication group to be restored, got %+v", resources)
+	}
+	if err := r.handleResources(context.Background(), obj, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resources := obj.Status.Resources; len(resources) > 0 {
+		t.Errorf("expected the restored replication group to be dropped, got %+v", resources)
+	}
+}
+
+func TestDownscaleRestoreFinalizer(t *testing.T) {
+	_, r, downscale := newDownscaleTest()
+	c := r.Client.(*fakeClient)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "downscale"}}
+
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !controllerutil.ContainsFinalizer(c.object, restoreFinalizer) {
+		t.Fatalf("expected the restore finalizer to be added, got %v", c.object.GetFinalizers())
+	}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the object is deleted within the window, it is removed once the replication group is restored.
+	now := metav1.Now()
+	c.object.SetDeletionTimestamp(&now)
+	if _, err := r.Reconcile(context.Background(), req); err == nil {
+		t.Errorf("expected the removal to wait for the modification to finish")
+	}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"modify cache-1 2->0", "modify cache-1 0->2"}; !reflect.DeepEqual(downscale.operations, expected) {
+		t.Errorf("expected operations %v, got %v", expected, downscale.operations)
+	}
+	if finalizers := c.object.GetFinalizers(); len(finalizers) > 0 {
+		t.Errorf("expected the finalizer to be removed, got %v", finalizers)
+	}
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// defaultKubeconfigKey is the key of the kubeconfig in the Secret if none is specified.
+	defaultKubeconfigKey = "kubeconfig"
+	// nodeGroupLabel is the label eks sets on the nodes of a managed node group.
+	nodeGroupLabel = "eks.amazonaws.com/nodegroup"
+	// cordonedByAnnotation marks the nodes cordoned by the controller with the object which
+	// cordoned them, so that only those nodes are uncordoned again.
+	cordonedByAnnotation = "kubeinbox.io/cordoned-by"
+	// mirrorPodAnnotation is set on the mirror pods of static pods, which cannot be evicted.
+	mirrorPodAnnotation = "kubernetes.io/config.mirror"
+)
+
+// newClusterClient returns a client for the cluster whose kubeconfig is stored in the referenced
+// Secret. The Secret is read uncached, so that the controller does not watch all the Secrets.
+func newClusterClient(ctx context.Context, reader client.Reader, namespace string,
+	ref costoptimizerv1alpha1.SecretKeyReference) (kubernetes.Interface, error) {
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return nil, fmt.Errorf("unable to get kubeconfig secret %s: %w", ref.Name, err)
+	}
+	key := ref.Key
+	if key == "" {
+		key = defaultKubeconfigKey
+	}
+	kubeconfig, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("kubeconfig secret %s has no key %s", ref.Name, key)
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig in secret %s: %w", ref.Name, err)
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+// drainNodeGroup cordons the nodes of the node group and evicts their pods, evictions blocked by
+// a pod disruption budget are retried in the next call. It returns the number of pods which
+// still have to leave the nodes.
+func drainNodeGroup(ctx context.Context, clientset kubernetes.Interface, nodeGroup, owner string) (int, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: nodeGroupLabel + "=" + nodeGroup})
+	if err != nil {
+		return 0, err
+	}
+	remaining := 0
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if !node.Spec.Unschedulable {
+			if err := setUnschedulable(ctx, clientset, node.Name, true, owner); err != nil {
+				return remaining, fmt.Errorf("unable to cordon node %s: %w", node.Name, err)
+			}
+		}
+		pods, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("spec.nodeName", node.Name).String(),
+		})
+		if err != nil {
+			return remaining, err
+		}
+		for j := range pods.Items {
+			pod := &pods.Items[j]
+			if !evictable(pod) {
+				continue
+			}
+			remaining++
+			if pod.DeletionTimestamp != nil {
+				// already evicted, waiting for it to terminate.
+				continue
+			}
+			err := clientset.PolicyV1().Evictions(pod.Namespace).Evict(ctx, &policyv1.Eviction{
+				ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name},
+			})
+			switch {
+			case apierrors.IsNotFound(err):
+				remaining--
+			case apierrors.IsTooManyRequests(err):
+				// blocked by a pod disruption budget.
+			case err != nil:
+				return remaining, fmt.Errorf("unable to evict pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+		}
+	}
+	return remaining, nil
+}
+
+// uncordonNodeGroup makes the nodes of the node group cordoned by owner schedulable again, nodes
+// cordoned by someone else are left alone.
+func uncordonNodeGroup(ctx context.Context, clientset kubernetes.Interface, nodeGroup, owner string) error {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: nodeGroupLabel + "=" + nodeGroup})
+	if err != nil {
+		return err
+	}
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if node.Spec.Unschedulable && node.Annotations[cordonedByAnnotation] == owner {
+			if err := setUnschedulable(ctx, clientset, node.Name, false, owner); err != nil {
+				return fmt.Errorf("unable to uncordon node %s: %w", node.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func setUnschedulable(ctx context.Context, clientset kubernetes.Interface, name string, unschedulable bool, owner string) error {
+	var annotation interface{} = owner
+	if !unschedulable {
+		// removes the annotation.
+		annotation = nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]interface{}{cordonedByAnnotation: annotation}},
+		"spec":     map[string]interface{}{"unschedulable": unschedulable},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = clientset.CoreV1().Nodes().Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// evictable returns true if the pod has to be evicted to drain its node. Pods of daemon sets,
+// mirror pods and terminated pods stay on the node.
+func evictable(pod *corev1.Pod) bool {
+	if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+		return false
+	}
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	if owner := metav1.GetControllerOf(pod); owner != nil && owner.Kind == "DaemonSet" {
+		return false
+	}
+	return true
+}
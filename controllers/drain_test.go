@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEvictable(t *testing.T) {
+	controller := true
+	tests := []struct {
+		name      string
+		pod       corev1.Pod
+		evictable bool
+	}{
+		{name: "running pod", pod: corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning}}, evictable: true},
+		{name: "succeeded pod", pod: corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodSucceeded}}, evictable: false},
+		{name: "mirror pod", pod: corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{mirrorPodAnnotation: "hash"},
+		}}, evictable: false},
+		{name: "daemon set pod", pod: corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "aws-node", Controller: &controller}},
+		}}, evictable: false},
+		{name: "replica set pod", pod: corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-5d8f", Controller: &controller}},
+		}}, evictable: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := evictable(&test.pod); got != test.evictable {
+				t.Errorf("expected evictable %t, got %t", test.evictable, got)
+			}
+		})
+	}
+}
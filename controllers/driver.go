@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"fmt"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/metrics"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// resourceDriver drives a kind of aws resource which can be started and stopped, the scheduling,
+// status, retry and metrics logic is shared by all the kinds through it. Drivers are registered
+// by kind with registerDriver, scheduling a new kind does not require a new controller. The
+// drivers of the kinds selected by their own object, e.g. rds databases, are built by their
+// controller from its operations instead.
+type resourceDriver interface {
+	// kind of the resources, e.g. EC2, it is used as metrics label and in the events.
+	kind() string
+	// resolveTargets returns the ids of the existing resources selected by the ids and the tags,
+	// a resource has to match both. All the resources having the tags are selected if no ids
+	// are given.
+	resolveTargets(logger logr.Logger, region string, ids []string, tags map[string]string) ([]string, error)
+	// getState returns the state reported by the service by id, resources which do not exist
+	// are left out.
+	getState(logger logr.Logger, region string, ids []string) (map[string]string, error)
+	// applyAction performs the operation on the resources, resources which could not be operated
+	// because of resource specific errors are returned as skipped.
+	applyAction(logger logr.Logger, region string, operation costoptimizerv1alpha1.Ec2OperationType, ids []string) ([]utils.InstanceError, error)
+	// states returns the state of a running and of a stopped resource, resources in any other
+	// state are in transition.
+	states() (running, stopped string)
+	// snapshotConfig returns the configuration of the resources by id which may get changed
+	// while they are stopped, e.g. the instance type of ec2 instances.
+	snapshotConfig(logger logr.Logger, region string, ids []string) (map[string]string, error)
+	// restoreConfig restores a configuration of the resource returned by snapshotConfig, the
+	// resource has to be stopped.
+	restoreConfig(logger logr.Logger, region, id, config string) error
+}
+
+// resourceDrivers is the registry of the drivers by kind.
+var resourceDrivers = map[string]resourceDriver{}
+
+// registerDriver adds the driver to the registry, it is called from the init function of the
+// file implementing the driver.
+func registerDriver(driver resourceDriver) {
+	if _, ok := resourceDrivers[driver.kind()]; ok {
+		panic("resource driver registered twice for kind " + driver.kind())
+	}
+	resourceDrivers[driver.kind()] = driver
+}
+
+// driverFor returns the driver of the kind.
+func driverFor(kind string) (resourceDriver, bool) {
+	driver, ok := resourceDrivers[kind]
+	return driver, ok
+}
+
+// requireDriver returns the driver of the kind, or an error if no driver is registered for it.
+func requireDriver(kind string) (resourceDriver, error) {
+	driver, ok := driverFor(kind)
+	if !ok {
+		return nil, fmt.Errorf("no resource driver registered for %s", kind)
+	}
+	return driver, nil
+}
+
+// desiredState returns the state the resources of the driver reach with the operation.
+func desiredState(driver resourceDriver, operation costoptimizerv1alpha1.Ec2OperationType) string {
+	running, stopped := driver.states()
+	if operation == costoptimizerv1alpha1.Start {
+		return running
+	}
+	return stopped
+}
+
+// actionRequired returns true if the operation has to be applied on a resource in the state,
+// resources already in the desired state or in transition are left alone.
+func actionRequired(driver resourceDriver, operation costoptimizerv1alpha1.Ec2OperationType, state string) bool {
+	running, stopped := driver.states()
+	switch operation {
+	case costoptimizerv1alpha1.Start:
+		return state == stopped
+	case costoptimizerv1alpha1.Stop:
+		return state == running
+	}
+	return false
+}
+
+// waitForState returns the targets which did not reach the state of the operation yet, it does
+// not block, callers wait for the pending targets by requeueing the object.
+func waitForState(logger logr.Logger, driver resourceDriver, region string, operation costoptimizerv1alpha1.Ec2OperationType,
+	ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	states, err := driver.getState(logger, region, ids)
+	if err != nil {
+		return nil, err
+	}
+	return pendingTargets(states, ids, desiredState(driver, operation)), nil
+}
+
+// pendingTargets returns the ids of the existing resources which are not in the state.
+func pendingTargets(states map[string]string, ids []string, state string) []string {
+	var pending []string
+	for _, id := range ids {
+		if current, found := states[id]; found && current != state {
+			pending = append(pending, id)
+		}
+	}
+	return pending
+}
+
+// operationTargets returns the resources which require the operation given their states, the
+// resources which do not exist are returned as skipped.
+func operationTargets(driver resourceDriver, operation costoptimizerv1alpha1.Ec2OperationType, states map[string]string,
+	ids []string) ([]string, []utils.InstanceError) {
+	var targets []string
+	var missing []utils.InstanceError
+	for _, id := range ids {
+		state, found := states[id]
+		switch {
+		case !found:
+			missing = append(missing, utils.InstanceError{InstanceID: id, Err: utils.ErrInstanceNotFound})
+		case actionRequired(driver, operation, state):
+			targets = append(targets, id)
+		}
+	}
+	return targets, missing
+}
+
+// applyEach performs the operation on the resources one by one for the services which operate on
+// a single resource per request, resource specific errors are returned as skipped.
+func applyEach(logger logr.Logger, region string, ids []string, operate func(logr.Logger, string, string) error) ([]utils.InstanceError, error) {
+	var skipped []utils.InstanceError
+	for _, id := range ids {
+		if err := operate(logger, region, id); err != nil {
+			if _, action := utils.ClassifyError(err); action != utils.ActionSkip {
+				return skipped, err
+			}
+			logger.Info("skipping resource", "id", id, "error", err.Error())
+			skipped = append(skipped, utils.InstanceError{InstanceID: id, Err: err})
+		}
+	}
+	return skipped, nil
+}
+
+// applyDriverAction performs the operation on the targets through the driver, the outcome is
+// recorded in the metrics and the events of the object, with an event for every resource the
+// operation got issued for. Callers pass the resources which require the operation only, see
+// operationTargets.
+func applyDriverAction(logger logr.Logger, recorder record.EventRecorder, obj runtime.Object, driver resourceDriver,
+	operation costoptimizerv1alpha1.Ec2OperationType, region string, ids []string) ([]utils.InstanceError, error) {
+	if _, ok := operationIssuedReasons[operation]; !ok {
+		logger.Info("specified invalid operation type", "operation", operation)
+		return nil, nil
+	}
+	region = utils.ResolveRegion(region)
+	metrics.OperationAttempted(driver.kind(), string(operation), region)
+	skipped, err := driver.applyAction(logger, region, operation, ids)
+	if err != nil {
+		reason, action := utils.ClassifyError(err)
+		metrics.OperationFailed(driver.kind(), string(operation), region, reason)
+		recorder.Eventf(obj, corev1.EventTypeWarning, eventReasonOperationFailed,
+			"%s of %s resource(s) failed with reason %s (action: %s): %v", operation, driver.kind(), reason, action, err)
+		return skipped, err
+	}
+	metrics.OperationSucceeded(driver.kind(), string(operation), region)
+	for _, id := range issuedTargets(ids, skipped) {
+		recorder.Eventf(obj, corev1.EventTypeNormal, operationIssuedReasons[operation],
+			"%s issued for %s %s in %s", operation, driver.kind(), id, region)
+	}
+	return skipped, nil
+}
+
+// issuedTargets returns the targets the operation got issued for, i.e. which were not skipped.
+func issuedTargets(ids []string, skipped []utils.InstanceError) []string {
+	skippedIDs := map[string]bool{}
+	for _, resourceErr := range skipped {
+		skippedIDs[resourceErr.InstanceID] = true
+	}
+	var issued []string
+	for _, id := range ids {
+		if !skippedIDs[id] {
+			issued = append(issued, id)
+		}
+	}
+	return issued
+}
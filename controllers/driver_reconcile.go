@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// resourcePollPeriod is the period at which the resources of an onDemand operation are checked
+// until they reached the state of the operation.
+const resourcePollPeriod = 30 * time.Second
+
+// driverOperation is the start/stop operation of an object on resources operated through a
+// driver. The objects starting/stopping resources, e.g. Ec2CostOptimizer, ResourceCostOptimizer
+// and RdsCostOptimizer, share the window, retry and status handling of the operation through
+// reconcileOnDemand and reconcileScheduled, only the selection of the resources is specific to
+// the object.
+type driverOperation struct {
+	obj             client.Object
+	driver          resourceDriver
+	region          string
+	operation       costoptimizerv1alpha1.Ec2OperationType
+	windowType      costoptimizerv1alpha1.Ec2OperationWindowType
+	startTimeWindow string
+	endTimeWindow   string
+	retryPolicy     *costoptimizerv1alpha1.RetryPolicy
+	// status of the operation, it is part of the status of the object.
+	status *costoptimizerv1alpha1.OperationStatus
+	// patchStatus applies mutate on the status of the operation and patches the status of the
+	// object if it got changed.
+	patchStatus func(mutate func(status *costoptimizerv1alpha1.OperationStatus))
+	// operate performs the operation on the resources which require it, the resources expected
+	// to reach the state of the operation are returned.
+	operate func() ([]string, error)
+	// outOfWindow is called outside of the time window of a scheduled operation, optional.
+	outOfWindow func() error
+	// recordConflicts is called once the spec of an onDemand operation got changed or once a
+	// scheduled operation entered its time window, optional.
+	recordConflicts func()
+	// completed is called once an onDemand operation completed, optional.
+	completed func()
+}
+
+// setState sets the state of the operation for its window type.
+func (op driverOperation) setState(state string) {
+	op.patchStatus(func(status *costoptimizerv1alpha1.OperationStatus) {
+		status.State = fmt.Sprintf("%s/%s", op.windowType, state)
+		status.ObservedGeneration = op.obj.GetGeneration()
+	})
+}
+
+// reconcileScheduled performs the operation while the current time is within the time window of
+// the object, the object is requeued to check the window again.
+func reconcileScheduled(logger logr.Logger, recorder record.EventRecorder, op driverOperation) (ctrl.Result, error) {
+	inWindow := isInTimeWindow(logger, op.startTimeWindow, op.endTimeWindow)
+	entered := recordWindowTransition(recorder, op.obj, op.startTimeWindow, op.endTimeWindow, op.status.State, inWindow)
+	if entered && op.recordConflicts != nil {
+		op.recordConflicts()
+	}
+
+	var err error
+	if inWindow {
+		op.setState(inTimeWindow)
+		logger.Info("current time is within the time window, starting operations")
+		_, err = op.operate()
+	} else {
+		op.setState(outOfTimeWindow)
+		logger.Info("ignoring as it is not in scheduled time window")
+		if op.outOfWindow != nil {
+			err = op.outOfWindow()
+		}
+	}
+	if err != nil {
+		logger.Error(err, "error processing scheduled operation")
+		if _, action := utils.ClassifyError(err); action == utils.ActionFail {
+			// retrying will not help, check again in the next schedule run.
+			err = nil
+		}
+	}
+	return ctrl.Result{RequeueAfter: wait.Jitter(1*time.Minute, 0.5)}, err
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+)
+
+func TestResourceDriversRegistered(t *testing.T) {
+	for _, kind := range []string{
+		string(costoptimizerv1alpha1.EC2), string(costoptimizerv1alpha1.Lightsail), string(costoptimizerv1alpha1.WorkSpaces),
+	} {
+		driver, ok := driverFor(kind)
+		if !ok {
+			t.Errorf("no driver registered for %s", kind)
+			continue
+		}
+		if driver.kind() != kind {
+			t.Errorf("driver registered for %s reports kind %s", kind, driver.kind())
+		}
+	}
+}
+
+func TestActionRequired(t *testing.T) {
+	tests := []struct {
+		name      string
+		driver    resourceDriver
+		operation costoptimizerv1alpha1.Ec2OperationType
+		state     string
+		want      bool
+	}{
+		{"stop running instance", ec2Driver{}, costoptimizerv1alpha1.Stop, "running", true},
+		{"stop pending instance", ec2Driver{}, costoptimizerv1alpha1.Stop, "pending", false},
+		{"stop running lightsail instance", lightsailDriver{}, costoptimizerv1alpha1.Stop, "running", true},
+		{"stop stopped lightsail instance", lightsailDriver{}, costoptimizerv1alpha1.Stop, "stopped", false},
+		{"start stopped lightsail instance", lightsailDriver{}, costoptimizerv1alpha1.Start, "stopped", true},
+		{"start stopping lightsail instance", lightsailDriver{}, costoptimizerv1alpha1.Start, "stopping", false},
+		{"stop available workspace", workSpacesDriver{}, costoptimizerv1alpha1.Stop, "AVAILABLE", true},
+		{"stop pending workspace", workSpacesDriver{}, costoptimizerv1alpha1.Stop, "PENDING", false},
+		{"start stopped workspace", workSpacesDriver{}, costoptimizerv1alpha1.Start, "STOPPED", true},
+		{"start available workspace", workSpacesDriver{}, costoptimizerv1alpha1.Start, "AVAILABLE", false},
+		{"stop available database", rdsDriver{}, costoptimizerv1alpha1.Stop, "available", true},
+		{"start stopping database", rdsDriver{}, costoptimizerv1alpha1.Start, "stopping", false},
+		{"stop available cluster", redshiftDriver{}, costoptimizerv1alpha1.Stop, "available", true},
+		{"start paused cluster", redshiftDriver{}, costoptimizerv1alpha1.Start, "paused", true},
+		{"stop running notebook instance", sageMakerNotebookDriver{}, costoptimizerv1alpha1.Stop, "InService", true},
+		{"stop pending notebook instance", sageMakerNotebookDriver{}, costoptimizerv1alpha1.Stop, "Pending", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := actionRequired(tt.driver, tt.operation, tt.state); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPendingTargets(t *testing.T) {
+	states := map[string]string{"ws-1": "STOPPED", "ws-2": "STOPPING", "ws-3": "AVAILABLE"}
+	want := desiredState(workSpacesDriver{}, costoptimizerv1alpha1.Stop)
+	// ws-4 does not exist anymore, there is nothing to wait for.
+	pending := pendingTargets(states, []string{"ws-1", "ws-2", "ws-3", "ws-4"}, want)
+	if !reflect.DeepEqual(pending, []string{"ws-2", "ws-3"}) {
+		t.Errorf("expected ws-2 and ws-3 to be pending, got %v", pending)
+	}
+}
+
+func TestApplyEach(t *testing.T) {
+	var operated []string
+	operate := func(_ logr.Logger, _, id string) error {
+		operated = append(operated, id)
+		switch id {
+		case "b":
+			return &utils.AWSError{Code: "InvalidResourceStateException"}
+		case "c":
+			return &utils.AWSError{Code: "AccessDeniedException"}
+		}
+		return nil
+	}
+
+	skipped, err := applyEach(logr.Discard(), "", []string{"a", "b", "c", "d"}, operate)
+	if !errors.Is(err, utils.ErrUnauthorized) {
+		t.Errorf("expected the unauthorized error, got %v", err)
+	}
+	if len(skipped) != 1 || skipped[0].InstanceID != "b" {
+		t.Errorf("expected b to be skipped, got %v", skipped)
+	}
+	if !reflect.DeepEqual(operated, []string{"a", "b", "c"}) {
+		t.Errorf("expected to stop after the unauthorized error, operated %v", operated)
+	}
+}
+
+func TestOperationTargets(t *testing.T) {
+	states := map[string]string{"i-1": "running", "i-2": "stopped", "i-3": "stopping"}
+	targets, missing := operationTargets(ec2Driver{}, costoptimizerv1alpha1.Stop, states, []string{"i-1", "i-2", "i-3", "i-4"})
+	if !reflect.DeepEqual(targets, []string{"i-1"}) {
+		t.Errorf("expected only the running instance to be stopped, got %v", targets)
+	}
+	if len(missing) != 1 || missing[0].InstanceID != "i-4" || !errors.Is(missing[0], utils.ErrInstanceNotFound) {
+		t.Errorf("expected i-4 to be skipped as not found, got %v", missing)
+	}
+}
@@ -0,0 +1,375 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// tableUpdating is the state of a table while its capacity is changed for the time window.
+const tableUpdating = "Updating"
+
+// payPerRequestSwitchInterval is the minimum time between two switches of a table to on-demand mode.
+const payPerRequestSwitchInterval = 24 * time.Hour
+
+// dynamoDbOperations are the dynamodb operations performed on the tables.
+type dynamoDbOperations interface {
+	describeTable(logger logr.Logger, region, tableName string) (utils.DynamoDbTable, error)
+	updateCapacity(logger logr.Logger, region string, table utils.DynamoDbTable, capacity utils.DynamoDbCapacity) error
+}
+
+// awsDynamoDbOperations performs the dynamodb operations through the aws cli.
+type awsDynamoDbOperations struct{}
+
+func (awsDynamoDbOperations) describeTable(logger logr.Logger, region, tableName string) (utils.DynamoDbTable, error) {
+	return utils.DescribeDynamoDbTable(logger, region, tableName)
+}
+
+func (awsDynamoDbOperations) updateCapacity(logger logr.Logger, region string, table utils.DynamoDbTable, capacity utils.DynamoDbCapacity) error {
+	return utils.UpdateDynamoDbTableCapacity(logger, region, table, capacity)
+}
+
+// DynamoDbCostOptimizerReconciler reconciles a DynamoDbCostOptimizer object
+type DynamoDbCostOptimizerReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	logger   logr.Logger
+	// operations performed on the tables, the aws cli is used if not set.
+	operations dynamoDbOperations
+}
+
+// dynamoDb returns the operations performed on the tables.
+func (r *DynamoDbCostOptimizerReconciler) dynamoDb() dynamoDbOperations {
+	if r.operations == nil {
+		return awsDynamoDbOperations{}
+	}
+	return r.operations
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DynamoDbCostOptimizerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.DynamoDbCostOptimizer{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=dynamodbcostoptimizers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=dynamodbcostoptimizers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=dynamodbcostoptimizers/finalizers,verbs=update
+
+// Reconcile changes the capacity of the tables within the time window, after saving their
+// capacity in the status, and restores the saved capacity once the window ends or the object is
+// deleted. A table is only updated once its previous update finished.
+func (r *DynamoDbCostOptimizerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling DynamoDbCostOptimizer ...")
+
+	dynamoDbCostOptimizer := &costoptimizerv1alpha1.DynamoDbCostOptimizer{}
+	if err := r.Get(ctx, req.NamespacedName, dynamoDbCostOptimizer); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	deleted, err := handleRestoreFinalizer(ctx, r.Client, dynamoDbCostOptimizer, func() error {
+		if err := r.handleTables(ctx, dynamoDbCostOptimizer, false); err != nil {
+			return err
+		}
+		// the object is removed once the updates restoring the tables finished.
+		return tablesRestored(dynamoDbCostOptimizer)
+	})
+	if err != nil {
+		r.logger.Error(err, "error handling the finalizer")
+		return ctrl.Result{}, err
+	}
+	if deleted {
+		return ctrl.Result{}, nil
+	}
+
+	inWindow := isInTimeWindow(r.logger, dynamoDbCostOptimizer.Spec.StartTimeWindow, dynamoDbCostOptimizer.Spec.EndTimeWindow)
+	err = r.handleTables(ctx, dynamoDbCostOptimizer, inWindow)
+	if err != nil {
+		r.logger.Error(err, "error processing dynamodb tables")
+		if _, action := utils.ClassifyError(err); action == utils.ActionFail {
+			// retrying will not help, check again in the next schedule run.
+			err = nil
+		}
+	}
+	return ctrl.Result{RequeueAfter: wait.Jitter(1*time.Minute, 0.5)}, err
+}
+
+// handleTables updates or restores the tables, the capacity of a table is saved in the status
+// before the table is updated so that it is never lost.
+func (r *DynamoDbCostOptimizerReconciler) handleTables(ctx context.Context, dynamoDbCostOptimizer *costoptimizerv1alpha1.DynamoDbCostOptimizer, inWindow bool) error {
+	previous := map[string]costoptimizerv1alpha1.DynamoDbTableStatus{}
+	for _, table := range dynamoDbCostOptimizer.Status.Tables {
+		previous[table.TableName] = table
+	}
+
+	schedules := dynamoDbCostOptimizer.Spec.Tables
+	statuses := make([]costoptimizerv1alpha1.DynamoDbTableStatus, 0, len(schedules))
+	tables := make([]utils.DynamoDbTable, len(schedules))
+	describeErrs := make([]error, len(schedules))
+	for i, schedule := range schedules {
+		status, ok := previous[schedule.TableName]
+		if !ok {
+			status = costoptimizerv1alpha1.DynamoDbTableStatus{TableName: schedule.TableName}
+		}
+		tables[i], describeErrs[i] = r.dynamoDb().describeTable(r.logger, dynamoDbCostOptimizer.Spec.Region, schedule.TableName)
+		if describeErrs[i] == nil && inWindow && tables[i].Active() && status.SavedCapacity == nil {
+			saved := tableCapacity(tables[i])
+			status.SavedCapacity = &saved
+		}
+		statuses = append(statuses, status)
+	}
+
+	state := outOfTimeWindow
+	if inWindow {
+		state = inTimeWindow
+	}
+	var firstErr error
+	err := saveThenMutate(ctx, r.Client, dynamoDbCostOptimizer, func() {
+		dynamoDbCostOptimizer.Status.State = fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Scheduled, state)
+		// copy, the statuses are updated below.
+		dynamoDbCostOptimizer.Status.Tables = append([]costoptimizerv1alpha1.DynamoDbTableStatus(nil), statuses...)
+	}, func() error {
+		for i, schedule := range schedules {
+			err := describeErrs[i]
+			if err == nil {
+				err = r.handleTable(dynamoDbCostOptimizer, schedule, tables[i], &statuses[i], inWindow)
+			} else {
+				statuses[i].Message = err.Error()
+			}
+			if err != nil {
+				if _, action := utils.ClassifyError(err); action != utils.ActionSkip && firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+		return r.patchStatus(ctx, dynamoDbCostOptimizer, func(status *costoptimizerv1alpha1.DynamoDbCostOptimizerStatus) {
+			status.Tables = statuses
+		})
+	})
+	if firstErr != nil {
+		return firstErr
+	}
+	return err
+}
+
+// tablesRestored returns an error while tables of the object are still to be restored.
+func tablesRestored(dynamoDbCostOptimizer *costoptimizerv1alpha1.DynamoDbCostOptimizer) error {
+	pending := 0
+	for _, table := range dynamoDbCostOptimizer.Status.Tables {
+		if table.SavedCapacity != nil {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("waiting for %d table(s) to be restored", pending)
+	}
+	return nil
+}
+
+// handleTable moves the table towards its capacity within or outside of the time window, waiting
+// for running updates to finish.
+func (r *DynamoDbCostOptimizerReconciler) handleTable(dynamoDbCostOptimizer *costoptimizerv1alpha1.DynamoDbCostOptimizer,
+	schedule costoptimizerv1alpha1.DynamoDbTableSchedule, table utils.DynamoDbTable, status *costoptimizerv1alpha1.DynamoDbTableStatus, inWindow bool) error {
+	if status.SavedCapacity == nil {
+		if inWindow {
+			status.Message = "waiting for the running table update to finish"
+		}
+		return nil
+	}
+	desired, transitional := desiredTableCapacity(schedule.DynamoDbTableCapacity, table), tableUpdating
+	if !inWindow {
+		desired, transitional = desiredTableCapacity(*status.SavedCapacity, table), downscaleRestoring
+	}
+	status.Message = ""
+
+	switch {
+	case !table.Active():
+		setTableState(status, transitional)
+		return nil
+	case tableCapacityReached(table, desired) && inWindow:
+		if status.State != scaledDown {
+			r.Recorder.Eventf(dynamoDbCostOptimizer, corev1.EventTypeNormal, eventReasonScaledDown,
+				"Changed capacity of table %s to %s", table.TableName, formatTableCapacity(desired))
+		}
+		setTableState(status, scaledDown)
+		return nil
+	case tableCapacityReached(table, desired):
+		r.Recorder.Eventf(dynamoDbCostOptimizer, corev1.EventTypeNormal, eventReasonRestored,
+			"Restored capacity of table %s to %s", table.TableName, formatTableCapacity(desired))
+		setTableState(status, restored)
+		status.SavedCapacity = nil
+		return nil
+	}
+
+	if next, allowed := payPerRequestSwitchAllowed(table, desired, time.Now()); !allowed {
+		status.Message = fmt.Sprintf("switching to %s is not allowed by aws before %s", utils.DynamoDbPayPerRequest, next.Format(time.RFC3339))
+		return nil
+	}
+	capacity := utils.DynamoDbCapacity{
+		BillingMode:        string(desired.BillingMode),
+		ReadCapacityUnits:  desired.ReadCapacityUnits,
+		WriteCapacityUnits: desired.WriteCapacityUnits,
+	}
+	for _, index := range desired.GlobalSecondaryIndexes {
+		capacity.Indexes = append(capacity.Indexes, utils.DynamoDbIndex{
+			IndexName: index.IndexName, ReadCapacityUnits: index.ReadCapacityUnits, WriteCapacityUnits: index.WriteCapacityUnits,
+		})
+	}
+	if err := r.dynamoDb().updateCapacity(r.logger, dynamoDbCostOptimizer.Spec.Region, table, capacity); err != nil {
+		reason, action := utils.ClassifyError(err)
+		status.Message = err.Error()
+		r.Recorder.Eventf(dynamoDbCostOptimizer, corev1.EventTypeWarning, eventReasonOperationFailed,
+			"Failed to update capacity of table %s with reason %s (action: %s): %v", table.TableName, reason, action, err)
+		return err
+	}
+	setTableState(status, transitional)
+	return nil
+}
+
+func setTableState(status *costoptimizerv1alpha1.DynamoDbTableStatus, state string) {
+	if status.State == state {
+		return
+	}
+	now := metav1.Now()
+	status.State, status.LastTransitionTime = state, &now
+}
+
+// tableCapacity returns the current capacity of the table.
+func tableCapacity(table utils.DynamoDbTable) costoptimizerv1alpha1.DynamoDbTableCapacity {
+	capacity := costoptimizerv1alpha1.DynamoDbTableCapacity{BillingMode: costoptimizerv1alpha1.DynamoDbBillingMode(table.BillingMode)}
+	if table.BillingMode != utils.DynamoDbProvisioned {
+		return capacity
+	}
+	capacity.ReadCapacityUnits, capacity.WriteCapacityUnits = table.ReadCapacityUnits, table.WriteCapacityUnits
+	for _, index := range table.Indexes {
+		capacity.GlobalSecondaryIndexes = append(capacity.GlobalSecondaryIndexes, costoptimizerv1alpha1.DynamoDbIndexCapacity{
+			IndexName: index.IndexName, ReadCapacityUnits: index.ReadCapacityUnits, WriteCapacityUnits: index.WriteCapacityUnits,
+		})
+	}
+	return capacity
+}
+
+// desiredTableCapacity completes the capacity with the current capacity of the table. The billing
+// mode defaults to the current one. In provisioned mode the table keeps its capacity if none is
+// given, and every index gets its given capacity, its current capacity or the capacity of the
+// table, as aws requires the capacity of all the indexes when switching to provisioned mode.
+func desiredTableCapacity(capacity costoptimizerv1alpha1.DynamoDbTableCapacity, table utils.DynamoDbTable) costoptimizerv1alpha1.DynamoDbTableCapacity {
+	desired := costoptimizerv1alpha1.DynamoDbTableCapacity{BillingMode: capacity.BillingMode}
+	if desired.BillingMode == "" {
+		desired.BillingMode = costoptimizerv1alpha1.DynamoDbBillingMode(table.BillingMode)
+	}
+	if desired.BillingMode != utils.DynamoDbProvisioned {
+		return desired
+	}
+	desired.ReadCapacityUnits, desired.WriteCapacityUnits = capacity.ReadCapacityUnits, capacity.WriteCapacityUnits
+	if desired.ReadCapacityUnits == 0 {
+		desired.ReadCapacityUnits = table.ReadCapacityUnits
+	}
+	if desired.WriteCapacityUnits == 0 {
+		desired.WriteCapacityUnits = table.WriteCapacityUnits
+	}
+	given := map[string]costoptimizerv1alpha1.DynamoDbIndexCapacity{}
+	for _, index := range capacity.GlobalSecondaryIndexes {
+		given[index.IndexName] = index
+	}
+	for _, index := range table.Indexes {
+		indexCapacity, ok := given[index.IndexName]
+		switch {
+		case ok:
+		case table.BillingMode == utils.DynamoDbProvisioned:
+			indexCapacity = costoptimizerv1alpha1.DynamoDbIndexCapacity{
+				IndexName: index.IndexName, ReadCapacityUnits: index.ReadCapacityUnits, WriteCapacityUnits: index.WriteCapacityUnits,
+			}
+		default:
+			indexCapacity = costoptimizerv1alpha1.DynamoDbIndexCapacity{
+				IndexName: index.IndexName, ReadCapacityUnits: desired.ReadCapacityUnits, WriteCapacityUnits: desired.WriteCapacityUnits,
+			}
+		}
+		desired.GlobalSecondaryIndexes = append(desired.GlobalSecondaryIndexes, indexCapacity)
+	}
+	return desired
+}
+
+// tableCapacityReached returns true if the table has the desired capacity.
+func tableCapacityReached(table utils.DynamoDbTable, desired costoptimizerv1alpha1.DynamoDbTableCapacity) bool {
+	if table.BillingMode != string(desired.BillingMode) {
+		return false
+	}
+	if table.BillingMode != utils.DynamoDbProvisioned {
+		return true
+	}
+	if table.ReadCapacityUnits != desired.ReadCapacityUnits || table.WriteCapacityUnits != desired.WriteCapacityUnits {
+		return false
+	}
+	indexes := map[string]utils.DynamoDbIndex{}
+	for _, index := range table.Indexes {
+		indexes[index.IndexName] = index
+	}
+	for _, index := range desired.GlobalSecondaryIndexes {
+		current, ok := indexes[index.IndexName]
+		if ok && (current.ReadCapacityUnits != index.ReadCapacityUnits || current.WriteCapacityUnits != index.WriteCapacityUnits) {
+			return false
+		}
+	}
+	return true
+}
+
+// payPerRequestSwitchAllowed returns false if the update switches the table to on-demand mode
+// while aws does not allow it yet, along with the time it is allowed again.
+func payPerRequestSwitchAllowed(table utils.DynamoDbTable, desired costoptimizerv1alpha1.DynamoDbTableCapacity, now time.Time) (time.Time, bool) {
+	if desired.BillingMode != utils.DynamoDbPayPerRequest || table.BillingMode == utils.DynamoDbPayPerRequest ||
+		table.LastUpdateToPayPerRequest == nil {
+		return now, true
+	}
+	next := table.LastUpdateToPayPerRequest.Add(payPerRequestSwitchInterval)
+	return next, !now.Before(next)
+}
+
+func formatTableCapacity(capacity costoptimizerv1alpha1.DynamoDbTableCapacity) string {
+	if capacity.BillingMode != utils.DynamoDbProvisioned {
+		return string(capacity.BillingMode)
+	}
+	return fmt.Sprintf("%d/%d (read/write) with %d index(es)", capacity.ReadCapacityUnits, capacity.WriteCapacityUnits,
+		len(capacity.GlobalSecondaryIndexes))
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *DynamoDbCostOptimizerReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.DynamoDbCostOptimizer,
+	mutate func(status *costoptimizerv1alpha1.DynamoDbCostOptimizerStatus)) error {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return err
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+		return err
+	}
+	return nil
+}
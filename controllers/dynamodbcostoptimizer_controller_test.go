@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// fakeDynamoDbOperations applies the capacity updates to the tables right away.
+type fakeDynamoDbOperations struct {
+	tables     map[string]utils.DynamoDbTable
+	operations []string
+}
+
+func (f *fakeDynamoDbOperations) describeTable(_ logr.Logger, _, tableName string) (utils.DynamoDbTable, error) {
+	return f.tables[tableName], nil
+}
+
+func (f *fakeDynamoDbOperations) updateCapacity(_ logr.Logger, _ string, table utils.DynamoDbTable, capacity utils.DynamoDbCapacity) error {
+	f.operations = append(f.operations, fmt.Sprintf("update %s %d/%d", table.TableName, capacity.ReadCapacityUnits, capacity.WriteCapacityUnits))
+	table.BillingMode, table.ReadCapacityUnits, table.WriteCapacityUnits = capacity.BillingMode, capacity.ReadCapacityUnits, capacity.WriteCapacityUnits
+	f.tables[table.TableName] = table
+	return nil
+}
+
+func newFakeDynamoDbOperations() *fakeDynamoDbOperations {
+	return &fakeDynamoDbOperations{tables: map[string]utils.DynamoDbTable{
+		"orders": {TableName: "orders", TableStatus: "ACTIVE", BillingMode: utils.DynamoDbProvisioned, ReadCapacityUnits: 5, WriteCapacityUnits: 5},
+	}}
+}
+
+func newDynamoDbCostOptimizer(startTimeWindow, endTimeWindow string) *costoptimizerv1alpha1.DynamoDbCostOptimizer {
+	return &costoptimizerv1alpha1.DynamoDbCostOptimizer{
+		ObjectMeta: metav1.ObjectMeta{Name: "dynamodb"},
+		Spec: costoptimizerv1alpha1.DynamoDbCostOptimizerSpec{
+			Tables: []costoptimizerv1alpha1.DynamoDbTableSchedule{{TableName: "orders",
+				DynamoDbTableCapacity: costoptimizerv1alpha1.DynamoDbTableCapacity{ReadCapacityUnits: 1, WriteCapacityUnits: 1}}},
+			StartTimeWindow: startTimeWindow, EndTimeWindow: endTimeWindow,
+		},
+	}
+}
+
+func TestDesiredTableCapacity(t *testing.T) {
+	provisioned := utils.DynamoDbTable{
+		TableName: "orders", BillingMode: utils.DynamoDbProvisioned, ReadCapacityUnits: 100, WriteCapacityUnits: 50,
+		Indexes: []utils.DynamoDbIndex{
+			{IndexName: "by-customer", ReadCapacityUnits: 40, WriteCapacityUnits: 20},
+			{IndexName: "by-date", ReadCapacityUnits: 10, WriteCapacityUnits: 5},
+		},
+	}
+	onDemand := utils.DynamoDbTable{
+		TableName: "orders", BillingMode: utils.DynamoDbPayPerRequest,
+		Indexes: []utils.DynamoDbIndex{{IndexName: "by-customer"}},
+	}
+	tests := []struct {
+		name     string
+		capacity costoptimizerv1alpha1.DynamoDbTableCapacity
+		table    utils.DynamoDbTable
+		expected costoptimizerv1alpha1.DynamoDbTableCapacity
+	}{
+		{
+			name: "reduce provisioned capacity",
+			capacity: costoptimizerv1alpha1.DynamoDbTableCapacity{
+				ReadCapacityUnits: 5, WriteCapacityUnits: 5,
+				GlobalSecondaryIndexes: []costoptimizerv1alpha1.DynamoDbIndexCapacity{{IndexName: "by-customer", ReadCapacityUnits: 2, WriteCapacityUnits: 2}},
+			},
+			table: provisioned,
+			expected: costoptimizerv1alpha1.DynamoDbTableCapacity{
+				BillingMode: utils.DynamoDbProvisioned, ReadCapacityUnits: 5, WriteCapacityUnits: 5,
+				GlobalSecondaryIndexes: []costoptimizerv1alpha1.DynamoDbIndexCapacity{
+					{IndexName: "by-customer", ReadCapacityUnits: 2, WriteCapacityUnits: 2},
+					{IndexName: "by-date", ReadCapacityUnits: 10, WriteCapacityUnits: 5},
+				},
+			},
+		},
+		{
+			name:     "switch to on-demand",
+			capacity: costoptimizerv1alpha1.DynamoDbTableCapacity{BillingMode: utils.DynamoDbPayPerRequest},
+			table:    provisioned,
+			expected: costoptimizerv1alpha1.DynamoDbTableCapacity{BillingMode: utils.DynamoDbPayPerRequest},
+		},
+		{
+			name:     "switch to provisioned",
+			capacity: costoptimizerv1alpha1.DynamoDbTableCapacity{BillingMode: utils.DynamoDbProvisioned, ReadCapacityUnits: 5, WriteCapacityUnits: 1},
+			table:    onDemand,
+			expected: costoptimizerv1alpha1.DynamoDbTableCapacity{
+				BillingMode: utils.DynamoDbProvisioned, ReadCapacityUnits: 5, WriteCapacityUnits: 1,
+				GlobalSecondaryIndexes: []costoptimizerv1alpha1.DynamoDbIndexCapacity{{IndexName: "by-customer", ReadCapacityUnits: 5, WriteCapacityUnits: 1}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			desired := desiredTableCapacity(test.capacity, test.table)
+			if !reflect.DeepEqual(desired, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, desired)
+			}
+			if tableCapacityReached(test.table, desired) {
+				t.Errorf("expected the table not to have the desired capacity")
+			}
+		})
+	}
+
+	if saved := tableCapacity(provisioned); !tableCapacityReached(provisioned, desiredTableCapacity(saved, provisioned)) {
+		t.Errorf("expected the table to have its saved capacity %+v", saved)
+	}
+}
+
+func TestPayPerRequestSwitchAllowed(t *testing.T) {
+	now := time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)
+	recently, longAgo := now.Add(-20*time.Hour), now.Add(-30*time.Hour)
+	onDemand := costoptimizerv1alpha1.DynamoDbTableCapacity{BillingMode: utils.DynamoDbPayPerRequest}
+	provisioned := costoptimizerv1alpha1.DynamoDbTableCapacity{BillingMode: utils.DynamoDbProvisioned}
+	tests := []struct {
+		name    string
+		table   utils.DynamoDbTable
+		desired costoptimizerv1alpha1.DynamoDbTableCapacity
+		allowed bool
+	}{
+		{name: "never switched", table: utils.DynamoDbTable{BillingMode: utils.DynamoDbProvisioned}, desired: onDemand, allowed: true},
+		{name: "switched long ago", table: utils.DynamoDbTable{BillingMode: utils.DynamoDbProvisioned, LastUpdateToPayPerRequest: &longAgo}, desired: onDemand, allowed: true},
+		{name: "switched recently", table: utils.DynamoDbTable{BillingMode: utils.DynamoDbProvisioned, LastUpdateToPayPerRequest: &recently}, desired: onDemand},
+		{name: "switch to provisioned", table: utils.DynamoDbTable{BillingMode: utils.DynamoDbPayPerRequest, LastUpdateToPayPerRequest: &recently}, desired: provisioned, allowed: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, allowed := payPerRequestSwitchAllowed(test.table, test.desired, now); allowed != test.allowed {
+				t.Errorf("expected allowed %t, got %t", test.allowed, allowed)
+			}
+		})
+	}
+}
+
+func TestHandleTablesSavesCapacityFirst(t *testing.T) {
+	dynamoDb := newFakeDynamoDbOperations()
+	obj := newDynamoDbCostOptimizer("00:00:00", "23:59:59")
+
+	conflict := errors.New("conflict")
+	c := &fakeClient{statusPatchErr: conflict}
+	r := &DynamoDbCostOptimizerReconciler{Client: c, Recorder: record.NewFakeRecorder(10), logger: logr.Discard(), operations: dynamoDb}
+	if err := r.handleTables(context.Background(), obj, true); !errors.Is(err, conflict) {
+		t.Errorf("expected the patch error, got %v", err)
+	}
+	if len(dynamoDb.operations) > 0 {
+		t.Errorf("expected the table to be left alone until its capacity is saved, got %v", dynamoDb.operations)
+	}
+
+	c.statusPatchErr = nil
+	if err := r.handleTables(context.Background(), obj, true); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if expected := []string{"update orders 1/1"}; !reflect.DeepEqual(dynamoDb.operations, expected) {
+		t.Errorf("expected operations %v, got %v", expected, dynamoDb.operations)
+	}
+	saved := obj.Status.Tables[0].SavedCapacity
+	if expected := (costoptimizerv1alpha1.DynamoDbTableCapacity{BillingMode: utils.DynamoDbProvisioned, ReadCapacityUnits: 5, WriteCapacityUnits: 5}); saved == nil || !reflect.DeepEqual(*saved, expected) {
+		t.Errorf("expected the saved capacity %v, got %v", expected, saved)
+	}
+}
+
+func TestDynamoDbRestoreFinalizer(t *testing.T) {
+	dynamoDb := newFakeDynamoDbOperations()
+	c := &fakeClient{object: newDynamoDbCostOptimizer("00:00:00", "23:59:59")}
+	r := &DynamoDbCostOptimizerReconciler{Client: c, Recorder: record.NewFakeRecorder(10), operations: dynamoDb}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "dynamodb"}}
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if !controllerutil.ContainsFinalizer(c.object, restoreFinalizer) {
+		t.Fatalf("expected the restore finalizer to be added, got %v", c.object.GetFinalizers())
+	}
+
+	// the object is deleted within the window, it is kept until the saved capacity is restored.
+	now := metav1.Now()
+	c.object.SetDeletionTimestamp(&now)
+	if _, err := r.Reconcile(context.Background(), req); err == nil {
+		t.Errorf("expected an error while the table is restored")
+	}
+	if !controllerutil.ContainsFinalizer(c.object, restoreFinalizer) {
+		t.Fatalf("expected the finalizer to be kept until the table is restored")
+	}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"update orders 1/1", "update orders 5/5"}; !reflect.DeepEqual(dynamoDb.operations, expected) {
+		t.Errorf("expected operations %v, got %v", expected, dynamoDb.operations)
+	}
+	if finalizers := c.object.GetFinalizers(); len(finalizers) > 0 {
+		t.Errorf("expected the finalizer to be removed, got %v", finalizers)
+	}
+}
@@ -0,0 +1,422 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/pricing"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	defaultVolumeMinAge       = 7 * 24 * time.Hour
+	defaultVolumeGracePeriod  = 7 * 24 * time.Hour
+	defaultVolumeScanInterval = time.Hour
+)
+
+// tags set on the volumes pending deletion and their snapshots.
+const (
+	// deletionSnapshotTag holds the id of the snapshot taken before the volume is deleted.
+	deletionSnapshotTag = "kubeinbox.io/deletion-snapshot"
+	// sourceVolumeTag is set on the snapshots with the id of the volume they were taken of.
+	sourceVolumeTag = "kubeinbox.io/source-volume"
+)
+
+// volumeActionSnapshotting is the action of the volumes whose snapshot is taken before they are
+// deleted.
+const volumeActionSnapshotting = "Snapshotting"
+
+// ebsOperations are the ec2 operations performed on the volumes and their snapshots.
+type ebsOperations interface {
+	describeAvailableVolumes(logger logr.Logger, region string, tags map[string]string) ([]utils.EbsVolume, error)
+	describeVolumesByTagKey(logger logr.Logger, region, key, state string) ([]utils.EbsVolume, error)
+	deleteVolume(logger logr.Logger, region, volumeID string) error
+	createSnapshot(logger logr.Logger, region, volumeID, description string, tags map[string]string) (string, error)
+	describeSnapshotState(logger logr.Logger, region, snapshotID string) (string, error)
+	describeSnapshots(logger logr.Logger, region string, ownerIDs, volumeIDs []string, tags map[string]string) ([]utils.EbsSnapshot, error)
+	createTags(logger logr.Logger, region string, resourceIDs []string, tags map[string]string) error
+	deleteTags(logger logr.Logger, region string, resourceIDs []string, keys ...string) error
+}
+
+// awsEbsOperations performs the ec2 operations through the aws cli.
+type awsEbsOperations struct{}
+
+func (awsEbsOperations) describeAvailableVolumes(logger logr.Logger, region string, tags map[string]string) ([]utils.EbsVolume, error) {
+	return utils.DescribeAvailableEbsVolumes(logger, region, tags)
+}
+
+func (awsEbsOperations) describeVolumesByTagKey(logger logr.Logger, region, key, state string) ([]utils.EbsVolume, error) {
+	return utils.DescribeEbsVolumesByTagKey(logger, region, key, state)
+}
+
+func (awsEbsOperations) deleteVolume(logger logr.Logger, region, volumeID string) error {
+	return utils.DeleteEbsVolume(logger, region, volumeID)
+}
+
+func (awsEbsOperations) createSnapshot(logger logr.Logger, region, volumeID, description string, tags map[string]string) (string, error) {
+	return utils.CreateEbsSnapshot(logger, region, volumeID, description, tags)
+}
+
+func (awsEbsOperations) describeSnapshotState(logger logr.Logger, region, snapshotID string) (string, error) {
+	return utils.DescribeEbsSnapshotState(logger, region, snapshotID)
+}
+
+func (awsEbsOperations) describeSnapshots(logger logr.Logger, region string, ownerIDs, volumeIDs []string,
+	tags map[string]string) ([]utils.EbsSnapshot, error) {
+	return utils.DescribeEbsSnapshots(logger, region, ownerIDs, volumeIDs, tags)
+}
+
+func (awsEbsOperations) createTags(logger logr.Logger, region string, resourceIDs []string, tags map[string]string) error {
+	return utils.CreateEc2Tags(logger, region, resourceIDs, tags)
+}
+
+func (awsEbsOperations) deleteTags(logger logr.Logger, region string, resourceIDs []string, keys ...string) error {
+	return utils.DeleteEc2Tags(logger, region, resourceIDs, keys...)
+}
+
+// EbsVolumeJanitorReconciler reconciles a EbsVolumeJanitor object
+type EbsVolumeJanitorReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Prices is the catalog of prices used to estimate the cost of the volumes, the cost is not
+	// estimated if nil.
+	Prices pricing.Catalog
+	logger logr.Logger
+	// operations performed on the volumes, the aws cli is used if not set.
+	operations ebsOperations
+}
+
+// ebs returns the operations performed on the volumes.
+func (r *EbsVolumeJanitorReconciler) ebs() ebsOperations {
+	if r.operations == nil {
+		return awsEbsOperations{}
+	}
+	return r.operations
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EbsVolumeJanitorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.EbsVolumeJanitor{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=ebsvolumejanitors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=ebsvolumejanitors/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=ebsvolumejanitors/finalizers,verbs=update
+
+// Reconcile scans the unattached volumes at the scan interval. Volumes which reached the min
+// age are tagged as pending deletion and deleted once the grace period is over, optionally
+// after a snapshot of them completed. Volumes attached again within the grace period are kept,
+// volumes which were not seen unattached by the previous scan start their grace period over.
+func (r *EbsVolumeJanitorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling EbsVolumeJanitor ...")
+
+	janitor := &costoptimizerv1alpha1.EbsVolumeJanitor{}
+	if err := r.Get(ctx, req.NamespacedName, janitor); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	policy := newVolumeJanitorPolicy(&janitor.Spec)
+	if last := janitor.Status.LastScanTime; last != nil && janitor.Status.ObservedGeneration == janitor.Generation {
+		if wait := time.Until(last.Add(policy.scanInterval)); wait > 0 {
+			r.logger.V(1).Info("scan is up to date", "next scan after", wait.String())
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	kept, err := r.keepAttachedVolumes(janitor)
+	if err != nil {
+		return r.scanError(ctx, janitor, policy, err)
+	}
+	available, err := r.ebs().describeAvailableVolumes(r.logger, janitor.Spec.Region, janitor.Spec.Tags)
+	if err != nil {
+		return r.scanError(ctx, janitor, policy, err)
+	}
+	sort.Slice(available, func(i, j int) bool { return available[i].VolumeID < available[j].VolumeID })
+
+	now := time.Now()
+	volumes := kept
+	var totalSize, reclaimedSize int64
+	var totalCost float64
+	var deleted int32
+	var pending []string
+	for _, volume := range available {
+		entry, err := r.handleVolume(janitor, policy, volume, now)
+		if err != nil {
+			if _, action := utils.ClassifyError(err); action != utils.ActionSkip {
+				return r.scanError(ctx, janitor, policy, err)
+			}
+			entry.Message = err.Error()
+		}
+		switch entry.Action {
+		case janitorActionPendingDeletion, volumeActionSnapshotting:
+			pending = append(pending, volume.VolumeID)
+		}
+		if entry.Action == janitorActionDeleted {
+			deleted++
+			reclaimedSize += int64(volume.Size)
+		} else {
+			totalSize += int64(volume.Size)
+			totalCost += parseUSD(entry.EstimatedMonthlyCost)
+		}
+		volumes = append(volumes, entry)
+	}
+	// the volumes pending deletion were seen unattached by this scan.
+	if len(pending) > 0 {
+		err := r.ebs().createTags(r.logger, janitor.Spec.Region, pending,
+			map[string]string{lastSeenUnusedTag: now.UTC().Format(time.RFC3339)})
+		if err != nil {
+			return r.scanError(ctx, janitor, policy, err)
+		}
+	}
+
+	scanTime := metav1.NewTime(now)
+	r.patchStatus(ctx, janitor, func(status *costoptimizerv1alpha1.EbsVolumeJanitorStatus) {
+		status.State = complete
+		status.Message = ""
+		status.ObservedGeneration = janitor.Generation
+		status.LastScanTime = &scanTime
+		status.TotalSizeGiB = totalSize
+		status.EstimatedMonthlyCost = formatUSD(totalCost)
+		status.DeletedVolumes += deleted
+		status.ReclaimedSizeGiB += reclaimedSize
+		status.Volumes = volumes
+	})
+	return ctrl.Result{RequeueAfter: policy.scanInterval}, nil
+}
+
+// keepAttachedVolumes removes the pending deletion tags of the volumes attached again within
+// the grace period.
+func (r *EbsVolumeJanitorReconciler) keepAttachedVolumes(janitor *costoptimizerv1alpha1.EbsVolumeJanitor) ([]costoptimizerv1alpha1.UnattachedVolume, error) {
+	attached, err := r.ebs().describeVolumesByTagKey(r.logger, janitor.Spec.Region, pendingDeletionTag, "in-use")
+	if err != nil || len(attached) == 0 {
+		return nil, err
+	}
+	volumeIDs := make([]string, 0, len(attached))
+	kept := make([]costoptimizerv1alpha1.UnattachedVolume, 0, len(attached))
+	for _, volume := range attached {
+		volumeIDs = append(volumeIDs, volume.VolumeID)
+		entry := r.newUnattachedVolume(janitor, volume)
+		entry.Action = janitorActionKept
+		kept = append(kept, entry)
+	}
+	if janitor.Spec.DryRun {
+		return kept, nil
+	}
+	if err := r.ebs().deleteTags(r.logger, janitor.Spec.Region, volumeIDs, pendingDeletionTag, lastSeenUnusedTag, deletionSnapshotTag); err != nil {
+		return nil, err
+	}
+	r.Recorder.Eventf(janitor, corev1.EventTypeNormal, eventReasonKept,
+		"Kept volumes %v which got attached within the grace period", volumeIDs)
+	return kept, nil
+}
+
+// handleVolume takes the next action on the unattached volume and returns its status entry.
+func (r *EbsVolumeJanitorReconciler) handleVolume(janitor *costoptimizerv1alpha1.EbsVolumeJanitor, policy volumeJanitorPolicy,
+	volume utils.EbsVolume, now time.Time) (costoptimizerv1alpha1.UnattachedVolume, error) {
+	region := janitor.Spec.Region
+	entry := r.newUnattachedVolume(janitor, volume)
+	action, deletionTime := policy.nextAction(volume.CreateTime, volume.Tags, now)
+	if (action == janitorActionPendingDeletion || action == janitorActionDeleted) &&
+		!seenUnusedRecently(volume.Tags, policy.scanInterval, now) {
+		// the volume may have been attached since it got marked, its grace period starts over.
+		action, deletionTime = janitorActionMarkedForDeletion, now.Add(policy.gracePeriod)
+	}
+	if action != janitorActionNone {
+		entry.DeletionTime = &metav1.Time{Time: deletionTime}
+	}
+	if janitor.Spec.DryRun {
+		entry.Action = janitorActionNone
+		if action != janitorActionNone {
+			entry.Action = janitorActionWouldDelete
+		}
+		return entry, nil
+	}
+
+	entry.Action = action
+	switch action {
+	case janitorActionMarkedForDeletion:
+		if _, ok := volume.Tags.Get(deletionSnapshotTag); ok {
+			// the snapshot of the previous marking may not hold the current data of the volume.
+			if err := r.ebs().deleteTags(r.logger, region, []string{volume.VolumeID}, deletionSnapshotTag); err != nil {
+				entry.Action, entry.DeletionTime = janitorActionNone, nil
+				return entry, err
+			}
+		}
+		err := r.ebs().createTags(r.logger, region, []string{volume.VolumeID}, map[string]string{
+			pendingDeletionTag: deletionTime.UTC().Format(time.RFC3339),
+			lastSeenUnusedTag:  now.UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			entry.Action, entry.DeletionTime = janitorActionNone, nil
+			return entry, err
+		}
+		r.Recorder.Eventf(janitor, corev1.EventTypeNormal, eventReasonMarkedForDeletion,
+			"Volume %s (%d GiB) is deleted after %s unless it gets attached", volume.VolumeID, volume.Size, deletionTime.Format(time.RFC3339))
+	case janitorActionDeleted:
+		// due, the action is updated once the volume got deleted.
+		entry.Action = janitorActionPendingDeletion
+		if !policy.snapshot {
+			return r.deleteVolume(janitor, volume, entry)
+		}
+		entry.Action = volumeActionSnapshotting
+		snapshotID, state, err := r.deletionSnapshot(region, volume, deletionTime)
+		if err != nil {
+			return entry, err
+		}
+		entry.SnapshotID = snapshotID
+		if state == "completed" {
+			return r.deleteVolume(janitor, volume, entry)
+		}
+	}
+	return entry, nil
+}
+
+// deletionSnapshot returns the snapshot taken of the volume before its deletion along with its
+// state, the snapshot is taken if there is none. The snapshot is tagged with the id of the volume
+// and looked up by it if the volume does not reference it, so that a snapshot is not taken twice
+// when tagging the volume fails.
+func (r *EbsVolumeJanitorReconciler) deletionSnapshot(region string, volume utils.EbsVolume, deletionTime time.Time) (string, string, error) {
+	snapshotID, _ := volume.Tags.Get(deletionSnapshotTag)
+	if snapshotID != "" {
+		state, err := r.ebs().describeSnapshotState(r.logger, region, snapshotID)
+		if err != nil && !isNotFound(err) {
+			return snapshotID, "", err
+		}
+		if state == "completed" || state == "pending" {
+			return snapshotID, state, nil
+		}
+		// the snapshot failed or is gone, a new one is taken.
+	}
+
+	snapshots, err := r.ebs().describeSnapshots(r.logger, region, []string{"self"}, []string{volume.VolumeID},
+		map[string]string{sourceVolumeTag: volume.VolumeID})
+	if err != nil {
+		return snapshotID, "", err
+	}
+	var found *utils.EbsSnapshot
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		// snapshots taken before the deletion was due may not hold the current data.
+		if snapshot.StartTime.Before(deletionTime) || (snapshot.State != "completed" && snapshot.State != "pending") {
+			continue
+		}
+		if found == nil || snapshot.StartTime.After(found.StartTime) {
+			found = snapshot
+		}
+	}
+	state := "pending"
+	if found != nil {
+		snapshotID, state = found.SnapshotID, found.State
+	} else {
+		snapshotID, err = r.ebs().createSnapshot(r.logger, region, volume.VolumeID,
+			fmt.Sprintf("Snapshot of volume %s taken before its deletion", volume.VolumeID),
+			map[string]string{sourceVolumeTag: volume.VolumeID})
+		if err != nil {
+			return "", "", err
+		}
+	}
+	if err := r.ebs().createTags(r.logger, region, []string{volume.VolumeID}, map[string]string{deletionSnapshotTag: snapshotID}); err != nil {
+		return snapshotID, state, err
+	}
+	return snapshotID, state, nil
+}
+
+func (r *EbsVolumeJanitorReconciler) deleteVolume(janitor *costoptimizerv1alpha1.EbsVolumeJanitor, volume utils.EbsVolume,
+	entry costoptimizerv1alpha1.UnattachedVolume) (costoptimizerv1alpha1.UnattachedVolume, error) {
+	if err := r.ebs().deleteVolume(r.logger, janitor.Spec.Region, volume.VolumeID); err != nil {
+		reason, action := utils.ClassifyError(err)
+		r.Recorder.Eventf(janitor, corev1.EventTypeWarning, eventReasonOperationFailed,
+			"Deleting volume %s failed with reason %s (action: %s): %v", volume.VolumeID, reason, action, err)
+		return entry, err
+	}
+	entry.Action = janitorActionDeleted
+	r.Recorder.Eventf(janitor, corev1.EventTypeNormal, eventReasonDeleted,
+		"Deleted volume %s (%d GiB, %s USD per month)", volume.VolumeID, volume.Size, entry.EstimatedMonthlyCost)
+	return entry, nil
+}
+
+func (r *EbsVolumeJanitorReconciler) newUnattachedVolume(janitor *costoptimizerv1alpha1.EbsVolumeJanitor,
+	volume utils.EbsVolume) costoptimizerv1alpha1.UnattachedVolume {
+	entry := costoptimizerv1alpha1.UnattachedVolume{
+		VolumeID:   volume.VolumeID,
+		SizeGiB:    volume.Size,
+		VolumeType: volume.VolumeType,
+		CreateTime: metav1.NewTime(volume.CreateTime),
+	}
+	if cost, ok := pricing.VolumeMonthlyPrice(r.Prices, utils.ResolveRegion(janitor.Spec.Region), volume.VolumeType, volume.Size); ok {
+		entry.EstimatedMonthlyCost = formatUSD(cost)
+	}
+	return entry
+}
+
+// scanError marks the scan as failed, errors which cannot be resolved by retrying are retried
+// at the next scan interval.
+func (r *EbsVolumeJanitorReconciler) scanError(ctx context.Context, janitor *costoptimizerv1alpha1.EbsVolumeJanitor,
+	policy volumeJanitorPolicy, err error) (ctrl.Result, error) {
+	r.logger.Error(err, "unable to scan volumes")
+	r.patchStatus(ctx, janitor, func(status *costoptimizerv1alpha1.EbsVolumeJanitorStatus) {
+		status.State = failed
+		status.Message = err.Error()
+		status.ObservedGeneration = janitor.Generation
+	})
+	if _, action := utils.ClassifyError(err); action == utils.ActionFail {
+		return ctrl.Result{RequeueAfter: policy.scanInterval}, nil
+	}
+	return ctrl.Result{}, err
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *EbsVolumeJanitorReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.EbsVolumeJanitor,
+	mutate func(status *costoptimizerv1alpha1.EbsVolumeJanitorStatus)) {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+	}
+}
+
+// volumeJanitorPolicy is the spec of an EbsVolumeJanitor with the defaults applied.
+type volumeJanitorPolicy struct {
+	janitorPolicy
+	snapshot bool
+}
+
+func newVolumeJanitorPolicy(spec *costoptimizerv1alpha1.EbsVolumeJanitorSpec) volumeJanitorPolicy {
+	return volumeJanitorPolicy{
+		janitorPolicy: newJanitorPolicy(spec.MinAge, spec.GracePeriod, spec.ScanInterval, janitorPolicy{
+			minAge:       defaultVolumeMinAge,
+			gracePeriod:  defaultVolumeGracePeriod,
+			scanInterval: defaultVolumeScanInterval,
+		}),
+		snapshot: spec.Snapshot,
+	}
+}
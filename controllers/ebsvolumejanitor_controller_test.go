@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	"k8s.io/client-go/tools/record"
+)
+
+// fakeEbsOperations performs the ec2 operations on the volumes and snapshots, the tagging fails
+// with tagErr. The operations performed are recorded in order.
+type fakeEbsOperations struct {
+	attached   []utils.EbsVolume
+	snapshots  []utils.EbsSnapshot
+	states     map[string]string
+	tagErr     error
+	operations []string
+}
+
+func (f *fakeEbsOperations) describeAvailableVolumes(logr.Logger, string, map[string]string) ([]utils.EbsVolume, error) {
+	return nil, nil
+}
+
+func (f *fakeEbsOperations) describeVolumesByTagKey(logr.Logger, string, string, string) ([]utils.EbsVolume, error) {
+	return f.attached, nil
+}
+
+func (f *fakeEbsOperations) deleteVolume(_ logr.Logger, _, volumeID string) error {
+	f.operations = append(f.operations, "delete "+volumeID)
+	return nil
+}
+
+func (f *fakeEbsOperations) createSnapshot(_ logr.Logger, _, volumeID, _ string, tags map[string]string) (string, error) {
+	f.operations = append(f.operations, fmt.Sprintf("snapshot %s %s", volumeID, formatTags(tags)))
+	return "snap-new", nil
+}
+
+func (f *fakeEbsOperations) describeSnapshotState(_ logr.Logger, _, snapshotID string) (string, error) {
+	state, ok := f.states[snapshotID]
+	if !ok {
+		return "", &utils.AWSError{Code: "InvalidSnapshot.NotFound", Operation: "DescribeSnapshots"}
+	}
+	return state, nil
+}
+
+func (f *fakeEbsOperations) describeSnapshots(logr.Logger, string, []string, []string, map[string]string) ([]utils.EbsSnapshot, error) {
+	return f.snapshots, nil
+}
+
+func (f *fakeEbsOperations) createTags(_ logr.Logger, _ string, resourceIDs []string, tags map[string]string) error {
+	f.operations = append(f.operations, fmt.Sprintf("tag %s %s", strings.Join(resourceIDs, ","), formatTags(tags)))
+	return f.tagErr
+}
+
+func (f *fakeEbsOperations) deleteTags(_ logr.Logger, _ string, resourceIDs []string, keys ...string) error {
+	f.operations = append(f.operations, fmt.Sprintf("untag %s %s", strings.Join(resourceIDs, ","), strings.Join(keys, ",")))
+	return nil
+}
+
+// formatTags returns the tags as key=value pairs sorted by key.
+func formatTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for key, value := range tags {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func TestHandleVolume(t *testing.T) {
+	now := time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-30 * 24 * time.Hour)
+	due := now.Add(-time.Hour)
+	seen := utils.Tag{Key: lastSeenUnusedTag, Value: now.Add(-time.Hour).Format(time.RFC3339)}
+	marked := utils.Tag{Key: pendingDeletionTag, Value: now.Add(24 * time.Hour).Format(time.RFC3339)}
+	dueMark := utils.Tag{Key: pendingDeletionTag, Value: due.Format(time.RFC3339)}
+	nowTag, remarkTag := now.Format(time.RFC3339), now.Add(defaultVolumeGracePeriod).Format(time.RFC3339)
+	denied := &utils.AWSError{Code: "UnauthorizedOperation", Operation: "CreateTags", Message: "denied"}
+
+	tests := []struct {
+		name       string
+		createTime time.Time
+		tags       utils.Tags
+		snapshot   bool
+		dryRun     bool
+		states     map[string]string
+		snapshots  []utils.EbsSnapshot
+		tagErr     error
+		action     string
+		snapshotID string
+		operations []string
+		events     []string
+	}{
+		{
+			name:   "mark",
+			action: janitorActionMarkedForDeletion,
+			operations: []string{fmt.Sprintf("tag vol-1 %s=%s,%s=%s", lastSeenUnusedTag, nowTag,
+				pendingDeletionTag, remarkTag)},
+			events: []string{eventReasonMarkedForDeletion},
+		},
+		{
+			name:   "pending",
+			tags:   utils.Tags{marked, seen},
+			action: janitorActionPendingDeletion,
+		},
+		{
+			name:   "not seen by the previous scan",
+			tags:   utils.Tags{dueMark, {Key: deletionSnapshotTag, Value: "snap-1"}},
+			action: janitorActionMarkedForDeletion,
+			operations: []string{
+				"untag vol-1 " + deletionSnapshotTag,
+				fmt.Sprintf("tag vol-1 %s=%s,%s=%s", lastSeenUnusedTag, nowTag, pendingDeletionTag, remarkTag),
+			},
+			events: []string{eventReasonMarkedForDeletion},
+		},
+		{
+			name:       "delete",
+			tags:       utils.Tags{dueMark, seen},
+			action:     janitorActionDeleted,
+			operations: []string{"delete vol-1"},
+			events:     []string{eventReasonDeleted},
+		},
+		{
+			name:       "snapshot",
+			tags:       utils.Tags{dueMark, seen},
+			snapshot:   true,
+			action:     volumeActionSnapshotting,
+			snapshotID: "snap-new",
+			operations: []string{
+				fmt.Sprintf("snapshot vol-1 %s=vol-1", sourceVolumeTag),
+				fmt.Sprintf("tag vol-1 %s=snap-new", deletionSnapshotTag),
+			},
+		},
+		{
+			name:       "snapshot pending",
+			tags:       utils.Tags{dueMark, seen, {Key: deletionSnapshotTag, Value: "snap-1"}},
+			snapshot:   true,
+			states:     map[string]string{"snap-1": "pending"},
+			action:     volumeActionSnapshotting,
+			snapshotID: "snap-1",
+		},
+		{
+			name:       "snapshot completed",
+			tags:       utils.Tags{dueMark, seen, {Key: deletionSnapshotTag, Value: "snap-1"}},
+			snapshot:   true,
+			states:     map[string]string{"snap-1": "completed"},
+			action:     janitorActionDeleted,
+			snapshotID: "snap-1",
+			operations: []string{"delete vol-1"},
+			events:     []string{eventReasonDeleted},
+		},
+		{
+			name:     "snapshot not tagged on the volume",
+			tags:     utils.Tags{dueMark, seen},
+			snapshot: true,
+			snapshots: []utils.EbsSnapshot{
+				{SnapshotID: "snap-old", State: "completed", StartTime: due.Add(-time.Hour)},
+				{SnapshotID: "snap-1", State: "completed", StartTime: due.Add(time.Minute)},
+			},
+			action:     janitorActionDeleted,
+			snapshotID: "snap-1",
+			operations: []string{
+				fmt.Sprintf("tag vol-1 %s=snap-1", deletionSnapshotTag),
+				"delete vol-1",
+			},
+			events: []string{eventReasonDeleted},
+		},
+		{
+			name:     "snapshot failed",
+			tags:     utils.Tags{dueMark, seen, {Key: deletionSnapshotTag, Value: "snap-1"}},
+			snapshot: true,
+			states:   map[string]string{"snap-1": "error"},
+			snapshots: []utils.EbsSnapshot{
+				{SnapshotID: "snap-1", State: "error", StartTime: due.Add(time.Minute)},
+			},
+			action:     volumeActionSnapshotting,
+			snapshotID: "snap-new",
+			operations: []string{
+				fmt.Sprintf("snapshot vol-1 %s=vol-1", sourceVolumeTag),
+				fmt.Sprintf("tag vol-1 %s=snap-new", deletionSnapshotTag),
+			},
+		},
+		{
+			name:     "tagging the snapshot failed",
+			tags:     utils.Tags{dueMark, seen},
+			snapshot: true,
+			tagErr:   denied,
+			action:   volumeActionSnapshotting,
+			operations: []string{
+				fmt.Sprintf("snapshot vol-1 %s=vol-1", sourceVolumeTag),
+				fmt.Sprintf("tag vol-1 %s=snap-new", deletionSnapshotTag),
+			},
+		},
+		{
+			name:   "dry run",
+			tags:   utils.Tags{dueMark, seen},
+			dryRun: true,
+			action: janitorActionWouldDelete,
+		},
+		{
+			name:       "dry run too young",
+			createTime: now,
+			dryRun:     true,
+			action:     janitorActionNone,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ebs := &fakeEbsOperations{states: test.states, snapshots: test.snapshots, tagErr: test.tagErr}
+			recorder := record.NewFakeRecorder(10)
+			r := &EbsVolumeJanitorReconciler{Recorder: recorder, logger: logr.Discard(), operations: ebs}
+			janitor := &costoptimizerv1alpha1.EbsVolumeJanitor{Spec: costoptimizerv1alpha1.EbsVolumeJanitorSpec{
+				Snapshot: test.snapshot, DryRun: test.dryRun}}
+			volume := utils.EbsVolume{VolumeID: "vol-1", Size: 10, VolumeType: "gp3", CreateTime: old, Tags: test.tags}
+			if !test.createTime.IsZero() {
+				volume.CreateTime = test.createTime
+			}
+
+			entry, err := r.handleVolume(janitor, newVolumeJanitorPolicy(&janitor.Spec), volume, now)
+			if !errors.Is(err, test.tagErr) {
+				t.Errorf("expected error %v, got %v", test.tagErr, err)
+			}
+			if entry.Action != test.action || entry.SnapshotID != test.snapshotID {
+				t.Errorf("expected action %s with snapshot %q, got %s with snapshot %q", test.action, test.snapshotID,
+					entry.Action, entry.SnapshotID)
+			}
+			if !reflect.DeepEqual(ebs.operations, test.operations) {
+				t.Errorf("expected operations %v, got %v", test.operations, ebs.operations)
+			}
+			if events := eventReasons(recorder); !reflect.DeepEqual(events, test.events) {
+				t.Errorf("expected events %v, got %v", test.events, events)
+			}
+		})
+	}
+}
+
+func TestKeepAttachedVolumes(t *testing.T) {
+	for _, dryRun := range []bool{false, true} {
+		t.Run(fmt.Sprintf("dry run %t", dryRun), func(t *testing.T) {
+			ebs := &fakeEbsOperations{attached: []utils.EbsVolume{{VolumeID: "vol-1"}, {VolumeID: "vol-2"}}}
+			recorder := record.NewFakeRecorder(10)
+			r := &EbsVolumeJanitorReconciler{Recorder: recorder, logger: logr.Discard(), operations: ebs}
+			janitor := &costoptimizerv1alpha1.EbsVolumeJanitor{Spec: costoptimizerv1alpha1.EbsVolumeJanitorSpec{DryRun: dryRun}}
+
+			kept, err := r.keepAttachedVolumes(janitor)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(kept) != 2 || kept[0].Action != janitorActionKept || kept[1].Action != janitorActionKept {
+				t.Errorf("expected both volumes to be kept, got %+v", kept)
+			}
+			var operations []string
+			if !dryRun {
+				operations = []string{fmt.Sprintf("untag vol-1,vol-2 %s,%s,%s", pendingDeletionTag, lastSeenUnusedTag, deletionSnapshotTag)}
+			}
+			if !reflect.DeepEqual(ebs.operations, operations) {
+				t.Errorf("expected operations %v, got %v", operations, ebs.operations)
+			}
+		})
+	}
+}
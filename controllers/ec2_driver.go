@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/metrics"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+)
+
+func init() {
+	registerDriver(ec2Driver{})
+}
+
+// ec2Driver drives ec2 instances, the instances are operated in a single request.
+type ec2Driver struct{}
+
+func (ec2Driver) kind() string {
+	return metrics.Ec2Kind
+}
+
+func (ec2Driver) resolveTargets(logger logr.Logger, region string, ids []string, tags map[string]string) ([]string, error) {
+	instances, err := utils.DescribeEc2InstancesByTags(logger, region, ids, tags)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		targets = append(targets, instance.InstanceID)
+	}
+	return targets, nil
+}
+
+func (ec2Driver) getState(logger logr.Logger, region string, ids []string) (map[string]string, error) {
+	instances, err := utils.DescribeEc2Instances(logger, region, ids)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]string, len(instances))
+	for _, instance := range instances {
+		states[instance.InstanceID] = instance.State
+	}
+	return states, nil
+}
+
+func (ec2Driver) applyAction(logger logr.Logger, region string, operation costoptimizerv1alpha1.Ec2OperationType,
+	ids []string) ([]utils.InstanceError, error) {
+	if operation == costoptimizerv1alpha1.Start {
+		return utils.StartEc2Instance(logger, region, ids)
+	}
+	return utils.StopEc2Instance(logger, region, ids)
+}
+
+func (ec2Driver) states() (string, string) {
+	return "running", "stopped"
+}
+
+// snapshotConfig returns the instance types of the instances.
+func (ec2Driver) snapshotConfig(logger logr.Logger, region string, ids []string) (map[string]string, error) {
+	instances, err := utils.DescribeEc2Instances(logger, region, ids)
+	if err != nil {
+		return nil, err
+	}
+	instanceTypes := make(map[string]string, len(instances))
+	for _, instance := range instances {
+		instanceTypes[instance.InstanceID] = instance.InstanceType
+	}
+	return instanceTypes, nil
+}
+
+// restoreConfig changes the type of the instance back to the saved instance type.
+func (ec2Driver) restoreConfig(logger logr.Logger, region, id, config string) error {
+	return utils.ModifyEc2InstanceType(logger, region, id, config)
+}
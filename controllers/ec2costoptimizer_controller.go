@@ -6,12 +6,18 @@ import (
 	"time"
 
 	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/metrics"
+	"github.com/KubeInBox/aws-utility-controller/pkg/pricing"
 	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -20,16 +26,29 @@ import (
 
 const (
 	inProgress      = "InProgress"
+	retrying        = "Retrying"
 	failed          = "Failed"
 	complete        = "Completed"
+	evaluating      = "Evaluating"
 	inTimeWindow    = "InTimeWindow"
 	outOfTimeWindow = "OutOfTimeWindow"
 )
 
+// condition reasons for successful operations, failures are reported with the
+// reasons derived from the aws error.
+const (
+	reasonSucceeded        = "Succeeded"
+	reasonInstancesSkipped = "InstancesSkipped"
+)
+
 // Ec2CostOptimizerReconciler reconciles a Ec2CostOptimizer object
 type Ec2CostOptimizerReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Prices is the catalog of on-demand prices used to estimate the savings, savings
+	// are not estimated if nil.
+	Prices pricing.Catalog
 	logger logr.Logger
 }
 
@@ -45,6 +64,8 @@ func (r *Ec2CostOptimizerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 //+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=ec2costoptimizers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=ec2costoptimizers/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=ec2costoptimizers/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -63,6 +84,7 @@ func (r *Ec2CostOptimizerReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			// object not found, could have been deleted after
 			// reconcile request, hence don't requeue
 			r.logger.V(1).Info("object not found")
+			metrics.DeleteObjectMetrics(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 		r.logger.Error(err, "unable to fetch object ")
@@ -72,25 +94,35 @@ func (r *Ec2CostOptimizerReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 	switch ec2CostOptimizer.Spec.WindowType {
 	case costoptimizerv1alpha1.OnDemand:
-		if ec2CostOptimizer.Status.State == fmt.Sprintf("%s/%s", costoptimizerv1alpha1.OnDemand, complete) {
-			r.logger.V(1).Info("ignoring already processed onDemand object")
-			return ctrl.Result{}, nil
-		}
-		r.logger.V(1).Info("Handling onDemand ec2 with operation", "type", ec2CostOptimizer.Spec.Operation)
-		r.UpdateStatus(ctx, ec2CostOptimizer, inProgress)
-		if err = r.handleOnDemandEc2Oprn(ec2CostOptimizer); err != nil {
-			r.logger.Error(err, "error processing onDemand ec2 operation")
-			r.UpdateStatus(ctx, ec2CostOptimizer, failed)
+		ec2, err := requireDriver(metrics.Ec2Kind)
+		if err != nil {
 			return ctrl.Result{}, err
 		}
-		r.UpdateStatus(ctx, ec2CostOptimizer, complete)
+		return reconcileOnDemand(r.logger, r.Recorder, r.driverOperation(ctx, ec2CostOptimizer, ec2))
 	case costoptimizerv1alpha1.Scheduled:
+		ec2, err := requireDriver(metrics.Ec2Kind)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
 		r.logger.V(1).Info("Handling scheduled ec2 with operation", "type", ec2CostOptimizer.Spec.Operation)
-		err = r.handleScheduledEc2Oprn(ctx, ec2CostOptimizer)
+		result, err := reconcileScheduled(r.logger, r.Recorder, r.driverOperation(ctx, ec2CostOptimizer, ec2))
+		r.observeInstances(ctx, ec2CostOptimizer)
+		if after, windowErr := timeUntilNextWindowAction(ec2CostOptimizer.Spec.StartTimeWindow, ec2CostOptimizer.Spec.EndTimeWindow); windowErr == nil {
+			metrics.SetNextScheduledAction(ec2CostOptimizer.Namespace, ec2CostOptimizer.Name, after)
+		}
+		return result, err
+	case costoptimizerv1alpha1.Idle:
+		r.logger.V(1).Info("Handling idle ec2 with operation", "type", ec2CostOptimizer.Spec.Operation)
+		err = r.handleIdleEc2Oprn(ctx, ec2CostOptimizer)
 		if err != nil {
-			r.logger.Error(err, "error processing scheduled ec2 operation")
+			r.logger.Error(err, "error processing idle ec2 operation")
+			if _, action := utils.ClassifyError(err); action == utils.ActionFail {
+				// retrying will not help, check again in the next evaluation.
+				err = nil
+			}
 		}
-		return ctrl.Result{RequeueAfter: wait.Jitter(1*time.Minute, 0.5)}, err
+		r.observeInstances(ctx, ec2CostOptimizer)
+		return ctrl.Result{RequeueAfter: wait.Jitter(idleEvaluationPeriod, 0.5)}, err
 	default:
 		r.logger.V(1).Info("invalid window type specified")
 	}
@@ -100,64 +132,166 @@ func (r *Ec2CostOptimizerReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	return ctrl.Result{}, nil
 }
 
-// handleOnDemandEc2Oprn will start/stop ec2 instances right away, upon error it will keep retrying
-// the operation until it gets succeeded.
-func (r *Ec2CostOptimizerReconciler) handleOnDemandEc2Oprn(ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer) error {
-	switch ec2CostOptimizer.Spec.Operation {
-	case costoptimizerv1alpha1.Start:
-		if err := utils.StartEc2Instance(r.logger, ec2CostOptimizer.Spec.InstanceIDs); err != nil {
-			return err
-		}
-	case costoptimizerv1alpha1.Stop:
-		if err := utils.StopEc2Instance(r.logger, ec2CostOptimizer.Spec.InstanceIDs); err != nil {
-			return err
+// driverOperation returns the start/stop operation of the object on its instances. Within the
+// time window of a scheduled stop the stopped instances are resized, they are started again with
+// their new type once the window is over.
+func (r *Ec2CostOptimizerReconciler) driverOperation(ctx context.Context, ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer,
+	ec2 resourceDriver) driverOperation {
+	spec := ec2CostOptimizer.Spec
+	return driverOperation{
+		obj:             ec2CostOptimizer,
+		driver:          ec2,
+		region:          spec.Region,
+		operation:       spec.Operation,
+		windowType:      spec.WindowType,
+		startTimeWindow: spec.StartTimeWindow,
+		endTimeWindow:   spec.EndTimeWindow,
+		retryPolicy:     spec.RetryPolicy,
+		status:          &ec2CostOptimizer.Status.OperationStatus,
+		patchStatus: func(mutate func(status *costoptimizerv1alpha1.OperationStatus)) {
+			r.patchStatus(ctx, ec2CostOptimizer, func(status *costoptimizerv1alpha1.Ec2CostOptimizerStatus) {
+				mutate(&status.OperationStatus)
+			})
+		},
+		operate: func() ([]string, error) {
+			settling, err := r.operateInstances(ctx, ec2CostOptimizer, spec.InstanceIDs)
+			// the instances are stopped now, which is the safe moment to change their type.
+			if err == nil && resizeEnabled(ec2CostOptimizer) {
+				err = r.resizeInstances(ctx, ec2CostOptimizer)
+			}
+			return settling, err
+		},
+		outOfWindow: func() error {
+			// perform counter operation, if it was stopped in time window then start or vice-versa.
+			if resizeEnabled(ec2CostOptimizer) {
+				return r.startResizedInstances(ctx, ec2CostOptimizer)
+			}
+			return nil
+		},
+		recordConflicts: func() {
+			r.recordConflicts(ctx, ec2CostOptimizer)
+		},
+		completed: func() {
+			// observed once, completed objects are not requeued anymore.
+			r.observeInstances(ctx, ec2CostOptimizer)
+		},
+	}
+}
+
+// operateInstances performs the operation of the object on the given instances which are not yet
+// in the desired state, instances in transition are left alone. The outcome is recorded in the
+// metrics, events and status of the object. The instances expected to reach the state of the
+// operation are returned, i.e. the existing instances which were not skipped.
+func (r *Ec2CostOptimizerReconciler) operateInstances(ctx context.Context, ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer,
+	instanceIDs []string) ([]string, error) {
+	ec2, err := requireDriver(metrics.Ec2Kind)
+	if err != nil {
+		return nil, err
+	}
+	states, err := ec2.getState(r.logger, ec2CostOptimizer.Spec.Region, instanceIDs)
+	if err != nil {
+		r.recordOperationResult(ctx, ec2CostOptimizer, nil, err)
+		return nil, err
+	}
+	targets, skipped := operationTargets(ec2, ec2CostOptimizer.Spec.Operation, states, instanceIDs)
+	if len(targets) > 0 {
+		var operationSkipped []utils.InstanceError
+		operationSkipped, err = applyDriverAction(r.logger, r.Recorder, ec2CostOptimizer, ec2, ec2CostOptimizer.Spec.Operation,
+			ec2CostOptimizer.Spec.Region, targets)
+		if err == nil && ec2CostOptimizer.Spec.Operation == costoptimizerv1alpha1.Stop {
+			stopped := issuedTargets(targets, operationSkipped)
+			r.patchStatus(ctx, ec2CostOptimizer, func(status *costoptimizerv1alpha1.Ec2CostOptimizerStatus) {
+				status.Savings = markStopped(ec2CostOptimizer, stopped, metav1.Now())
+			})
 		}
-	default:
-		r.logger.Info("specified invalid ec2 operation type")
+		skipped = append(skipped, operationSkipped...)
 	}
-	return nil
+	r.recordOperationResult(ctx, ec2CostOptimizer, skipped, err)
+	if err != nil {
+		return nil, err
+	}
+	return issuedTargets(instanceIDs, skipped), nil
 }
 
-func (r *Ec2CostOptimizerReconciler) handleScheduledEc2Oprn(ctx context.Context, ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer) error {
-	if !isInTimeWindow(r.logger, ec2CostOptimizer.Spec.StartTimeWindow, ec2CostOptimizer.Spec.EndTimeWindow) {
-		r.UpdateStatus(ctx, ec2CostOptimizer, outOfTimeWindow)
-		r.logger.Info("ignoring as it is not in scheduled time window")
-		// perform counter operation, if it was stopped in time window then start or vice-versa.
-		return nil
+// recordOperationResult records the skipped instances and the outcome of the operation
+// in the status of the object.
+func (r *Ec2CostOptimizerReconciler) recordOperationResult(ctx context.Context, obj *costoptimizerv1alpha1.Ec2CostOptimizer,
+	skipped []utils.InstanceError, err error) {
+	condition := metav1.Condition{
+		Type:               costoptimizerv1alpha1.ConditionOperationSucceeded,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonSucceeded,
+		Message:            fmt.Sprintf("%s operation succeeded", obj.Spec.Operation),
+		ObservedGeneration: obj.Generation,
+	}
+	switch {
+	case err != nil:
+		reason, action := utils.ClassifyError(err)
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = reason
+		condition.Message = fmt.Sprintf("%s operation failed (action: %s): %v", obj.Spec.Operation, action, err)
+	case len(skipped) > 0:
+		condition.Reason = reasonInstancesSkipped
+		condition.Message = fmt.Sprintf("%s operation succeeded, skipped %d instance(s)", obj.Spec.Operation, len(skipped))
 	}
-	r.UpdateStatus(ctx, ec2CostOptimizer, inTimeWindow)
-	r.logger.Info("current time is within the time window, starting operations")
 
-	// start/stop if it is in given time window
-	if err := r.handleOnDemandEc2Oprn(ec2CostOptimizer); err != nil {
-		return err
+	previous := map[string]string{}
+	for _, instance := range obj.Status.SkippedInstances {
+		previous[instance.InstanceID] = instance.Reason
 	}
-	return nil
+	skippedInstances := make([]costoptimizerv1alpha1.SkippedInstance, 0, len(skipped))
+	for _, instanceErr := range skipped {
+		reason, _ := utils.ClassifyError(instanceErr.Err)
+		// the instances are checked on every reconciliation, only report newly skipped ones.
+		if previous[instanceErr.InstanceID] != reason {
+			r.Recorder.Eventf(obj, corev1.EventTypeWarning, eventReasonInstanceSkipped,
+				"%s skipped instance %s with reason %s: %v", obj.Spec.Operation, instanceErr.InstanceID, reason, instanceErr.Err)
+		}
+		skippedInstances = append(skippedInstances, costoptimizerv1alpha1.SkippedInstance{
+			InstanceID: instanceErr.InstanceID,
+			Reason:     reason,
+			Message:    instanceErr.Err.Error(),
+		})
+	}
+
+	r.patchStatus(ctx, obj, func(status *costoptimizerv1alpha1.Ec2CostOptimizerStatus) {
+		status.SkippedInstances = skippedInstances
+		meta.SetStatusCondition(&status.Conditions, condition)
+	})
 }
 
 func (r *Ec2CostOptimizerReconciler) UpdateStatus(ctx context.Context, obj *costoptimizerv1alpha1.Ec2CostOptimizer, msg string) {
+	r.patchStatus(ctx, obj, func(status *costoptimizerv1alpha1.Ec2CostOptimizerStatus) {
+		status.State = fmt.Sprintf("%s/%s", obj.Spec.WindowType, msg)
+	})
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *Ec2CostOptimizerReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.Ec2CostOptimizer,
+	mutate func(status *costoptimizerv1alpha1.Ec2CostOptimizerStatus)) error {
 	// create patches for the object and its possible status
 	statusPatch := client.MergeFrom(obj.DeepCopy())
 
-	obj.Status.State = fmt.Sprintf("%s/%s", obj.Spec.WindowType, msg)
+	mutate(&obj.Status)
 	data, err := statusPatch.Data(obj)
 	if err != nil {
-		return
+		return err
 	}
 
 	// no change in the patch we get length of 2.
 	if len(data) <= 2 {
-		return
+		return nil
 	}
 
 	// patch status of a given object.
 	err = r.Status().Patch(ctx, obj, statusPatch)
 	if err != nil {
 		r.logger.Error(err, "failed to update status")
-		return
+		return err
 	}
 
 	r.logger.Info(fmt.Sprintf("updated status with state %s", obj.Status.State))
+	return nil
 }
 
 func isInTimeWindow(logger logr.Logger, startTimeWindow, endTimeWindow string) bool {
@@ -165,37 +299,62 @@ func isInTimeWindow(logger logr.Logger, startTimeWindow, endTimeWindow string) b
 		return false
 	}
 
+	currentTime, startTime, endTime, err := parseTimeWindow(startTimeWindow, endTimeWindow)
+	if err != nil {
+		logger.Error(err, "invalid time window")
+		return false
+	}
+
+	logger.V(1).Info("", "curr time", currentTime.Format(timeWindowFormat), "start time", startTimeWindow, "end time", endTimeWindow)
+	if currentTime.After(startTime) && currentTime.Before(endTime) {
+		return true
+	}
+
+	return false
+}
+
+// timeUntilNextWindowAction returns the time until the next start or end of the time window.
+func timeUntilNextWindowAction(startTimeWindow, endTimeWindow string) (time.Duration, error) {
+	currentTime, startTime, endTime, err := parseTimeWindow(startTimeWindow, endTimeWindow)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case currentTime.Before(startTime):
+		return startTime.Sub(currentTime), nil
+	case currentTime.Before(endTime):
+		return endTime.Sub(currentTime), nil
+	default:
+		// the window has ended for today, it starts again tomorrow.
+		return startTime.Add(24 * time.Hour).Sub(currentTime), nil
+	}
+}
+
+const timeWindowFormat = "15:04:05"
+
+// parseTimeWindow parses the time window along with the current IST time, all of them are
+// parsed on the same day so that they can be compared with each other.
+func parseTimeWindow(startTimeWindow, endTimeWindow string) (currentTime, startTime, endTime time.Time, err error) {
 	// load current IST time
 	loc, err := time.LoadLocation("Asia/Kolkata")
 	if err != nil {
-		logger.Error(err, "failed to load timezone location")
-		return false
+		return currentTime, startTime, endTime, fmt.Errorf("failed to load timezone location: %w", err)
 	}
 	now := time.Now().In(loc)
 
 	// parse timestamp from current IST time
-	timeFormat := "15:04:05"
-	currTimeFormat := fmt.Sprintf("%02d:%02d:%02d", now.Hour(), now.Minute(), now.Second())
-	currentTime, err := time.Parse(timeFormat, currTimeFormat)
+	currentTime, err = time.Parse(timeWindowFormat, fmt.Sprintf("%02d:%02d:%02d", now.Hour(), now.Minute(), now.Second()))
 	if err != nil {
-		logger.Error(err, "failed to parse current time")
-		return false
+		return currentTime, startTime, endTime, fmt.Errorf("failed to parse current time: %w", err)
 	}
-	startTime, err := time.Parse(timeFormat, startTimeWindow)
+	startTime, err = time.Parse(timeWindowFormat, startTimeWindow)
 	if err != nil {
-		logger.Error(err, "invalid start time")
-		return false
+		return currentTime, startTime, endTime, fmt.Errorf("invalid start time: %w", err)
 	}
-	endTime, err := time.Parse(timeFormat, endTimeWindow)
+	endTime, err = time.Parse(timeWindowFormat, endTimeWindow)
 	if err != nil {
-		logger.Error(err, "invalid end time")
-		return false
-	}
-
-	logger.V(1).Info("", "curr time", currTimeFormat, "start time", startTimeWindow, "end time", endTimeWindow)
-	if currentTime.After(startTime) && currentTime.Before(endTime) {
-		return true
+		return currentTime, startTime, endTime, fmt.Errorf("invalid end time: %w", err)
 	}
-
-	return false
+	return currentTime, startTime, endTime, nil
 }
@@ -0,0 +1,329 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/instancetypes"
+	"github.com/KubeInBox/aws-utility-controller/pkg/pricing"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	defaultReportLookbackPeriod    = 14 * 24 * time.Hour
+	defaultTargetCPUUtilization    = 60
+	defaultTargetMemoryUtilization = 80
+	defaultReportInterval          = 24 * time.Hour
+)
+
+// networkHeadroom is the fraction of the baseline network bandwidth of a recommended type the
+// peak network traffic of an instance may use.
+const networkHeadroom = 0.8
+
+// Ec2RightsizingReportReconciler reconciles a Ec2RightsizingReport object
+type Ec2RightsizingReportReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Prices is the catalog of on-demand prices used to estimate the savings, savings are
+	// not estimated if nil.
+	Prices pricing.Catalog
+	// InstanceTypes is the catalog of instance type specs, defaults to the bundled catalog.
+	InstanceTypes *instancetypes.Catalog
+	logger        logr.Logger
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Ec2RightsizingReportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.InstanceTypes == nil {
+		r.InstanceTypes = instancetypes.Default()
+	}
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.Ec2RightsizingReport{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=ec2rightsizingreports,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=ec2rightsizingreports/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=ec2rightsizingreports/finalizers,verbs=update
+
+// Reconcile gathers the usage of the selected instances and recommends smaller instance types
+// fitting their usage, the report is refreshed at the report interval.
+func (r *Ec2RightsizingReportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling Ec2RightsizingReport ...")
+
+	report := &costoptimizerv1alpha1.Ec2RightsizingReport{}
+	if err := r.Get(ctx, req.NamespacedName, report); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	policy := newRightsizingPolicy(&report.Spec)
+	if last := report.Status.LastReportTime; last != nil && report.Status.ObservedGeneration == report.Generation {
+		if wait := time.Until(last.Add(policy.reportInterval)); wait > 0 {
+			r.logger.V(1).Info("report is up to date", "next report after", wait.String())
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	selector := report.Spec.Selector
+	if len(selector.InstanceIDs) == 0 && len(selector.Tags) == 0 {
+		r.reportFailed(ctx, report, fmt.Errorf("selector has to specify instance ids or tags"))
+		return ctrl.Result{}, nil
+	}
+
+	instances, err := utils.DescribeEc2InstancesByTags(r.logger, report.Spec.Region, selector.InstanceIDs, selector.Tags)
+	if err != nil {
+		return r.reportError(ctx, report, policy, err)
+	}
+
+	region := utils.ResolveRegion(report.Spec.Region)
+	end := time.Now()
+	results := make([]costoptimizerv1alpha1.InstanceRecommendations, 0, len(instances))
+	var total float64
+	for _, instance := range instances {
+		usage, err := r.gatherUsage(region, instance.InstanceID, end.Add(-policy.lookbackPeriod), end, policy.period())
+		if err != nil {
+			return r.reportError(ctx, report, policy, err)
+		}
+		result, savings := r.recommend(instance, region, usage, policy)
+		results = append(results, result)
+		total += savings
+	}
+
+	now := metav1.Now()
+	r.patchStatus(ctx, report, func(status *costoptimizerv1alpha1.Ec2RightsizingReportStatus) {
+		status.State = complete
+		status.Message = ""
+		status.ObservedGeneration = report.Generation
+		status.LastReportTime = &now
+		status.EstimatedMonthlySavings = formatUSD(total)
+		status.Instances = results
+	})
+	r.Recorder.Eventf(report, corev1.EventTypeNormal, eventReasonReportGenerated,
+		"Report generated for %d instance(s), estimated monthly savings %s USD", len(results), formatUSD(total))
+	return ctrl.Result{RequeueAfter: policy.reportInterval}, nil
+}
+
+// reportError marks the report as failed, errors which cannot be resolved by retrying are
+// retried at the next report interval.
+func (r *Ec2RightsizingReportReconciler) reportError(ctx context.Context, report *costoptimizerv1alpha1.Ec2RightsizingReport,
+	policy rightsizingPolicy, err error) (ctrl.Result, error) {
+	r.logger.Error(err, "unable to generate rightsizing report")
+	r.reportFailed(ctx, report, err)
+	if _, action := utils.ClassifyError(err); action == utils.ActionFail {
+		return ctrl.Result{RequeueAfter: policy.reportInterval}, nil
+	}
+	return ctrl.Result{}, err
+}
+
+func (r *Ec2RightsizingReportReconciler) reportFailed(ctx context.Context, report *costoptimizerv1alpha1.Ec2RightsizingReport, err error) {
+	r.Recorder.Eventf(report, corev1.EventTypeWarning, eventReasonReportFailed, "Report failed: %v", err)
+	r.patchStatus(ctx, report, func(status *costoptimizerv1alpha1.Ec2RightsizingReportStatus) {
+		status.State = failed
+		status.Message = err.Error()
+		status.ObservedGeneration = report.Generation
+	})
+}
+
+// instanceUsage is the peak usage of an instance over the lookback period.
+type instanceUsage struct {
+	datapoints int
+	maxCPU     float64
+	// maxMemory is only known if the instance publishes memory metrics.
+	maxMemory                float64
+	memoryKnown              bool
+	maxNetworkBytesPerSecond float64
+}
+
+// gatherUsage fetches the peak cpu, memory and network usage of the instance.
+func (r *Ec2RightsizingReportReconciler) gatherUsage(region, instanceID string, start, end time.Time, period time.Duration) (instanceUsage, error) {
+	var usage instanceUsage
+	cpu, err := utils.GetEc2MetricStatistics(r.logger, region, instanceID, "CPUUtilization", utils.StatisticMaximum, start, end, period)
+	if err != nil {
+		return usage, err
+	}
+	usage.datapoints = len(cpu)
+	for _, datapoint := range cpu {
+		usage.maxCPU = math.Max(usage.maxCPU, datapoint.Value)
+	}
+
+	// the cloudwatch agent publishes the memory with the dimensions configured by append_dimensions,
+	// which have to be discovered to query the statistics.
+	dimensionSets, err := utils.ListInstanceMetricDimensions(r.logger, region, utils.NamespaceCWAgent, instanceID, "mem_used_percent")
+	if err != nil {
+		return usage, err
+	}
+	for _, dimensions := range dimensionSets {
+		memory, err := utils.GetMetricStatistics(r.logger, region, utils.NamespaceCWAgent, "mem_used_percent",
+			utils.StatisticMaximum, dimensions, start, end, period)
+		if err != nil {
+			return usage, err
+		}
+		usage.memoryKnown = usage.memoryKnown || len(memory) > 0
+		for _, datapoint := range memory {
+			usage.maxMemory = math.Max(usage.maxMemory, datapoint.Value)
+		}
+	}
+
+	network := map[time.Time]float64{}
+	for _, metricName := range []string{"NetworkIn", "NetworkOut"} {
+		datapoints, err := utils.GetEc2MetricStatistics(r.logger, region, instanceID, metricName, utils.StatisticSum, start, end, period)
+		if err != nil {
+			return usage, err
+		}
+		for _, datapoint := range datapoints {
+			network[datapoint.Timestamp] += datapoint.Value
+		}
+	}
+	for _, bytes := range network {
+		usage.maxNetworkBytesPerSecond = math.Max(usage.maxNetworkBytesPerSecond, bytes/period.Seconds())
+	}
+	return usage, nil
+}
+
+// recommend returns the recommendations for the instance along with the estimated monthly
+// savings of the first recommendation.
+func (r *Ec2RightsizingReportReconciler) recommend(instance utils.Ec2Instance, region string, usage instanceUsage,
+	policy rightsizingPolicy) (costoptimizerv1alpha1.InstanceRecommendations, float64) {
+	result := costoptimizerv1alpha1.InstanceRecommendations{
+		InstanceID:               instance.InstanceID,
+		InstanceType:             instance.InstanceType,
+		MaxCPUUtilization:        strconv.FormatFloat(usage.maxCPU, 'f', 2, 64),
+		MaxNetworkBytesPerSecond: int64(usage.maxNetworkBytesPerSecond),
+		Datapoints:               int32(usage.datapoints),
+	}
+	if usage.memoryKnown {
+		result.MaxMemoryUtilization = strconv.FormatFloat(usage.maxMemory, 'f', 2, 64)
+	}
+
+	current, ok := r.InstanceTypes.Lookup(instance.InstanceType)
+	if !ok {
+		result.Message = fmt.Sprintf("instance type %s is not in the catalog", instance.InstanceType)
+		return result, 0
+	}
+	if float64(usage.datapoints) < policy.expectedDatapoints()*minDatapointsRatio {
+		result.Message = fmt.Sprintf("not enough datapoints for %s, the instance has to run for most of the lookback period",
+			policy.lookbackPeriod)
+		return result, 0
+	}
+
+	key := pricing.Key{Region: region, Tenancy: pricing.Tenancy(instance.Tenancy), OperatingSystem: pricing.OperatingSystem(instance.Platform)}
+	currentPrice, currentPriceKnown := r.hourlyPrice(key, current.Name)
+	var firstSavings float64
+	for _, candidate := range r.InstanceTypes.Smaller(current.Name) {
+		if !policy.fits(current, candidate, usage) {
+			continue
+		}
+		recommendation := costoptimizerv1alpha1.Recommendation{InstanceType: candidate.Name}
+		if price, ok := r.hourlyPrice(key, candidate.Name); ok && currentPriceKnown {
+			savings := (currentPrice - price) * pricing.HoursPerMonth
+			recommendation.EstimatedMonthlySavings = formatUSD(savings)
+			if len(result.Recommendations) == 0 {
+				firstSavings = savings
+			}
+		}
+		result.Recommendations = append(result.Recommendations, recommendation)
+	}
+	switch {
+	case len(result.Recommendations) == 0:
+		result.Message = "no smaller instance type fits the usage"
+	case !usage.memoryKnown:
+		result.Message = "memory usage is unknown, recommendations only consider cpu and network usage"
+	}
+	return result, firstSavings
+}
+
+func (r *Ec2RightsizingReportReconciler) hourlyPrice(key pricing.Key, instanceType string) (float64, bool) {
+	if r.Prices == nil {
+		return 0, false
+	}
+	key.InstanceType = instanceType
+	return r.Prices.Price(key)
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *Ec2RightsizingReportReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.Ec2RightsizingReport,
+	mutate func(status *costoptimizerv1alpha1.Ec2RightsizingReportStatus)) {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+	}
+}
+
+// rightsizingPolicy is the effective rightsizing policy of a report, with defaults applied.
+type rightsizingPolicy struct {
+	lookbackPeriod          time.Duration
+	targetCPUUtilization    float64
+	targetMemoryUtilization float64
+	reportInterval          time.Duration
+}
+
+func newRightsizingPolicy(spec *costoptimizerv1alpha1.Ec2RightsizingReportSpec) rightsizingPolicy {
+	policy := rightsizingPolicy{
+		lookbackPeriod:          defaultReportLookbackPeriod,
+		targetCPUUtilization:    defaultTargetCPUUtilization,
+		targetMemoryUtilization: defaultTargetMemoryUtilization,
+		reportInterval:          defaultReportInterval,
+	}
+	if spec.LookbackPeriod != nil && spec.LookbackPeriod.Duration > 0 {
+		policy.lookbackPeriod = spec.LookbackPeriod.Duration
+	}
+	if spec.TargetCPUUtilization > 0 {
+		policy.targetCPUUtilization = float64(spec.TargetCPUUtilization)
+	}
+	if spec.TargetMemoryUtilization > 0 {
+		policy.targetMemoryUtilization = float64(spec.TargetMemoryUtilization)
+	}
+	if spec.ReportInterval != nil && spec.ReportInterval.Duration > 0 {
+		policy.reportInterval = spec.ReportInterval.Duration
+	}
+	return policy
+}
+
+func (p rightsizingPolicy) period() time.Duration {
+	return metricPeriod(p.lookbackPeriod)
+}
+
+func (p rightsizingPolicy) expectedDatapoints() float64 {
+	return float64(p.lookbackPeriod) / float64(p.period())
+}
+
+// fits returns true if the peak usage of an instance of the current type, scaled to the
+// candidate type, stays below the targets.
+func (p rightsizingPolicy) fits(current, candidate instancetypes.InstanceType, usage instanceUsage) bool {
+	if usage.maxCPU*float64(current.VCPUs)/float64(candidate.VCPUs) > p.targetCPUUtilization {
+		return false
+	}
+	if usage.memoryKnown && usage.maxMemory*float64(current.MemoryMiB)/float64(candidate.MemoryMiB) > p.targetMemoryUtilization {
+		return false
+	}
+	return usage.maxNetworkBytesPerSecond*8 <= candidate.NetworkGbps*1e9*networkHeadroom
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/instancetypes"
+	"github.com/KubeInBox/aws-utility-controller/pkg/pricing"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRightsizingRecommend(t *testing.T) {
+	prices, err := pricing.NewTable([]pricing.TableEntry{
+		{InstanceType: "m5.2xlarge", Region: "us-east-1", OperatingSystem: "Linux", HourlyPrice: "0.384"},
+		{InstanceType: "m5.xlarge", Region: "us-east-1", OperatingSystem: "Linux", HourlyPrice: "0.192"},
+		{InstanceType: "m5.large", Region: "us-east-1", OperatingSystem: "Linux", HourlyPrice: "0.096"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Ec2RightsizingReportReconciler{Prices: prices, InstanceTypes: instancetypes.Default(), logger: logr.Discard()}
+	policy := newRightsizingPolicy(&costoptimizerv1alpha1.Ec2RightsizingReportSpec{
+		LookbackPeriod: &metav1.Duration{Duration: 48 * time.Hour},
+	})
+	instance := utils.Ec2Instance{InstanceID: "i-1", InstanceType: "m5.2xlarge", State: "running", Platform: "Linux/UNIX", Tenancy: "default"}
+
+	tests := []struct {
+		name    string
+		usage   instanceUsage
+		types   []string
+		savings float64
+	}{
+		{name: "low usage", usage: instanceUsage{datapoints: 48, maxCPU: 12, maxMemory: 15, memoryKnown: true},
+			types: []string{"m5.large", "m5.xlarge"}, savings: 210.24},
+		{name: "memory bound", usage: instanceUsage{datapoints: 48, maxCPU: 12, maxMemory: 35, memoryKnown: true},
+			types: []string{"m5.xlarge"}, savings: 140.16},
+		{name: "cpu bound", usage: instanceUsage{datapoints: 48, maxCPU: 20, memoryKnown: false},
+			types: []string{"m5.xlarge"}, savings: 140.16},
+		{name: "busy", usage: instanceUsage{datapoints: 48, maxCPU: 50}},
+		{name: "not enough datapoints", usage: instanceUsage{datapoints: 10, maxCPU: 1}},
+	}
+	for _, test := range tests {
+		result, savings := r.recommend(instance, "us-east-1", test.usage, policy)
+		var types []string
+		for _, recommendation := range result.Recommendations {
+			types = append(types, recommendation.InstanceType)
+		}
+		if len(types) != len(test.types) {
+			t.Errorf("%s: expected recommendations %v, got %v", test.name, test.types, types)
+			continue
+		}
+		for i := range types {
+			if types[i] != test.types[i] {
+				t.Errorf("%s: expected recommendations %v, got %v", test.name, test.types, types)
+			}
+		}
+		if formatUSD(savings) != formatUSD(test.savings) {
+			t.Errorf("%s: expected savings %.2f, got %.2f", test.name, test.savings, savings)
+		}
+		if len(types) == 0 && result.Message == "" {
+			t.Errorf("%s: expected a message explaining the missing recommendations", test.name)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// ecsOperations are the ecs operations performed on the services.
+type ecsOperations interface {
+	describeServices(logger logr.Logger, region, cluster string, tags map[string]string) ([]utils.EcsService, error)
+	updateDesiredCount(logger logr.Logger, region, cluster, service string, desiredCount int32) error
+}
+
+// awsEcsOperations performs the ecs operations through the aws cli.
+type awsEcsOperations struct{}
+
+func (awsEcsOperations) describeServices(logger logr.Logger, region, cluster string, tags map[string]string) ([]utils.EcsService, error) {
+	return utils.DescribeEcsServices(logger, region, cluster, tags)
+}
+
+func (awsEcsOperations) updateDesiredCount(logger logr.Logger, region, cluster, service string, desiredCount int32) error {
+	return utils.UpdateEcsServiceDesiredCount(logger, region, cluster, service, desiredCount)
+}
+
+// EcsServiceOptimizerReconciler reconciles a EcsServiceOptimizer object
+type EcsServiceOptimizerReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	logger   logr.Logger
+	// operations performed on the services, the aws cli is used if not set.
+	operations ecsOperations
+}
+
+// ecs returns the operations performed on the services.
+func (r *EcsServiceOptimizerReconciler) ecs() ecsOperations {
+	if r.operations == nil {
+		return awsEcsOperations{}
+	}
+	return r.operations
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EcsServiceOptimizerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.EcsServiceOptimizer{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=ecsserviceoptimizers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=ecsserviceoptimizers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=ecsserviceoptimizers/finalizers,verbs=update
+
+// Reconcile sets the desired count of the matched services to zero within the time window,
+// after saving it in the status, and restores the saved desired count once the window ends or the
+// object is deleted.
+func (r *EcsServiceOptimizerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling EcsServiceOptimizer ...")
+
+	ecsServiceOptimizer := &costoptimizerv1alpha1.EcsServiceOptimizer{}
+	if err := r.Get(ctx, req.NamespacedName, ecsServiceOptimizer); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	deleted, err := handleRestoreFinalizer(ctx, r.Client, ecsServiceOptimizer, func() error {
+		return r.handleServices(ctx, ecsServiceOptimizer, false)
+	})
+	if err != nil {
+		r.logger.Error(err, "error handling the finalizer")
+		return ctrl.Result{}, err
+	}
+	if deleted {
+		return ctrl.Result{}, nil
+	}
+
+	inWindow := isInTimeWindow(r.logger, ecsServiceOptimizer.Spec.StartTimeWindow, ecsServiceOptimizer.Spec.EndTimeWindow)
+	err = r.handleServices(ctx, ecsServiceOptimizer, inWindow)
+	if err != nil {
+		r.logger.Error(err, "error processing ecs services")
+		if _, action := utils.ClassifyError(err); action == utils.ActionFail {
+			// retrying will not help, check again in the next schedule run.
+			err = nil
+		}
+	}
+	return ctrl.Result{RequeueAfter: wait.Jitter(1*time.Minute, 0.5)}, err
+}
+
+// matchedEcsService is a service matched by the spec.
+type matchedEcsService struct {
+	cluster string
+	utils.EcsService
+}
+
+// handleServices scales down or restores the services, the desired count of a service is saved
+// in the status before the service is scaled down so that it is never lost.
+func (r *EcsServiceOptimizerReconciler) handleServices(ctx context.Context, ecsServiceOptimizer *costoptimizerv1alpha1.EcsServiceOptimizer, inWindow bool) error {
+	var matched []matchedEcsService
+	for _, cluster := range ecsServiceOptimizer.Spec.Clusters {
+		services, err := r.ecs().describeServices(r.logger, ecsServiceOptimizer.Spec.Region, cluster, ecsServiceOptimizer.Spec.Tags)
+		if err != nil {
+			return err
+		}
+		for _, service := range services {
+			matched = append(matched, matchedEcsService{cluster: cluster, EcsService: service})
+		}
+	}
+	described := map[string]utils.EcsService{}
+	for _, service := range matched {
+		described[service.cluster+"/"+service.ServiceName] = service.EcsService
+	}
+
+	state := outOfTimeWindow
+	if inWindow {
+		state = inTimeWindow
+	}
+	statuses := ecsServiceStatuses(ecsServiceOptimizer.Status.Services, matched, inWindow)
+	var firstErr error
+	err := saveThenMutate(ctx, r.Client, ecsServiceOptimizer, func() {
+		ecsServiceOptimizer.Status.State = fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Scheduled, state)
+		// copy, the statuses are updated below.
+		ecsServiceOptimizer.Status.Services = append([]costoptimizerv1alpha1.EcsServiceStatus(nil), statuses...)
+	}, func() error {
+		for i := range statuses {
+			var err error
+			if service, found := described[statuses[i].Cluster+"/"+statuses[i].Name]; found && inWindow {
+				err = r.scaleDownService(ecsServiceOptimizer, service, &statuses[i])
+			} else if !inWindow {
+				err = r.restoreService(ecsServiceOptimizer, &statuses[i])
+			}
+			if err != nil {
+				if _, action := utils.ClassifyError(err); action != utils.ActionSkip && firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+		return r.patchStatus(ctx, ecsServiceOptimizer, func(status *costoptimizerv1alpha1.EcsServiceOptimizerStatus) {
+			status.Services = statuses
+		})
+	})
+	if firstErr != nil {
+		return firstErr
+	}
+	return err
+}
+
+// ecsServiceStatuses returns the status of the matched services, saving their desired count within
+// the time window. Services which are not matched anymore are kept as long as they have a saved
+// desired count, so that they are still restored.
+func ecsServiceStatuses(previous []costoptimizerv1alpha1.EcsServiceStatus, matched []matchedEcsService, inWindow bool) []costoptimizerv1alpha1.EcsServiceStatus {
+	previousByName := map[string]costoptimizerv1alpha1.EcsServiceStatus{}
+	for _, service := range previous {
+		previousByName[service.Cluster+"/"+service.Name] = service
+	}
+
+	statuses := make([]costoptimizerv1alpha1.EcsServiceStatus, 0, len(matched))
+	for _, service := range matched {
+		key := service.cluster + "/" + service.ServiceName
+		status, ok := previousByName[key]
+		if !ok {
+			status = costoptimizerv1alpha1.EcsServiceStatus{Cluster: service.cluster, Name: service.ServiceName}
+		}
+		delete(previousByName, key)
+		if inWindow && status.SavedDesiredCount == nil {
+			desiredCount := service.DesiredCount
+			status.SavedDesiredCount = &desiredCount
+		}
+		statuses = append(statuses, status)
+	}
+	for _, service := range previous {
+		if _, ok := previousByName[service.Cluster+"/"+service.Name]; ok && service.SavedDesiredCount != nil {
+			service.Message = "service not matched anymore"
+			statuses = append(statuses, service)
+		}
+	}
+	return statuses
+}
+
+func (r *EcsServiceOptimizerReconciler) scaleDownService(ecsServiceOptimizer *costoptimizerv1alpha1.EcsServiceOptimizer, service utils.EcsService,
+	status *costoptimizerv1alpha1.EcsServiceStatus) error {
+	if service.DesiredCount != 0 {
+		if err := r.ecs().updateDesiredCount(r.logger, ecsServiceOptimizer.Spec.Region, status.Cluster, status.Name, 0); err != nil {
+			return r.serviceOperationFailed(ecsServiceOptimizer, status, "scale down", err)
+		}
+	}
+	status.Message = ""
+	if status.State != scaledDown {
+		now := metav1.Now()
+		status.State, status.LastTransitionTime = scaledDown, &now
+		r.Recorder.Eventf(ecsServiceOptimizer, corev1.EventTypeNormal, eventReasonScaledDown,
+			"Scaled down ecs service %s/%s from %d tasks", status.Cluster, status.Name, service.DesiredCount)
+	}
+	return nil
+}
+
+func (r *EcsServiceOptimizerReconciler) restoreService(ecsServiceOptimizer *costoptimizerv1alpha1.EcsServiceOptimizer,
+	status *costoptimizerv1alpha1.EcsServiceStatus) error {
+	saved := status.SavedDesiredCount
+	if saved == nil {
+		return nil
+	}
+	if err := r.ecs().updateDesiredCount(r.logger, ecsServiceOptimizer.Spec.Region, status.Cluster, status.Name, *saved); err != nil {
+		err = r.serviceOperationFailed(ecsServiceOptimizer, status, "restore", err)
+		if isNotFound(err) {
+			// deleted meanwhile, nothing to restore.
+			status.SavedDesiredCount = nil
+		}
+		return err
+	}
+
+	r.Recorder.Eventf(ecsServiceOptimizer, corev1.EventTypeNormal, eventReasonRestored,
+		"Restored ecs service %s/%s to %d tasks", status.Cluster, status.Name, *saved)
+	now := metav1.Now()
+	status.State, status.LastTransitionTime = restored, &now
+	status.SavedDesiredCount, status.Message = nil, ""
+	return nil
+}
+
+func (r *EcsServiceOptimizerReconciler) serviceOperationFailed(ecsServiceOptimizer *costoptimizerv1alpha1.EcsServiceOptimizer,
+	status *costoptimizerv1alpha1.EcsServiceStatus, operation string, err error) error {
+	reason, action := utils.ClassifyError(err)
+	status.Message = err.Error()
+	r.Recorder.Eventf(ecsServiceOptimizer, corev1.EventTypeWarning, eventReasonOperationFailed,
+		"Failed to %s ecs service %s/%s with reason %s (action: %s): %v", operation, status.Cluster, status.Name, reason, action, err)
+	return err
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *EcsServiceOptimizerReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.EcsServiceOptimizer,
+	mutate func(status *costoptimizerv1alpha1.EcsServiceOptimizerStatus)) error {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return err
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// fakeEcsOperations performs the ecs operations on the services of the dev cluster, the
+// operations performed are recorded in order.
+type fakeEcsOperations struct {
+	services   []utils.EcsService
+	operations []string
+}
+
+func (f *fakeEcsOperations) describeServices(logr.Logger, string, string, map[string]string) ([]utils.EcsService, error) {
+	return f.services, nil
+}
+
+func (f *fakeEcsOperations) updateDesiredCount(_ logr.Logger, _, cluster, service string, desiredCount int32) error {
+	f.operations = append(f.operations, fmt.Sprintf("update %s/%s %d", cluster, service, desiredCount))
+	for i := range f.services {
+		if f.services[i].ServiceName == service {
+			f.services[i].DesiredCount = desiredCount
+		}
+	}
+	return nil
+}
+
+func TestEcsServiceStatuses(t *testing.T) {
+	three, five := int32(3), int32(5)
+	matched := []matchedEcsService{
+		{cluster: "dev", EcsService: utils.EcsService{ServiceName: "api", DesiredCount: 2}},
+		{cluster: "dev", EcsService: utils.EcsService{ServiceName: "worker", DesiredCount: 0}},
+	}
+	previous := []costoptimizerv1alpha1.EcsServiceStatus{
+		{Cluster: "dev", Name: "worker", State: scaledDown, SavedDesiredCount: &three},
+		{Cluster: "dev", Name: "untagged", State: scaledDown, SavedDesiredCount: &five},
+		{Cluster: "dev", Name: "gone", State: restored},
+	}
+	two, zero := int32(2), int32(0)
+	tests := []struct {
+		name     string
+		previous []costoptimizerv1alpha1.EcsServiceStatus
+		inWindow bool
+		expected []costoptimizerv1alpha1.EcsServiceStatus
+	}{
+		{
+			name:     "window entered",
+			inWindow: true,
+			expected: []costoptimizerv1alpha1.EcsServiceStatus{
+				{Cluster: "dev", Name: "api", SavedDesiredCount: &two},
+				{Cluster: "dev", Name: "worker", SavedDesiredCount: &zero},
+			},
+		},
+		{
+			name:     "saved counts are kept",
+			previous: previous,
+			inWindow: true,
+			expected: []costoptimizerv1alpha1.EcsServiceStatus{
+				{Cluster: "dev", Name: "api", SavedDesiredCount: &two},
+				{Cluster: "dev", Name: "worker", State: scaledDown, SavedDesiredCount: &three},
+				{Cluster: "dev", Name: "untagged", State: scaledDown, SavedDesiredCount: &five, Message: "service not matched anymore"},
+			},
+		},
+		{
+			name:     "out of window",
+			previous: previous,
+			expected: []costoptimizerv1alpha1.EcsServiceStatus{
+				{Cluster: "dev", Name: "api"},
+				{Cluster: "dev", Name: "worker", State: scaledDown, SavedDesiredCount: &three},
+				{Cluster: "dev", Name: "untagged", State: scaledDown, SavedDesiredCount: &five, Message: "service not matched anymore"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if statuses := ecsServiceStatuses(test.previous, matched, test.inWindow); !reflect.DeepEqual(statuses, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, statuses)
+			}
+		})
+	}
+}
+
+func TestHandleServicesSavesDesiredCountFirst(t *testing.T) {
+	ecs := &fakeEcsOperations{services: []utils.EcsService{{ServiceName: "api", DesiredCount: 2}}}
+	obj := &costoptimizerv1alpha1.EcsServiceOptimizer{Spec: costoptimizerv1alpha1.EcsServiceOptimizerSpec{Clusters: []string{"dev"}}}
+
+	conflict := errors.New("conflict")
+	c := &fakeClient{statusPatchErr: conflict}
+	r := &EcsServiceOptimizerReconciler{Client: c, Recorder: record.NewFakeRecorder(10), logger: logr.Discard(), operations: ecs}
+	if err := r.handleServices(context.Background(), obj, true); !errors.Is(err, conflict) {
+		t.Errorf("expected the patch error, got %v", err)
+	}
+	if len(ecs.operations) > 0 {
+		t.Errorf("expected the service to be left alone until its desired count is saved, got %v", ecs.operations)
+	}
+
+	c.statusPatchErr = nil
+	if err := r.handleServices(context.Background(), obj, true); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if expected := []string{"update dev/api 0"}; !reflect.DeepEqual(ecs.operations, expected) {
+		t.Errorf("expected operations %v, got %v", expected, ecs.operations)
+	}
+	if saved := obj.Status.Services[0].SavedDesiredCount; saved == nil || *saved != 2 {
+		t.Errorf("expected the desired count to be saved, got %v", saved)
+	}
+}
+
+func TestEcsRestoreFinalizer(t *testing.T) {
+	ecs := &fakeEcsOperations{services: []utils.EcsService{{ServiceName: "api", DesiredCount: 2}}}
+	obj := &costoptimizerv1alpha1.EcsServiceOptimizer{
+		ObjectMeta: metav1.ObjectMeta{Name: "ecs"},
+		Spec: costoptimizerv1alpha1.EcsServiceOptimizerSpec{Clusters: []string{"dev"},
+			StartTimeWindow: "00:00:00", EndTimeWindow: "23:59:59"},
+	}
+	c := &fakeClient{object: obj}
+	r := &EcsServiceOptimizerReconciler{Client: c, Recorder: record.NewFakeRecorder(10), operations: ecs}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "ecs"}}
+
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !controllerutil.ContainsFinalizer(c.object, restoreFinalizer) {
+		t.Fatalf("expected the restore finalizer to be added, got %v", c.object.GetFinalizers())
+	}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the object is deleted within the window, the saved desired count is restored.
+	now := metav1.Now()
+	c.object.SetDeletionTimestamp(&now)
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"update dev/api 0", "update dev/api 2"}; !reflect.DeepEqual(ecs.operations, expected) {
+		t.Errorf("expected operations %v, got %v", expected, ecs.operations)
+	}
+	if finalizers := c.object.GetFinalizers(); len(finalizers) > 0 {
+		t.Errorf("expected the finalizer to be removed, got %v", finalizers)
+	}
+}
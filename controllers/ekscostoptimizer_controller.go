@@ -0,0 +1,357 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// draining is the state of a node group whose nodes are being drained before it is scaled down.
+const draining = "Draining"
+
+// defaultDrainTimeout is the time after which a node group is scaled down even though its nodes
+// could not be drained.
+const defaultDrainTimeout = 10 * time.Minute
+
+// eksOperations are the eks operations performed on the node groups, the nodes of a node group
+// are drained and uncordoned through the client of its cluster.
+type eksOperations interface {
+	describeNodeGroup(logger logr.Logger, region, clusterName, name string) (utils.EksNodeGroup, error)
+	updateScaling(logger logr.Logger, region, clusterName, name string, minSize, maxSize, desiredSize int32) error
+	drainNodeGroup(ctx context.Context, clientset kubernetes.Interface, nodeGroup, owner string) (int, error)
+	uncordonNodeGroup(ctx context.Context, clientset kubernetes.Interface, nodeGroup, owner string) error
+}
+
+// awsEksOperations performs the eks operations through the aws cli.
+type awsEksOperations struct{}
+
+func (awsEksOperations) describeNodeGroup(logger logr.Logger, region, clusterName, name string) (utils.EksNodeGroup, error) {
+	return utils.DescribeEksNodeGroup(logger, region, clusterName, name)
+}
+
+func (awsEksOperations) updateScaling(logger logr.Logger, region, clusterName, name string, minSize, maxSize, desiredSize int32) error {
+	return utils.UpdateEksNodeGroupScaling(logger, region, clusterName, name, minSize, maxSize, desiredSize)
+}
+
+func (awsEksOperations) drainNodeGroup(ctx context.Context, clientset kubernetes.Interface, nodeGroup, owner string) (int, error) {
+	return drainNodeGroup(ctx, clientset, nodeGroup, owner)
+}
+
+func (awsEksOperations) uncordonNodeGroup(ctx context.Context, clientset kubernetes.Interface, nodeGroup, owner string) error {
+	return uncordonNodeGroup(ctx, clientset, nodeGroup, owner)
+}
+
+// EksCostOptimizerReconciler reconciles a EksCostOptimizer object
+type EksCostOptimizerReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// APIReader reads the kubeconfig Secrets without caching them.
+	APIReader client.Reader
+	logger    logr.Logger
+	// operations performed on the node groups, the aws cli is used if not set.
+	operations eksOperations
+}
+
+// eks returns the operations performed on the node groups.
+func (r *EksCostOptimizerReconciler) eks() eksOperations {
+	if r.operations == nil {
+		return awsEksOperations{}
+	}
+	return r.operations
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EksCostOptimizerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.EksCostOptimizer{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=ekscostoptimizers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=ekscostoptimizers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=ekscostoptimizers/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get
+
+// Reconcile scales the managed node groups to the configured scaling config within the time
+// window, after saving their scaling config in the status, and restores the saved scaling
+// config once the window ends or the object is deleted.
+func (r *EksCostOptimizerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling EksCostOptimizer ...")
+
+	eksCostOptimizer := &costoptimizerv1alpha1.EksCostOptimizer{}
+	if err := r.Get(ctx, req.NamespacedName, eksCostOptimizer); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	deleted, err := handleRestoreFinalizer(ctx, r.Client, eksCostOptimizer, func() error {
+		return r.handleNodeGroups(ctx, eksCostOptimizer, false)
+	})
+	if err != nil {
+		r.logger.Error(err, "error handling the finalizer")
+		return ctrl.Result{}, err
+	}
+	if deleted {
+		return ctrl.Result{}, nil
+	}
+
+	inWindow := isInTimeWindow(r.logger, eksCostOptimizer.Spec.StartTimeWindow, eksCostOptimizer.Spec.EndTimeWindow)
+	err = r.handleNodeGroups(ctx, eksCostOptimizer, inWindow)
+	if err != nil {
+		r.logger.Error(err, "error processing node groups")
+		if _, action := utils.ClassifyError(err); action == utils.ActionFail {
+			// retrying will not help, check again in the next schedule run.
+			err = nil
+		}
+	}
+	return ctrl.Result{RequeueAfter: wait.Jitter(1*time.Minute, 0.5)}, err
+}
+
+// handleNodeGroups scales down or restores the node groups, the scaling config of a node group
+// is saved in the status before the node group is drained or scaled down so that it is never lost.
+func (r *EksCostOptimizerReconciler) handleNodeGroups(ctx context.Context, eksCostOptimizer *costoptimizerv1alpha1.EksCostOptimizer, inWindow bool) error {
+	described := map[string]utils.EksNodeGroup{}
+	for _, name := range eksCostOptimizer.Spec.NodeGroupNames {
+		nodeGroup, err := r.eks().describeNodeGroup(r.logger, eksCostOptimizer.Spec.Region, eksCostOptimizer.Spec.ClusterName, name)
+		if err != nil {
+			if _, action := utils.ClassifyError(err); action == utils.ActionSkip {
+				continue
+			}
+			return err
+		}
+		described[name] = nodeGroup
+	}
+	previous := map[string]costoptimizerv1alpha1.EksNodeGroupStatus{}
+	for _, nodeGroup := range eksCostOptimizer.Status.NodeGroups {
+		previous[nodeGroup.Name] = nodeGroup
+	}
+
+	state := outOfTimeWindow
+	if inWindow {
+		state = inTimeWindow
+	}
+	statuses := make([]costoptimizerv1alpha1.EksNodeGroupStatus, 0, len(eksCostOptimizer.Spec.NodeGroupNames))
+	for _, name := range eksCostOptimizer.Spec.NodeGroupNames {
+		status, ok := previous[name]
+		if !ok {
+			status = costoptimizerv1alpha1.EksNodeGroupStatus{Name: name}
+		}
+		if nodeGroup, found := described[name]; found && inWindow && status.SavedScalingConfig == nil {
+			status.SavedScalingConfig = &costoptimizerv1alpha1.EksScalingConfig{
+				MinSize: nodeGroup.MinSize, MaxSize: nodeGroup.MaxSize, DesiredSize: nodeGroup.DesiredSize,
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	// the client of the eks cluster is only created once nodes have to be drained or uncordoned.
+	var clientset kubernetes.Interface
+	clusterClient := func() (kubernetes.Interface, error) {
+		if clientset != nil {
+			return clientset, nil
+		}
+		var err error
+		clientset, err = newClusterClient(ctx, r.APIReader, eksCostOptimizer.Namespace, eksCostOptimizer.Spec.Drain.KubeconfigSecretRef)
+		return clientset, err
+	}
+	var firstErr error
+	err := saveThenMutate(ctx, r.Client, eksCostOptimizer, func() {
+		eksCostOptimizer.Status.State = fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Scheduled, state)
+		// copy, the statuses are updated below.
+		eksCostOptimizer.Status.NodeGroups = append([]costoptimizerv1alpha1.EksNodeGroupStatus(nil), statuses...)
+	}, func() error {
+		for i := range statuses {
+			nodeGroup, found := described[statuses[i].Name]
+			if !found {
+				statuses[i].Message = "node group not found"
+				continue
+			}
+			var err error
+			if inWindow {
+				err = r.scaleDownNodeGroup(ctx, eksCostOptimizer, clusterClient, nodeGroup, &statuses[i])
+			} else {
+				err = r.restoreNodeGroup(ctx, eksCostOptimizer, clusterClient, &statuses[i])
+			}
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return r.patchStatus(ctx, eksCostOptimizer, func(status *costoptimizerv1alpha1.EksCostOptimizerStatus) {
+			status.NodeGroups = statuses
+		})
+	})
+	if firstErr != nil {
+		return firstErr
+	}
+	return err
+}
+
+// scaleDownNodeGroup drains the nodes of the node group, if configured, and scales it down. Nodes
+// are only drained when the node group is scaled down to zero nodes, as eks chooses the nodes
+// which are removed otherwise.
+func (r *EksCostOptimizerReconciler) scaleDownNodeGroup(ctx context.Context, eksCostOptimizer *costoptimizerv1alpha1.EksCostOptimizer,
+	clusterClient func() (kubernetes.Interface, error), nodeGroup utils.EksNodeGroup, status *costoptimizerv1alpha1.EksNodeGroupStatus) error {
+	target := scalingConfig(eksCostOptimizer)
+	if nodeGroup.MinSize == target.MinSize && nodeGroup.MaxSize == target.MaxSize && nodeGroup.DesiredSize == target.DesiredSize {
+		r.nodeGroupScaledDown(eksCostOptimizer, status, target)
+		return nil
+	}
+
+	if eksCostOptimizer.Spec.Drain != nil && target.DesiredSize == 0 && status.State != scaledDown {
+		clientset, err := clusterClient()
+		if err != nil {
+			status.Message = err.Error()
+			return err
+		}
+		drained, err := r.drainNodeGroup(ctx, eksCostOptimizer, clientset, status)
+		if err != nil || !drained {
+			return err
+		}
+	}
+
+	err := r.eks().updateScaling(r.logger, eksCostOptimizer.Spec.Region, eksCostOptimizer.Spec.ClusterName,
+		nodeGroup.Name, target.MinSize, target.MaxSize, target.DesiredSize)
+	if err != nil {
+		return r.nodeGroupOperationFailed(eksCostOptimizer, status, "scale down", err)
+	}
+	r.nodeGroupScaledDown(eksCostOptimizer, status, target)
+	return nil
+}
+
+// drainNodeGroup drains the nodes of the node group and returns true once they are drained or
+// the drain timed out.
+func (r *EksCostOptimizerReconciler) drainNodeGroup(ctx context.Context, eksCostOptimizer *costoptimizerv1alpha1.EksCostOptimizer,
+	clientset kubernetes.Interface, status *costoptimizerv1alpha1.EksNodeGroupStatus) (bool, error) {
+	if status.DrainStartTime == nil {
+		now := metav1.Now()
+		status.State, status.DrainStartTime, status.LastTransitionTime = draining, &now, &now
+		r.Recorder.Eventf(eksCostOptimizer, corev1.EventTypeNormal, eventReasonDrainStarted,
+			"Draining the nodes of node group %s", status.Name)
+	}
+	remaining, err := r.eks().drainNodeGroup(ctx, clientset, status.Name, eksCostOptimizer.Namespace+"/"+eksCostOptimizer.Name)
+	if err != nil {
+		status.Message = err.Error()
+		return false, err
+	}
+	if remaining == 0 {
+		return true, nil
+	}
+
+	timeout := defaultDrainTimeout
+	if eksCostOptimizer.Spec.Drain.Timeout != nil {
+		timeout = eksCostOptimizer.Spec.Drain.Timeout.Duration
+	}
+	if time.Since(status.DrainStartTime.Time) < timeout {
+		status.Message = fmt.Sprintf("waiting for %d pods to be evicted", remaining)
+		return false, nil
+	}
+	r.Recorder.Eventf(eksCostOptimizer, corev1.EventTypeWarning, eventReasonDrainTimedOut,
+		"Draining node group %s timed out after %s with %d pods left, scaling it down anyway", status.Name, timeout, remaining)
+	return true, nil
+}
+
+func (r *EksCostOptimizerReconciler) nodeGroupScaledDown(eksCostOptimizer *costoptimizerv1alpha1.EksCostOptimizer,
+	status *costoptimizerv1alpha1.EksNodeGroupStatus, target costoptimizerv1alpha1.EksScalingConfig) {
+	status.Message = ""
+	if status.State != scaledDown {
+		now := metav1.Now()
+		status.State, status.LastTransitionTime = scaledDown, &now
+		r.Recorder.Eventf(eksCostOptimizer, corev1.EventTypeNormal, eventReasonScaledDown,
+			"Scaled down node group %s to %d/%d/%d (min/max/desired)", status.Name,
+			target.MinSize, target.MaxSize, target.DesiredSize)
+	}
+}
+
+// restoreNodeGroup restores the saved scaling config of the node group and uncordons the nodes
+// which were cordoned by the controller but not removed, e.g. because scaling down failed.
+func (r *EksCostOptimizerReconciler) restoreNodeGroup(ctx context.Context, eksCostOptimizer *costoptimizerv1alpha1.EksCostOptimizer,
+	clusterClient func() (kubernetes.Interface, error), status *costoptimizerv1alpha1.EksNodeGroupStatus) error {
+	saved := status.SavedScalingConfig
+	if saved == nil {
+		return nil
+	}
+	err := r.eks().updateScaling(r.logger, eksCostOptimizer.Spec.Region, eksCostOptimizer.Spec.ClusterName,
+		status.Name, saved.MinSize, saved.MaxSize, saved.DesiredSize)
+	if err != nil {
+		return r.nodeGroupOperationFailed(eksCostOptimizer, status, "restore", err)
+	}
+	if eksCostOptimizer.Spec.Drain != nil && status.DrainStartTime != nil {
+		clientset, err := clusterClient()
+		if err == nil {
+			err = r.eks().uncordonNodeGroup(ctx, clientset, status.Name, eksCostOptimizer.Namespace+"/"+eksCostOptimizer.Name)
+		}
+		if err != nil {
+			// the scaling config is restored again along with the next attempt.
+			status.Message = err.Error()
+			return err
+		}
+	}
+
+	r.Recorder.Eventf(eksCostOptimizer, corev1.EventTypeNormal, eventReasonRestored,
+		"Restored node group %s to %d/%d/%d (min/max/desired)", status.Name,
+		saved.MinSize, saved.MaxSize, saved.DesiredSize)
+	now := metav1.Now()
+	status.State, status.LastTransitionTime = restored, &now
+	status.SavedScalingConfig, status.DrainStartTime, status.Message = nil, nil, ""
+	return nil
+}
+
+func (r *EksCostOptimizerReconciler) nodeGroupOperationFailed(eksCostOptimizer *costoptimizerv1alpha1.EksCostOptimizer,
+	status *costoptimizerv1alpha1.EksNodeGroupStatus, operation string, err error) error {
+	reason, action := utils.ClassifyError(err)
+	status.Message = err.Error()
+	r.Recorder.Eventf(eksCostOptimizer, corev1.EventTypeWarning, eventReasonOperationFailed,
+		"Failed to %s node group %s with reason %s (action: %s): %v", operation, status.Name, reason, action, err)
+	return err
+}
+
+// scalingConfig returns the scaling config of the node groups within the time window, the max
+// size is raised to the min and desired size, and to one which is the lowest max size of eks.
+func scalingConfig(eksCostOptimizer *costoptimizerv1alpha1.EksCostOptimizer) costoptimizerv1alpha1.EksScalingConfig {
+	config := eksCostOptimizer.Spec.ScalingConfig
+	for _, size := range []int32{1, config.MinSize, config.DesiredSize} {
+		if config.MaxSize < size {
+			config.MaxSize = size
+		}
+	}
+	return config
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *EksCostOptimizerReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.EksCostOptimizer,
+	mutate func(status *costoptimizerv1alpha1.EksCostOptimizerStatus)) error {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return err
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+		return err
+	}
+	return nil
+}
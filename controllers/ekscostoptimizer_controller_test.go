@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: eks
+  cluster:
+    server: https://eks.example.com
+contexts:
+- name: eks
+  context:
+    cluster: eks
+    user: eks
+current-context: eks
+users:
+- name: eks
+  user:
+    token: token
+`
+
+// kubeconfigReader serves the kubeconfig Secret of the eks cluster.
+type kubeconfigReader struct {
+	client.Reader
+}
+
+func (kubeconfigReader) Get(_ context.Context, _ client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	obj.(*corev1.Secret).Data = map[string][]byte{defaultKubeconfigKey: []byte(testKubeconfig)}
+	return nil
+}
+
+// fakeEksOperations performs the eks operations on the node groups, the drains leave remaining
+// pods on the nodes. The operations performed are recorded in order.
+type fakeEksOperations struct {
+	nodeGroups map[string]utils.EksNodeGroup
+	remaining  int
+	err        error
+	operations []string
+}
+
+func (f *fakeEksOperations) describeNodeGroup(_ logr.Logger, _, _, name string) (utils.EksNodeGroup, error) {
+	nodeGroup, ok := f.nodeGroups[name]
+	if !ok {
+		return utils.EksNodeGroup{}, &utils.AWSError{Code: "ResourceNotFoundException", Operation: "DescribeNodegroup"}
+	}
+	return nodeGroup, nil
+}
+
+func (f *fakeEksOperations) updateScaling(_ logr.Logger, _, _, name string, minSize, maxSize, desiredSize int32) error {
+	f.operations = append(f.operations, fmt.Sprintf("update %s %d/%d/%d", name, minSize, maxSize, desiredSize))
+	if f.err != nil {
+		return f.err
+	}
+	f.nodeGroups[name] = utils.EksNodeGroup{Name: name, MinSize: minSize, MaxSize: maxSize, DesiredSize: desiredSize}
+	return nil
+}
+
+func (f *fakeEksOperations) drainNodeGroup(_ context.Context, _ kubernetes.Interface, nodeGroup, owner string) (int, error) {
+	f.operations = append(f.operations, fmt.Sprintf("drain %s %s", nodeGroup, owner))
+	return f.remaining, nil
+}
+
+func (f *fakeEksOperations) uncordonNodeGroup(_ context.Context, _ kubernetes.Interface, nodeGroup, owner string) error {
+	f.operations = append(f.operations, fmt.Sprintf("uncordon %s %s", nodeGroup, owner))
+	return nil
+}
+
+// newEksTest returns an object scaling down the workers node group to zero nodes and the
+// reconciler processing it.
+func newEksTest(drain bool) (*costoptimizerv1alpha1.EksCostOptimizer, *EksCostOptimizerReconciler, *fakeEksOperations) {
+	obj := &costoptimizerv1alpha1.EksCostOptimizer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "eks"},
+		Spec: costoptimizerv1alpha1.EksCostOptimizerSpec{ClusterName: "cluster", NodeGroupNames: []string{"workers"},
+			StartTimeWindow: "00:00:00", EndTimeWindow: "23:59:59"},
+	}
+	if drain {
+		obj.Spec.Drain = &costoptimizerv1alpha1.DrainPolicy{
+			KubeconfigSecretRef: costoptimizerv1alpha1.SecretKeyReference{Name: "kubeconfig"}}
+	}
+	eks := &fakeEksOperations{nodeGroups: map[string]utils.EksNodeGroup{
+		"workers": {Name: "workers", MinSize: 2, MaxSize: 6, DesiredSize: 3},
+	}}
+	r := &EksCostOptimizerReconciler{Client: &fakeClient{object: obj}, APIReader: kubeconfigReader{},
+		Recorder: record.NewFakeRecorder(10), logger: logr.Discard(), operations: eks}
+	return obj, r, eks
+}
+
+func TestHandleNodeGroupsScaleDownAndRestore(t *testing.T) {
+	obj, r, eks := newEksTest(false)
+	saved := &costoptimizerv1alpha1.EksScalingConfig{MinSize: 2, MaxSize: 6, DesiredSize: 3}
+
+	if err := r.handleNodeGroups(context.Background(), obj, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	status := obj.Status.NodeGroups[0]
+	if status.State != scaledDown || !reflect.DeepEqual(status.SavedScalingConfig, saved) {
+		t.Errorf("expected the node group to be scaled down with its saved config, got %+v", status)
+	}
+
+	// scaled down already, the saved config is kept.
+	if err := r.handleNodeGroups(context.Background(), obj, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status := obj.Status.NodeGroups[0]; !reflect.DeepEqual(status.SavedScalingConfig, saved) {
+		t.Errorf("expected the saved config to be kept, got %+v", status.SavedScalingConfig)
+	}
+
+	if err := r.handleNodeGroups(context.Background(), obj, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	status = obj.Status.NodeGroups[0]
+	if status.State != restored || status.SavedScalingConfig != nil {
+		t.Errorf("expected the node group to be restored, got %+v", status)
+	}
+	if expected := []string{"update workers 0/1/0", "update workers 2/6/3"}; !reflect.DeepEqual(eks.operations, expected) {
+		t.Errorf("expected operations %v, got %v", expected, eks.operations)
+	}
+}
+
+func TestHandleNodeGroupsSavesScalingConfigFirst(t *testing.T) {
+	obj, r, eks := newEksTest(false)
+	conflict := errors.New("conflict")
+	r.Client.(*fakeClient).statusPatchErr = conflict
+
+	if err := r.handleNodeGroups(context.Background(), obj, true); !errors.Is(err, conflict) {
+		t.Errorf("expected the patch error, got %v", err)
+	}
+	if len(eks.operations) > 0 {
+		t.Errorf("expected the node group to be left alone until its scaling config is saved, got %v", eks.operations)
+	}
+}
+
+func TestHandleNodeGroupsDrain(t *testing.T) {
+	tests := []struct {
+		name       string
+		remaining  int
+		drainStart time.Duration
+		state      string
+		operations []string
+		events     []string
+	}{
+		{
+			name:       "waiting for pods to be evicted",
+			remaining:  2,
+			state:      draining,
+			operations: []string{"drain workers default/eks"},
+			events:     []string{eventReasonDrainStarted},
+		},
+		{
+			name:       "drained",
+			state:      scaledDown,
+			operations: []string{"drain workers default/eks", "update workers 0/1/0"},
+			events:     []string{eventReasonDrainStarted, eventReasonScaledDown},
+		},
+		{
+			name:       "drain timed out",
+			remaining:  2,
+			drainStart: defaultDrainTimeout + time.Minute,
+			state:      scaledDown,
+			operations: []string{"drain workers default/eks", "update workers 0/1/0"},
+			events:     []string{eventReasonDrainTimedOut, eventReasonScaledDown},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			obj, r, eks := newEksTest(true)
+			eks.remaining = test.remaining
+			if test.drainStart > 0 {
+				drainStart := metav1.NewTime(time.Now().Add(-test.drainStart))
+				obj.Status.NodeGroups = []costoptimizerv1alpha1.EksNodeGroupStatus{{Name: "workers", State: draining,
+					SavedScalingConfig: &costoptimizerv1alpha1.EksScalingConfig{MinSize: 2, MaxSize: 6, DesiredSize: 3},
+					DrainStartTime:     &drainStart}}
+			}
+
+			if err := r.handleNodeGroups(context.Background(), obj, true); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if state := obj.Status.NodeGroups[0].State; state != test.state {
+				t.Errorf("expected state %s, got %s", test.state, state)
+			}
+			if !reflect.DeepEqual(eks.operations, test.operations) {
+				t.Errorf("expected operations %v, got %v", test.operations, eks.operations)
+			}
+			if events := eventReasons(r.Recorder.(*record.FakeRecorder)); !reflect.DeepEqual(events, test.events) {
+				t.Errorf("expected events %v, got %v", test.events, events)
+			}
+		})
+	}
+}
+
+func TestHandleNodeGroupsRestoreUncordons(t *testing.T) {
+	obj, r, eks := newEksTest(true)
+	eks.nodeGroups["workers"] = utils.EksNodeGroup{Name: "workers", MaxSize: 1}
+	drainStart := metav1.Now()
+	obj.Status.NodeGroups = []costoptimizerv1alpha1.EksNodeGroupStatus{{Name: "workers", State: scaledDown,
+		SavedScalingConfig: &costoptimizerv1alpha1.EksScalingConfig{MinSize: 2, MaxSize: 6, DesiredSize: 3},
+		DrainStartTime:     &drainStart}}
+
+	if err := r.handleNodeGroups(context.Background(), obj, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"update workers 2/6/3", "uncordon workers default/eks"}; !reflect.DeepEqual(eks.operations, expected) {
+		t.Errorf("expected operations %v, got %v", expected, eks.operations)
+	}
+	if status := obj.Status.NodeGroups[0]; status.State != restored || status.DrainStartTime != nil {
+		t.Errorf("expected the node group to be restored, got %+v", status)
+	}
+}
+
+func TestEksRestoreFinalizer(t *testing.T) {
+	_, r, eks := newEksTest(false)
+	c := r.Client.(*fakeClient)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "eks"}}
+
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !controllerutil.ContainsFinalizer(c.object, restoreFinalizer) {
+		t.Fatalf("expected the restore finalizer to be added, got %v", c.object.GetFinalizers())
+	}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the object is deleted within the window, the saved scaling config is restored.
+	eks.operations = nil
+	now := metav1.Now()
+	c.object.SetDeletionTimestamp(&now)
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"update workers 2/6/3"}; !reflect.DeepEqual(eks.operations, expected) {
+		t.Errorf("expected operations %v, got %v", expected, eks.operations)
+	}
+	if finalizers := c.object.GetFinalizers(); len(finalizers) > 0 {
+		t.Errorf("expected the finalizer to be removed, got %v", finalizers)
+	}
+}
@@ -0,0 +1,308 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/pricing"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	defaultEipIdleDuration = 7 * 24 * time.Hour
+	defaultEipScanInterval = time.Hour
+	defaultKeepTag         = "kubeinbox.io/keep"
+)
+
+// firstSeenTag is set on the unassociated elastic ips with the time they were first found
+// unassociated, in RFC3339.
+const firstSeenTag = "kubeinbox.io/first-seen"
+
+// actions taken on the unassociated elastic ips.
+const (
+	eipActionFirstSeen    = "FirstSeen"
+	eipActionIdle         = "Idle"
+	eipActionReleased     = "Released"
+	eipActionKept         = "Kept"
+	eipActionWouldRelease = "WouldRelease"
+)
+
+// eipOperations are the ec2 operations performed on the elastic ips.
+type eipOperations interface {
+	describeAddresses(logger logr.Logger, region string, tags map[string]string) ([]utils.ElasticIP, error)
+	createTags(logger logr.Logger, region string, resourceIDs []string, tags map[string]string) error
+	deleteTags(logger logr.Logger, region string, resourceIDs []string, keys ...string) error
+	releaseAddress(logger logr.Logger, region, allocationID string) error
+}
+
+// awsEipOperations performs the ec2 operations through the aws cli.
+type awsEipOperations struct{}
+
+func (awsEipOperations) describeAddresses(logger logr.Logger, region string, tags map[string]string) ([]utils.ElasticIP, error) {
+	return utils.DescribeElasticIPs(logger, region, tags)
+}
+
+func (awsEipOperations) createTags(logger logr.Logger, region string, resourceIDs []string, tags map[string]string) error {
+	return utils.CreateEc2Tags(logger, region, resourceIDs, tags)
+}
+
+func (awsEipOperations) deleteTags(logger logr.Logger, region string, resourceIDs []string, keys ...string) error {
+	return utils.DeleteEc2Tags(logger, region, resourceIDs, keys...)
+}
+
+func (awsEipOperations) releaseAddress(logger logr.Logger, region, allocationID string) error {
+	return utils.ReleaseElasticIP(logger, region, allocationID)
+}
+
+// ElasticIpJanitorReconciler reconciles a ElasticIpJanitor object
+type ElasticIpJanitorReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Prices is the catalog of prices used to estimate the cost of the idle addresses, the cost
+	// is not estimated if nil.
+	Prices pricing.Catalog
+	logger logr.Logger
+	// operations performed on the elastic ips, the aws cli is used if not set.
+	operations eipOperations
+}
+
+// eips returns the operations performed on the elastic ips.
+func (r *ElasticIpJanitorReconciler) eips() eipOperations {
+	if r.operations == nil {
+		return awsEipOperations{}
+	}
+	return r.operations
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ElasticIpJanitorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.ElasticIpJanitor{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=elasticipjanitors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=elasticipjanitors/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=elasticipjanitors/finalizers,verbs=update
+
+// Reconcile scans the elastic ips of the regions at the scan interval. Unassociated elastic ips
+// are tagged with the time they were first seen unassociated and released once they stayed
+// unassociated for the idle duration, unless they carry the keep tag. Elastic ips associated
+// again lose their first seen tag, elastic ips which were not seen unassociated by the previous
+// scan are first seen again.
+func (r *ElasticIpJanitorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling ElasticIpJanitor ...")
+
+	janitor := &costoptimizerv1alpha1.ElasticIpJanitor{}
+	if err := r.Get(ctx, req.NamespacedName, janitor); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	idleDuration, scanInterval := defaultEipIdleDuration, defaultEipScanInterval
+	if janitor.Spec.IdleDuration != nil {
+		idleDuration = janitor.Spec.IdleDuration.Duration
+	}
+	if janitor.Spec.ScanInterval != nil && janitor.Spec.ScanInterval.Duration > 0 {
+		scanInterval = janitor.Spec.ScanInterval.Duration
+	}
+	if last := janitor.Status.LastScanTime; last != nil && janitor.Status.ObservedGeneration == janitor.Generation {
+		if wait := time.Until(last.Add(scanInterval)); wait > 0 {
+			r.logger.V(1).Info("scan is up to date", "next scan after", wait.String())
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	regions := janitor.Spec.Regions
+	if len(regions) == 0 {
+		regions = []string{""}
+	}
+	now := time.Now()
+	var entries []costoptimizerv1alpha1.UnassociatedAddress
+	var released int32
+	var idleCost float64
+	for _, region := range regions {
+		addresses, err := r.eips().describeAddresses(r.logger, region, janitor.Spec.Tags)
+		if err != nil {
+			return r.scanError(ctx, janitor, scanInterval, err)
+		}
+		var idle []string
+		for _, address := range addresses {
+			entry, listed, err := r.handleAddress(janitor, utils.ResolveRegion(region), address, idleDuration, scanInterval, now)
+			if err != nil {
+				if _, action := utils.ClassifyError(err); action != utils.ActionSkip {
+					return r.scanError(ctx, janitor, scanInterval, err)
+				}
+				entry.Message = err.Error()
+			}
+			if !listed {
+				continue
+			}
+			switch entry.Action {
+			case eipActionReleased:
+				released++
+			case eipActionKept:
+			case eipActionIdle:
+				idle = append(idle, address.AllocationID)
+				fallthrough
+			default:
+				if price, ok := pricing.ElasticIPMonthlyPrice(r.Prices, utils.ResolveRegion(region)); ok {
+					idleCost += price
+				}
+			}
+			entries = append(entries, entry)
+		}
+		// the idle elastic ips were seen unassociated by this scan.
+		if len(idle) > 0 && !janitor.Spec.DryRun {
+			err := r.eips().createTags(r.logger, region, idle, map[string]string{lastSeenUnusedTag: now.UTC().Format(time.RFC3339)})
+			if err != nil {
+				return r.scanError(ctx, janitor, scanInterval, err)
+			}
+		}
+	}
+
+	if released > 0 {
+		r.Recorder.Eventf(janitor, corev1.EventTypeNormal, eventReasonDeleted, "Released %d unassociated elastic ip(s)", released)
+	}
+	scanTime := metav1.NewTime(now)
+	r.patchStatus(ctx, janitor, func(status *costoptimizerv1alpha1.ElasticIpJanitorStatus) {
+		status.State = complete
+		status.Message = ""
+		status.ObservedGeneration = janitor.Generation
+		status.LastScanTime = &scanTime
+		status.EstimatedMonthlyCost = formatUSD(idleCost)
+		status.ReleasedAddresses += released
+		status.Addresses = entries
+	})
+	return ctrl.Result{RequeueAfter: scanInterval}, nil
+}
+
+// handleAddress takes the next action on the elastic ip and returns its status entry, and
+// whether the elastic ip is listed in the status at all.
+func (r *ElasticIpJanitorReconciler) handleAddress(janitor *costoptimizerv1alpha1.ElasticIpJanitor, region string,
+	address utils.ElasticIP, idleDuration, scanInterval time.Duration, now time.Time) (costoptimizerv1alpha1.UnassociatedAddress, bool, error) {
+	entry := costoptimizerv1alpha1.UnassociatedAddress{AllocationID: address.AllocationID, PublicIP: address.PublicIP, Region: region}
+	_, seen := address.Tags.Get(firstSeenTag)
+	if address.AssociationID != "" {
+		if !seen {
+			return entry, false, nil
+		}
+		// associated again, the idle duration starts over once it gets unassociated.
+		entry.Action = eipActionKept
+		if janitor.Spec.DryRun {
+			return entry, true, nil
+		}
+		return entry, true, r.eips().deleteTags(r.logger, region, []string{address.AllocationID}, firstSeenTag, lastSeenUnusedTag)
+	}
+	keepTag := janitor.Spec.KeepTag
+	if keepTag == "" {
+		keepTag = defaultKeepTag
+	}
+	if _, keep := address.Tags.Get(keepTag); keep {
+		entry.Action = eipActionKept
+		return entry, true, nil
+	}
+
+	action, firstSeen := eipNextAction(address.Tags, idleDuration, now)
+	if action != eipActionFirstSeen && !seenUnusedRecently(address.Tags, scanInterval, now) {
+		// the elastic ip may have been associated since it was first seen, it starts over.
+		action, firstSeen = eipActionFirstSeen, now
+	}
+	entry.FirstSeenTime = &metav1.Time{Time: firstSeen}
+	entry.ReleaseTime = &metav1.Time{Time: firstSeen.Add(idleDuration)}
+	entry.Action = action
+	switch {
+	case janitor.Spec.DryRun:
+		if action == eipActionReleased {
+			entry.Action = eipActionWouldRelease
+		}
+	case action == eipActionFirstSeen:
+		err := r.eips().createTags(r.logger, region, []string{address.AllocationID}, map[string]string{
+			firstSeenTag:      firstSeen.UTC().Format(time.RFC3339),
+			lastSeenUnusedTag: now.UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			return entry, true, err
+		}
+		r.Recorder.Eventf(janitor, corev1.EventTypeNormal, eventReasonMarkedForDeletion,
+			"Elastic ip %s (%s) is released after %s unless it gets associated", address.PublicIP, address.AllocationID,
+			entry.ReleaseTime.Format(time.RFC3339))
+	case action == eipActionReleased:
+		if err := r.eips().releaseAddress(r.logger, region, address.AllocationID); err != nil {
+			reason, errAction := utils.ClassifyError(err)
+			r.Recorder.Eventf(janitor, corev1.EventTypeWarning, eventReasonOperationFailed,
+				"Releasing elastic ip %s failed with reason %s (action: %s): %v", address.PublicIP, reason, errAction, err)
+			entry.Action = eipActionIdle
+			return entry, true, err
+		}
+	}
+	return entry, true, nil
+}
+
+// eipNextAction returns the action to take on the unassociated elastic ip and the time it was
+// first seen unassociated, which is now if it was not seen before.
+func eipNextAction(tags utils.Tags, idleDuration time.Duration, now time.Time) (string, time.Time) {
+	value, ok := tags.Get(firstSeenTag)
+	if !ok {
+		return eipActionFirstSeen, now
+	}
+	firstSeen, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		// tagged by hand, the idle duration starts now.
+		return eipActionFirstSeen, now
+	}
+	if now.Sub(firstSeen) < idleDuration {
+		return eipActionIdle, firstSeen
+	}
+	return eipActionReleased, firstSeen
+}
+
+// scanError marks the scan as failed, errors which cannot be resolved by retrying are retried
+// at the next scan interval.
+func (r *ElasticIpJanitorReconciler) scanError(ctx context.Context, janitor *costoptimizerv1alpha1.ElasticIpJanitor,
+	scanInterval time.Duration, err error) (ctrl.Result, error) {
+	r.logger.Error(err, "unable to scan elastic ips")
+	r.patchStatus(ctx, janitor, func(status *costoptimizerv1alpha1.ElasticIpJanitorStatus) {
+		status.State = failed
+		status.Message = err.Error()
+		status.ObservedGeneration = janitor.Generation
+	})
+	if _, action := utils.ClassifyError(err); action == utils.ActionFail {
+		return ctrl.Result{RequeueAfter: scanInterval}, nil
+	}
+	return ctrl.Result{}, err
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *ElasticIpJanitorReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.ElasticIpJanitor,
+	mutate func(status *costoptimizerv1alpha1.ElasticIpJanitorStatus)) {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+	}
+}
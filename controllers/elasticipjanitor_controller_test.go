@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	"k8s.io/client-go/tools/record"
+)
+
+// fakeEipOperations performs the ec2 operations on the elastic ips, the release fails with
+// releaseErr. The operations performed are recorded in order.
+type fakeEipOperations struct {
+	releaseErr error
+	operations []string
+}
+
+func (f *fakeEipOperations) describeAddresses(logr.Logger, string, map[string]string) ([]utils.ElasticIP, error) {
+	return nil, nil
+}
+
+func (f *fakeEipOperations) createTags(_ logr.Logger, _ string, resourceIDs []string, tags map[string]string) error {
+	f.operations = append(f.operations, fmt.Sprintf("tag %s %s", strings.Join(resourceIDs, ","), formatTags(tags)))
+	return nil
+}
+
+func (f *fakeEipOperations) deleteTags(_ logr.Logger, _ string, resourceIDs []string, keys ...string) error {
+	f.operations = append(f.operations, fmt.Sprintf("untag %s %s", strings.Join(resourceIDs, ","), strings.Join(keys, ",")))
+	return nil
+}
+
+func (f *fakeEipOperations) releaseAddress(_ logr.Logger, _, allocationID string) error {
+	f.operations = append(f.operations, "release "+allocationID)
+	return f.releaseErr
+}
+
+func TestEipNextAction(t *testing.T) {
+	now := time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		tags      utils.Tags
+		action    string
+		firstSeen time.Time
+	}{
+		{name: "not seen before", action: eipActionFirstSeen, firstSeen: now},
+		{name: "invalid tag", tags: utils.Tags{{Key: firstSeenTag, Value: "yesterday"}}, action: eipActionFirstSeen, firstSeen: now},
+		{
+			name:      "idle",
+			tags:      utils.Tags{{Key: firstSeenTag, Value: "2023-03-09T12:00:00Z"}},
+			action:    eipActionIdle,
+			firstSeen: time.Date(2023, 3, 9, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "idle for too long",
+			tags:      utils.Tags{{Key: firstSeenTag, Value: "2023-03-08T12:00:00Z"}},
+			action:    eipActionReleased,
+			firstSeen: time.Date(2023, 3, 8, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			action, firstSeen := eipNextAction(test.tags, 48*time.Hour, now)
+			if action != test.action || !firstSeen.Equal(test.firstSeen) {
+				t.Errorf("expected %s first seen at %s, got %s at %s", test.action, test.firstSeen, action, firstSeen)
+			}
+		})
+	}
+}
+
+func TestHandleAddress(t *testing.T) {
+	now := time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)
+	nowTag := now.Format(time.RFC3339)
+	seen := utils.Tag{Key: lastSeenUnusedTag, Value: now.Add(-time.Hour).Format(time.RFC3339)}
+	idle := utils.Tag{Key: firstSeenTag, Value: now.Add(-24 * time.Hour).Format(time.RFC3339)}
+	due := utils.Tag{Key: firstSeenTag, Value: now.Add(-72 * time.Hour).Format(time.RFC3339)}
+	denied := &utils.AWSError{Code: "UnauthorizedOperation", Operation: "ReleaseAddress", Message: "denied"}
+	firstSeen := fmt.Sprintf("tag eipalloc-1 %s=%s,%s=%s", firstSeenTag, nowTag, lastSeenUnusedTag, nowTag)
+
+	tests := []struct {
+		name        string
+		association string
+		tags        utils.Tags
+		dryRun      bool
+		releaseErr  error
+		action      string
+		listed      bool
+		operations  []string
+		events      []string
+	}{
+		{
+			name:       "first seen",
+			action:     eipActionFirstSeen,
+			listed:     true,
+			operations: []string{firstSeen},
+			events:     []string{eventReasonMarkedForDeletion},
+		},
+		{
+			name:   "idle",
+			tags:   utils.Tags{idle, seen},
+			action: eipActionIdle,
+			listed: true,
+		},
+		{
+			name:       "not seen by the previous scan",
+			tags:       utils.Tags{due},
+			action:     eipActionFirstSeen,
+			listed:     true,
+			operations: []string{firstSeen},
+			events:     []string{eventReasonMarkedForDeletion},
+		},
+		{
+			name:        "associated",
+			association: "eipassoc-1",
+		},
+		{
+			name:        "associated again",
+			association: "eipassoc-1",
+			tags:        utils.Tags{idle, seen},
+			action:      eipActionKept,
+			listed:      true,
+			operations:  []string{fmt.Sprintf("untag eipalloc-1 %s,%s", firstSeenTag, lastSeenUnusedTag)},
+		},
+		{
+			name:   "keep tag",
+			tags:   utils.Tags{due, seen, {Key: defaultKeepTag, Value: "true"}},
+			action: eipActionKept,
+			listed: true,
+		},
+		{
+			name:       "release",
+			tags:       utils.Tags{due, seen},
+			action:     eipActionReleased,
+			listed:     true,
+			operations: []string{"release eipalloc-1"},
+		},
+		{
+			name:       "release failed",
+			tags:       utils.Tags{due, seen},
+			releaseErr: denied,
+			action:     eipActionIdle,
+			listed:     true,
+			operations: []string{"release eipalloc-1"},
+			events:     []string{eventReasonOperationFailed},
+		},
+		{
+			name:   "dry run",
+			tags:   utils.Tags{due, seen},
+			dryRun: true,
+			action: eipActionWouldRelease,
+			listed: true,
+		},
+		{
+			name:   "dry run first seen",
+			dryRun: true,
+			action: eipActionFirstSeen,
+			listed: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			eips := &fakeEipOperations{releaseErr: test.releaseErr}
+			recorder := record.NewFakeRecorder(10)
+			r := &ElasticIpJanitorReconciler{Recorder: recorder, logger: logr.Discard(), operations: eips}
+			janitor := &costoptimizerv1alpha1.ElasticIpJanitor{Spec: costoptimizerv1alpha1.ElasticIpJanitorSpec{DryRun: test.dryRun}}
+			address := utils.ElasticIP{AllocationID: "eipalloc-1", PublicIP: "203.0.113.1", AssociationID: test.association,
+				Tags: test.tags}
+
+			entry, listed, err := r.handleAddress(janitor, "eu-west-1", address, 48*time.Hour, time.Hour, now)
+			if !errors.Is(err, test.releaseErr) {
+				t.Errorf("expected error %v, got %v", test.releaseErr, err)
+			}
+			if entry.Action != test.action || listed != test.listed {
+				t.Errorf("expected action %q listed %t, got %q listed %t", test.action, test.listed, entry.Action, listed)
+			}
+			if !reflect.DeepEqual(eips.operations, test.operations) {
+				t.Errorf("expected operations %v, got %v", test.operations, eips.operations)
+			}
+			if events := eventReasons(recorder); !reflect.DeepEqual(events, test.events) {
+				t.Errorf("expected events %v, got %v", test.events, events)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// event reasons emitted by the controller, alerts may depend on them so they must not be changed.
+const (
+	eventReasonWindowEntered        = "WindowEntered"
+	eventReasonWindowExited         = "WindowExited"
+	eventReasonStartIssued          = "StartIssued"
+	eventReasonStopIssued           = "StopIssued"
+	eventReasonOperationFailed      = "OperationFailed"
+	eventReasonInstanceSkipped      = "InstanceSkipped"
+	eventReasonRetryBudgetExhausted = "RetryBudgetExhausted"
+	eventReasonConflict             = "Conflict"
+	eventReasonOverride             = "Override"
+	eventReasonIdleDetected         = "IdleDetected"
+	eventReasonReportGenerated      = "ReportGenerated"
+	eventReasonReportFailed         = "ReportFailed"
+	eventReasonResized              = "Resized"
+	eventReasonResizeRolledBack     = "ResizeRolledBack"
+	eventReasonResizeIncompatible   = "ResizeIncompatible"
+	eventReasonResizeFailed         = "ResizeFailed"
+	eventReasonDatabaseRestopped    = "DatabaseRestopped"
+	eventReasonScaledDown           = "ScaledDown"
+	eventReasonRestored             = "Restored"
+	eventReasonDrainStarted         = "DrainStarted"
+	eventReasonDrainTimedOut        = "DrainTimedOut"
+	eventReasonMarkedForDeletion    = "MarkedForDeletion"
+	eventReasonDeleted              = "Deleted"
+	eventReasonKept                 = "Kept"
+)
+
+// operationIssuedReasons maps the operations to the reason of the event emitted once they are issued.
+var operationIssuedReasons = map[costoptimizerv1alpha1.Ec2OperationType]string{
+	costoptimizerv1alpha1.Start: eventReasonStartIssued,
+	costoptimizerv1alpha1.Stop:  eventReasonStopIssued,
+}
+
+// recordWindowTransition emits an event if the object entered or exited its time window,
+// previousState is the state of the object before the current reconciliation. It returns true
+// if the object entered its window.
+func recordWindowTransition(recorder record.EventRecorder, obj runtime.Object, startTimeWindow, endTimeWindow, previousState string,
+	inWindow bool) bool {
+	wasInWindow := previousState == fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Scheduled, inTimeWindow)
+	switch {
+	case inWindow && !wasInWindow:
+		recorder.Eventf(obj, corev1.EventTypeNormal, eventReasonWindowEntered, "Entered time window %s-%s", startTimeWindow, endTimeWindow)
+		return true
+	case !inWindow && wasInWindow:
+		recorder.Eventf(obj, corev1.EventTypeNormal, eventReasonWindowExited, "Exited time window %s-%s", startTimeWindow, endTimeWindow)
+	}
+	return false
+}
+
+// recordConflicts emits events if other objects in the namespace currently perform a different
+// operation on the instances of the object. An onDemand operation overrides a scheduled one,
+// whereas two scheduled objects in their time windows conflict with each other.
+func (r *Ec2CostOptimizerReconciler) recordConflicts(ctx context.Context, ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer) {
+	others := &costoptimizerv1alpha1.Ec2CostOptimizerList{}
+	if err := r.List(ctx, others, client.InNamespace(ec2CostOptimizer.Namespace)); err != nil {
+		r.logger.Error(err, "unable to list objects for conflict detection")
+		return
+	}
+
+	instanceIDs := map[string]bool{}
+	for _, instanceID := range ec2CostOptimizer.Spec.InstanceIDs {
+		instanceIDs[instanceID] = true
+	}
+	for i := range others.Items {
+		other := &others.Items[i]
+		if other.Name == ec2CostOptimizer.Name || other.Spec.Operation == ec2CostOptimizer.Spec.Operation ||
+			other.Status.State != fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Scheduled, inTimeWindow) {
+			continue
+		}
+		var shared []string
+		for _, instanceID := range other.Spec.InstanceIDs {
+			if instanceIDs[instanceID] {
+				shared = append(shared, instanceID)
+			}
+		}
+		if len(shared) == 0 {
+			continue
+		}
+
+		if ec2CostOptimizer.Spec.WindowType == costoptimizerv1alpha1.OnDemand {
+			message := fmt.Sprintf("%s of %s overrides scheduled %s of %s for instances %s", ec2CostOptimizer.Spec.Operation,
+				ec2CostOptimizer.Name, other.Spec.Operation, other.Name, strings.Join(shared, ","))
+			r.Recorder.Event(ec2CostOptimizer, corev1.EventTypeNormal, eventReasonOverride, message)
+			r.Recorder.Event(other, corev1.EventTypeNormal, eventReasonOverride, message)
+			continue
+		}
+		message := fmt.Sprintf("scheduled %s of %s conflicts with scheduled %s of %s for instances %s", ec2CostOptimizer.Spec.Operation,
+			ec2CostOptimizer.Name, other.Spec.Operation, other.Name, strings.Join(shared, ","))
+		r.Recorder.Event(ec2CostOptimizer, corev1.EventTypeWarning, eventReasonConflict, message)
+	}
+}
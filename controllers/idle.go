@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultMaxCPUUtilization = 5
+	defaultLookbackPeriod    = 6 * time.Hour
+)
+
+// idleEvaluationPeriod is the period at which the usage of the instances of idle objects is evaluated.
+const idleEvaluationPeriod = 10 * time.Minute
+
+// minDatapointsRatio is the ratio of the expected datapoints which have to be present for an
+// instance to be considered idle, so that missing metrics are not mistaken for an idle instance.
+const minDatapointsRatio = 0.75
+
+// idlePolicy is the effective idle policy of an object, with defaults applied.
+type idlePolicy struct {
+	maxCPUUtilization      float64
+	maxNetworkBytesPerHour int64
+	lookbackPeriod         time.Duration
+}
+
+func newIdlePolicy(policy *costoptimizerv1alpha1.IdlePolicy) idlePolicy {
+	effective := idlePolicy{
+		maxCPUUtilization: defaultMaxCPUUtilization,
+		lookbackPeriod:    defaultLookbackPeriod,
+	}
+	if policy == nil {
+		return effective
+	}
+	if policy.MaxCPUUtilization > 0 {
+		effective.maxCPUUtilization = float64(policy.MaxCPUUtilization)
+	}
+	effective.maxNetworkBytesPerHour = policy.MaxNetworkBytesPerHour
+	if policy.LookbackPeriod != nil && policy.LookbackPeriod.Duration > 0 {
+		effective.lookbackPeriod = policy.LookbackPeriod.Duration
+	}
+	return effective
+}
+
+// period returns the period of the datapoints for the lookback period.
+func (p idlePolicy) period() time.Duration {
+	return metricPeriod(p.lookbackPeriod)
+}
+
+// metricPeriod returns the period of the datapoints fetched for a lookback period, cloudwatch
+// only keeps datapoints with a period below one hour for 15 days, hence hourly datapoints are
+// used for long lookback periods.
+func metricPeriod(lookbackPeriod time.Duration) time.Duration {
+	if lookbackPeriod > 24*time.Hour {
+		return time.Hour
+	}
+	return 5 * time.Minute
+}
+
+// evaluateUsage evaluates the usage of an instance from its cpu utilization and network
+// datapoints, the network datapoints are the sums of the bytes in and out per period.
+func (p idlePolicy) evaluateUsage(instanceID string, cpu, networkIn, networkOut []utils.Datapoint) costoptimizerv1alpha1.InstanceUsage {
+	usage := costoptimizerv1alpha1.InstanceUsage{
+		InstanceID:     instanceID,
+		Datapoints:     int32(len(cpu)),
+		EvaluationTime: metav1.Now(),
+	}
+
+	maxCPU := 0.0
+	for _, datapoint := range cpu {
+		maxCPU = math.Max(maxCPU, datapoint.Value)
+	}
+	usage.MaxCPUUtilization = strconv.FormatFloat(maxCPU, 'f', 2, 64)
+
+	network := map[time.Time]float64{}
+	for _, datapoints := range [][]utils.Datapoint{networkIn, networkOut} {
+		for _, datapoint := range datapoints {
+			network[datapoint.Timestamp] += datapoint.Value
+		}
+	}
+	perHour := time.Hour.Seconds() / p.period().Seconds()
+	for _, bytes := range network {
+		if bytesPerHour := int64(bytes * perHour); bytesPerHour > usage.MaxNetworkBytesPerHour {
+			usage.MaxNetworkBytesPerHour = bytesPerHour
+		}
+	}
+
+	expected := float64(p.lookbackPeriod) / float64(p.period())
+	usage.Idle = float64(len(cpu)) >= expected*minDatapointsRatio && maxCPU < p.maxCPUUtilization &&
+		(p.maxNetworkBytesPerHour == 0 || usage.MaxNetworkBytesPerHour < p.maxNetworkBytesPerHour)
+	return usage
+}
+
+// handleIdleEc2Oprn evaluates the usage of the running instances over the lookback period of
+// the idle policy and stops the instances found idle, the usage is recorded in the status.
+func (r *Ec2CostOptimizerReconciler) handleIdleEc2Oprn(ctx context.Context, ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer) error {
+	if ec2CostOptimizer.Spec.Operation != costoptimizerv1alpha1.Stop {
+		r.logger.Info("idle window only supports the stop operation")
+		r.UpdateStatus(ctx, ec2CostOptimizer, failed)
+		return nil
+	}
+
+	instances, err := utils.DescribeEc2Instances(r.logger, ec2CostOptimizer.Spec.Region, ec2CostOptimizer.Spec.InstanceIDs)
+	if err != nil {
+		return err
+	}
+
+	policy := newIdlePolicy(ec2CostOptimizer.Spec.IdlePolicy)
+	var usages []costoptimizerv1alpha1.InstanceUsage
+	var idleInstanceIDs []string
+	var metricsErr error
+	for _, instance := range instances {
+		if instance.State != "running" {
+			continue
+		}
+		usage, err := r.instanceUsage(ec2CostOptimizer, policy, instance.InstanceID)
+		if err != nil {
+			r.logger.Error(err, "unable to get instance metrics", "instance", instance.InstanceID)
+			metricsErr = err
+			continue
+		}
+		usages = append(usages, usage)
+		if usage.Idle {
+			idleInstanceIDs = append(idleInstanceIDs, instance.InstanceID)
+			r.Recorder.Eventf(ec2CostOptimizer, corev1.EventTypeNormal, eventReasonIdleDetected,
+				"Instance %s idle for %s: max cpu utilization %s%%, max network %d bytes/hour",
+				instance.InstanceID, policy.lookbackPeriod, usage.MaxCPUUtilization, usage.MaxNetworkBytesPerHour)
+		}
+	}
+	r.patchStatus(ctx, ec2CostOptimizer, func(status *costoptimizerv1alpha1.Ec2CostOptimizerStatus) {
+		status.InstanceUsage = usages
+	})
+
+	if len(idleInstanceIDs) > 0 {
+		r.UpdateStatus(ctx, ec2CostOptimizer, inProgress)
+		if _, err := r.operateInstances(ctx, ec2CostOptimizer, idleInstanceIDs); err != nil {
+			r.UpdateStatus(ctx, ec2CostOptimizer, failed)
+			return err
+		}
+	}
+	// the object is only completed while it keeps instances stopped, savings are accounted for
+	// completed objects only.
+	if len(idleInstanceIDs) > 0 || len(stoppedByObject(ec2CostOptimizer, instances)) > 0 {
+		r.UpdateStatus(ctx, ec2CostOptimizer, complete)
+	} else {
+		r.UpdateStatus(ctx, ec2CostOptimizer, evaluating)
+	}
+	return metricsErr
+}
+
+// stoppedByObject returns the ids of the instances which are stopped and were stopped by the
+// object, instances stopped by other means are left out.
+func stoppedByObject(ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer, instances []utils.Ec2Instance) []string {
+	if ec2CostOptimizer.Status.Savings == nil {
+		return nil
+	}
+	stoppedTimes := map[string]bool{}
+	for _, instance := range ec2CostOptimizer.Status.Savings.Instances {
+		stoppedTimes[instance.InstanceID] = instance.StoppedTime != nil
+	}
+	var stopped []string
+	for _, instance := range instances {
+		if (instance.State == "stopped" || instance.State == "stopping") && stoppedTimes[instance.InstanceID] {
+			stopped = append(stopped, instance.InstanceID)
+		}
+	}
+	return stopped
+}
+
+// instanceUsage fetches the metrics of the instance for the lookback period and evaluates its usage.
+func (r *Ec2CostOptimizerReconciler) instanceUsage(ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer, policy idlePolicy,
+	instanceID string) (costoptimizerv1alpha1.InstanceUsage, error) {
+	end := time.Now()
+	start := end.Add(-policy.lookbackPeriod)
+	region := ec2CostOptimizer.Spec.Region
+
+	cpu, err := utils.GetEc2MetricStatistics(r.logger, region, instanceID, "CPUUtilization", utils.StatisticAverage, start, end, policy.period())
+	if err != nil {
+		return costoptimizerv1alpha1.InstanceUsage{}, err
+	}
+	networkIn, err := utils.GetEc2MetricStatistics(r.logger, region, instanceID, "NetworkIn", utils.StatisticSum, start, end, policy.period())
+	if err != nil {
+		return costoptimizerv1alpha1.InstanceUsage{}, err
+	}
+	networkOut, err := utils.GetEc2MetricStatistics(r.logger, region, instanceID, "NetworkOut", utils.StatisticSum, start, end, policy.period())
+	if err != nil {
+		return costoptimizerv1alpha1.InstanceUsage{}, err
+	}
+	return policy.evaluateUsage(instanceID, cpu, networkIn, networkOut), nil
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func datapoints(period time.Duration, values ...float64) []utils.Datapoint {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	result := make([]utils.Datapoint, 0, len(values))
+	for i, value := range values {
+		result = append(result, utils.Datapoint{Timestamp: start.Add(time.Duration(i) * period), Value: value})
+	}
+	return result
+}
+
+func TestIdlePolicyEvaluateUsage(t *testing.T) {
+	policy := newIdlePolicy(&costoptimizerv1alpha1.IdlePolicy{
+		MaxCPUUtilization:      10,
+		MaxNetworkBytesPerHour: 1200,
+		LookbackPeriod:         &metav1.Duration{Duration: 20 * time.Minute},
+	})
+	period := policy.period()
+
+	tests := []struct {
+		name       string
+		cpu        []float64
+		networkIn  []float64
+		networkOut []float64
+		idle       bool
+	}{
+		{name: "idle", cpu: []float64{1, 2, 9, 3}, networkIn: []float64{10, 20, 30, 40}, networkOut: []float64{10, 20, 30, 40}, idle: true},
+		{name: "busy cpu", cpu: []float64{1, 2, 12, 3}, idle: false},
+		{name: "busy network", cpu: []float64{1, 2, 3, 3}, networkIn: []float64{10, 60, 30, 40}, networkOut: []float64{10, 50, 30, 40}, idle: false},
+		{name: "missing datapoints", cpu: []float64{1, 2}, idle: false},
+	}
+	for _, test := range tests {
+		usage := policy.evaluateUsage("i-1", datapoints(period, test.cpu...), datapoints(period, test.networkIn...),
+			datapoints(period, test.networkOut...))
+		if usage.Idle != test.idle {
+			t.Errorf("%s: expected idle %t, got %+v", test.name, test.idle, usage)
+		}
+	}
+
+	usage := policy.evaluateUsage("i-1", datapoints(period, 1, 9.5), datapoints(period, 10, 40), datapoints(period, 20, 40))
+	if usage.MaxCPUUtilization != "9.50" || usage.MaxNetworkBytesPerHour != 960 || usage.Datapoints != 2 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+}
+
+func TestIdlePolicyDefaults(t *testing.T) {
+	policy := newIdlePolicy(nil)
+	if policy.maxCPUUtilization != defaultMaxCPUUtilization || policy.lookbackPeriod != defaultLookbackPeriod ||
+		policy.maxNetworkBytesPerHour != 0 || policy.period() != 5*time.Minute {
+		t.Errorf("expected default idle policy, got %+v", policy)
+	}
+	policy = newIdlePolicy(&costoptimizerv1alpha1.IdlePolicy{LookbackPeriod: &metav1.Duration{Duration: 72 * time.Hour}})
+	if policy.period() != time.Hour {
+		t.Errorf("expected hourly datapoints for long lookback periods, got %s", policy.period())
+	}
+}
+
+func TestStoppedByObject(t *testing.T) {
+	obj := &costoptimizerv1alpha1.Ec2CostOptimizer{}
+	instances := []utils.Ec2Instance{
+		{InstanceID: "i-1", State: "stopped"},
+		{InstanceID: "i-2", State: "stopped"},
+		{InstanceID: "i-3", State: "running"},
+	}
+	if stopped := stoppedByObject(obj, instances); len(stopped) != 0 {
+		t.Errorf("expected no instances stopped by an object which did not stop any, got %v", stopped)
+	}
+
+	stoppedTime := metav1.Now()
+	obj.Status.Savings = &costoptimizerv1alpha1.Savings{Instances: []costoptimizerv1alpha1.InstanceSavings{
+		{InstanceID: "i-1", StoppedTime: &stoppedTime},
+		{InstanceID: "i-2"},
+		{InstanceID: "i-3", StoppedTime: &stoppedTime},
+	}}
+	if stopped := stoppedByObject(obj, instances); len(stopped) != 1 || stopped[0] != "i-1" {
+		t.Errorf("expected only i-1 to be stopped by the object, got %v", stopped)
+	}
+}
@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/metrics"
+	"github.com/KubeInBox/aws-utility-controller/pkg/pricing"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxAccountingGap bounds the time accounted between two observations of the instances, so
+// that a downtime of the controller is not accounted as savings.
+const maxAccountingGap = 15 * time.Minute
+
+// observeInstances observes the instances of the object, records the number of instances by
+// state and accounts the savings of the instances kept stopped.
+func (r *Ec2CostOptimizerReconciler) observeInstances(ctx context.Context, ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer) {
+	instances, err := utils.DescribeEc2Instances(r.logger, ec2CostOptimizer.Spec.Region, ec2CostOptimizer.Spec.InstanceIDs)
+	if err != nil {
+		r.logger.Error(err, "unable to describe instances")
+		return
+	}
+
+	counts := map[string]int{}
+	for _, instance := range instances {
+		counts[instance.State]++
+	}
+	metrics.SetManagedInstances(ec2CostOptimizer.Namespace, ec2CostOptimizer.Name, counts)
+	r.accountSavings(ctx, ec2CostOptimizer, instances)
+}
+
+// accountSavings adds the time elapsed since the last observation to the stopped time of the
+// instances the object stopped, if the object keeps them stopped, and estimates the savings from
+// their on-demand price.
+func (r *Ec2CostOptimizerReconciler) accountSavings(ctx context.Context, ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer,
+	instances []utils.Ec2Instance) {
+	savings, stoppedHours := r.estimateSavings(ec2CostOptimizer, instances, metav1.Now())
+	if err := r.patchStatus(ctx, ec2CostOptimizer, func(status *costoptimizerv1alpha1.Ec2CostOptimizerStatus) {
+		status.Savings = savings
+	}); err != nil {
+		// the hours are accounted again from the recorded savings on the next observation.
+		return
+	}
+	metrics.AddHoursSaved(ec2CostOptimizer.Namespace, ec2CostOptimizer.Name, stoppedHours)
+	metrics.SetEstimatedSavings(ec2CostOptimizer.Namespace, ec2CostOptimizer.Name, parseUSD(savings.EstimatedSavings))
+}
+
+// estimateSavings returns the savings of the object observed at now, along with the hours the
+// instances were kept stopped since the last observation.
+func (r *Ec2CostOptimizerReconciler) estimateSavings(ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer,
+	instances []utils.Ec2Instance, now metav1.Time) (*costoptimizerv1alpha1.Savings, float64) {
+	previous := map[string]costoptimizerv1alpha1.InstanceSavings{}
+	var elapsed time.Duration
+	if savings := ec2CostOptimizer.Status.Savings; savings != nil {
+		for _, instanceSavings := range savings.Instances {
+			previous[instanceSavings.InstanceID] = instanceSavings
+		}
+		elapsed = accountedTime(ec2CostOptimizer, savings.LastUpdateTime.Time, now.Time)
+	}
+
+	described := map[string]utils.Ec2Instance{}
+	for _, instance := range instances {
+		described[instance.InstanceID] = instance
+	}
+
+	region := utils.ResolveRegion(ec2CostOptimizer.Spec.Region)
+	savings := &costoptimizerv1alpha1.Savings{LastUpdateTime: now}
+	var total, stoppedHours float64
+	for _, instanceID := range ec2CostOptimizer.Spec.InstanceIDs {
+		instanceSavings, accounted := previous[instanceID]
+		instance, ok := described[instanceID]
+		if !ok {
+			// keep the savings of instances which do not exist anymore.
+			if accounted {
+				savings.Instances = append(savings.Instances, instanceSavings)
+				total += parseUSD(instanceSavings.EstimatedSavings)
+			}
+			continue
+		}
+
+		instanceSavings.InstanceID = instanceID
+		instanceSavings.InstanceType = instance.InstanceType
+		switch instance.State {
+		case "stopped":
+			// instances stopped by hand or before the object existed are not accounted.
+			if instanceSavings.StoppedTime != nil {
+				instanceSavings.StoppedSeconds += int64(elapsed.Seconds())
+				stoppedHours += elapsed.Hours()
+			}
+		case "stopping":
+		default:
+			// started again, it is accounted again once the object stops it.
+			instanceSavings.StoppedTime = nil
+		}
+		estimated := 0.0
+		instanceSavings.HourlyPrice = ""
+		if price, ok := r.hourlyPrice(instance, region); ok {
+			instanceSavings.HourlyPrice = strconv.FormatFloat(price, 'f', -1, 64)
+			estimated = float64(instanceSavings.StoppedSeconds) / time.Hour.Seconds() * price
+		}
+		instanceSavings.EstimatedSavings = formatUSD(estimated)
+		savings.Instances = append(savings.Instances, instanceSavings)
+		total += estimated
+	}
+	savings.EstimatedSavings = formatUSD(total)
+	return savings, stoppedHours
+}
+
+// markStopped records that the object stopped the instances, so that their stopped time is
+// accounted as savings.
+func markStopped(ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer, instanceIDs []string, now metav1.Time) *costoptimizerv1alpha1.Savings {
+	savings := &costoptimizerv1alpha1.Savings{EstimatedSavings: formatUSD(0), LastUpdateTime: now}
+	if ec2CostOptimizer.Status.Savings != nil {
+		savings = ec2CostOptimizer.Status.Savings.DeepCopy()
+	}
+	for _, instanceID := range instanceIDs {
+		i := 0
+		for i < len(savings.Instances) && savings.Instances[i].InstanceID != instanceID {
+			i++
+		}
+		if i == len(savings.Instances) {
+			savings.Instances = append(savings.Instances, costoptimizerv1alpha1.InstanceSavings{
+				InstanceID: instanceID, EstimatedSavings: formatUSD(0),
+			})
+		}
+		if savings.Instances[i].StoppedTime == nil {
+			stoppedTime := now
+			savings.Instances[i].StoppedTime = &stoppedTime
+		}
+	}
+	return savings
+}
+
+// accountedTime returns the time between the last observation and now during which the object
+// kept its instances stopped, bounded by maxAccountingGap. Scheduled objects only account the
+// time within their window, the time the schedule would have run the instances anyway is not
+// saved.
+func accountedTime(ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer, last, now time.Time) time.Duration {
+	if !keepsInstancesStopped(ec2CostOptimizer) || !now.After(last) {
+		return 0
+	}
+	if now.Sub(last) > maxAccountingGap {
+		last = now.Add(-maxAccountingGap)
+	}
+	if ec2CostOptimizer.Spec.WindowType != costoptimizerv1alpha1.Scheduled {
+		return now.Sub(last)
+	}
+	overlap, err := windowOverlap(ec2CostOptimizer.Spec.StartTimeWindow, ec2CostOptimizer.Spec.EndTimeWindow, last, now)
+	if err != nil {
+		return 0
+	}
+	return overlap
+}
+
+// windowOverlap returns the part of the time between from and to which lies within the daily
+// time window, the window is in IST like for isInTimeWindow.
+func windowOverlap(startTimeWindow, endTimeWindow string, from, to time.Time) (time.Duration, error) {
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		return 0, fmt.Errorf("failed to load timezone location: %w", err)
+	}
+	start, err := time.Parse(timeWindowFormat, startTimeWindow)
+	if err != nil {
+		return 0, fmt.Errorf("invalid start time: %w", err)
+	}
+	end, err := time.Parse(timeWindowFormat, endTimeWindow)
+	if err != nil {
+		return 0, fmt.Errorf("invalid end time: %w", err)
+	}
+	sinceMidnight := func(t time.Time) time.Duration {
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	}
+
+	var overlap time.Duration
+	// the accounted time is short, the windows of the days of from and to cover it.
+	for day := from.In(loc); !day.After(to.In(loc).AddDate(0, 0, 1)); day = day.AddDate(0, 0, 1) {
+		midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+		windowStart, windowEnd := midnight.Add(sinceMidnight(start)), midnight.Add(sinceMidnight(end))
+		if windowStart.Before(from) {
+			windowStart = from
+		}
+		if windowEnd.After(to) {
+			windowEnd = to
+		}
+		if windowEnd.After(windowStart) {
+			overlap += windowEnd.Sub(windowStart)
+		}
+	}
+	return overlap, nil
+}
+
+// hourlyPrice returns the on-demand price of the instance from the configured price catalog.
+func (r *Ec2CostOptimizerReconciler) hourlyPrice(instance utils.Ec2Instance, region string) (float64, bool) {
+	if r.Prices == nil {
+		return 0, false
+	}
+	key := pricing.Key{
+		InstanceType:    instance.InstanceType,
+		Region:          region,
+		Tenancy:         pricing.Tenancy(instance.Tenancy),
+		OperatingSystem: pricing.OperatingSystem(instance.Platform),
+	}
+	price, ok := r.Prices.Price(key)
+	if !ok {
+		r.logger.V(1).Info("no price found for instance", "instance", instance.InstanceID, "key", key)
+	}
+	return price, ok
+}
+
+// keepsInstancesStopped returns true if the object currently keeps its instances stopped, i.e.
+// a scheduled stop within its time window or an idle stop. Completed onDemand objects are not
+// observed anymore, their savings are not accounted.
+func keepsInstancesStopped(ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer) bool {
+	if ec2CostOptimizer.Spec.Operation != costoptimizerv1alpha1.Stop {
+		return false
+	}
+	switch ec2CostOptimizer.Status.State {
+	case fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Scheduled, inTimeWindow),
+		fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Idle, complete):
+		return true
+	}
+	return false
+}
+
+func formatUSD(dollars float64) string {
+	return strconv.FormatFloat(dollars, 'f', 2, 64)
+}
+
+func parseUSD(dollars string) float64 {
+	value, _ := strconv.ParseFloat(dollars, 64)
+	return value
+}
@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/pricing"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func scheduledStop(state string) *costoptimizerv1alpha1.Ec2CostOptimizer {
+	return &costoptimizerv1alpha1.Ec2CostOptimizer{
+		Spec: costoptimizerv1alpha1.Ec2CostOptimizerSpec{
+			InstanceIDs:     []string{"i-1", "i-2", "i-3"},
+			Operation:       costoptimizerv1alpha1.Stop,
+			WindowType:      costoptimizerv1alpha1.Scheduled,
+			StartTimeWindow: "20:00:00",
+			EndTimeWindow:   "23:59:59",
+			Region:          "us-east-1",
+		},
+		Status: costoptimizerv1alpha1.Ec2CostOptimizerStatus{
+			OperationStatus: costoptimizerv1alpha1.OperationStatus{State: fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Scheduled, state)},
+		},
+	}
+}
+
+func TestAccountedTime(t *testing.T) {
+	ist, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("timezone data not available: %v", err)
+	}
+	windowStart := time.Date(2024, 1, 1, 20, 0, 0, 0, ist)
+
+	tests := []struct {
+		name      string
+		obj       *costoptimizerv1alpha1.Ec2CostOptimizer
+		last, now time.Time
+		want      time.Duration
+	}{
+		{"within the window", scheduledStop(inTimeWindow), windowStart.Add(time.Minute), windowStart.Add(6 * time.Minute), 5 * time.Minute},
+		{"only the scheduled part", scheduledStop(inTimeWindow), windowStart.Add(-5 * time.Minute), windowStart.Add(5 * time.Minute), 5 * time.Minute},
+		{"bounded by the accounting gap", scheduledStop(inTimeWindow), windowStart.Add(time.Minute), windowStart.Add(time.Hour), maxAccountingGap},
+		{"out of the window", scheduledStop(outOfTimeWindow), windowStart.Add(time.Minute), windowStart.Add(6 * time.Minute), 0},
+		{"clock went backwards", scheduledStop(inTimeWindow), windowStart.Add(6 * time.Minute), windowStart.Add(time.Minute), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := accountedTime(tt.obj, tt.last, tt.now); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	start := scheduledStop(inTimeWindow)
+	start.Spec.Operation = costoptimizerv1alpha1.Start
+	if got := accountedTime(start, windowStart, windowStart.Add(time.Minute)); got != 0 {
+		t.Errorf("expected no savings for a start operation, got %s", got)
+	}
+	idle := scheduledStop(complete)
+	idle.Spec.WindowType, idle.Status.State = costoptimizerv1alpha1.Idle, fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Idle, complete)
+	if got := accountedTime(idle, windowStart.Add(-time.Hour), windowStart.Add(-50*time.Minute)); got != 10*time.Minute {
+		t.Errorf("expected idle stops to be accounted regardless of the window, got %s", got)
+	}
+}
+
+func TestWindowOverlapAcrossDays(t *testing.T) {
+	ist, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("timezone data not available: %v", err)
+	}
+	from := time.Date(2024, 1, 1, 23, 55, 0, 0, ist)
+	overlap, err := windowOverlap("00:00:00", "06:00:00", from, from.Add(10*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overlap != 5*time.Minute {
+		t.Errorf("expected the 5 minutes after midnight, got %s", overlap)
+	}
+}
+
+func TestEstimateSavings(t *testing.T) {
+	table, err := pricing.NewTable([]pricing.TableEntry{
+		{InstanceType: "m5.large", Region: "us-east-1", OperatingSystem: "Linux", HourlyPrice: "0.096"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Ec2CostOptimizerReconciler{Prices: table, logger: logr.Discard()}
+
+	obj := scheduledStop(inTimeWindow)
+	obj.Spec.StartTimeWindow, obj.Spec.EndTimeWindow = "00:00:00", "23:59:59"
+	now := metav1.NewTime(time.Now())
+	// avoid the seconds around midnight, which are not part of the window.
+	if ist, err := time.LoadLocation("Asia/Kolkata"); err == nil {
+		day := now.In(ist)
+		now = metav1.NewTime(time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, ist))
+	}
+	stoppedTime := metav1.NewTime(now.Add(-time.Hour))
+	obj.Status.Savings = &costoptimizerv1alpha1.Savings{
+		LastUpdateTime: metav1.NewTime(now.Add(-10 * time.Minute)),
+		Instances: []costoptimizerv1alpha1.InstanceSavings{
+			{InstanceID: "i-1", StoppedSeconds: 3000, StoppedTime: &stoppedTime},
+			{InstanceID: "i-3", StoppedSeconds: 600, StoppedTime: &stoppedTime},
+		},
+	}
+	instances := []utils.Ec2Instance{
+		{InstanceID: "i-1", InstanceType: "m5.large", State: "stopped", Platform: "Linux/UNIX", Tenancy: "default"},
+		// stopped by hand, the object did not stop it.
+		{InstanceID: "i-2", InstanceType: "m5.large", State: "stopped", Platform: "Linux/UNIX", Tenancy: "default"},
+		// started again.
+		{InstanceID: "i-3", InstanceType: "m5.large", State: "running", Platform: "Linux/UNIX", Tenancy: "default"},
+	}
+
+	savings, stoppedHours := r.estimateSavings(obj, instances, now)
+	if stoppedHours != (10 * time.Minute).Hours() {
+		t.Errorf("expected 10 minutes to be accounted, got %v hours", stoppedHours)
+	}
+	if len(savings.Instances) != 3 {
+		t.Fatalf("expected savings for 3 instances, got %+v", savings.Instances)
+	}
+	for _, instance := range savings.Instances {
+		switch instance.InstanceID {
+		case "i-1":
+			if instance.StoppedSeconds != 3600 || instance.EstimatedSavings != "0.10" || instance.HourlyPrice != "0.096" {
+				t.Errorf("i-1: expected an hour at 0.096, got %+v", instance)
+			}
+		case "i-2":
+			if instance.StoppedSeconds != 0 {
+				t.Errorf("i-2: expected no savings for an instance stopped by hand, got %+v", instance)
+			}
+		case "i-3":
+			if instance.StoppedSeconds != 600 || instance.StoppedTime != nil {
+				t.Errorf("i-3: expected the started instance not to be accounted anymore, got %+v", instance)
+			}
+		}
+	}
+	if savings.EstimatedSavings != "0.11" {
+		t.Errorf("expected total savings of 0.11, got %s", savings.EstimatedSavings)
+	}
+}
+
+// hoursSaved returns the hours saved by the object recorded in the metrics.
+func hoursSaved(t *testing.T, name string) float64 {
+	t.Helper()
+	families, err := crmetrics.Registry.Gather()
+	if err != nil {
+		t.Fatalf("unable to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "aws_utility_controller_ec2_estimated_hours_saved_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "name" && label.GetValue() == name {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestAccountSavings(t *testing.T) {
+	idleStop := func(name string) *costoptimizerv1alpha1.Ec2CostOptimizer {
+		stoppedTime := metav1.NewTime(time.Now().Add(-time.Hour))
+		return &costoptimizerv1alpha1.Ec2CostOptimizer{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: costoptimizerv1alpha1.Ec2CostOptimizerSpec{
+				InstanceIDs: []string{"i-1"},
+				Operation:   costoptimizerv1alpha1.Stop,
+				WindowType:  costoptimizerv1alpha1.Idle,
+			},
+			Status: costoptimizerv1alpha1.Ec2CostOptimizerStatus{
+				OperationStatus: costoptimizerv1alpha1.OperationStatus{State: fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Idle, complete)},
+				Savings: &costoptimizerv1alpha1.Savings{
+					LastUpdateTime: metav1.NewTime(time.Now().Add(-6 * time.Minute)),
+					Instances:      []costoptimizerv1alpha1.InstanceSavings{{InstanceID: "i-1", StoppedTime: &stoppedTime}},
+				},
+			},
+		}
+	}
+	instances := []utils.Ec2Instance{{InstanceID: "i-1", InstanceType: "m5.large", State: "stopped"}}
+
+	failing := &fakeClient{statusPatchErr: errors.New("conflict")}
+	r := &Ec2CostOptimizerReconciler{Client: failing, logger: logr.Discard()}
+	r.accountSavings(context.TODO(), idleStop("unpatched"), instances)
+	if failing.statusPatches != 1 {
+		t.Errorf("expected the savings to be patched, got %d patches", failing.statusPatches)
+	}
+	if hours := hoursSaved(t, "unpatched"); hours != 0 {
+		t.Errorf("expected no hours to be counted before the savings are recorded, got %v", hours)
+	}
+
+	r = &Ec2CostOptimizerReconciler{Client: &fakeClient{}, logger: logr.Discard()}
+	r.accountSavings(context.TODO(), idleStop("patched"), instances)
+	if hours := hoursSaved(t, "patched"); hours < 0.09 || hours > 0.11 {
+		t.Errorf("expected about 6 minutes to be counted, got %v hours", hours)
+	}
+}
+
+func TestMarkStopped(t *testing.T) {
+	obj := scheduledStop(inTimeWindow)
+	now := metav1.Now()
+	earlier := metav1.NewTime(now.Add(-time.Hour))
+	obj.Status.Savings = &costoptimizerv1alpha1.Savings{
+		Instances: []costoptimizerv1alpha1.InstanceSavings{{InstanceID: "i-1", StoppedTime: &earlier}},
+	}
+
+	savings := markStopped(obj, []string{"i-1", "i-2"}, now)
+	if len(savings.Instances) != 2 {
+		t.Fatalf("expected 2 instances, got %+v", savings.Instances)
+	}
+	if !savings.Instances[0].StoppedTime.Equal(&earlier) {
+		t.Errorf("expected the stopped time of i-1 to be kept, got %v", savings.Instances[0].StoppedTime)
+	}
+	if savings.Instances[1].InstanceID != "i-2" || !savings.Instances[1].StoppedTime.Equal(&now) {
+		t.Errorf("expected i-2 to be marked as stopped now, got %+v", savings.Instances[1])
+	}
+	if obj.Status.Savings.Instances[0].StoppedTime != &earlier || len(obj.Status.Savings.Instances) != 1 {
+		t.Errorf("expected the status not to be modified")
+	}
+}
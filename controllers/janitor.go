@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// pendingDeletionTag is set on the aws resources marked for deletion by a janitor, it holds the
+// time after which the resource is deleted in RFC3339.
+const pendingDeletionTag = "kubeinbox.io/pending-deletion"
+
+// lastSeenUnusedTag is set on the aws resources marked for deletion by a janitor with the time
+// they were last found unused in RFC3339, the janitor refreshes it at every scan.
+const lastSeenUnusedTag = "kubeinbox.io/last-seen-unused"
+
+// actions taken by the janitors on the resources they clean up.
+const (
+	janitorActionNone              = "None"
+	janitorActionMarkedForDeletion = "MarkedForDeletion"
+	janitorActionPendingDeletion   = "PendingDeletion"
+	janitorActionDeleted           = "Deleted"
+	janitorActionKept              = "Kept"
+	janitorActionWouldDelete       = "WouldDelete"
+)
+
+// janitorPolicy is the age and grace period a resource has to reach before a janitor deletes
+// it, and the interval at which the janitor scans the resources.
+type janitorPolicy struct {
+	minAge       time.Duration
+	gracePeriod  time.Duration
+	scanInterval time.Duration
+}
+
+// newJanitorPolicy returns the policy for the given spec fields, falling back to the defaults
+// for the fields which are not set.
+func newJanitorPolicy(minAge, gracePeriod, scanInterval *metav1.Duration, defaults janitorPolicy) janitorPolicy {
+	policy := defaults
+	if minAge != nil {
+		policy.minAge = minAge.Duration
+	}
+	if gracePeriod != nil {
+		policy.gracePeriod = gracePeriod.Duration
+	}
+	if scanInterval != nil && scanInterval.Duration > 0 {
+		policy.scanInterval = scanInterval.Duration
+	}
+	return policy
+}
+
+// nextAction returns the action to take on a resource created at createTime and the time after
+// which it is deleted. Resources younger than the min age are left alone, the others are marked
+// for deletion and deleted once the grace period is over.
+func (p janitorPolicy) nextAction(createTime time.Time, tags utils.Tags, now time.Time) (string, time.Time) {
+	if now.Sub(createTime) < p.minAge {
+		return janitorActionNone, time.Time{}
+	}
+	value, ok := tags.Get(pendingDeletionTag)
+	if !ok {
+		return janitorActionMarkedForDeletion, now.Add(p.gracePeriod)
+	}
+	deletionTime, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		// tagged by hand, the grace period starts now.
+		return janitorActionMarkedForDeletion, now.Add(p.gracePeriod)
+	}
+	if now.Before(deletionTime) {
+		return janitorActionPendingDeletion, deletionTime
+	}
+	return janitorActionDeleted, deletionTime
+}
+
+// seenUnusedRecently returns true if the resource was found unused by the previous scan, i.e. its
+// last seen unused tag is at most two scan intervals old. A resource which was not seen unused by
+// the previous scan may have been used in between, e.g. while the janitor was not running, its
+// grace period has to start over. A use starting and ending within a scan interval is not seen.
+func seenUnusedRecently(tags utils.Tags, scanInterval time.Duration, now time.Time) bool {
+	value, ok := tags.Get(lastSeenUnusedTag)
+	if !ok {
+		return false
+	}
+	lastSeen, err := time.Parse(time.RFC3339, value)
+	return err == nil && now.Sub(lastSeen) <= 2*scanInterval
+}
+
+// isNotFound returns true if the aws resource of the failed operation does not exist.
+func isNotFound(err error) bool {
+	reason, _ := utils.ClassifyError(err)
+	return reason == utils.ReasonInstanceNotFound
+}
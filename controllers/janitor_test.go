@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+)
+
+func TestJanitorPolicyNextAction(t *testing.T) {
+	policy := janitorPolicy{minAge: 24 * time.Hour, gracePeriod: 48 * time.Hour}
+	now := time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-72 * time.Hour)
+
+	tests := []struct {
+		name         string
+		volume       utils.EbsVolume
+		action       string
+		deletionTime time.Time
+	}{
+		{
+			name:   "too young",
+			volume: utils.EbsVolume{CreateTime: now.Add(-time.Hour)},
+			action: janitorActionNone,
+		},
+		{
+			name:         "not yet marked",
+			volume:       utils.EbsVolume{CreateTime: old},
+			action:       janitorActionMarkedForDeletion,
+			deletionTime: now.Add(48 * time.Hour),
+		},
+		{
+			name:         "invalid mark",
+			volume:       utils.EbsVolume{CreateTime: old, Tags: utils.Tags{{Key: pendingDeletionTag, Value: "yes"}}},
+			action:       janitorActionMarkedForDeletion,
+			deletionTime: now.Add(48 * time.Hour),
+		},
+		{
+			name:         "within grace period",
+			volume:       utils.EbsVolume{CreateTime: old, Tags: utils.Tags{{Key: pendingDeletionTag, Value: "2023-03-11T00:00:00Z"}}},
+			action:       janitorActionPendingDeletion,
+			deletionTime: time.Date(2023, 3, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "grace period over",
+			volume:       utils.EbsVolume{CreateTime: old, Tags: utils.Tags{{Key: pendingDeletionTag, Value: "2023-03-10T00:00:00Z"}}},
+			action:       janitorActionDeleted,
+			deletionTime: time.Date(2023, 3, 10, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			action, deletionTime := policy.nextAction(test.volume.CreateTime, test.volume.Tags, now)
+			if action != test.action || !deletionTime.Equal(test.deletionTime) {
+				t.Errorf("expected %s at %s, got %s at %s", test.action, test.deletionTime, action, deletionTime)
+			}
+		})
+	}
+}
+
+func TestSeenUnusedRecently(t *testing.T) {
+	now := time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		tags   utils.Tags
+		recent bool
+	}{
+		{name: "never seen"},
+		{name: "invalid time", tags: utils.Tags{{Key: lastSeenUnusedTag, Value: "yesterday"}}},
+		{name: "seen by the previous scan", tags: utils.Tags{{Key: lastSeenUnusedTag, Value: "2023-03-10T11:00:00Z"}}, recent: true},
+		{name: "missed a scan", tags: utils.Tags{{Key: lastSeenUnusedTag, Value: "2023-03-10T09:00:00Z"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if recent := seenUnusedRecently(test.tags, time.Hour, now); recent != test.recent {
+				t.Errorf("expected %t, got %t", test.recent, recent)
+			}
+		})
+	}
+}
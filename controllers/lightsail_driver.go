@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+)
+
+func init() {
+	registerDriver(lightsailDriver{})
+}
+
+// lightsailDriver drives lightsail instances, selected by their name.
+type lightsailDriver struct{}
+
+func (lightsailDriver) kind() string {
+	return string(costoptimizerv1alpha1.Lightsail)
+}
+
+func (lightsailDriver) resolveTargets(logger logr.Logger, region string, ids []string, tags map[string]string) ([]string, error) {
+	instances, err := utils.DescribeLightsailInstances(logger, region, ids)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, instance := range instances {
+		if instance.Tags.Matches(tags) {
+			targets = append(targets, instance.Name)
+		}
+	}
+	return targets, nil
+}
+
+func (lightsailDriver) getState(logger logr.Logger, region string, ids []string) (map[string]string, error) {
+	instances, err := utils.DescribeLightsailInstances(logger, region, ids)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]string, len(instances))
+	for _, instance := range instances {
+		states[instance.Name] = instance.State
+	}
+	return states, nil
+}
+
+func (lightsailDriver) applyAction(logger logr.Logger, region string, operation costoptimizerv1alpha1.Ec2OperationType,
+	ids []string) ([]utils.InstanceError, error) {
+	if operation == costoptimizerv1alpha1.Start {
+		return applyEach(logger, region, ids, utils.StartLightsailInstance)
+	}
+	return applyEach(logger, region, ids, utils.StopLightsailInstance)
+}
+
+func (lightsailDriver) states() (string, string) {
+	return "running", "stopped"
+}
+
+// snapshotConfig returns nothing, the bundle of a lightsail instance cannot be changed.
+func (lightsailDriver) snapshotConfig(logr.Logger, string, []string) (map[string]string, error) {
+	return nil, nil
+}
+
+func (lightsailDriver) restoreConfig(logr.Logger, string, string, string) error {
+	return nil
+}
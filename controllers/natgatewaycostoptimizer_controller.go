@@ -0,0 +1,419 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// states of a nat gateway, restored once recreated.
+const (
+	natGatewayDeleting = "Deleting"
+	natGatewayDeleted  = "Deleted"
+	natGatewayCreating = "Creating"
+)
+
+// natGatewayOperations are the ec2 operations performed on the nat gateways.
+type natGatewayOperations interface {
+	describeGateways(logger logr.Logger, region string, natGatewayIDs []string) ([]utils.NatGateway, error)
+	describeRoutes(logger logr.Logger, region, natGatewayID string) ([]utils.NatGatewayRoute, error)
+	deleteGateway(logger logr.Logger, region, natGatewayID string) error
+	createGateway(logger logr.Logger, region, subnetID, allocationID, clientToken string, tags map[string]string) (string, error)
+	replaceRoute(logger logr.Logger, region string, route utils.NatGatewayRoute, natGatewayID string) error
+	createTags(logger logr.Logger, region string, resourceIDs []string, tags map[string]string) error
+	deleteTags(logger logr.Logger, region string, resourceIDs []string, keys ...string) error
+}
+
+// awsNatGatewayOperations performs the ec2 operations through the aws cli.
+type awsNatGatewayOperations struct{}
+
+func (awsNatGatewayOperations) describeGateways(logger logr.Logger, region string, natGatewayIDs []string) ([]utils.NatGateway, error) {
+	return utils.DescribeNatGateways(logger, region, natGatewayIDs)
+}
+
+func (awsNatGatewayOperations) describeRoutes(logger logr.Logger, region, natGatewayID string) ([]utils.NatGatewayRoute, error) {
+	return utils.DescribeNatGatewayRoutes(logger, region, natGatewayID)
+}
+
+func (awsNatGatewayOperations) deleteGateway(logger logr.Logger, region, natGatewayID string) error {
+	return utils.DeleteNatGateway(logger, region, natGatewayID)
+}
+
+func (awsNatGatewayOperations) createGateway(logger logr.Logger, region, subnetID, allocationID, clientToken string,
+	tags map[string]string) (string, error) {
+	return utils.CreateNatGateway(logger, region, subnetID, allocationID, clientToken, tags)
+}
+
+func (awsNatGatewayOperations) replaceRoute(logger logr.Logger, region string, route utils.NatGatewayRoute, natGatewayID string) error {
+	return utils.ReplaceNatGatewayRoute(logger, region, route, natGatewayID)
+}
+
+func (awsNatGatewayOperations) createTags(logger logr.Logger, region string, resourceIDs []string, tags map[string]string) error {
+	return utils.CreateEc2Tags(logger, region, resourceIDs, tags)
+}
+
+func (awsNatGatewayOperations) deleteTags(logger logr.Logger, region string, resourceIDs []string, keys ...string) error {
+	return utils.DeleteEc2Tags(logger, region, resourceIDs, keys...)
+}
+
+// NatGatewayCostOptimizerReconciler reconciles a NatGatewayCostOptimizer object
+type NatGatewayCostOptimizerReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	logger   logr.Logger
+	// operations performed on the nat gateways, the aws cli is used if not set.
+	operations natGatewayOperations
+}
+
+// natGateways returns the operations performed on the nat gateways.
+func (r *NatGatewayCostOptimizerReconciler) natGateways() natGatewayOperations {
+	if r.operations == nil {
+		return awsNatGatewayOperations{}
+	}
+	return r.operations
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NatGatewayCostOptimizerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.NatGatewayCostOptimizer{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=natgatewaycostoptimizers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=natgatewaycostoptimizers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=natgatewaycostoptimizers/finalizers,verbs=update
+
+// Reconcile deletes the nat gateways within the time window, after saving their subnet, elastic
+// ip and routes in the status, and recreates them with the same elastic ip once the window ends
+// or the object is deleted, pointing the saved routes to the new gateways.
+func (r *NatGatewayCostOptimizerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling NatGatewayCostOptimizer ...")
+
+	natGatewayCostOptimizer := &costoptimizerv1alpha1.NatGatewayCostOptimizer{}
+	if err := r.Get(ctx, req.NamespacedName, natGatewayCostOptimizer); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	deleted, err := handleRestoreFinalizer(ctx, r.Client, natGatewayCostOptimizer, func() error {
+		if err := r.handleGateways(ctx, natGatewayCostOptimizer, false); err != nil {
+			return err
+		}
+		// the object is removed once the routes point to the recreated gateways.
+		return gatewaysRestored(natGatewayCostOptimizer)
+	})
+	if err != nil {
+		r.logger.Error(err, "error handling the finalizer")
+		return ctrl.Result{}, err
+	}
+	if deleted {
+		return ctrl.Result{}, nil
+	}
+
+	inWindow := isInTimeWindow(r.logger, natGatewayCostOptimizer.Spec.StartTimeWindow, natGatewayCostOptimizer.Spec.EndTimeWindow)
+	err = r.handleGateways(ctx, natGatewayCostOptimizer, inWindow)
+	if err != nil {
+		r.logger.Error(err, "error processing nat gateways")
+		if _, action := utils.ClassifyError(err); action == utils.ActionFail {
+			// retrying will not help, check again in the next schedule run.
+			err = nil
+		}
+	}
+	return ctrl.Result{RequeueAfter: wait.Jitter(1*time.Minute, 0.5)}, err
+}
+
+// handleGateways deletes or recreates the gateways, the configuration of a gateway is saved in
+// the status before the gateway is deleted so that it is never lost.
+func (r *NatGatewayCostOptimizerReconciler) handleGateways(ctx context.Context, natGatewayCostOptimizer *costoptimizerv1alpha1.NatGatewayCostOptimizer, inWindow bool) error {
+	previous := map[string]costoptimizerv1alpha1.NatGatewayStatus{}
+	for _, gateway := range natGatewayCostOptimizer.Status.NatGateways {
+		previous[gateway.Name] = gateway
+	}
+	statuses := make([]costoptimizerv1alpha1.NatGatewayStatus, 0, len(natGatewayCostOptimizer.Spec.NatGatewayIDs))
+	natGatewayIDs := make([]string, 0, len(natGatewayCostOptimizer.Spec.NatGatewayIDs))
+	for _, name := range natGatewayCostOptimizer.Spec.NatGatewayIDs {
+		status, ok := previous[name]
+		if !ok || status.NatGatewayID == "" {
+			status = costoptimizerv1alpha1.NatGatewayStatus{Name: name, NatGatewayID: name}
+		}
+		statuses = append(statuses, status)
+		natGatewayIDs = append(natGatewayIDs, status.NatGatewayID)
+	}
+
+	region := natGatewayCostOptimizer.Spec.Region
+	gateways, err := r.natGateways().describeGateways(r.logger, region, natGatewayIDs)
+	if err != nil {
+		return err
+	}
+	described := map[string]utils.NatGateway{}
+	for _, gateway := range gateways {
+		described[gateway.NatGatewayID] = gateway
+	}
+
+	state := outOfTimeWindow
+	if inWindow {
+		state = inTimeWindow
+	}
+	for i := range statuses {
+		gateway, found := described[statuses[i].NatGatewayID]
+		if !found || !inWindow || statuses[i].SavedConfig != nil || gateway.State != "available" {
+			continue
+		}
+		routes, err := r.natGateways().describeRoutes(r.logger, region, gateway.NatGatewayID)
+		if err != nil {
+			return err
+		}
+		statuses[i].SavedConfig = savedNatGateway(gateway, routes)
+	}
+	// tells apart the gateways recreated at the end of successive windows.
+	windowStart, err := lastWindowStart(natGatewayCostOptimizer.Spec.StartTimeWindow, time.Now())
+	if err != nil {
+		// the gateways are recreated anyway, the client token still differs per deleted gateway.
+		r.logger.Error(err, "invalid time window")
+	}
+	var firstErr error
+	err = saveThenMutate(ctx, r.Client, natGatewayCostOptimizer, func() {
+		natGatewayCostOptimizer.Status.State = fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Scheduled, state)
+		// copy, the statuses are updated below.
+		natGatewayCostOptimizer.Status.NatGateways = append([]costoptimizerv1alpha1.NatGatewayStatus(nil), statuses...)
+	}, func() error {
+		for i := range statuses {
+			gateway, found := described[statuses[i].NatGatewayID]
+			var err error
+			if inWindow {
+				err = r.deleteGateway(natGatewayCostOptimizer, gateway, found, &statuses[i])
+			} else {
+				err = r.recreateGateway(natGatewayCostOptimizer, gateway, found, &statuses[i], windowStart)
+			}
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return r.patchStatus(ctx, natGatewayCostOptimizer, func(status *costoptimizerv1alpha1.NatGatewayCostOptimizerStatus) {
+			status.NatGateways = statuses
+		})
+	})
+	if firstErr != nil {
+		return firstErr
+	}
+	return err
+}
+
+// gatewaysRestored returns an error while gateways of the object are still to be recreated.
+func gatewaysRestored(natGatewayCostOptimizer *costoptimizerv1alpha1.NatGatewayCostOptimizer) error {
+	pending := 0
+	for _, status := range natGatewayCostOptimizer.Status.NatGateways {
+		if status.SavedConfig != nil {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("waiting for %d nat gateway(s) to be recreated", pending)
+	}
+	return nil
+}
+
+// deleteGateway deletes the gateway once its configuration is saved. The elastic ip of the
+// gateway gets the keep tag of the elastic ip janitor, so that it is not released while it is
+// unassociated.
+func (r *NatGatewayCostOptimizerReconciler) deleteGateway(natGatewayCostOptimizer *costoptimizerv1alpha1.NatGatewayCostOptimizer,
+	gateway utils.NatGateway, found bool, status *costoptimizerv1alpha1.NatGatewayStatus) error {
+	if status.SavedConfig == nil {
+		if !found {
+			status.Message = "nat gateway not found"
+		}
+		// pending gateways are deleted once available.
+		return nil
+	}
+	region := natGatewayCostOptimizer.Spec.Region
+	switch {
+	case found && gateway.State == "available":
+		if allocationID := status.SavedConfig.AllocationID; allocationID != "" {
+			err := r.natGateways().createTags(r.logger, region, []string{allocationID}, map[string]string{defaultKeepTag: natGatewayCostOptimizer.Name})
+			if err != nil {
+				return r.gatewayOperationFailed(natGatewayCostOptimizer, status, "tag elastic ip of", err)
+			}
+		}
+		if err := r.natGateways().deleteGateway(r.logger, region, gateway.NatGatewayID); err != nil {
+			return r.gatewayOperationFailed(natGatewayCostOptimizer, status, "delete", err)
+		}
+		r.Recorder.Eventf(natGatewayCostOptimizer, corev1.EventTypeNormal, eventReasonDeleted,
+			"Deleting nat gateway %s, %d route(s) saved", gateway.NatGatewayID, len(status.SavedConfig.Routes))
+		setGatewayState(status, natGatewayDeleting)
+	case found && gateway.State == "deleting":
+		setGatewayState(status, natGatewayDeleting)
+	case !found || gateway.State == "deleted" || gateway.State == "failed":
+		setGatewayState(status, natGatewayDeleted)
+	}
+	return nil
+}
+
+// recreateGateway creates the gateway again from its saved configuration and points the saved
+// routes to it once it is available. The gateway deleted in the window starting at windowStart
+// is recreated only once, even if the new id could not be saved in the status.
+func (r *NatGatewayCostOptimizerReconciler) recreateGateway(natGatewayCostOptimizer *costoptimizerv1alpha1.NatGatewayCostOptimizer,
+	gateway utils.NatGateway, found bool, status *costoptimizerv1alpha1.NatGatewayStatus, windowStart time.Time) error {
+	saved := status.SavedConfig
+	if saved == nil {
+		return nil
+	}
+	region := natGatewayCostOptimizer.Spec.Region
+	switch {
+	case found && (gateway.State == "pending" || gateway.State == "deleting"):
+		// the elastic ip can be reused once the deleted gateway released it.
+		return nil
+	case found && gateway.State == "available" && status.State != natGatewayCreating:
+		// never got deleted, the routes still point to the gateway.
+		status.SavedConfig, status.Message = nil, ""
+		return nil
+	case found && gateway.State == "available":
+		for _, route := range saved.Routes {
+			err := r.natGateways().replaceRoute(r.logger, region, utils.NatGatewayRoute{
+				RouteTableID:             route.RouteTableID,
+				DestinationCidrBlock:     route.DestinationCidrBlock,
+				DestinationIpv6CidrBlock: route.DestinationIpv6CidrBlock,
+				DestinationPrefixListID:  route.DestinationPrefixListID,
+			}, gateway.NatGatewayID)
+			if err != nil {
+				return r.gatewayOperationFailed(natGatewayCostOptimizer, status, "restore routes of", err)
+			}
+		}
+		if saved.AllocationID != "" {
+			if err := r.natGateways().deleteTags(r.logger, region, []string{saved.AllocationID}, defaultKeepTag); err != nil {
+				r.logger.Error(err, "unable to remove keep tag of elastic ip", "allocation", saved.AllocationID)
+			}
+		}
+		r.Recorder.Eventf(natGatewayCostOptimizer, corev1.EventTypeNormal, eventReasonRestored,
+			"Recreated nat gateway %s as %s, restored %d route(s)", status.Name, gateway.NatGatewayID, len(saved.Routes))
+		setGatewayState(status, restored)
+		status.SavedConfig, status.Message = nil, ""
+		return nil
+	}
+
+	// deleted, or the recreated gateway failed.
+	clientToken := natGatewayClientToken(natGatewayCostOptimizer, saved.SubnetID, windowStart, status.NatGatewayID)
+	natGatewayID, err := r.natGateways().createGateway(r.logger, region, saved.SubnetID, saved.AllocationID, clientToken, saved.Tags)
+	if err != nil {
+		return r.gatewayOperationFailed(natGatewayCostOptimizer, status, "recreate", err)
+	}
+	status.NatGatewayID, status.Message = natGatewayID, ""
+	setGatewayState(status, natGatewayCreating)
+	return nil
+}
+
+// natGatewayClientToken returns the client token of the creation of a gateway replacing the
+// gateway replacedID, deleted in the window starting at windowStart. A creation retried with the
+// same token returns the gateway created before, a gateway replacing a failed one gets a new token.
+func natGatewayClientToken(natGatewayCostOptimizer *costoptimizerv1alpha1.NatGatewayCostOptimizer, subnetID string,
+	windowStart time.Time, replacedID string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%s/%s", natGatewayCostOptimizer.UID, subnetID,
+		windowStart.UTC().Format(time.RFC3339), replacedID)))
+	// client tokens are up to 64 ascii characters.
+	return hex.EncodeToString(sum[:])
+}
+
+// lastWindowStart returns the most recent start of the time window before now, the window is in
+// IST.
+func lastWindowStart(startTimeWindow string, now time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load timezone location: %w", err)
+	}
+	startTime, err := time.Parse(timeWindowFormat, startTimeWindow)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid start time: %w", err)
+	}
+	now = now.In(loc)
+	start := time.Date(now.Year(), now.Month(), now.Day(), startTime.Hour(), startTime.Minute(), startTime.Second(), 0, loc)
+	if start.After(now) {
+		start = start.AddDate(0, 0, -1)
+	}
+	return start, nil
+}
+
+func setGatewayState(status *costoptimizerv1alpha1.NatGatewayStatus, state string) {
+	if status.State == state {
+		return
+	}
+	now := metav1.Now()
+	status.State, status.LastTransitionTime = state, &now
+}
+
+func (r *NatGatewayCostOptimizerReconciler) gatewayOperationFailed(natGatewayCostOptimizer *costoptimizerv1alpha1.NatGatewayCostOptimizer,
+	status *costoptimizerv1alpha1.NatGatewayStatus, operation string, err error) error {
+	reason, action := utils.ClassifyError(err)
+	status.Message = err.Error()
+	r.Recorder.Eventf(natGatewayCostOptimizer, corev1.EventTypeWarning, eventReasonOperationFailed,
+		"Failed to %s nat gateway %s with reason %s (action: %s): %v", operation, status.NatGatewayID, reason, action, err)
+	return err
+}
+
+// savedNatGateway returns the configuration of the gateway needed to recreate it, tags reserved
+// by aws cannot be set and are left out.
+func savedNatGateway(gateway utils.NatGateway, routes []utils.NatGatewayRoute) *costoptimizerv1alpha1.SavedNatGateway {
+	saved := &costoptimizerv1alpha1.SavedNatGateway{SubnetID: gateway.SubnetID}
+	if gateway.ConnectivityType != "private" {
+		saved.AllocationID = gateway.AllocationID
+	}
+	for _, tag := range gateway.Tags {
+		if strings.HasPrefix(tag.Key, "aws:") {
+			continue
+		}
+		if saved.Tags == nil {
+			saved.Tags = map[string]string{}
+		}
+		saved.Tags[tag.Key] = tag.Value
+	}
+	for _, route := range routes {
+		saved.Routes = append(saved.Routes, costoptimizerv1alpha1.NatGatewayRoute{
+			RouteTableID:             route.RouteTableID,
+			DestinationCidrBlock:     route.DestinationCidrBlock,
+			DestinationIpv6CidrBlock: route.DestinationIpv6CidrBlock,
+			DestinationPrefixListID:  route.DestinationPrefixListID,
+		})
+	}
+	return saved
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *NatGatewayCostOptimizerReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.NatGatewayCostOptimizer,
+	mutate func(status *costoptimizerv1alpha1.NatGatewayCostOptimizerStatus)) error {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return err
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+		return err
+	}
+	return nil
+}
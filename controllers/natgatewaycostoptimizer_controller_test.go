@@ -0,0 +1,429 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// fakeNatGatewayOperations performs the ec2 operations on the nat gateways, the operations fail
+// with the error set for them. A creation retried with the same client token returns the gateway
+// created before, like aws does. The operations performed are recorded in order, along with the
+// client tokens of the creations.
+type fakeNatGatewayOperations struct {
+	gateways     map[string]utils.NatGateway
+	routes       []utils.NatGatewayRoute
+	deleteErr    error
+	createErr    error
+	replaceErr   error
+	created      int
+	clientTokens []string
+	operations   []string
+}
+
+func (f *fakeNatGatewayOperations) describeGateways(_ logr.Logger, _ string, natGatewayIDs []string) ([]utils.NatGateway, error) {
+	var gateways []utils.NatGateway
+	for _, id := range natGatewayIDs {
+		if gateway, ok := f.gateways[id]; ok {
+			gateways = append(gateways, gateway)
+		}
+	}
+	return gateways, nil
+}
+
+func (f *fakeNatGatewayOperations) describeRoutes(logr.Logger, string, string) ([]utils.NatGatewayRoute, error) {
+	return f.routes, nil
+}
+
+func (f *fakeNatGatewayOperations) deleteGateway(_ logr.Logger, _, natGatewayID string) error {
+	f.operations = append(f.operations, "delete "+natGatewayID)
+	if f.deleteErr != nil {
+		return f.deleteErr
+	}
+	gateway := f.gateways[natGatewayID]
+	gateway.State = "deleting"
+	f.gateways[natGatewayID] = gateway
+	return nil
+}
+
+func (f *fakeNatGatewayOperations) createGateway(_ logr.Logger, _, subnetID, allocationID, clientToken string,
+	_ map[string]string) (string, error) {
+	f.operations = append(f.operations, fmt.Sprintf("create %s %s", subnetID, allocationID))
+	f.clientTokens = append(f.clientTokens, clientToken)
+	if f.createErr != nil {
+		return "", f.createErr
+	}
+	for i, token := range f.clientTokens[:len(f.clientTokens)-1] {
+		if token == clientToken && f.createErr == nil {
+			return fmt.Sprintf("nat-new%d", i+1), nil
+		}
+	}
+	f.created++
+	natGatewayID := fmt.Sprintf("nat-new%d", f.created)
+	f.gateways[natGatewayID] = utils.NatGateway{NatGatewayID: natGatewayID, SubnetID: subnetID, State: "pending",
+		AllocationID: allocationID}
+	return natGatewayID, nil
+}
+
+func (f *fakeNatGatewayOperations) replaceRoute(_ logr.Logger, _ string, route utils.NatGatewayRoute, natGatewayID string) error {
+	f.operations = append(f.operations, fmt.Sprintf("route %s %s %s", route.RouteTableID, route.DestinationCidrBlock, natGatewayID))
+	return f.replaceErr
+}
+
+func (f *fakeNatGatewayOperations) createTags(_ logr.Logger, _ string, resourceIDs []string, tags map[string]string) error {
+	f.operations = append(f.operations, fmt.Sprintf("tag %s %s", strings.Join(resourceIDs, ","), formatTags(tags)))
+	return nil
+}
+
+func (f *fakeNatGatewayOperations) deleteTags(_ logr.Logger, _ string, resourceIDs []string, keys ...string) error {
+	f.operations = append(f.operations, fmt.Sprintf("untag %s %s", strings.Join(resourceIDs, ","), strings.Join(keys, ",")))
+	return nil
+}
+
+// setState sets the state of the gateway, as aws does once an operation completes.
+func (f *fakeNatGatewayOperations) setState(natGatewayID, state string) {
+	gateway := f.gateways[natGatewayID]
+	gateway.State = state
+	f.gateways[natGatewayID] = gateway
+}
+
+// newNatGatewayTest returns an object deleting the nat gateway nat-1 within the time window, and
+// the reconciler processing it.
+func newNatGatewayTest() (*costoptimizerv1alpha1.NatGatewayCostOptimizer, *NatGatewayCostOptimizerReconciler, *fakeNatGatewayOperations) {
+	obj := &costoptimizerv1alpha1.NatGatewayCostOptimizer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nat", UID: "uid-1"},
+		Spec: costoptimizerv1alpha1.NatGatewayCostOptimizerSpec{NatGatewayIDs: []string{"nat-1"},
+			StartTimeWindow: "00:00:00", EndTimeWindow: "23:59:59"},
+	}
+	nat := &fakeNatGatewayOperations{
+		gateways: map[string]utils.NatGateway{
+			"nat-1": {NatGatewayID: "nat-1", SubnetID: "subnet-1", State: "available", AllocationID: "eipalloc-1"},
+		},
+		routes: []utils.NatGatewayRoute{{RouteTableID: "rtb-1", DestinationCidrBlock: "0.0.0.0/0"}},
+	}
+	r := &NatGatewayCostOptimizerReconciler{Client: &fakeClient{object: obj}, Recorder: record.NewFakeRecorder(10),
+		logger: logr.Discard(), operations: nat}
+	return obj, r, nat
+}
+
+func TestSavedNatGateway(t *testing.T) {
+	routes := []utils.NatGatewayRoute{
+		{RouteTableID: "rtb-1", DestinationCidrBlock: "0.0.0.0/0"},
+		{RouteTableID: "rtb-2", DestinationPrefixListID: "pl-1"},
+	}
+	tests := []struct {
+		name     string
+		gateway  utils.NatGateway
+		expected *costoptimizerv1alpha1.SavedNatGateway
+	}{
+		{
+			name: "public",
+			gateway: utils.NatGateway{
+				NatGatewayID: "nat-1", SubnetID: "subnet-1", ConnectivityType: "public", AllocationID: "eipalloc-1",
+				Tags: utils.Tags{{Key: "Name", Value: "dev"}, {Key: "aws:cloudformation:stack-name", Value: "dev"}},
+			},
+			expected: &costoptimizerv1alpha1.SavedNatGateway{
+				SubnetID: "subnet-1", AllocationID: "eipalloc-1", Tags: map[string]string{"Name": "dev"},
+				Routes: []costoptimizerv1alpha1.NatGatewayRoute{
+					{RouteTableID: "rtb-1", DestinationCidrBlock: "0.0.0.0/0"},
+					{RouteTableID: "rtb-2", DestinationPrefixListID: "pl-1"},
+				},
+			},
+		},
+		{
+			name:    "private",
+			gateway: utils.NatGateway{NatGatewayID: "nat-2", SubnetID: "subnet-2", ConnectivityType: "private", AllocationID: "eipalloc-2"},
+			expected: &costoptimizerv1alpha1.SavedNatGateway{
+				SubnetID: "subnet-2",
+				Routes: []costoptimizerv1alpha1.NatGatewayRoute{
+					{RouteTableID: "rtb-1", DestinationCidrBlock: "0.0.0.0/0"},
+					{RouteTableID: "rtb-2", DestinationPrefixListID: "pl-1"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if saved := savedNatGateway(test.gateway, routes); !reflect.DeepEqual(saved, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, saved)
+			}
+		})
+	}
+}
+
+func TestHandleGatewaysDeleteAndRecreate(t *testing.T) {
+	obj, r, nat := newNatGatewayTest()
+	keep := fmt.Sprintf("%s=nat", defaultKeepTag)
+
+	steps := []struct {
+		name         string
+		inWindow     bool
+		before       func()
+		state        string
+		natGatewayID string
+		saved        bool
+		operations   []string
+	}{
+		{
+			name:         "delete",
+			inWindow:     true,
+			state:        natGatewayDeleting,
+			natGatewayID: "nat-1",
+			saved:        true,
+			operations:   []string{"tag eipalloc-1 " + keep, "delete nat-1"},
+		},
+		{
+			name:         "wait for the deletion",
+			state:        natGatewayDeleting,
+			natGatewayID: "nat-1",
+			saved:        true,
+		},
+		{
+			name:         "recreate",
+			before:       func() { nat.setState("nat-1", "deleted") },
+			state:        natGatewayCreating,
+			natGatewayID: "nat-new1",
+			saved:        true,
+			operations:   []string{"create subnet-1 eipalloc-1"},
+		},
+		{
+			name:         "wait for the creation",
+			state:        natGatewayCreating,
+			natGatewayID: "nat-new1",
+			saved:        true,
+		},
+		{
+			name:         "restore the routes",
+			before:       func() { nat.setState("nat-new1", "available") },
+			state:        restored,
+			natGatewayID: "nat-new1",
+			operations:   []string{"route rtb-1 0.0.0.0/0 nat-new1", fmt.Sprintf("untag eipalloc-1 %s", defaultKeepTag)},
+		},
+	}
+
+	for _, step := range steps {
+		nat.operations = nil
+		if step.before != nil {
+			step.before()
+		}
+		if err := r.handleGateways(context.Background(), obj, step.inWindow); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+		status := obj.Status.NatGateways[0]
+		if status.State != step.state || status.NatGatewayID != step.natGatewayID || (status.SavedConfig != nil) != step.saved {
+			t.Errorf("%s: expected state %s of %s with saved config %t, got %+v", step.name, step.state, step.natGatewayID,
+				step.saved, status)
+		}
+		if !reflect.DeepEqual(nat.operations, step.operations) {
+			t.Errorf("%s: expected operations %v, got %v", step.name, step.operations, nat.operations)
+		}
+	}
+}
+
+func TestHandleGatewaysSavesConfigFirst(t *testing.T) {
+	obj, r, nat := newNatGatewayTest()
+	conflict := errors.New("conflict")
+	r.Client.(*fakeClient).statusPatchErr = conflict
+
+	if err := r.handleGateways(context.Background(), obj, true); !errors.Is(err, conflict) {
+		t.Errorf("expected the patch error, got %v", err)
+	}
+	if len(nat.operations) > 0 {
+		t.Errorf("expected the gateway to be left alone until its config is saved, got %v", nat.operations)
+	}
+}
+
+func TestHandleGatewaysFailures(t *testing.T) {
+	denied := &utils.AWSError{Code: "UnauthorizedOperation", Operation: "ec2", Message: "denied"}
+	saved := &costoptimizerv1alpha1.SavedNatGateway{SubnetID: "subnet-1", AllocationID: "eipalloc-1",
+		Routes: []costoptimizerv1alpha1.NatGatewayRoute{{RouteTableID: "rtb-1", DestinationCidrBlock: "0.0.0.0/0"}}}
+
+	tests := []struct {
+		name       string
+		inWindow   bool
+		status     *costoptimizerv1alpha1.NatGatewayStatus
+		gateway    utils.NatGateway
+		setup      func(nat *fakeNatGatewayOperations)
+		state      string
+		operations []string
+	}{
+		{
+			name:       "delete failed",
+			inWindow:   true,
+			gateway:    utils.NatGateway{NatGatewayID: "nat-1", SubnetID: "subnet-1", State: "available"},
+			setup:      func(nat *fakeNatGatewayOperations) { nat.deleteErr = denied },
+			operations: []string{"delete nat-1"},
+		},
+		{
+			name:       "recreate failed",
+			status:     &costoptimizerv1alpha1.NatGatewayStatus{Name: "nat-1", NatGatewayID: "nat-1", State: natGatewayDeleted, SavedConfig: saved},
+			gateway:    utils.NatGateway{NatGatewayID: "nat-1", State: "deleted"},
+			setup:      func(nat *fakeNatGatewayOperations) { nat.createErr = denied },
+			state:      natGatewayDeleted,
+			operations: []string{"create subnet-1 eipalloc-1"},
+		},
+		{
+			name:       "restoring the routes failed",
+			status:     &costoptimizerv1alpha1.NatGatewayStatus{Name: "nat-1", NatGatewayID: "nat-new1", State: natGatewayCreating, SavedConfig: saved},
+			gateway:    utils.NatGateway{NatGatewayID: "nat-new1", State: "available"},
+			setup:      func(nat *fakeNatGatewayOperations) { nat.replaceErr = denied },
+			state:      natGatewayCreating,
+			operations: []string{"route rtb-1 0.0.0.0/0 nat-new1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			obj, r, nat := newNatGatewayTest()
+			nat.gateways = map[string]utils.NatGateway{test.gateway.NatGatewayID: test.gateway}
+			nat.routes = nil
+			test.setup(nat)
+			if test.status != nil {
+				obj.Status.NatGateways = []costoptimizerv1alpha1.NatGatewayStatus{*test.status}
+			}
+
+			if err := r.handleGateways(context.Background(), obj, test.inWindow); !errors.Is(err, denied) {
+				t.Errorf("expected the operation error, got %v", err)
+			}
+			status := obj.Status.NatGateways[0]
+			if status.State != test.state || status.Message == "" || status.SavedConfig == nil {
+				t.Errorf("expected state %q with the error message and the saved config kept, got %+v", test.state, status)
+			}
+			if !reflect.DeepEqual(nat.operations, test.operations) {
+				t.Errorf("expected operations %v, got %v", test.operations, nat.operations)
+			}
+			if events := eventReasons(r.Recorder.(*record.FakeRecorder)); !reflect.DeepEqual(events, []string{eventReasonOperationFailed}) {
+				t.Errorf("expected an operation failed event, got %v", events)
+			}
+		})
+	}
+}
+
+func TestHandleGatewaysRetriesCreationWithTheSameToken(t *testing.T) {
+	obj, r, nat := newNatGatewayTest()
+	nat.gateways["nat-1"] = utils.NatGateway{NatGatewayID: "nat-1", State: "deleted"}
+	obj.Status.State = fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Scheduled, outOfTimeWindow)
+	obj.Status.NatGateways = []costoptimizerv1alpha1.NatGatewayStatus{{Name: "nat-1", NatGatewayID: "nat-1",
+		State: natGatewayDeleted, SavedConfig: &costoptimizerv1alpha1.SavedNatGateway{SubnetID: "subnet-1"}}}
+	conflict := errors.New("conflict")
+	c := r.Client.(*fakeClient)
+	c.object = obj.DeepCopy()
+
+	// the gateway is created but its id is not saved.
+	saved := obj.DeepCopy()
+	c.statusPatchErr = conflict
+	if err := r.handleGateways(context.Background(), obj, false); !errors.Is(err, conflict) {
+		t.Fatalf("expected the patch error, got %v", err)
+	}
+	obj = saved
+	c.statusPatchErr = nil
+	if err := r.handleGateways(context.Background(), obj, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nat.clientTokens) != 2 || nat.clientTokens[0] == "" || nat.clientTokens[0] != nat.clientTokens[1] {
+		t.Errorf("expected the creation to be retried with the same client token, got %v", nat.clientTokens)
+	}
+
+	// the recreated gateway failed, it is replaced by a new one.
+	nat.gateways[obj.Status.NatGateways[0].NatGatewayID] = utils.NatGateway{NatGatewayID: obj.Status.NatGateways[0].NatGatewayID,
+		State: "failed"}
+	if err := r.handleGateways(context.Background(), obj, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nat.clientTokens) != 3 || nat.clientTokens[2] == nat.clientTokens[1] {
+		t.Errorf("expected a new client token to replace the failed gateway, got %v", nat.clientTokens)
+	}
+}
+
+func TestNatGatewayClientToken(t *testing.T) {
+	obj := &costoptimizerv1alpha1.NatGatewayCostOptimizer{ObjectMeta: metav1.ObjectMeta{UID: "uid-1"}}
+	windowStart := time.Date(2023, 3, 10, 20, 0, 0, 0, time.UTC)
+	token := natGatewayClientToken(obj, "subnet-1", windowStart, "nat-1")
+	if len(token) > 64 {
+		t.Errorf("expected a client token of up to 64 characters, got %q", token)
+	}
+	if natGatewayClientToken(obj, "subnet-1", windowStart, "nat-1") != token {
+		t.Errorf("expected the client token to be deterministic")
+	}
+	if natGatewayClientToken(obj, "subnet-1", windowStart.Add(24*time.Hour), "nat-1") == token {
+		t.Errorf("expected the next window to get another client token")
+	}
+}
+
+func TestLastWindowStart(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected time.Time
+	}{
+		{name: "after the start", now: time.Date(2023, 3, 10, 22, 0, 0, 0, ist), expected: time.Date(2023, 3, 10, 20, 0, 0, 0, ist)},
+		{name: "before the start", now: time.Date(2023, 3, 10, 8, 0, 0, 0, ist), expected: time.Date(2023, 3, 9, 20, 0, 0, 0, ist)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, err := lastWindowStart("20:00:00", test.now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !start.Equal(test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, start)
+			}
+		})
+	}
+}
+
+func TestNatGatewayRestoreFinalizer(t *testing.T) {
+	_, r, nat := newNatGatewayTest()
+	c := r.Client.(*fakeClient)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "nat"}}
+
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !controllerutil.ContainsFinalizer(c.object, restoreFinalizer) {
+		t.Fatalf("expected the restore finalizer to be added, got %v", c.object.GetFinalizers())
+	}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the object is deleted within the window, the gateway is recreated before it is removed.
+	now := metav1.Now()
+	c.object.SetDeletionTimestamp(&now)
+	nat.setState("nat-1", "deleted")
+	if _, err := r.Reconcile(context.Background(), req); err == nil {
+		t.Errorf("expected the removal to wait for the gateway to be recreated")
+	}
+	if !controllerutil.ContainsFinalizer(c.object, restoreFinalizer) {
+		t.Fatalf("expected the finalizer to be kept, got %v", c.object.GetFinalizers())
+	}
+
+	nat.operations = nil
+	nat.setState("nat-new1", "available")
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"route rtb-1 0.0.0.0/0 nat-new1", fmt.Sprintf("untag eipalloc-1 %s", defaultKeepTag)}; !reflect.DeepEqual(nat.operations, expected) {
+		t.Errorf("expected operations %v, got %v", expected, nat.operations)
+	}
+	if finalizers := c.object.GetFinalizers(); len(finalizers) > 0 {
+		t.Errorf("expected the finalizer to be removed, got %v", finalizers)
+	}
+}
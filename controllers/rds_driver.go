@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+)
+
+// rdsKind is the kind of the databases in the metrics and the events.
+const rdsKind = "RDS"
+
+// rdsDriver drives rds DB instances and Aurora clusters, the id of a database is its kind
+// followed by its identifier, e.g. DBInstance/orders. It is not registered, the databases are
+// selected by RdsCostOptimizer objects.
+type rdsDriver struct {
+	operations rdsOperations
+}
+
+// databaseID returns the id of the database of the kind in the driver.
+func databaseID(kind, identifier string) string {
+	return kind + "/" + identifier
+}
+
+func (rdsDriver) kind() string {
+	return rdsKind
+}
+
+// resolveTargets returns the existing databases, rds databases are not selected by their tags.
+func (d rdsDriver) resolveTargets(logger logr.Logger, region string, ids []string, _ map[string]string) ([]string, error) {
+	states, err := d.getState(logger, region, ids)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, id := range ids {
+		if _, found := states[id]; found {
+			targets = append(targets, id)
+		}
+	}
+	return targets, nil
+}
+
+func (d rdsDriver) getState(logger logr.Logger, region string, ids []string) (map[string]string, error) {
+	identifiers := map[string][]string{}
+	var kinds []string
+	for _, id := range ids {
+		kind, identifier, err := splitDatabaseID(id)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := identifiers[kind]; !ok {
+			kinds = append(kinds, kind)
+		}
+		identifiers[kind] = append(identifiers[kind], identifier)
+	}
+	states := map[string]string{}
+	for _, kind := range kinds {
+		databases, err := d.operations.describeDatabases(logger, region, kind, identifiers[kind])
+		if err != nil {
+			return nil, err
+		}
+		for _, database := range databases {
+			states[databaseID(kind, database.Identifier)] = database.Status
+		}
+	}
+	return states, nil
+}
+
+func (d rdsDriver) applyAction(logger logr.Logger, region string, operation costoptimizerv1alpha1.Ec2OperationType,
+	ids []string) ([]utils.InstanceError, error) {
+	operate := d.operations.stopDatabase
+	if operation == costoptimizerv1alpha1.Start {
+		operate = d.operations.startDatabase
+	}
+	return applyEach(logger, region, ids, func(logger logr.Logger, region, id string) error {
+		kind, identifier, err := splitDatabaseID(id)
+		if err != nil {
+			return err
+		}
+		return operate(logger, region, kind, identifier)
+	})
+}
+
+func (rdsDriver) states() (string, string) {
+	return "available", "stopped"
+}
+
+// snapshotConfig returns nothing, the class of a database is not changed while it is stopped.
+func (rdsDriver) snapshotConfig(logr.Logger, string, []string) (map[string]string, error) {
+	return nil, nil
+}
+
+func (rdsDriver) restoreConfig(logr.Logger, string, string, string) error {
+	return nil
+}
+
+// splitDatabaseID returns the kind and the identifier of the database with the id.
+func splitDatabaseID(id string) (string, string, error) {
+	kind, identifier, ok := strings.Cut(id, "/")
+	if !ok || (kind != utils.RdsDBInstance && kind != utils.RdsDBCluster) {
+		return "", "", fmt.Errorf("invalid database id %s", id)
+	}
+	return kind, identifier, nil
+}
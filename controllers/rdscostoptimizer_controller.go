@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"context"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// rdsOperations are the rds operations performed on the databases.
+type rdsOperations interface {
+	describeDatabases(logger logr.Logger, region, kind string, identifiers []string) ([]utils.RdsDatabase, error)
+	startDatabase(logger logr.Logger, region, kind, identifier string) error
+	stopDatabase(logger logr.Logger, region, kind, identifier string) error
+}
+
+// awsRdsOperations performs the rds operations through the aws cli.
+type awsRdsOperations struct{}
+
+func (awsRdsOperations) describeDatabases(logger logr.Logger, region, kind string, identifiers []string) ([]utils.RdsDatabase, error) {
+	return utils.DescribeRdsDatabases(logger, region, kind, identifiers)
+}
+
+func (awsRdsOperations) startDatabase(logger logr.Logger, region, kind, identifier string) error {
+	return utils.StartRdsDatabase(logger, region, kind, identifier)
+}
+
+func (awsRdsOperations) stopDatabase(logger logr.Logger, region, kind, identifier string) error {
+	return utils.StopRdsDatabase(logger, region, kind, identifier)
+}
+
+// RdsCostOptimizerReconciler reconciles a RdsCostOptimizer object
+type RdsCostOptimizerReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	logger   logr.Logger
+	// operations performed on the databases, the aws cli is used if not set.
+	operations rdsOperations
+}
+
+// rds returns the operations performed on the databases.
+func (r *RdsCostOptimizerReconciler) rds() rdsOperations {
+	if r.operations == nil {
+		return awsRdsOperations{}
+	}
+	return r.operations
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RdsCostOptimizerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.RdsCostOptimizer{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=rdscostoptimizers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=rdscostoptimizers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=rdscostoptimizers/finalizers,verbs=update
+
+// Reconcile starts/stops the selected DB instances and Aurora clusters right away or within
+// the time window through the rds driver, failed onDemand operations are retried within the
+// retry budget of the object. As rds restarts stopped databases automatically after seven days,
+// the databases stopped by a Scheduled object are stopped again while its window is active.
+func (r *RdsCostOptimizerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling RdsCostOptimizer ...")
+
+	rdsCostOptimizer := &costoptimizerv1alpha1.RdsCostOptimizer{}
+	if err := r.Get(ctx, req.NamespacedName, rdsCostOptimizer); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	switch rdsCostOptimizer.Spec.WindowType {
+	case costoptimizerv1alpha1.OnDemand:
+		return reconcileOnDemand(r.logger, r.Recorder, r.driverOperation(ctx, rdsCostOptimizer))
+	case costoptimizerv1alpha1.Scheduled:
+		return reconcileScheduled(r.logger, r.Recorder, r.driverOperation(ctx, rdsCostOptimizer))
+	default:
+		r.logger.V(1).Info("invalid window type specified")
+	}
+	return ctrl.Result{}, nil
+}
+
+// driver returns the driver of the databases.
+func (r *RdsCostOptimizerReconciler) driver() rdsDriver {
+	return rdsDriver{operations: r.rds()}
+}
+
+// driverOperation returns the start/stop operation of the object on its databases.
+func (r *RdsCostOptimizerReconciler) driverOperation(ctx context.Context, rdsCostOptimizer *costoptimizerv1alpha1.RdsCostOptimizer) driverOperation {
+	spec := rdsCostOptimizer.Spec
+	return driverOperation{
+		obj:             rdsCostOptimizer,
+		driver:          r.driver(),
+		region:          spec.Region,
+		operation:       spec.Operation,
+		windowType:      spec.WindowType,
+		startTimeWindow: spec.StartTimeWindow,
+		endTimeWindow:   spec.EndTimeWindow,
+		retryPolicy:     spec.RetryPolicy,
+		status:          &rdsCostOptimizer.Status.OperationStatus,
+		patchStatus: func(mutate func(status *costoptimizerv1alpha1.OperationStatus)) {
+			r.patchStatus(ctx, rdsCostOptimizer, func(status *costoptimizerv1alpha1.RdsCostOptimizerStatus) {
+				mutate(&status.OperationStatus)
+			})
+		},
+		operate: func() ([]string, error) {
+			return r.handleDatabases(ctx, rdsCostOptimizer)
+		},
+		outOfWindow: func() error {
+			r.resetDatabases(ctx, rdsCostOptimizer)
+			return nil
+		},
+	}
+}
+
+// handleDatabases performs the operation on the databases which are not yet in the desired
+// state, databases in transition are left alone until they settle. The databases which are
+// expected to reach the state of the operation are returned.
+func (r *RdsCostOptimizerReconciler) handleDatabases(ctx context.Context, rdsCostOptimizer *costoptimizerv1alpha1.RdsCostOptimizer) ([]string, error) {
+	var ids []string
+	for _, identifier := range rdsCostOptimizer.Spec.DBInstanceIdentifiers {
+		ids = append(ids, databaseID(utils.RdsDBInstance, identifier))
+	}
+	for _, identifier := range rdsCostOptimizer.Spec.DBClusterIdentifiers {
+		ids = append(ids, databaseID(utils.RdsDBCluster, identifier))
+	}
+	states, err := r.driver().getState(r.logger, rdsCostOptimizer.Spec.Region, ids)
+	if err != nil {
+		return nil, err
+	}
+	previous := map[string]costoptimizerv1alpha1.DatabaseStatus{}
+	for _, database := range rdsCostOptimizer.Status.Databases {
+		previous[databaseID(database.Kind, database.Identifier)] = database
+	}
+
+	databases := make([]costoptimizerv1alpha1.DatabaseStatus, 0, len(ids))
+	var settling []string
+	var firstErr error
+	for _, id := range ids {
+		database, ok := previous[id]
+		if !ok {
+			kind, identifier, _ := splitDatabaseID(id)
+			database = costoptimizerv1alpha1.DatabaseStatus{Identifier: identifier, Kind: kind}
+		}
+		status, found := states[id]
+		database.Status = status
+		if !found {
+			database.Message = "database not found"
+		} else if err := r.handleDatabase(rdsCostOptimizer, &database); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if found && database.Message == "" {
+			settling = append(settling, id)
+		}
+		databases = append(databases, database)
+	}
+
+	r.patchStatus(ctx, rdsCostOptimizer, func(status *costoptimizerv1alpha1.RdsCostOptimizerStatus) {
+		status.Databases = databases
+	})
+	return settling, firstErr
+}
+
+// resetDatabases forgets the actions performed on the databases once the time window exited, so
+// that the databases stopped in the next window are not reported as restarted by rds.
+func (r *RdsCostOptimizerReconciler) resetDatabases(ctx context.Context, rdsCostOptimizer *costoptimizerv1alpha1.RdsCostOptimizer) {
+	r.patchStatus(ctx, rdsCostOptimizer, func(status *costoptimizerv1alpha1.RdsCostOptimizerStatus) {
+		for i := range status.Databases {
+			database := &status.Databases[i]
+			database.LastAction, database.LastActionTime, database.Restops = "", nil, 0
+		}
+	})
+}
+
+// handleDatabase performs the operation on the database through the driver if it is required by
+// its status, resource specific errors are recorded in the status of the database only.
+func (r *RdsCostOptimizerReconciler) handleDatabase(rdsCostOptimizer *costoptimizerv1alpha1.RdsCostOptimizer,
+	database *costoptimizerv1alpha1.DatabaseStatus) error {
+	operation := rdsCostOptimizer.Spec.Operation
+	driver := r.driver()
+	if !actionRequired(driver, operation, database.Status) {
+		// already in the desired state or in transition.
+		return nil
+	}
+	if operation == costoptimizerv1alpha1.Stop && rdsCostOptimizer.Spec.WindowType == costoptimizerv1alpha1.Scheduled &&
+		database.LastAction == costoptimizerv1alpha1.Stop {
+		// stopped before within the current window, rds restarts stopped databases after seven
+		// days.
+		database.Restops++
+		r.Recorder.Eventf(rdsCostOptimizer, corev1.EventTypeNormal, eventReasonDatabaseRestopped,
+			"%s %s got started again, stopping it again", database.Kind, database.Identifier)
+	}
+
+	id := databaseID(database.Kind, database.Identifier)
+	skipped, err := applyDriverAction(r.logger, r.Recorder, rdsCostOptimizer, driver, operation, rdsCostOptimizer.Spec.Region, []string{id})
+	if err != nil {
+		database.Message = err.Error()
+		return err
+	}
+	if len(skipped) > 0 {
+		reason, _ := utils.ClassifyError(skipped[0].Err)
+		database.Message = skipped[0].Err.Error()
+		r.Recorder.Eventf(rdsCostOptimizer, corev1.EventTypeWarning, eventReasonInstanceSkipped,
+			"%s skipped %s %s with reason %s: %v", operation, database.Kind, database.Identifier, reason, skipped[0].Err)
+		return nil
+	}
+	now := metav1.Now()
+	database.LastAction = operation
+	database.LastActionTime = &now
+	database.Message = ""
+	return nil
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *RdsCostOptimizerReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.RdsCostOptimizer,
+	mutate func(status *costoptimizerv1alpha1.RdsCostOptimizerStatus)) {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+	}
+}
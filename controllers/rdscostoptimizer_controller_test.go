@@ -0,0 +1,304 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// fakeRdsOperations performs the rds operations on the databases of statuses, keyed by
+// kind/identifier, the operations fail with err. The identifiers operated are recorded by
+// operation.
+type fakeRdsOperations struct {
+	statuses map[string]string
+	err      error
+	operated map[string][]string
+}
+
+func newFakeRdsOperations(err error) *fakeRdsOperations {
+	return &fakeRdsOperations{statuses: map[string]string{}, err: err, operated: map[string][]string{}}
+}
+
+func (f *fakeRdsOperations) describeDatabases(_ logr.Logger, _, kind string, identifiers []string) ([]utils.RdsDatabase, error) {
+	var databases []utils.RdsDatabase
+	for _, identifier := range identifiers {
+		if status, ok := f.statuses[kind+"/"+identifier]; ok {
+			databases = append(databases, utils.RdsDatabase{Identifier: identifier, Status: status})
+		}
+	}
+	return databases, nil
+}
+
+func (f *fakeRdsOperations) startDatabase(_ logr.Logger, _, kind, identifier string) error {
+	return f.operate("start", kind, identifier, "starting")
+}
+
+func (f *fakeRdsOperations) stopDatabase(_ logr.Logger, _, kind, identifier string) error {
+	return f.operate("stop", kind, identifier, "stopping")
+}
+
+func (f *fakeRdsOperations) operate(operation, kind, identifier, status string) error {
+	f.operated[operation] = append(f.operated[operation], identifier)
+	if f.err != nil {
+		return f.err
+	}
+	f.statuses[kind+"/"+identifier] = status
+	return nil
+}
+
+// eventReasons returns the reasons of the events recorded by the fake recorder.
+func eventReasons(recorder *record.FakeRecorder) []string {
+	var reasons []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			reasons = append(reasons, strings.Fields(event)[1])
+		default:
+			return reasons
+		}
+	}
+}
+
+func TestHandleDatabase(t *testing.T) {
+	denied := &utils.AWSError{Code: "AccessDenied", Operation: "StopDBInstance", Message: "denied"}
+	tests := []struct {
+		name      string
+		operation costoptimizerv1alpha1.Ec2OperationType
+		scheduled bool
+		database  costoptimizerv1alpha1.DatabaseStatus
+		err       error
+		operated  map[string][]string
+		expected  costoptimizerv1alpha1.DatabaseStatus
+		events    []string
+	}{
+		{
+			name:      "stop available database",
+			operation: costoptimizerv1alpha1.Stop,
+			database:  costoptimizerv1alpha1.DatabaseStatus{Identifier: "db-1", Kind: utils.RdsDBInstance, Status: "available"},
+			operated:  map[string][]string{"stop": {"db-1"}},
+			expected:  costoptimizerv1alpha1.DatabaseStatus{Identifier: "db-1", Kind: utils.RdsDBInstance, Status: "available", LastAction: costoptimizerv1alpha1.Stop},
+			events:    []string{eventReasonStopIssued},
+		},
+		{
+			name:      "stop database restarted by rds",
+			operation: costoptimizerv1alpha1.Stop,
+			scheduled: true,
+			database: costoptimizerv1alpha1.DatabaseStatus{Identifier: "db-1", Kind: utils.RdsDBInstance, Status: "available",
+				LastAction: costoptimizerv1alpha1.Stop, Restops: 1},
+			operated: map[string][]string{"stop": {"db-1"}},
+			expected: costoptimizerv1alpha1.DatabaseStatus{Identifier: "db-1", Kind: utils.RdsDBInstance, Status: "available",
+				LastAction: costoptimizerv1alpha1.Stop, Restops: 2},
+			events: []string{eventReasonDatabaseRestopped, eventReasonStopIssued},
+		},
+		{
+			name:      "stop database again on demand",
+			operation: costoptimizerv1alpha1.Stop,
+			database:  costoptimizerv1alpha1.DatabaseStatus{Identifier: "db-1", Kind: utils.RdsDBInstance, Status: "available", LastAction: costoptimizerv1alpha1.Stop},
+			operated:  map[string][]string{"stop": {"db-1"}},
+			expected:  costoptimizerv1alpha1.DatabaseStatus{Identifier: "db-1", Kind: utils.RdsDBInstance, Status: "available", LastAction: costoptimizerv1alpha1.Stop},
+			events:    []string{eventReasonStopIssued},
+		},
+		{
+			name:      "stopped database",
+			operation: costoptimizerv1alpha1.Stop,
+			database:  costoptimizerv1alpha1.DatabaseStatus{Identifier: "db-1", Kind: utils.RdsDBInstance, Status: "stopped", LastAction: costoptimizerv1alpha1.Stop},
+			operated:  map[string][]string{},
+			expected:  costoptimizerv1alpha1.DatabaseStatus{Identifier: "db-1", Kind: utils.RdsDBInstance, Status: "stopped", LastAction: costoptimizerv1alpha1.Stop},
+		},
+		{
+			name:      "database in transition",
+			operation: costoptimizerv1alpha1.Stop,
+			database:  costoptimizerv1alpha1.DatabaseStatus{Identifier: "db-1", Kind: utils.RdsDBInstance, Status: "starting", LastAction: costoptimizerv1alpha1.Stop},
+			operated:  map[string][]string{},
+			expected:  costoptimizerv1alpha1.DatabaseStatus{Identifier: "db-1", Kind: utils.RdsDBInstance, Status: "starting", LastAction: costoptimizerv1alpha1.Stop},
+		},
+		{
+			name:      "start stopped database",
+			operation: costoptimizerv1alpha1.Start,
+			database:  costoptimizerv1alpha1.DatabaseStatus{Identifier: "db-1", Kind: utils.RdsDBInstance, Status: "stopped", Message: "previous failure"},
+			operated:  map[string][]string{"start": {"db-1"}},
+			expected:  costoptimizerv1alpha1.DatabaseStatus{Identifier: "db-1", Kind: utils.RdsDBInstance, Status: "stopped", LastAction: costoptimizerv1alpha1.Start},
+			events:    []string{eventReasonStartIssued},
+		},
+		{
+			name:      "stop failed",
+			operation: costoptimizerv1alpha1.Stop,
+			database:  costoptimizerv1alpha1.DatabaseStatus{Identifier: "db-1", Kind: utils.RdsDBInstance, Status: "available"},
+			err:       denied,
+			operated:  map[string][]string{"stop": {"db-1"}},
+			expected:  costoptimizerv1alpha1.DatabaseStatus{Identifier: "db-1", Kind: utils.RdsDBInstance, Status: "available", Message: denied.Error()},
+			events:    []string{eventReasonOperationFailed},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rds := newFakeRdsOperations(test.err)
+			recorder := record.NewFakeRecorder(10)
+			r := &RdsCostOptimizerReconciler{Recorder: recorder, logger: logr.Discard(), operations: rds}
+			obj := &costoptimizerv1alpha1.RdsCostOptimizer{Spec: costoptimizerv1alpha1.RdsCostOptimizerSpec{
+				Operation: test.operation, WindowType: costoptimizerv1alpha1.OnDemand}}
+			if test.scheduled {
+				obj.Spec.WindowType = costoptimizerv1alpha1.Scheduled
+			}
+
+			database := test.database
+			if err := r.handleDatabase(obj, &database); !errors.Is(err, test.err) {
+				t.Errorf("expected error %v, got %v", test.err, err)
+			}
+			if (database.LastActionTime != nil) != (len(test.operated) > 0 && test.err == nil) {
+				t.Errorf("expected the last action time to be set for issued operations only, got %v", database.LastActionTime)
+			}
+			database.LastActionTime = nil
+			if !reflect.DeepEqual(database, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, database)
+			}
+			if !reflect.DeepEqual(rds.operated, test.operated) {
+				t.Errorf("expected operations %v, got %v", test.operated, rds.operated)
+			}
+			if events := eventReasons(recorder); !reflect.DeepEqual(events, test.events) {
+				t.Errorf("expected events %v, got %v", test.events, events)
+			}
+		})
+	}
+}
+
+// reconcileRds reconciles the object served by the client.
+func reconcileRds(t *testing.T, r *RdsCostOptimizerReconciler) ctrl.Result {
+	t.Helper()
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "rds"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return result
+}
+
+func TestReconcileRdsOnDemandStop(t *testing.T) {
+	rds := newFakeRdsOperations(nil)
+	rds.statuses[utils.RdsDBInstance+"/db-1"] = "available"
+	c := &fakeClient{object: &costoptimizerv1alpha1.RdsCostOptimizer{
+		ObjectMeta: metav1.ObjectMeta{Name: "rds", Generation: 1},
+		Spec: costoptimizerv1alpha1.RdsCostOptimizerSpec{Operation: costoptimizerv1alpha1.Stop,
+			WindowType: costoptimizerv1alpha1.OnDemand, DBInstanceIdentifiers: []string{"db-1"}},
+	}}
+	r := &RdsCostOptimizerReconciler{Client: c, Recorder: record.NewFakeRecorder(10), operations: rds}
+	state := func() string {
+		return c.object.(*costoptimizerv1alpha1.RdsCostOptimizer).Status.State
+	}
+
+	// the stop is issued, the object is requeued until the database is stopped.
+	if result := reconcileRds(t, r); result.RequeueAfter != resourcePollPeriod {
+		t.Errorf("expected the object to be requeued until the database is stopped, got %v", result.RequeueAfter)
+	}
+	if expected := fmt.Sprintf("%s/%s", costoptimizerv1alpha1.OnDemand, inProgress); state() != expected {
+		t.Errorf("expected state %s, got %s", expected, state())
+	}
+	rds.statuses[utils.RdsDBInstance+"/db-1"] = "stopped"
+	if result := reconcileRds(t, r); result.RequeueAfter != 0 {
+		t.Errorf("expected a completed onDemand stop not to be requeued, got %v", result.RequeueAfter)
+	}
+	if expected := fmt.Sprintf("%s/%s", costoptimizerv1alpha1.OnDemand, complete); state() != expected {
+		t.Errorf("expected state %s, got %s", expected, state())
+	}
+
+	// rds restarted the database, the completed object is left alone.
+	rds.statuses[utils.RdsDBInstance+"/db-1"] = "available"
+	reconcileRds(t, r)
+	if stops := rds.operated["stop"]; len(stops) != 1 {
+		t.Errorf("expected the database to be stopped once, got %v", stops)
+	}
+}
+
+func TestReconcileRdsScheduledRestop(t *testing.T) {
+	rds := newFakeRdsOperations(nil)
+	rds.statuses[utils.RdsDBInstance+"/db-1"] = "available"
+	obj := &costoptimizerv1alpha1.RdsCostOptimizer{
+		ObjectMeta: metav1.ObjectMeta{Name: "rds", Generation: 1},
+		Spec: costoptimizerv1alpha1.RdsCostOptimizerSpec{Operation: costoptimizerv1alpha1.Stop,
+			WindowType: costoptimizerv1alpha1.Scheduled, StartTimeWindow: "00:00:00", EndTimeWindow: "23:59:59",
+			DBInstanceIdentifiers: []string{"db-1"}},
+	}
+	c := &fakeClient{object: obj}
+	recorder := record.NewFakeRecorder(10)
+	r := &RdsCostOptimizerReconciler{Client: c, Recorder: recorder, operations: rds}
+	database := func() costoptimizerv1alpha1.DatabaseStatus {
+		return c.object.(*costoptimizerv1alpha1.RdsCostOptimizer).Status.Databases[0]
+	}
+
+	reconcileRds(t, r)
+	// rds restarted the database within the window.
+	rds.statuses[utils.RdsDBInstance+"/db-1"] = "available"
+	reconcileRds(t, r)
+	if restops := database().Restops; restops != 1 {
+		t.Errorf("expected the database to be stopped again once, got %d", restops)
+	}
+	if events := eventReasons(recorder); !containsString(events, eventReasonDatabaseRestopped) {
+		t.Errorf("expected a %s event, got %v", eventReasonDatabaseRestopped, events)
+	}
+
+	// the window exited, the database is started by someone else and stopped in the next window.
+	window := c.object.(*costoptimizerv1alpha1.RdsCostOptimizer)
+	window.Spec.StartTimeWindow, window.Spec.EndTimeWindow = "00:00:00", "00:00:00"
+	reconcileRds(t, r)
+	if db := database(); db.LastAction != "" || db.Restops != 0 {
+		t.Errorf("expected the actions to be reset out of the window, got %+v", db)
+	}
+	window = c.object.(*costoptimizerv1alpha1.RdsCostOptimizer)
+	window.Spec.StartTimeWindow, window.Spec.EndTimeWindow = "00:00:00", "23:59:59"
+	rds.statuses[utils.RdsDBInstance+"/db-1"] = "available"
+	reconcileRds(t, r)
+	if events := eventReasons(recorder); containsString(events, eventReasonDatabaseRestopped) {
+		t.Errorf("expected no %s event in a new window, got %v", eventReasonDatabaseRestopped, events)
+	}
+}
+
+func TestReconcileRdsOnDemandRetry(t *testing.T) {
+	throttled := &utils.AWSError{Code: "Throttling", Operation: "StopDBCluster", Message: "rate exceeded"}
+	rds := newFakeRdsOperations(throttled)
+	rds.statuses[utils.RdsDBCluster+"/aurora-1"] = "available"
+	c := &fakeClient{object: &costoptimizerv1alpha1.RdsCostOptimizer{
+		ObjectMeta: metav1.ObjectMeta{Name: "rds", Generation: 1},
+		Spec: costoptimizerv1alpha1.RdsCostOptimizerSpec{Operation: costoptimizerv1alpha1.Stop,
+			WindowType: costoptimizerv1alpha1.OnDemand, DBClusterIdentifiers: []string{"aurora-1"},
+			RetryPolicy: &costoptimizerv1alpha1.RetryPolicy{MaxAttempts: 2}},
+	}}
+	r := &RdsCostOptimizerReconciler{Client: c, Recorder: record.NewFakeRecorder(10), operations: rds}
+	status := func() costoptimizerv1alpha1.RdsCostOptimizerStatus {
+		return c.object.(*costoptimizerv1alpha1.RdsCostOptimizer).Status
+	}
+
+	if result := reconcileRds(t, r); result.RequeueAfter != defaultInitialBackoff {
+		t.Errorf("expected a retry after %v, got %v", defaultInitialBackoff, result.RequeueAfter)
+	}
+	if s := status(); s.State != fmt.Sprintf("%s/%s", costoptimizerv1alpha1.OnDemand, retrying) || s.Attempts != 1 {
+		t.Errorf("expected a first failed attempt, got %s after %d attempt(s)", s.State, s.Attempts)
+	}
+	if message := status().Databases[0].Message; message != throttled.Error() {
+		t.Errorf("expected the error in the status of the database, got %q", message)
+	}
+
+	// the retry is due, the budget of two attempts is exhausted.
+	obj := c.object.(*costoptimizerv1alpha1.RdsCostOptimizer)
+	obj.Status.NextRetryTime = nil
+	if result := reconcileRds(t, r); result.RequeueAfter != 0 {
+		t.Errorf("expected a failed operation not to be requeued, got %v", result.RequeueAfter)
+	}
+	if s := status(); s.State != fmt.Sprintf("%s/%s", costoptimizerv1alpha1.OnDemand, failed) || s.Attempts != 2 {
+		t.Errorf("expected the operation to fail, got %s after %d attempt(s)", s.State, s.Attempts)
+	}
+	if stops := rds.operated["stop"]; len(stops) != 2 {
+		t.Errorf("expected the database to be stopped twice, got %v", stops)
+	}
+}
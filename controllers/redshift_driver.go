@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+)
+
+// redshiftKind is the kind of the clusters in the metrics and the events.
+const redshiftKind = "Redshift"
+
+// redshiftDriver drives provisioned redshift clusters, selected by their identifier, Stop pauses
+// and Start resumes the clusters. It is not registered, the clusters are selected by
+// RedshiftCostOptimizer objects.
+type redshiftDriver struct {
+	operations redshiftOperations
+}
+
+func (redshiftDriver) kind() string {
+	return redshiftKind
+}
+
+// resolveTargets returns the existing clusters, redshift clusters are not selected by their tags.
+func (d redshiftDriver) resolveTargets(logger logr.Logger, region string, ids []string, _ map[string]string) ([]string, error) {
+	states, err := d.getState(logger, region, ids)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, id := range ids {
+		if _, found := states[id]; found {
+			targets = append(targets, id)
+		}
+	}
+	return targets, nil
+}
+
+func (d redshiftDriver) getState(logger logr.Logger, region string, ids []string) (map[string]string, error) {
+	clusters, err := d.operations.describeClusters(logger, region, ids)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]string, len(clusters))
+	for _, cluster := range clusters {
+		states[cluster.Identifier] = cluster.Status
+	}
+	return states, nil
+}
+
+func (d redshiftDriver) applyAction(logger logr.Logger, region string, operation costoptimizerv1alpha1.Ec2OperationType,
+	ids []string) ([]utils.InstanceError, error) {
+	if operation == costoptimizerv1alpha1.Start {
+		return applyEach(logger, region, ids, d.operations.resumeCluster)
+	}
+	return applyEach(logger, region, ids, d.operations.pauseCluster)
+}
+
+func (redshiftDriver) states() (string, string) {
+	return "available", "paused"
+}
+
+// snapshotConfig returns nothing, the node type of a cluster is not changed while it is paused.
+func (redshiftDriver) snapshotConfig(logr.Logger, string, []string) (map[string]string, error) {
+	return nil, nil
+}
+
+func (redshiftDriver) restoreConfig(logr.Logger, string, string, string) error {
+	return nil
+}
@@ -0,0 +1,266 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// maxStatusTransitions is the number of status transitions kept per resource.
+const maxStatusTransitions = 10
+
+// redshiftSettleTimeout is the time a cluster may stay in transition, e.g. pausing, clusters which
+// do not leave their status within it are reported as failed.
+const redshiftSettleTimeout = time.Hour
+
+// redshiftOperations are the redshift operations performed on the clusters.
+type redshiftOperations interface {
+	describeClusters(logger logr.Logger, region string, identifiers []string) ([]utils.RedshiftCluster, error)
+	pauseCluster(logger logr.Logger, region, identifier string) error
+	resumeCluster(logger logr.Logger, region, identifier string) error
+}
+
+// awsRedshiftOperations performs the redshift operations through the aws cli.
+type awsRedshiftOperations struct{}
+
+func (awsRedshiftOperations) describeClusters(logger logr.Logger, region string, identifiers []string) ([]utils.RedshiftCluster, error) {
+	return utils.DescribeRedshiftClusters(logger, region, identifiers)
+}
+
+func (awsRedshiftOperations) pauseCluster(logger logr.Logger, region, identifier string) error {
+	return utils.PauseRedshiftCluster(logger, region, identifier)
+}
+
+func (awsRedshiftOperations) resumeCluster(logger logr.Logger, region, identifier string) error {
+	return utils.ResumeRedshiftCluster(logger, region, identifier)
+}
+
+// RedshiftCostOptimizerReconciler reconciles a RedshiftCostOptimizer object
+type RedshiftCostOptimizerReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	logger   logr.Logger
+	// operations performed on the clusters, the aws cli is used if not set.
+	operations redshiftOperations
+}
+
+// redshift returns the operations performed on the clusters.
+func (r *RedshiftCostOptimizerReconciler) redshift() redshiftOperations {
+	if r.operations == nil {
+		return awsRedshiftOperations{}
+	}
+	return r.operations
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RedshiftCostOptimizerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.RedshiftCostOptimizer{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=redshiftcostoptimizers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=redshiftcostoptimizers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=redshiftcostoptimizers/finalizers,verbs=update
+
+// Reconcile pauses/resumes the selected clusters right away or within the time window through the
+// redshift driver. An onDemand operation is completed once all the clusters reached the
+// paused/available status, it fails right away if clusters do not exist or are stuck in transition,
+// other failures are retried within the retry budget of the object.
+func (r *RedshiftCostOptimizerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling RedshiftCostOptimizer ...")
+
+	redshiftCostOptimizer := &costoptimizerv1alpha1.RedshiftCostOptimizer{}
+	if err := r.Get(ctx, req.NamespacedName, redshiftCostOptimizer); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	switch redshiftCostOptimizer.Spec.WindowType {
+	case costoptimizerv1alpha1.OnDemand:
+		return reconcileOnDemand(r.logger, r.Recorder, r.driverOperation(ctx, redshiftCostOptimizer))
+	case costoptimizerv1alpha1.Scheduled:
+		return reconcileScheduled(r.logger, r.Recorder, r.driverOperation(ctx, redshiftCostOptimizer))
+	default:
+		r.logger.V(1).Info("invalid window type specified")
+	}
+	return ctrl.Result{}, nil
+}
+
+// driver returns the driver of the clusters.
+func (r *RedshiftCostOptimizerReconciler) driver() redshiftDriver {
+	return redshiftDriver{operations: r.redshift()}
+}
+
+// driverOperation returns the pause/resume operation of the object on its clusters.
+func (r *RedshiftCostOptimizerReconciler) driverOperation(ctx context.Context,
+	redshiftCostOptimizer *costoptimizerv1alpha1.RedshiftCostOptimizer) driverOperation {
+	spec := redshiftCostOptimizer.Spec
+	return driverOperation{
+		obj:             redshiftCostOptimizer,
+		driver:          r.driver(),
+		region:          spec.Region,
+		operation:       spec.Operation,
+		windowType:      spec.WindowType,
+		startTimeWindow: spec.StartTimeWindow,
+		endTimeWindow:   spec.EndTimeWindow,
+		retryPolicy:     spec.RetryPolicy,
+		status:          &redshiftCostOptimizer.Status.OperationStatus,
+		patchStatus: func(mutate func(status *costoptimizerv1alpha1.OperationStatus)) {
+			r.patchStatus(ctx, redshiftCostOptimizer, func(status *costoptimizerv1alpha1.RedshiftCostOptimizerStatus) {
+				mutate(&status.OperationStatus)
+			})
+		},
+		operate: func() ([]string, error) {
+			return r.handleClusters(ctx, redshiftCostOptimizer)
+		},
+	}
+}
+
+// handleClusters performs the operation on the clusters which are not yet in the desired status,
+// clusters in transition are left alone until they settle. The clusters which are expected to
+// reach the desired status are returned, clusters which do not exist or did not leave their
+// transition status within redshiftSettleTimeout are reported with utils.ErrNotSettled.
+func (r *RedshiftCostOptimizerReconciler) handleClusters(ctx context.Context, redshiftCostOptimizer *costoptimizerv1alpha1.RedshiftCostOptimizer) ([]string, error) {
+	driver := r.driver()
+	statuses, err := driver.getState(r.logger, redshiftCostOptimizer.Spec.Region, redshiftCostOptimizer.Spec.ClusterIdentifiers)
+	if err != nil {
+		return nil, err
+	}
+	previous := map[string]costoptimizerv1alpha1.RedshiftClusterStatus{}
+	for _, cluster := range redshiftCostOptimizer.Status.Clusters {
+		previous[cluster.Identifier] = cluster
+	}
+
+	now := metav1.Now()
+	failedClusters := 0
+	var settling []string
+	var firstErr error
+	clusters := make([]costoptimizerv1alpha1.RedshiftClusterStatus, 0, len(redshiftCostOptimizer.Spec.ClusterIdentifiers))
+	for _, identifier := range redshiftCostOptimizer.Spec.ClusterIdentifiers {
+		cluster, ok := previous[identifier]
+		if !ok {
+			cluster = costoptimizerv1alpha1.RedshiftClusterStatus{Identifier: identifier}
+		}
+		status, found := statuses[identifier]
+		if status != cluster.Status {
+			cluster.Transitions = appendStatusTransition(cluster.Transitions, cluster.Status, status, now)
+			cluster.Status = status
+		}
+		switch {
+		case !found:
+			failedClusters++
+			cluster.Message = "cluster not found"
+		case status == desiredState(driver, redshiftCostOptimizer.Spec.Operation):
+			cluster.Message = ""
+		case r.redshiftStuck(redshiftCostOptimizer.Spec.Operation, cluster, now.Time):
+			failedClusters++
+			cluster.Message = fmt.Sprintf("cluster did not leave status %s within %s", status, redshiftSettleTimeout)
+		default:
+			if err := r.handleCluster(redshiftCostOptimizer, &cluster); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if found && cluster.Message == "" {
+			settling = append(settling, identifier)
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	r.patchStatus(ctx, redshiftCostOptimizer, func(status *costoptimizerv1alpha1.RedshiftCostOptimizerStatus) {
+		status.Clusters = clusters
+	})
+	if firstErr == nil && failedClusters > 0 {
+		// waiting will not help, the object is processed again once its spec is changed.
+		firstErr = fmt.Errorf("%d cluster(s) not found or stuck in transition: %w", failedClusters, utils.ErrNotSettled)
+	}
+	return settling, firstErr
+}
+
+// redshiftStuck returns true if the cluster is in transition for longer than redshiftSettleTimeout,
+// counted from the last status change observed.
+func (r *RedshiftCostOptimizerReconciler) redshiftStuck(operation costoptimizerv1alpha1.Ec2OperationType,
+	cluster costoptimizerv1alpha1.RedshiftClusterStatus, now time.Time) bool {
+	if actionRequired(r.driver(), operation, cluster.Status) || len(cluster.Transitions) == 0 {
+		return false
+	}
+	return now.Sub(cluster.Transitions[len(cluster.Transitions)-1].Time.Time) > redshiftSettleTimeout
+}
+
+// handleCluster performs the operation on the cluster through the driver if it is required by its
+// status, resource specific errors are recorded in the status of the cluster only.
+func (r *RedshiftCostOptimizerReconciler) handleCluster(redshiftCostOptimizer *costoptimizerv1alpha1.RedshiftCostOptimizer,
+	cluster *costoptimizerv1alpha1.RedshiftClusterStatus) error {
+	operation := redshiftCostOptimizer.Spec.Operation
+	driver := r.driver()
+	if !actionRequired(driver, operation, cluster.Status) {
+		// in transition.
+		cluster.Message = ""
+		return nil
+	}
+
+	skipped, err := applyDriverAction(r.logger, r.Recorder, redshiftCostOptimizer, driver, operation,
+		redshiftCostOptimizer.Spec.Region, []string{cluster.Identifier})
+	if err != nil {
+		cluster.Message = err.Error()
+		return err
+	}
+	if len(skipped) > 0 {
+		reason, _ := utils.ClassifyError(skipped[0].Err)
+		cluster.Message = skipped[0].Err.Error()
+		r.Recorder.Eventf(redshiftCostOptimizer, corev1.EventTypeWarning, eventReasonInstanceSkipped,
+			"%s skipped cluster %s with reason %s: %v", operation, cluster.Identifier, reason, skipped[0].Err)
+		return nil
+	}
+	now := metav1.Now()
+	cluster.LastAction = operation
+	cluster.LastActionTime = &now
+	cluster.Message = ""
+	return nil
+}
+
+// appendStatusTransition appends the transition, dropping the oldest transitions beyond
+// maxStatusTransitions.
+func appendStatusTransition(transitions []costoptimizerv1alpha1.StatusTransition, from, to string, now metav1.Time) []costoptimizerv1alpha1.StatusTransition {
+	transitions = append(transitions, costoptimizerv1alpha1.StatusTransition{From: from, To: to, Time: now})
+	if len(transitions) > maxStatusTransitions {
+		transitions = transitions[len(transitions)-maxStatusTransitions:]
+	}
+	return transitions
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *RedshiftCostOptimizerReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.RedshiftCostOptimizer,
+	mutate func(status *costoptimizerv1alpha1.RedshiftCostOptimizerStatus)) {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+	}
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// fakeRedshiftOperations performs the redshift operations on the clusters, the operations fail
+// with err. The operations performed are recorded in order.
+type fakeRedshiftOperations struct {
+	statuses   map[string]string
+	err        error
+	operations []string
+}
+
+func (f *fakeRedshiftOperations) describeClusters(logr.Logger, string, []string) ([]utils.RedshiftCluster, error) {
+	var clusters []utils.RedshiftCluster
+	for identifier, status := range f.statuses {
+		clusters = append(clusters, utils.RedshiftCluster{Identifier: identifier, Status: status})
+	}
+	return clusters, nil
+}
+
+func (f *fakeRedshiftOperations) pauseCluster(_ logr.Logger, _, identifier string) error {
+	f.operations = append(f.operations, "pause "+identifier)
+	return f.err
+}
+
+func (f *fakeRedshiftOperations) resumeCluster(_ logr.Logger, _, identifier string) error {
+	f.operations = append(f.operations, "resume "+identifier)
+	return f.err
+}
+
+func TestAppendStatusTransition(t *testing.T) {
+	now := metav1.NewTime(time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC))
+	transitions := appendStatusTransition(nil, "", "available", now)
+	if len(transitions) != 1 || transitions[0].From != "" || transitions[0].To != "available" {
+		t.Fatalf("unexpected transitions %+v", transitions)
+	}
+
+	var full []costoptimizerv1alpha1.StatusTransition
+	for i := 0; i < maxStatusTransitions; i++ {
+		full = append(full, costoptimizerv1alpha1.StatusTransition{From: fmt.Sprint(i), To: fmt.Sprint(i + 1), Time: now})
+	}
+	transitions = appendStatusTransition(full, "pausing", "paused", now)
+	if len(transitions) != maxStatusTransitions {
+		t.Fatalf("expected %d transitions, got %d", maxStatusTransitions, len(transitions))
+	}
+	if transitions[0].From != "1" || transitions[len(transitions)-1].To != "paused" {
+		t.Errorf("expected the oldest transition to be dropped, got %+v", transitions)
+	}
+}
+
+func TestHandleClusters(t *testing.T) {
+	recent := metav1.NewTime(time.Now().Add(-time.Minute))
+	old := metav1.NewTime(time.Now().Add(-redshiftSettleTimeout - time.Minute))
+	throttled := &utils.AWSError{Code: "Throttling", Operation: "PauseCluster", Message: "rate exceeded"}
+	incorrectState := &utils.AWSError{Code: "InvalidClusterState", Operation: "PauseCluster", Message: "pausing"}
+
+	tests := []struct {
+		name       string
+		operation  costoptimizerv1alpha1.Ec2OperationType
+		status     string
+		previous   *costoptimizerv1alpha1.RedshiftClusterStatus
+		opErr      error
+		settling   []string
+		err        error
+		operations []string
+		events     []string
+	}{
+		{
+			name:       "pause",
+			operation:  costoptimizerv1alpha1.Stop,
+			status:     "available",
+			settling:   []string{"cluster-1"},
+			operations: []string{"pause cluster-1"},
+			events:     []string{eventReasonStopIssued},
+		},
+		{
+			name:       "resume",
+			operation:  costoptimizerv1alpha1.Start,
+			status:     "paused",
+			settling:   []string{"cluster-1"},
+			operations: []string{"resume cluster-1"},
+			events:     []string{eventReasonStartIssued},
+		},
+		{
+			name:      "settled",
+			operation: costoptimizerv1alpha1.Stop,
+			status:    "paused",
+			settling:  []string{"cluster-1"},
+		},
+		{
+			name:      "in transition",
+			operation: costoptimizerv1alpha1.Stop,
+			status:    "pausing",
+			previous: &costoptimizerv1alpha1.RedshiftClusterStatus{Identifier: "cluster-1", Status: "pausing",
+				Transitions: []costoptimizerv1alpha1.StatusTransition{{From: "available", To: "pausing", Time: recent}}},
+			settling: []string{"cluster-1"},
+		},
+		{
+			name:      "stuck in transition",
+			operation: costoptimizerv1alpha1.Stop,
+			status:    "pausing",
+			previous: &costoptimizerv1alpha1.RedshiftClusterStatus{Identifier: "cluster-1", Status: "pausing",
+				Transitions: []costoptimizerv1alpha1.StatusTransition{{From: "available", To: "pausing", Time: old}}},
+			err: utils.ErrNotSettled,
+		},
+		{
+			name:      "not found",
+			operation: costoptimizerv1alpha1.Stop,
+			err:       utils.ErrNotSettled,
+		},
+		{
+			name:       "operation failed",
+			operation:  costoptimizerv1alpha1.Stop,
+			status:     "available",
+			opErr:      throttled,
+			err:        throttled,
+			operations: []string{"pause cluster-1"},
+			events:     []string{eventReasonOperationFailed},
+		},
+		{
+			name:       "operation skipped",
+			operation:  costoptimizerv1alpha1.Stop,
+			status:     "available",
+			opErr:      incorrectState,
+			operations: []string{"pause cluster-1"},
+			events:     []string{eventReasonInstanceSkipped},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			redshift := &fakeRedshiftOperations{statuses: map[string]string{}, err: test.opErr}
+			if test.status != "" {
+				redshift.statuses["cluster-1"] = test.status
+			}
+			obj := &costoptimizerv1alpha1.RedshiftCostOptimizer{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "redshift"},
+				Spec: costoptimizerv1alpha1.RedshiftCostOptimizerSpec{ClusterIdentifiers: []string{"cluster-1"},
+					Operation: test.operation, WindowType: costoptimizerv1alpha1.OnDemand},
+			}
+			if test.previous != nil {
+				obj.Status.Clusters = []costoptimizerv1alpha1.RedshiftClusterStatus{*test.previous}
+			}
+			recorder := record.NewFakeRecorder(10)
+			r := &RedshiftCostOptimizerReconciler{Client: &fakeClient{object: obj}, Recorder: recorder, logger: logr.Discard(),
+				operations: redshift}
+
+			settling, err := r.handleClusters(context.Background(), obj)
+			if !errors.Is(err, test.err) {
+				t.Errorf("expected error %v, got %v", test.err, err)
+			}
+			if !reflect.DeepEqual(settling, test.settling) {
+				t.Errorf("expected settling clusters %v, got %v", test.settling, settling)
+			}
+			if cluster := obj.Status.Clusters[0]; (cluster.Message != "") != (len(test.settling) == 0) {
+				t.Errorf("unexpected message %q", cluster.Message)
+			}
+			if !reflect.DeepEqual(redshift.operations, test.operations) {
+				t.Errorf("expected operations %v, got %v", test.operations, redshift.operations)
+			}
+			if events := eventReasons(recorder); !reflect.DeepEqual(events, test.events) {
+				t.Errorf("expected events %v, got %v", test.events, events)
+			}
+		})
+	}
+}
+
+func TestReconcileRedshiftOnDemandClusterNotFound(t *testing.T) {
+	obj := &costoptimizerv1alpha1.RedshiftCostOptimizer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "redshift", Generation: 1},
+		Spec: costoptimizerv1alpha1.RedshiftCostOptimizerSpec{ClusterIdentifiers: []string{"cluster-1", "cluster-2"},
+			Operation: costoptimizerv1alpha1.Stop, WindowType: costoptimizerv1alpha1.OnDemand},
+	}
+	redshift := &fakeRedshiftOperations{statuses: map[string]string{"cluster-1": "paused"}}
+	c := &fakeClient{object: obj}
+	r := &RedshiftCostOptimizerReconciler{Client: c, Recorder: record.NewFakeRecorder(10), logger: logr.Discard(),
+		operations: redshift}
+
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "redshift"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RequeueAfter != 0 {
+		t.Errorf("expected the object not to be requeued, got %+v", result)
+	}
+	status := c.object.(*costoptimizerv1alpha1.RedshiftCostOptimizer).Status
+	if state := status.State; state != fmt.Sprintf("%s/%s", costoptimizerv1alpha1.OnDemand, failed) {
+		t.Errorf("expected the operation to fail, got %s", state)
+	}
+}
@@ -0,0 +1,282 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/metrics"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// states of a resized instance.
+const (
+	resizeResized      = "Resized"
+	resizeStarting     = "Starting"
+	resizeStarted      = "Started"
+	resizeRolledBack   = "RolledBack"
+	resizeIncompatible = "Incompatible"
+	resizeFailed       = "Failed"
+)
+
+// resizeOperation is the operation label of the metrics recorded for instance type changes.
+const resizeOperation = "Resize"
+
+// resizeEnabled returns true if the object resizes its instances, only scheduled stops do as
+// the instances have to be stopped to change their type.
+func resizeEnabled(ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer) bool {
+	return ec2CostOptimizer.Spec.Resize != nil && ec2CostOptimizer.Spec.WindowType == costoptimizerv1alpha1.Scheduled &&
+		ec2CostOptimizer.Spec.Operation == costoptimizerv1alpha1.Stop
+}
+
+// resizeInstances changes the type of the stopped instances to their target type, instances
+// which are not yet stopped are resized in a later reconciliation. Instances whose resize got
+// rolled back or was found incompatible are not resized to the same type again.
+func (r *Ec2CostOptimizerReconciler) resizeInstances(ctx context.Context, ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer) error {
+	targets, err := r.resizeTargets(ctx, ec2CostOptimizer)
+	if err != nil {
+		return err
+	}
+	instances, err := utils.DescribeEc2Instances(r.logger, ec2CostOptimizer.Spec.Region, ec2CostOptimizer.Spec.InstanceIDs)
+	if err != nil {
+		return err
+	}
+
+	region := utils.ResolveRegion(ec2CostOptimizer.Spec.Region)
+	resized := resizedInstances(ec2CostOptimizer)
+	// only the instances stopped by the object were running before the window.
+	stoppedByUs := map[string]bool{}
+	for _, instanceID := range stoppedByObject(ec2CostOptimizer, instances) {
+		stoppedByUs[instanceID] = true
+	}
+	instanceTypes := map[string]utils.Ec2InstanceType{}
+	var resizeErr error
+	for _, instance := range instances {
+		target := targets[instance.InstanceID]
+		if instance.State != "stopped" || target == "" || target == instance.InstanceType {
+			continue
+		}
+		if previous, ok := resized[instance.InstanceID]; ok && previous.InstanceType == target &&
+			(previous.State == resizeRolledBack || previous.State == resizeIncompatible) {
+			continue
+		}
+
+		instanceType, ok := instanceTypes[target]
+		if !ok {
+			if instanceType, err = utils.DescribeEc2InstanceType(r.logger, region, target); err != nil {
+				return err
+			}
+			instanceTypes[target] = instanceType
+		}
+
+		entry := costoptimizerv1alpha1.ResizedInstance{
+			InstanceID:           instance.InstanceID,
+			PreviousInstanceType: instance.InstanceType,
+			InstanceType:         target,
+			Restart:              stoppedByUs[instance.InstanceID],
+			LastTransitionTime:   metav1.Now(),
+		}
+		if err := utils.CheckInstanceTypeCompatibility(instance, instanceType); err != nil {
+			entry.State, entry.Message = resizeIncompatible, err.Error()
+			r.Recorder.Eventf(ec2CostOptimizer, corev1.EventTypeWarning, eventReasonResizeIncompatible,
+				"Not resizing instance %s: %v", instance.InstanceID, err)
+			resized[instance.InstanceID] = entry
+			continue
+		}
+
+		metrics.OperationAttempted(metrics.Ec2Kind, resizeOperation, region)
+		if err := utils.ModifyEc2InstanceType(r.logger, region, instance.InstanceID, target); err != nil {
+			reason, _ := utils.ClassifyError(err)
+			metrics.OperationFailed(metrics.Ec2Kind, resizeOperation, region, reason)
+			entry.State, entry.Message = resizeFailed, err.Error()
+			r.Recorder.Eventf(ec2CostOptimizer, corev1.EventTypeWarning, eventReasonResizeFailed,
+				"Resizing instance %s to %s failed with reason %s: %v", instance.InstanceID, target, reason, err)
+			resized[instance.InstanceID] = entry
+			resizeErr = err
+			continue
+		}
+		metrics.OperationSucceeded(metrics.Ec2Kind, resizeOperation, region)
+		entry.State = resizeResized
+		r.Recorder.Eventf(ec2CostOptimizer, corev1.EventTypeNormal, eventReasonResized,
+			"Resized instance %s from %s to %s", instance.InstanceID, instance.InstanceType, target)
+		resized[instance.InstanceID] = entry
+	}
+
+	r.setResizedInstances(ctx, ec2CostOptimizer, resized)
+	return resizeErr
+}
+
+// instancesToStart returns the resized instances to start at the end of the time window, the
+// instances which were running before it and the instances still starting.
+func instancesToStart(resized map[string]costoptimizerv1alpha1.ResizedInstance) []string {
+	var instanceIDs []string
+	for instanceID, entry := range resized {
+		if (entry.State == resizeResized && entry.Restart) || entry.State == resizeStarting {
+			instanceIDs = append(instanceIDs, instanceID)
+		}
+	}
+	sort.Strings(instanceIDs)
+	return instanceIDs
+}
+
+// startResizedInstances starts the instances resized during the time window which were running
+// before it, so that the new instance type is verified. Instances which were already stopped are
+// kept stopped, a Stop never starts them. Instances failing to start are only detected on a later
+// reconciliation as start failures may be asynchronous, the instance goes from pending back to
+// stopped, hence the instances are Starting until they are found running. If an instance fails
+// to start because of its new type, its previous type is restored before starting it again.
+func (r *Ec2CostOptimizerReconciler) startResizedInstances(ctx context.Context, ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer) error {
+	resized := resizedInstances(ec2CostOptimizer)
+	instanceIDs := instancesToStart(resized)
+	if len(instanceIDs) == 0 {
+		return nil
+	}
+	instances, err := utils.DescribeEc2Instances(r.logger, ec2CostOptimizer.Spec.Region, instanceIDs)
+	if err != nil {
+		return err
+	}
+
+	region := utils.ResolveRegion(ec2CostOptimizer.Spec.Region)
+	var startErr error
+	for _, instance := range instances {
+		entry := resized[instance.InstanceID]
+		switch {
+		case instance.State == "running":
+			// started by us or by someone else.
+			entry.State, entry.Message, entry.LastTransitionTime = resizeStarted, "", metav1.Now()
+		case instance.State != "stopped":
+			// check again once the instance settled.
+			continue
+		case entry.State == resizeStarting:
+			// the start got accepted but the instance did not come up.
+			entry = r.rollbackStart(ec2CostOptimizer, region, entry,
+				errors.New("instance stopped while starting: "+instance.StateReason))
+		default:
+			entry, err = r.startResized(ec2CostOptimizer, region, entry)
+			if err != nil {
+				startErr = err
+			}
+		}
+		resized[instance.InstanceID] = entry
+	}
+
+	r.setResizedInstances(ctx, ec2CostOptimizer, resized)
+	return startErr
+}
+
+// startResized starts the stopped resized instance, the instance is Starting if the start got
+// accepted. Errors caused by the instance type roll the resize back, other errors are returned
+// and the start is tried again in the next reconciliation, without touching the instance type.
+func (r *Ec2CostOptimizerReconciler) startResized(ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer, region string,
+	entry costoptimizerv1alpha1.ResizedInstance) (costoptimizerv1alpha1.ResizedInstance, error) {
+	skipped, err := utils.StartEc2Instance(r.logger, region, []string{entry.InstanceID})
+	if err == nil && len(skipped) > 0 {
+		err = skipped[0].Err
+	}
+	switch {
+	case err == nil:
+		entry.State, entry.Message, entry.LastTransitionTime = resizeStarting, "", metav1.Now()
+		return entry, nil
+	case utils.IsInstanceTypeStartError(err):
+		return r.rollbackStart(ec2CostOptimizer, region, entry, err), nil
+	}
+
+	reason, action := utils.ClassifyError(err)
+	if action == utils.ActionFail && entry.Message != err.Error() {
+		r.Recorder.Eventf(ec2CostOptimizer, corev1.EventTypeWarning, eventReasonResizeFailed,
+			"Unable to start resized instance %s with reason %s: %v", entry.InstanceID, reason, err)
+	}
+	entry.Message = err.Error()
+	return entry, err
+}
+
+// rollbackStart restores the previous instance type of an instance which failed to start with
+// its new type and starts it again.
+func (r *Ec2CostOptimizerReconciler) rollbackStart(ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer, region string,
+	entry costoptimizerv1alpha1.ResizedInstance, startErr error) costoptimizerv1alpha1.ResizedInstance {
+	r.logger.Error(startErr, "unable to start resized instance, rolling back", "instance", entry.InstanceID)
+	entry.LastTransitionTime = metav1.Now()
+	if err := r.rollbackResize(region, entry); err != nil {
+		entry.State = resizeFailed
+		entry.Message = fmt.Sprintf("start failed: %v, rollback to %s failed: %v", startErr, entry.PreviousInstanceType, err)
+		r.Recorder.Eventf(ec2CostOptimizer, corev1.EventTypeWarning, eventReasonResizeFailed,
+			"Instance %s: %s", entry.InstanceID, entry.Message)
+		return entry
+	}
+	entry.State = resizeRolledBack
+	entry.Message = fmt.Sprintf("start failed: %v", startErr)
+	r.Recorder.Eventf(ec2CostOptimizer, corev1.EventTypeWarning, eventReasonResizeRolledBack,
+		"Instance %s failed to start as %s, rolled back to %s: %v", entry.InstanceID, entry.InstanceType,
+		entry.PreviousInstanceType, startErr)
+	return entry
+}
+
+// rollbackResize restores the previous instance type of the instance and starts it.
+func (r *Ec2CostOptimizerReconciler) rollbackResize(region string, entry costoptimizerv1alpha1.ResizedInstance) error {
+	ec2, err := requireDriver(metrics.Ec2Kind)
+	if err != nil {
+		return err
+	}
+	metrics.OperationAttempted(metrics.Ec2Kind, resizeOperation, region)
+	if err := ec2.restoreConfig(r.logger, region, entry.InstanceID, entry.PreviousInstanceType); err != nil {
+		reason, _ := utils.ClassifyError(err)
+		metrics.OperationFailed(metrics.Ec2Kind, resizeOperation, region, reason)
+		return err
+	}
+	metrics.OperationSucceeded(metrics.Ec2Kind, resizeOperation, region)
+	_, err = ec2.applyAction(r.logger, region, costoptimizerv1alpha1.Start, []string{entry.InstanceID})
+	return err
+}
+
+// resizeTargets returns the target instance type by instance id.
+func (r *Ec2CostOptimizerReconciler) resizeTargets(ctx context.Context, ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer) (map[string]string, error) {
+	policy := ec2CostOptimizer.Spec.Resize
+	targets := map[string]string{}
+	switch {
+	case policy.TargetInstanceType != "":
+		for _, instanceID := range ec2CostOptimizer.Spec.InstanceIDs {
+			targets[instanceID] = policy.TargetInstanceType
+		}
+	case policy.FromReport != "":
+		report := &costoptimizerv1alpha1.Ec2RightsizingReport{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: ec2CostOptimizer.Namespace, Name: policy.FromReport}, report); err != nil {
+			return nil, fmt.Errorf("unable to get rightsizing report %s: %w", policy.FromReport, err)
+		}
+		for _, instance := range report.Status.Instances {
+			if len(instance.Recommendations) > 0 {
+				targets[instance.InstanceID] = instance.Recommendations[0].InstanceType
+			}
+		}
+	default:
+		r.logger.Info("resize requires a target instance type or a rightsizing report")
+	}
+	return targets, nil
+}
+
+func resizedInstances(ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer) map[string]costoptimizerv1alpha1.ResizedInstance {
+	resized := map[string]costoptimizerv1alpha1.ResizedInstance{}
+	for _, entry := range ec2CostOptimizer.Status.ResizedInstances {
+		resized[entry.InstanceID] = entry
+	}
+	return resized
+}
+
+// setResizedInstances records the resized instances in the status, in the order of the spec.
+func (r *Ec2CostOptimizerReconciler) setResizedInstances(ctx context.Context, ec2CostOptimizer *costoptimizerv1alpha1.Ec2CostOptimizer,
+	resized map[string]costoptimizerv1alpha1.ResizedInstance) {
+	entries := make([]costoptimizerv1alpha1.ResizedInstance, 0, len(resized))
+	for _, instanceID := range ec2CostOptimizer.Spec.InstanceIDs {
+		if entry, ok := resized[instanceID]; ok {
+			entries = append(entries, entry)
+		}
+	}
+	r.patchStatus(ctx, ec2CostOptimizer, func(status *costoptimizerv1alpha1.Ec2CostOptimizerStatus) {
+		status.ResizedInstances = entries
+	})
+}
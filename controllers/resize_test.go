@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+)
+
+func TestInstancesToStart(t *testing.T) {
+	tests := []struct {
+		name    string
+		resized []costoptimizerv1alpha1.ResizedInstance
+		want    []string
+	}{
+		{
+			name: "resized instances running before the window are started",
+			resized: []costoptimizerv1alpha1.ResizedInstance{
+				{InstanceID: "i-2", State: resizeResized, Restart: true},
+				{InstanceID: "i-1", State: resizeResized, Restart: true},
+			},
+			want: []string{"i-1", "i-2"},
+		},
+		{
+			name: "resized instances stopped before the window are kept stopped",
+			resized: []costoptimizerv1alpha1.ResizedInstance{
+				{InstanceID: "i-1", State: resizeResized},
+				{InstanceID: "i-2", State: resizeResized, Restart: true},
+			},
+			want: []string{"i-2"},
+		},
+		{
+			name: "starting instances are checked again",
+			resized: []costoptimizerv1alpha1.ResizedInstance{
+				{InstanceID: "i-1", State: resizeStarting},
+			},
+			want: []string{"i-1"},
+		},
+		{
+			name: "settled instances are not started",
+			resized: []costoptimizerv1alpha1.ResizedInstance{
+				{InstanceID: "i-1", State: resizeStarted, Restart: true},
+				{InstanceID: "i-2", State: resizeRolledBack, Restart: true},
+				{InstanceID: "i-3", State: resizeFailed, Restart: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &costoptimizerv1alpha1.Ec2CostOptimizer{}
+			obj.Status.ResizedInstances = tt.resized
+			if got := instancesToStart(resizedInstances(obj)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("instancesToStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
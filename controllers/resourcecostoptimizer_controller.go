@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// ResourceCostOptimizerReconciler reconciles a ResourceCostOptimizer object
+type ResourceCostOptimizerReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	logger   logr.Logger
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ResourceCostOptimizerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.ResourceCostOptimizer{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=resourcecostoptimizers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=resourcecostoptimizers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=resourcecostoptimizers/finalizers,verbs=update
+
+// Reconcile starts/stops the selected resources right away or within the time window through the
+// driver of their type, the same way Ec2CostOptimizer does for ec2 instances.
+func (r *ResourceCostOptimizerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling ResourceCostOptimizer ...")
+
+	resourceCostOptimizer := &costoptimizerv1alpha1.ResourceCostOptimizer{}
+	if err := r.Get(ctx, req.NamespacedName, resourceCostOptimizer); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	driver, ok := driverFor(string(resourceCostOptimizer.Spec.ResourceType))
+	if !ok {
+		r.logger.V(1).Info("invalid resource type specified", "type", resourceCostOptimizer.Spec.ResourceType)
+		return ctrl.Result{}, nil
+	}
+	if len(resourceCostOptimizer.Spec.ResourceIDs) == 0 && len(resourceCostOptimizer.Spec.Tags) == 0 {
+		r.logger.Info("either resource ids or tags have to be specified")
+		return ctrl.Result{}, nil
+	}
+
+	switch resourceCostOptimizer.Spec.WindowType {
+	case costoptimizerv1alpha1.OnDemand:
+		return reconcileOnDemand(r.logger, r.Recorder, r.driverOperation(ctx, resourceCostOptimizer, driver))
+	case costoptimizerv1alpha1.Scheduled:
+		return reconcileScheduled(r.logger, r.Recorder, r.driverOperation(ctx, resourceCostOptimizer, driver))
+	default:
+		r.logger.V(1).Info("invalid window type specified")
+	}
+	return ctrl.Result{}, nil
+}
+
+// driverOperation returns the operation of the object on the selected resources.
+func (r *ResourceCostOptimizerReconciler) driverOperation(ctx context.Context,
+	resourceCostOptimizer *costoptimizerv1alpha1.ResourceCostOptimizer, driver resourceDriver) driverOperation {
+	spec := resourceCostOptimizer.Spec
+	return driverOperation{
+		obj:             resourceCostOptimizer,
+		driver:          driver,
+		region:          spec.Region,
+		operation:       spec.Operation,
+		windowType:      spec.WindowType,
+		startTimeWindow: spec.StartTimeWindow,
+		endTimeWindow:   spec.EndTimeWindow,
+		retryPolicy:     spec.RetryPolicy,
+		status:          &resourceCostOptimizer.Status.OperationStatus,
+		patchStatus: func(mutate func(status *costoptimizerv1alpha1.OperationStatus)) {
+			r.patchStatus(ctx, resourceCostOptimizer, func(status *costoptimizerv1alpha1.ResourceCostOptimizerStatus) {
+				mutate(&status.OperationStatus)
+			})
+		},
+		operate: func() ([]string, error) {
+			return r.handleResources(ctx, resourceCostOptimizer, driver)
+		},
+	}
+}
+
+// handleResources performs the operation on the selected resources which are not yet in the
+// desired state, resources in transition are left alone until they settle. The resources which
+// are expected to reach the state of the operation are returned.
+func (r *ResourceCostOptimizerReconciler) handleResources(ctx context.Context,
+	resourceCostOptimizer *costoptimizerv1alpha1.ResourceCostOptimizer, driver resourceDriver) ([]string, error) {
+	spec := resourceCostOptimizer.Spec
+	targets, err := driver.resolveTargets(r.logger, spec.Region, spec.ResourceIDs, spec.Tags)
+	if err != nil {
+		return nil, err
+	}
+	states := map[string]string{}
+	if len(targets) > 0 {
+		if states, err = driver.getState(r.logger, spec.Region, targets); err != nil {
+			return nil, err
+		}
+	}
+	previous := map[string]costoptimizerv1alpha1.SchedulableResourceStatus{}
+	for _, status := range resourceCostOptimizer.Status.Resources {
+		previous[status.ID] = status
+	}
+
+	ids := selectedResources(spec.ResourceIDs, targets)
+	statuses := make([]costoptimizerv1alpha1.SchedulableResourceStatus, 0, len(ids))
+	var settling []string
+	var firstErr error
+	for _, id := range ids {
+		status, ok := previous[id]
+		if !ok {
+			status = costoptimizerv1alpha1.SchedulableResourceStatus{ID: id}
+		}
+		state, found := states[id]
+		status.Status = state
+		switch {
+		case !found:
+			status.Message = fmt.Sprintf("%s resource not found", spec.ResourceType)
+			if len(spec.Tags) > 0 {
+				status.Message = fmt.Sprintf("%s resource not found or not matching the tags", spec.ResourceType)
+			}
+		case actionRequired(driver, spec.Operation, state):
+			if err := r.operateResource(resourceCostOptimizer, driver, &status); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if found && status.Message == "" {
+			settling = append(settling, id)
+		}
+		statuses = append(statuses, status)
+	}
+
+	r.patchStatus(ctx, resourceCostOptimizer, func(status *costoptimizerv1alpha1.ResourceCostOptimizerStatus) {
+		status.Resources = statuses
+	})
+	return settling, firstErr
+}
+
+// operateResource performs the operation on the resource through the driver, resource specific
+// errors are recorded in the status of the resource only.
+func (r *ResourceCostOptimizerReconciler) operateResource(resourceCostOptimizer *costoptimizerv1alpha1.ResourceCostOptimizer,
+	driver resourceDriver, status *costoptimizerv1alpha1.SchedulableResourceStatus) error {
+	spec := resourceCostOptimizer.Spec
+	skipped, err := applyDriverAction(r.logger, r.Recorder, resourceCostOptimizer, driver, spec.Operation, spec.Region, []string{status.ID})
+	if err != nil {
+		status.Message = err.Error()
+		return err
+	}
+	if len(skipped) > 0 {
+		reason, _ := utils.ClassifyError(skipped[0].Err)
+		status.Message = skipped[0].Err.Error()
+		r.Recorder.Eventf(resourceCostOptimizer, corev1.EventTypeWarning, eventReasonInstanceSkipped,
+			"%s skipped %s %s with reason %s: %v", spec.Operation, spec.ResourceType, status.ID, reason, skipped[0].Err)
+		return nil
+	}
+	now := metav1.Now()
+	status.LastAction = spec.Operation
+	status.LastActionTime = &now
+	status.Message = ""
+	return nil
+}
+
+// selectedResources returns the ids of the spec followed by the resolved targets which are not
+// part of the ids, i.e. the resources selected by their tags only.
+func selectedResources(ids, targets []string) []string {
+	selected := append([]string(nil), ids...)
+	for _, target := range targets {
+		if !containsString(ids, target) {
+			selected = append(selected, target)
+		}
+	}
+	return selected
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *ResourceCostOptimizerReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.ResourceCostOptimizer,
+	mutate func(status *costoptimizerv1alpha1.ResourceCostOptimizerStatus)) {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+	}
+}
@@ -0,0 +1,17 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectedResources(t *testing.T) {
+	// ids keep their order, resources selected by their tags only follow them.
+	selected := selectedResources([]string{"b", "a", "missing"}, []string{"a", "b", "c"})
+	if !reflect.DeepEqual(selected, []string{"b", "a", "missing", "c"}) {
+		t.Errorf("unexpected selection %v", selected)
+	}
+	if selected := selectedResources(nil, []string{"c"}); !reflect.DeepEqual(selected, []string{"c"}) {
+		t.Errorf("unexpected selection %v", selected)
+	}
+}
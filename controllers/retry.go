@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = 30 * time.Second
+	defaultMaxBackoff     = 10 * time.Minute
+)
+
+// retryPolicy is the effective retry policy of an object, with defaults applied.
+type retryPolicy struct {
+	maxAttempts    int32
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+func newRetryPolicy(policy *costoptimizerv1alpha1.RetryPolicy) retryPolicy {
+	effective := retryPolicy{
+		maxAttempts:    defaultMaxAttempts,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+	}
+	if policy == nil {
+		return effective
+	}
+	if policy.MaxAttempts > 0 {
+		effective.maxAttempts = policy.MaxAttempts
+	}
+	if policy.InitialBackoff != nil && policy.InitialBackoff.Duration > 0 {
+		effective.initialBackoff = policy.InitialBackoff.Duration
+	}
+	if policy.MaxBackoff != nil && policy.MaxBackoff.Duration > 0 {
+		effective.maxBackoff = policy.MaxBackoff.Duration
+	}
+	if effective.maxBackoff < effective.initialBackoff {
+		effective.maxBackoff = effective.initialBackoff
+	}
+	return effective
+}
+
+// backoff returns the delay before the next attempt, given the number of failed attempts.
+func (p retryPolicy) backoff(attempts int32) time.Duration {
+	backoff := p.initialBackoff
+	for i := int32(1); i < attempts; i++ {
+		backoff *= 2
+		if backoff >= p.maxBackoff {
+			return p.maxBackoff
+		}
+	}
+	return backoff
+}
+
+// nextAttempt returns the number of failed attempts once the attempt failed with err, and the
+// delay before the next attempt. The delay is zero if the operation has to be given up, as the
+// retry budget is exhausted or retrying will not help.
+func (p retryPolicy) nextAttempt(attempts int32, err error) (int32, time.Duration) {
+	attempts++
+	if _, action := utils.ClassifyError(err); action == utils.ActionFail || attempts >= p.maxAttempts {
+		return attempts, 0
+	}
+	return attempts, p.backoff(attempts)
+}
+
+// reconcileOnDemand performs the onDemand operation and waits until the resources reached the
+// state of the operation, failed operations are retried with an exponential backoff until the
+// retry budget of the object is exhausted, after which the object is marked as Failed until its
+// spec is changed. A changed spec is processed again even if the previous operation completed.
+func reconcileOnDemand(logger logr.Logger, recorder record.EventRecorder, op driverOperation) (ctrl.Result, error) {
+	if op.status.ObservedGeneration != op.obj.GetGeneration() {
+		// spec got changed, start over with a fresh retry budget.
+		op.patchStatus(func(status *costoptimizerv1alpha1.OperationStatus) {
+			status.ObservedGeneration = op.obj.GetGeneration()
+			status.Attempts = 0
+			status.NextRetryTime = nil
+		})
+		if op.recordConflicts != nil {
+			op.recordConflicts()
+		}
+	} else {
+		switch op.status.State {
+		case fmt.Sprintf("%s/%s", costoptimizerv1alpha1.OnDemand, complete):
+			logger.V(1).Info("ignoring already processed onDemand object")
+			return ctrl.Result{}, nil
+		case fmt.Sprintf("%s/%s", costoptimizerv1alpha1.OnDemand, failed):
+			logger.V(1).Info("ignoring onDemand object with exhausted retry budget")
+			return ctrl.Result{}, nil
+		}
+	}
+
+	if next := op.status.NextRetryTime; next != nil {
+		if wait := time.Until(next.Time); wait > 0 {
+			logger.V(1).Info("waiting for next retry", "after", wait.String())
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	logger.V(1).Info("Handling onDemand operation", "kind", op.driver.kind(), "type", op.operation)
+	op.setState(inProgress)
+	targets, err := op.operate()
+	if err == nil {
+		var pending []string
+		pending, err = waitForState(logger, op.driver, op.region, op.operation, targets)
+		if err == nil && len(pending) > 0 {
+			logger.V(1).Info("waiting for resources to settle", "pending", pending)
+			return ctrl.Result{RequeueAfter: resourcePollPeriod}, nil
+		}
+	}
+	if err == nil {
+		op.patchStatus(func(status *costoptimizerv1alpha1.OperationStatus) {
+			status.State = fmt.Sprintf("%s/%s", costoptimizerv1alpha1.OnDemand, complete)
+			status.NextRetryTime = nil
+		})
+		if op.completed != nil {
+			op.completed()
+		}
+		return ctrl.Result{}, nil
+	}
+	logger.Error(err, "error processing onDemand operation")
+
+	attempts, backoff := newRetryPolicy(op.retryPolicy).nextAttempt(op.status.Attempts, err)
+	if backoff == 0 {
+		logger.Info("giving up onDemand operation", "attempts", attempts)
+		recorder.Eventf(op.obj, corev1.EventTypeWarning, eventReasonRetryBudgetExhausted,
+			"Giving up %s after %d attempt(s)", op.operation, attempts)
+		op.patchStatus(func(status *costoptimizerv1alpha1.OperationStatus) {
+			status.State = fmt.Sprintf("%s/%s", costoptimizerv1alpha1.OnDemand, failed)
+			status.Attempts = attempts
+			status.NextRetryTime = nil
+		})
+		return ctrl.Result{}, nil
+	}
+
+	// requeue ourselves instead of returning the error, the status updates are filtered out
+	// by the predicate and the rate limiter of the controller would retry forever.
+	nextRetryTime := metav1.NewTime(time.Now().Add(backoff))
+	op.patchStatus(func(status *costoptimizerv1alpha1.OperationStatus) {
+		status.State = fmt.Sprintf("%s/%s", costoptimizerv1alpha1.OnDemand, retrying)
+		status.Attempts = attempts
+		status.NextRetryTime = &nextRetryTime
+	})
+	return ctrl.Result{RequeueAfter: backoff}, nil
+}
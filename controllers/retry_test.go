@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := newRetryPolicy(&costoptimizerv1alpha1.RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: &metav1.Duration{Duration: 10 * time.Second},
+		MaxBackoff:     &metav1.Duration{Duration: time.Minute},
+	})
+	if policy.maxAttempts != 4 {
+		t.Errorf("expected 4 max attempts, got %d", policy.maxAttempts)
+	}
+
+	expected := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for i, want := range expected {
+		if got := policy.backoff(int32(i + 1)); got != want {
+			t.Errorf("attempt %d: expected backoff %s, got %s", i+1, want, got)
+		}
+	}
+}
+
+func TestRetryPolicyDefaults(t *testing.T) {
+	policy := newRetryPolicy(nil)
+	if policy.maxAttempts != defaultMaxAttempts || policy.initialBackoff != defaultInitialBackoff ||
+		policy.maxBackoff != defaultMaxBackoff {
+		t.Errorf("expected default retry policy, got %+v", policy)
+	}
+}
+
+func TestRetryPolicyNextAttempt(t *testing.T) {
+	policy := newRetryPolicy(&costoptimizerv1alpha1.RetryPolicy{MaxAttempts: 3})
+	throttled := &utils.AWSError{Code: "RequestLimitExceeded"}
+
+	if attempts, backoff := policy.nextAttempt(0, throttled); attempts != 1 || backoff != defaultInitialBackoff {
+		t.Errorf("expected first retry after %s, got attempt %d after %s", defaultInitialBackoff, attempts, backoff)
+	}
+	if attempts, backoff := policy.nextAttempt(1, throttled); attempts != 2 || backoff != 2*defaultInitialBackoff {
+		t.Errorf("expected second retry after %s, got attempt %d after %s", 2*defaultInitialBackoff, attempts, backoff)
+	}
+	if attempts, backoff := policy.nextAttempt(2, throttled); attempts != 3 || backoff != 0 {
+		t.Errorf("expected retry budget to be exhausted, got attempt %d after %s", attempts, backoff)
+	}
+	if _, backoff := policy.nextAttempt(0, &utils.AWSError{Code: "UnauthorizedOperation"}); backoff != 0 {
+		t.Errorf("expected unauthorized operation not to be retried, got backoff %s", backoff)
+	}
+}
+
+// fakeDriver reports the states of its resources, the operations are performed by the operate
+// function of the driverOperation in the tests.
+type fakeDriver struct {
+	resourceStates map[string]string
+}
+
+func (fakeDriver) kind() string {
+	return "Fake"
+}
+
+func (fakeDriver) resolveTargets(logr.Logger, string, []string, map[string]string) ([]string, error) {
+	return nil, nil
+}
+
+func (d fakeDriver) getState(_ logr.Logger, _ string, ids []string) (map[string]string, error) {
+	states := map[string]string{}
+	for _, id := range ids {
+		if state, ok := d.resourceStates[id]; ok {
+			states[id] = state
+		}
+	}
+	return states, nil
+}
+
+func (fakeDriver) applyAction(logr.Logger, string, costoptimizerv1alpha1.Ec2OperationType, []string) ([]utils.InstanceError, error) {
+	return nil, nil
+}
+
+func (fakeDriver) states() (string, string) {
+	return "running", "stopped"
+}
+
+func (fakeDriver) snapshotConfig(logr.Logger, string, []string) (map[string]string, error) {
+	return nil, nil
+}
+
+func (fakeDriver) restoreConfig(logr.Logger, string, string, string) error {
+	return nil
+}
+
+func TestReconcileOnDemand(t *testing.T) {
+	onDemand := func(state string) string {
+		return fmt.Sprintf("%s/%s", costoptimizerv1alpha1.OnDemand, state)
+	}
+	tests := []struct {
+		name          string
+		status        costoptimizerv1alpha1.OperationStatus
+		states        map[string]string
+		operateErr    error
+		wantOperated  bool
+		wantState     string
+		wantAttempts  int32
+		wantRequeue   time.Duration
+		wantCompleted bool
+		wantEvents    []string
+	}{
+		{
+			name:         "waits for the resources to settle",
+			states:       map[string]string{"r-1": "stopping"},
+			wantOperated: true,
+			wantState:    onDemand(inProgress),
+			wantRequeue:  resourcePollPeriod,
+		},
+		{
+			name:          "completes once the resources settled",
+			status:        costoptimizerv1alpha1.OperationStatus{State: onDemand(inProgress), ObservedGeneration: 1},
+			states:        map[string]string{"r-1": "stopped"},
+			wantOperated:  true,
+			wantState:     onDemand(complete),
+			wantCompleted: true,
+		},
+		{
+			name:         "retries throttled operations",
+			status:       costoptimizerv1alpha1.OperationStatus{ObservedGeneration: 1},
+			operateErr:   &utils.AWSError{Code: "RequestLimitExceeded"},
+			wantOperated: true,
+			wantState:    onDemand(retrying),
+			wantAttempts: 1,
+			wantRequeue:  defaultInitialBackoff,
+		},
+		{
+			name:         "gives up operations which cannot succeed",
+			status:       costoptimizerv1alpha1.OperationStatus{ObservedGeneration: 1},
+			operateErr:   &utils.AWSError{Code: "UnauthorizedOperation"},
+			wantOperated: true,
+			wantState:    onDemand(failed),
+			wantAttempts: 1,
+			wantEvents:   []string{eventReasonRetryBudgetExhausted},
+		},
+		{
+			name:      "ignores completed operations",
+			status:    costoptimizerv1alpha1.OperationStatus{State: onDemand(complete), ObservedGeneration: 1},
+			wantState: onDemand(complete),
+		},
+		{
+			name:          "performs the operation again once the spec changed",
+			status:        costoptimizerv1alpha1.OperationStatus{State: onDemand(failed), Attempts: 5},
+			states:        map[string]string{"r-1": "stopped"},
+			wantOperated:  true,
+			wantState:     onDemand(complete),
+			wantCompleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &costoptimizerv1alpha1.ResourceCostOptimizer{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 1},
+				Status:     costoptimizerv1alpha1.ResourceCostOptimizerStatus{OperationStatus: tt.status},
+			}
+			recorder := record.NewFakeRecorder(10)
+			operated, completed := false, false
+			op := driverOperation{
+				obj:        obj,
+				driver:     fakeDriver{resourceStates: tt.states},
+				operation:  costoptimizerv1alpha1.Stop,
+				windowType: costoptimizerv1alpha1.OnDemand,
+				status:     &obj.Status.OperationStatus,
+				patchStatus: func(mutate func(status *costoptimizerv1alpha1.OperationStatus)) {
+					mutate(&obj.Status.OperationStatus)
+				},
+				operate: func() ([]string, error) {
+					operated = true
+					if tt.operateErr != nil {
+						return nil, tt.operateErr
+					}
+					return []string{"r-1"}, nil
+				},
+				completed: func() {
+					completed = true
+				},
+			}
+
+			result, err := reconcileOnDemand(logr.Discard(), recorder, op)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if operated != tt.wantOperated {
+				t.Errorf("expected operated %t, got %t", tt.wantOperated, operated)
+			}
+			if completed != tt.wantCompleted {
+				t.Errorf("expected completed %t, got %t", tt.wantCompleted, completed)
+			}
+			if obj.Status.State != tt.wantState {
+				t.Errorf("expected state %s, got %s", tt.wantState, obj.Status.State)
+			}
+			if obj.Status.Attempts != tt.wantAttempts {
+				t.Errorf("expected %d attempt(s), got %d", tt.wantAttempts, obj.Status.Attempts)
+			}
+			if obj.Status.ObservedGeneration != 1 {
+				t.Errorf("expected observed generation 1, got %d", obj.Status.ObservedGeneration)
+			}
+			if result.RequeueAfter != tt.wantRequeue {
+				t.Errorf("expected requeue after %s, got %s", tt.wantRequeue, result.RequeueAfter)
+			}
+			if events := eventReasons(recorder); !reflect.DeepEqual(events, tt.wantEvents) {
+				t.Errorf("expected events %v, got %v", tt.wantEvents, events)
+			}
+		})
+	}
+}
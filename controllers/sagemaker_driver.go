@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+)
+
+// sageMakerNotebookKind is the kind of the notebook instances in the metrics and the events.
+const sageMakerNotebookKind = "SageMakerNotebook"
+
+// sageMakerNotebookDriver drives sagemaker notebook instances, selected by their name. It is not
+// registered, the notebook instances are selected by SageMakerCostOptimizer objects.
+type sageMakerNotebookDriver struct {
+	operations sageMakerOperations
+}
+
+func (sageMakerNotebookDriver) kind() string {
+	return sageMakerNotebookKind
+}
+
+// resolveTargets returns the existing notebook instances, notebook instances are not selected by
+// their tags.
+func (d sageMakerNotebookDriver) resolveTargets(logger logr.Logger, region string, ids []string, _ map[string]string) ([]string, error) {
+	states, err := d.getState(logger, region, ids)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, id := range ids {
+		if _, found := states[id]; found {
+			targets = append(targets, id)
+		}
+	}
+	return targets, nil
+}
+
+func (d sageMakerNotebookDriver) getState(logger logr.Logger, region string, ids []string) (map[string]string, error) {
+	notebooks, err := d.operations.describeNotebookInstances(logger, region, ids)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]string, len(notebooks))
+	for _, notebook := range notebooks {
+		states[notebook.Name] = notebook.Status
+	}
+	return states, nil
+}
+
+func (d sageMakerNotebookDriver) applyAction(logger logr.Logger, region string, operation costoptimizerv1alpha1.Ec2OperationType,
+	ids []string) ([]utils.InstanceError, error) {
+	if operation == costoptimizerv1alpha1.Start {
+		return applyEach(logger, region, ids, d.operations.startNotebookInstance)
+	}
+	return applyEach(logger, region, ids, d.operations.stopNotebookInstance)
+}
+
+func (sageMakerNotebookDriver) states() (string, string) {
+	return "InService", "Stopped"
+}
+
+// snapshotConfig returns nothing, the instance type of a notebook instance is not changed while
+// it is stopped.
+func (sageMakerNotebookDriver) snapshotConfig(logr.Logger, string, []string) (map[string]string, error) {
+	return nil, nil
+}
+
+func (sageMakerNotebookDriver) restoreConfig(logr.Logger, string, string, string) error {
+	return nil
+}
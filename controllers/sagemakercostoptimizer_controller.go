@@ -0,0 +1,333 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/pricing"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const defaultStudioAppIdleThreshold = 2 * time.Hour
+
+// maxDeletedApps is the number of deleted studio apps kept in the status.
+const maxDeletedApps = 50
+
+// sageMakerOperations are the sagemaker operations performed on the notebook instances and the
+// studio apps.
+type sageMakerOperations interface {
+	describeNotebookInstances(logger logr.Logger, region string, names []string) ([]utils.SageMakerNotebookInstance, error)
+	startNotebookInstance(logger logr.Logger, region, name string) error
+	stopNotebookInstance(logger logr.Logger, region, name string) error
+	describeKernelGatewayApps(logger logr.Logger, region, domainID string) ([]utils.SageMakerApp, error)
+	deleteApp(logger logr.Logger, region string, app utils.SageMakerApp) error
+}
+
+// awsSageMakerOperations performs the sagemaker operations through the aws cli.
+type awsSageMakerOperations struct{}
+
+func (awsSageMakerOperations) describeNotebookInstances(logger logr.Logger, region string, names []string) ([]utils.SageMakerNotebookInstance, error) {
+	return utils.DescribeSageMakerNotebookInstances(logger, region, names)
+}
+
+func (awsSageMakerOperations) startNotebookInstance(logger logr.Logger, region, name string) error {
+	return utils.StartSageMakerNotebookInstance(logger, region, name)
+}
+
+func (awsSageMakerOperations) stopNotebookInstance(logger logr.Logger, region, name string) error {
+	return utils.StopSageMakerNotebookInstance(logger, region, name)
+}
+
+func (awsSageMakerOperations) describeKernelGatewayApps(logger logr.Logger, region, domainID string) ([]utils.SageMakerApp, error) {
+	return utils.DescribeSageMakerKernelGatewayApps(logger, region, domainID)
+}
+
+func (awsSageMakerOperations) deleteApp(logger logr.Logger, region string, app utils.SageMakerApp) error {
+	return utils.DeleteSageMakerApp(logger, region, app)
+}
+
+// SageMakerCostOptimizerReconciler reconciles a SageMakerCostOptimizer object
+type SageMakerCostOptimizerReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Prices is the catalog of prices used to estimate the savings, savings are not estimated
+	// if nil.
+	Prices pricing.Catalog
+	logger logr.Logger
+	// operations performed on the notebook instances and the studio apps, the aws cli is used if
+	// not set.
+	operations sageMakerOperations
+}
+
+// sageMaker returns the operations performed on the notebook instances and the studio apps.
+func (r *SageMakerCostOptimizerReconciler) sageMaker() sageMakerOperations {
+	if r.operations == nil {
+		return awsSageMakerOperations{}
+	}
+	return r.operations
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SageMakerCostOptimizerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.SageMakerCostOptimizer{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=sagemakercostoptimizers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=sagemakercostoptimizers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=sagemakercostoptimizers/finalizers,verbs=update
+
+// Reconcile stops the notebook instances within the time window through the notebook driver and
+// deletes the studio KernelGateway apps which are idle for longer than the threshold. Notebook
+// instances are not started again at the end of the window, they are started by their users when
+// needed.
+func (r *SageMakerCostOptimizerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling SageMakerCostOptimizer ...")
+
+	sageMakerCostOptimizer := &costoptimizerv1alpha1.SageMakerCostOptimizer{}
+	if err := r.Get(ctx, req.NamespacedName, sageMakerCostOptimizer); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	// the state is updated for the window before the resources are handled.
+	enteredWindow := sageMakerCostOptimizer.Status.State != fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Scheduled, inTimeWindow)
+	spec := sageMakerCostOptimizer.Spec
+	return reconcileScheduled(r.logger, r.Recorder, driverOperation{
+		obj:             sageMakerCostOptimizer,
+		driver:          r.driver(),
+		region:          spec.Region,
+		operation:       costoptimizerv1alpha1.Stop,
+		windowType:      costoptimizerv1alpha1.Scheduled,
+		startTimeWindow: spec.StartTimeWindow,
+		endTimeWindow:   spec.EndTimeWindow,
+		status:          &sageMakerCostOptimizer.Status.OperationStatus,
+		patchStatus: func(mutate func(status *costoptimizerv1alpha1.OperationStatus)) {
+			r.patchStatus(ctx, sageMakerCostOptimizer, func(status *costoptimizerv1alpha1.SageMakerCostOptimizerStatus) {
+				mutate(&status.OperationStatus)
+			})
+		},
+		operate: func() ([]string, error) {
+			return nil, r.handleResources(ctx, sageMakerCostOptimizer, true, enteredWindow)
+		},
+		outOfWindow: func() error {
+			return r.handleResources(ctx, sageMakerCostOptimizer, false, false)
+		},
+	})
+}
+
+// driver returns the driver of the notebook instances.
+func (r *SageMakerCostOptimizerReconciler) driver() sageMakerNotebookDriver {
+	return sageMakerNotebookDriver{operations: r.sageMaker()}
+}
+
+// handleResources stops the notebook instances and deletes the idle studio apps within the time
+// window, the status of the notebook instances is refreshed outside of the window as well.
+func (r *SageMakerCostOptimizerReconciler) handleResources(ctx context.Context, sageMakerCostOptimizer *costoptimizerv1alpha1.SageMakerCostOptimizer,
+	inWindow, enteredWindow bool) error {
+	deletedApps := sageMakerCostOptimizer.Status.DeletedApps
+	if inWindow && enteredWindow {
+		// entered the window, report the apps deleted in this window only.
+		deletedApps = nil
+	}
+
+	notebooks, err := r.handleNotebooks(sageMakerCostOptimizer, inWindow)
+	var appsMessage string
+	if inWindow && sageMakerCostOptimizer.Spec.StudioApps != nil {
+		deleted, appsErr := r.deleteIdleApps(sageMakerCostOptimizer, time.Now())
+		deletedApps = append(append([]costoptimizerv1alpha1.DeletedStudioApp(nil), deletedApps...), deleted...)
+		if len(deletedApps) > maxDeletedApps {
+			deletedApps = deletedApps[len(deletedApps)-maxDeletedApps:]
+		}
+		if appsErr != nil {
+			appsMessage = appsErr.Error()
+			if err == nil {
+				err = appsErr
+			}
+		}
+	}
+
+	r.patchStatus(ctx, sageMakerCostOptimizer, func(status *costoptimizerv1alpha1.SageMakerCostOptimizerStatus) {
+		status.NotebookInstances = notebooks
+		status.DeletedApps = deletedApps
+		status.EstimatedHourlySavings = formatUSD(sageMakerHourlySavings(r.Prices, utils.ResolveRegion(sageMakerCostOptimizer.Spec.Region), notebooks, deletedApps))
+		status.Message = appsMessage
+	})
+	return err
+}
+
+// handleNotebooks stops the running notebook instances within the time window through the driver
+// and returns their status.
+func (r *SageMakerCostOptimizerReconciler) handleNotebooks(sageMakerCostOptimizer *costoptimizerv1alpha1.SageMakerCostOptimizer,
+	inWindow bool) ([]costoptimizerv1alpha1.NotebookInstanceStatus, error) {
+	names := sageMakerCostOptimizer.Spec.NotebookInstanceNames
+	if len(names) == 0 {
+		return nil, nil
+	}
+	previous := map[string]costoptimizerv1alpha1.NotebookInstanceStatus{}
+	for _, notebook := range sageMakerCostOptimizer.Status.NotebookInstances {
+		previous[notebook.Name] = notebook
+	}
+	driver := r.driver()
+	described, err := r.sageMaker().describeNotebookInstances(r.logger, sageMakerCostOptimizer.Spec.Region, names)
+	if err != nil {
+		// keep reporting the previous status.
+		return sageMakerCostOptimizer.Status.NotebookInstances, err
+	}
+	notebooks := map[string]utils.SageMakerNotebookInstance{}
+	for _, notebook := range described {
+		notebooks[notebook.Name] = notebook
+	}
+
+	statuses := make([]costoptimizerv1alpha1.NotebookInstanceStatus, 0, len(names))
+	var firstErr error
+	for _, name := range names {
+		status, ok := previous[name]
+		if !ok {
+			status = costoptimizerv1alpha1.NotebookInstanceStatus{Name: name}
+		}
+		notebook, found := notebooks[name]
+		if !found {
+			status.Status, status.StoppedTime, status.Message = "", nil, "notebook instance not found"
+			statuses = append(statuses, status)
+			continue
+		}
+		status.Status, status.InstanceType, status.Message = notebook.Status, notebook.InstanceType, ""
+		if notebook.Status == "InService" || notebook.Status == "Pending" {
+			// running again, started by its user.
+			status.StoppedTime = nil
+		}
+		if inWindow && actionRequired(driver, costoptimizerv1alpha1.Stop, notebook.Status) {
+			skipped, err := applyDriverAction(r.logger, r.Recorder, sageMakerCostOptimizer, driver, costoptimizerv1alpha1.Stop,
+				sageMakerCostOptimizer.Spec.Region, []string{name})
+			switch {
+			case err != nil:
+				status.Message = err.Error()
+				if firstErr == nil {
+					firstErr = err
+				}
+			case len(skipped) > 0:
+				reason, _ := utils.ClassifyError(skipped[0].Err)
+				status.Message = skipped[0].Err.Error()
+				r.Recorder.Eventf(sageMakerCostOptimizer, corev1.EventTypeWarning, eventReasonInstanceSkipped,
+					"Stop skipped notebook instance %s with reason %s: %v", name, reason, skipped[0].Err)
+			default:
+				now := metav1.Now()
+				status.Status, status.StoppedTime = "Stopping", &now
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, firstErr
+}
+
+// deleteIdleApps deletes the KernelGateway apps which are idle for longer than the threshold and
+// returns them.
+func (r *SageMakerCostOptimizerReconciler) deleteIdleApps(sageMakerCostOptimizer *costoptimizerv1alpha1.SageMakerCostOptimizer,
+	now time.Time) ([]costoptimizerv1alpha1.DeletedStudioApp, error) {
+	policy := sageMakerCostOptimizer.Spec.StudioApps
+	threshold := defaultStudioAppIdleThreshold
+	if policy.IdleThreshold != nil {
+		threshold = policy.IdleThreshold.Duration
+	}
+	apps, err := r.sageMaker().describeKernelGatewayApps(r.logger, sageMakerCostOptimizer.Spec.Region, policy.DomainID)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []costoptimizerv1alpha1.DeletedStudioApp
+	for _, app := range apps {
+		lastActivity := appLastActivity(app)
+		if now.Sub(lastActivity) < threshold {
+			continue
+		}
+		if err := r.sageMaker().deleteApp(r.logger, sageMakerCostOptimizer.Spec.Region, app); err != nil {
+			reason, action := utils.ClassifyError(err)
+			r.Recorder.Eventf(sageMakerCostOptimizer, corev1.EventTypeWarning, eventReasonOperationFailed,
+				"Deletion of studio app %s failed with reason %s (action: %s): %v", app.AppName, reason, action, err)
+			if action == utils.ActionSkip {
+				continue
+			}
+			return deleted, err
+		}
+		r.Recorder.Eventf(sageMakerCostOptimizer, corev1.EventTypeNormal, eventReasonDeleted,
+			"Deleted studio app %s of domain %s, idle since %s", app.AppName, app.DomainID, lastActivity.Format(time.RFC3339))
+		deleted = append(deleted, costoptimizerv1alpha1.DeletedStudioApp{
+			DomainID:         app.DomainID,
+			UserProfileName:  app.UserProfileName,
+			SpaceName:        app.SpaceName,
+			AppName:          app.AppName,
+			InstanceType:     app.InstanceType,
+			LastActivityTime: metav1.NewTime(lastActivity),
+			DeletionTime:     metav1.NewTime(now),
+		})
+	}
+	return deleted, nil
+}
+
+// appLastActivity returns the last user activity of the app, or its creation time if it was
+// never used. The activity reported by SageMaker includes the health checks of the app, so an
+// app is never found idle too early, but apps left open without being used are kept as well.
+func appLastActivity(app utils.SageMakerApp) time.Time {
+	if app.LastUserActivityTime != nil && app.LastUserActivityTime.After(app.CreationTime) {
+		return *app.LastUserActivityTime
+	}
+	return app.CreationTime
+}
+
+// sageMakerHourlySavings returns the hourly price of the notebook instances stopped by the
+// controller and of the deleted apps, instance types without a known price are left out.
+func sageMakerHourlySavings(prices pricing.Catalog, region string, notebooks []costoptimizerv1alpha1.NotebookInstanceStatus,
+	apps []costoptimizerv1alpha1.DeletedStudioApp) float64 {
+	var savings float64
+	for _, notebook := range notebooks {
+		if notebook.StoppedTime == nil {
+			continue
+		}
+		if price, ok := pricing.SageMakerHourlyPrice(prices, region, notebook.InstanceType); ok {
+			savings += price
+		}
+	}
+	for _, app := range apps {
+		if price, ok := pricing.SageMakerHourlyPrice(prices, region, app.InstanceType); ok {
+			savings += price
+		}
+	}
+	return savings
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *SageMakerCostOptimizerReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.SageMakerCostOptimizer,
+	mutate func(status *costoptimizerv1alpha1.SageMakerCostOptimizerStatus)) {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+	}
+}
@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/pricing"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// fakeSageMakerOperations performs the sagemaker operations on the notebook instances and the
+// studio apps, stopping a notebook instance fails with stopErr and the deletion of an app fails
+// with the error set for its name. The stopped notebook instances are recorded.
+type fakeSageMakerOperations struct {
+	notebooks  []utils.SageMakerNotebookInstance
+	stopErr    error
+	stopped    []string
+	apps       []utils.SageMakerApp
+	deleteErrs map[string]error
+}
+
+func (f *fakeSageMakerOperations) describeNotebookInstances(logr.Logger, string, []string) ([]utils.SageMakerNotebookInstance, error) {
+	return f.notebooks, nil
+}
+
+func (f *fakeSageMakerOperations) startNotebookInstance(logr.Logger, string, string) error {
+	return nil
+}
+
+func (f *fakeSageMakerOperations) stopNotebookInstance(_ logr.Logger, _, name string) error {
+	f.stopped = append(f.stopped, name)
+	return f.stopErr
+}
+
+func (f *fakeSageMakerOperations) describeKernelGatewayApps(logr.Logger, string, string) ([]utils.SageMakerApp, error) {
+	return f.apps, nil
+}
+
+func (f *fakeSageMakerOperations) deleteApp(_ logr.Logger, _ string, app utils.SageMakerApp) error {
+	return f.deleteErrs[app.AppName]
+}
+
+func TestAppLastActivity(t *testing.T) {
+	created := time.Date(2023, 3, 10, 8, 0, 0, 0, time.UTC)
+	used := time.Date(2023, 3, 10, 11, 0, 0, 0, time.UTC)
+	stale := time.Date(2023, 3, 9, 11, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		app      utils.SageMakerApp
+		expected time.Time
+	}{
+		{name: "never used", app: utils.SageMakerApp{CreationTime: created}, expected: created},
+		{name: "used", app: utils.SageMakerApp{CreationTime: created, LastUserActivityTime: &used}, expected: used},
+		{name: "activity before creation", app: utils.SageMakerApp{CreationTime: created, LastUserActivityTime: &stale}, expected: created},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if lastActivity := appLastActivity(test.app); !lastActivity.Equal(test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, lastActivity)
+			}
+		})
+	}
+}
+
+func TestSageMakerHourlySavings(t *testing.T) {
+	now := metav1.Now()
+	notebooks := []costoptimizerv1alpha1.NotebookInstanceStatus{
+		{Name: "stopped", InstanceType: "ml.t3.large", StoppedTime: &now},
+		{Name: "running", InstanceType: "ml.m5.xlarge"},
+		{Name: "unknown", InstanceType: "ml.unknown", StoppedTime: &now},
+	}
+	apps := []costoptimizerv1alpha1.DeletedStudioApp{{AppName: "default", InstanceType: "ml.t3.medium"}}
+	if savings := formatUSD(sageMakerHourlySavings(pricing.DefaultTable(), "us-east-1", notebooks, apps)); savings != "0.15" {
+		t.Errorf("expected 0.15, got %s", savings)
+	}
+}
+
+func TestDeleteIdleApps(t *testing.T) {
+	now := time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)
+	created := now.Add(-6 * time.Hour)
+	used := now.Add(-30 * time.Minute)
+	// refreshed by the health checks of an app left open in a browser, it is not found idle.
+	healthChecked := now.Add(-time.Minute)
+	apps := []utils.SageMakerApp{
+		{DomainID: "d-1", AppName: "never-used", CreationTime: created},
+		{DomainID: "d-1", AppName: "used", CreationTime: created, LastUserActivityTime: &used},
+		{DomainID: "d-1", AppName: "left-open", CreationTime: created, LastUserActivityTime: &healthChecked},
+		{DomainID: "d-1", AppName: "deleting", CreationTime: created},
+		{DomainID: "d-1", AppName: "idle", CreationTime: created, LastUserActivityTime: &created},
+	}
+	inUse := &utils.AWSError{Code: "ResourceInUseException", Operation: "DeleteApp", Message: "app is being deleted"}
+	denied := &utils.AWSError{Code: "AccessDeniedException", Operation: "DeleteApp", Message: "denied"}
+
+	tests := []struct {
+		name    string
+		errs    map[string]error
+		err     error
+		deleted []string
+		events  []string
+	}{
+		{
+			name:    "idle apps deleted",
+			errs:    map[string]error{"deleting": inUse},
+			deleted: []string{"never-used", "idle"},
+			events:  []string{eventReasonDeleted, eventReasonOperationFailed, eventReasonDeleted},
+		},
+		{
+			name:    "deletion failed",
+			errs:    map[string]error{"idle": denied},
+			err:     denied,
+			deleted: []string{"never-used", "deleting"},
+			events:  []string{eventReasonDeleted, eventReasonDeleted, eventReasonOperationFailed},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := &SageMakerCostOptimizerReconciler{Recorder: recorder, logger: logr.Discard(),
+				operations: &fakeSageMakerOperations{apps: apps, deleteErrs: test.errs}}
+			obj := &costoptimizerv1alpha1.SageMakerCostOptimizer{Spec: costoptimizerv1alpha1.SageMakerCostOptimizerSpec{
+				StudioApps: &costoptimizerv1alpha1.StudioAppPolicy{},
+			}}
+
+			deleted, err := r.deleteIdleApps(obj, now)
+			if !errors.Is(err, test.err) {
+				t.Errorf("expected error %v, got %v", test.err, err)
+			}
+			var names []string
+			for _, app := range deleted {
+				names = append(names, app.AppName)
+			}
+			if !reflect.DeepEqual(names, test.deleted) {
+				t.Errorf("expected deleted apps %v, got %v", test.deleted, names)
+			}
+			if events := eventReasons(recorder); !reflect.DeepEqual(events, test.events) {
+				t.Errorf("expected events %v, got %v", test.events, events)
+			}
+		})
+	}
+}
+
+func TestHandleNotebooks(t *testing.T) {
+	throttled := &utils.AWSError{Code: "ThrottlingException", Operation: "StopNotebookInstance", Message: "rate exceeded"}
+	inUse := &utils.AWSError{Code: "ResourceInUseException", Operation: "StopNotebookInstance", Message: "updating"}
+
+	tests := []struct {
+		name     string
+		status   string
+		inWindow bool
+		stopErr  error
+		err      error
+		stopped  []string
+		expected string
+		message  string
+		events   []string
+	}{
+		{
+			name:     "stop running notebook instance",
+			status:   "InService",
+			inWindow: true,
+			stopped:  []string{"notebook-1"},
+			expected: "Stopping",
+			events:   []string{eventReasonStopIssued},
+		},
+		{
+			name:     "running notebook instance out of the window",
+			status:   "InService",
+			expected: "InService",
+		},
+		{
+			name:     "stopped notebook instance",
+			status:   "Stopped",
+			inWindow: true,
+			expected: "Stopped",
+		},
+		{
+			name:     "stop failed",
+			status:   "InService",
+			inWindow: true,
+			stopErr:  throttled,
+			err:      throttled,
+			stopped:  []string{"notebook-1"},
+			expected: "InService",
+			message:  throttled.Error(),
+			events:   []string{eventReasonOperationFailed},
+		},
+		{
+			name:     "stop skipped",
+			status:   "InService",
+			inWindow: true,
+			stopErr:  inUse,
+			stopped:  []string{"notebook-1"},
+			expected: "InService",
+			message:  inUse.Error(),
+			events:   []string{eventReasonInstanceSkipped},
+		},
+		{
+			name:     "not found",
+			inWindow: true,
+			message:  "notebook instance not found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sageMaker := &fakeSageMakerOperations{stopErr: test.stopErr}
+			if test.status != "" {
+				sageMaker.notebooks = []utils.SageMakerNotebookInstance{{Name: "notebook-1", Status: test.status, InstanceType: "ml.t3.medium"}}
+			}
+			recorder := record.NewFakeRecorder(10)
+			r := &SageMakerCostOptimizerReconciler{Recorder: recorder, logger: logr.Discard(), operations: sageMaker}
+			obj := &costoptimizerv1alpha1.SageMakerCostOptimizer{Spec: costoptimizerv1alpha1.SageMakerCostOptimizerSpec{
+				NotebookInstanceNames: []string{"notebook-1"},
+			}}
+
+			notebooks, err := r.handleNotebooks(obj, test.inWindow)
+			if !errors.Is(err, test.err) {
+				t.Errorf("expected error %v, got %v", test.err, err)
+			}
+			if notebook := notebooks[0]; notebook.Status != test.expected || notebook.Message != test.message {
+				t.Errorf("expected status %q with message %q, got %q with %q", test.expected, test.message, notebook.Status, notebook.Message)
+			}
+			if stopped := notebooks[0].StoppedTime != nil; stopped != (test.expected == "Stopping") {
+				t.Errorf("expected the stopped time to be set for stopped notebook instances only, got %v", notebooks[0].StoppedTime)
+			}
+			if !reflect.DeepEqual(sageMaker.stopped, test.stopped) {
+				t.Errorf("expected stopped notebook instances %v, got %v", test.stopped, sageMaker.stopped)
+			}
+			if events := eventReasons(recorder); !reflect.DeepEqual(events, test.events) {
+				t.Errorf("expected events %v, got %v", test.events, events)
+			}
+		})
+	}
+}
+
+func TestReconcileSageMakerScheduled(t *testing.T) {
+	sageMaker := &fakeSageMakerOperations{notebooks: []utils.SageMakerNotebookInstance{{Name: "notebook-1", Status: "InService"}}}
+	c := &fakeClient{object: &costoptimizerv1alpha1.SageMakerCostOptimizer{
+		ObjectMeta: metav1.ObjectMeta{Name: "sagemaker"},
+		Spec: costoptimizerv1alpha1.SageMakerCostOptimizerSpec{NotebookInstanceNames: []string{"notebook-1"},
+			StartTimeWindow: "00:00:00", EndTimeWindow: "23:59:59"},
+	}}
+	r := &SageMakerCostOptimizerReconciler{Client: c, Recorder: record.NewFakeRecorder(10), operations: sageMaker}
+
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "sagemaker"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RequeueAfter == 0 {
+		t.Errorf("expected the object to be requeued to check the window again")
+	}
+	status := c.object.(*costoptimizerv1alpha1.SageMakerCostOptimizer).Status
+	if expected := fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Scheduled, inTimeWindow); status.State != expected {
+		t.Errorf("expected state %s, got %s", expected, status.State)
+	}
+	if len(status.NotebookInstances) != 1 || status.NotebookInstances[0].StoppedTime == nil {
+		t.Errorf("expected the notebook instance to be stopped, got %+v", status.NotebookInstances)
+	}
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// restoreFinalizer is the finalizer of the objects which save the configuration of resources in
+// their status before changing it, the saved configurations are restored before the object is
+// removed so that the resources are not left scaled down.
+const restoreFinalizer = "kubeinbox.io.kubeinbox.io/restore-saved-config"
+
+// saveThenMutate patches the status of the object with the configurations saved by save, mutate
+// changes the resources only once the patch succeeded so that a configuration is never lost.
+// The patch error is returned without calling mutate, the resources are left alone until the
+// next attempt.
+func saveThenMutate(ctx context.Context, c client.Client, obj client.Object, save func(), mutate func() error) error {
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	save()
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	if len(data) > 2 {
+		if err := c.Status().Patch(ctx, obj, patch); err != nil {
+			return fmt.Errorf("unable to save the configuration in the status: %w", err)
+		}
+	}
+	return mutate()
+}
+
+// handleRestoreFinalizer adds the restore finalizer to the object, or once the object is deleted
+// restores the saved configurations through restore and removes the finalizer. It returns true if
+// the object is deleted, the caller is done with the object then. The finalizer is kept while the
+// restore fails, it has to be removed by hand to give up on the resources.
+func handleRestoreFinalizer(ctx context.Context, c client.Client, obj client.Object, restore func() error) (bool, error) {
+	if obj.GetDeletionTimestamp().IsZero() {
+		if controllerutil.ContainsFinalizer(obj, restoreFinalizer) {
+			return false, nil
+		}
+		patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+		controllerutil.AddFinalizer(obj, restoreFinalizer)
+		return false, c.Patch(ctx, obj, patch)
+	}
+
+	if !controllerutil.ContainsFinalizer(obj, restoreFinalizer) {
+		return true, nil
+	}
+	if err := restore(); err != nil {
+		return true, fmt.Errorf("unable to restore the saved configurations: %w", err)
+	}
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	controllerutil.RemoveFinalizer(obj, restoreFinalizer)
+	return true, c.Patch(ctx, obj, patch)
+}
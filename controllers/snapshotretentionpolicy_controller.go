@@ -0,0 +1,283 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const defaultRetentionInterval = 24 * time.Hour
+
+// actions taken on the expired snapshots.
+const (
+	snapshotActionWouldDelete = "WouldDelete"
+	snapshotActionDeleted     = "Deleted"
+	snapshotActionSkipped     = "Skipped"
+	snapshotActionFailed      = "Failed"
+)
+
+// noSourceVolumeID is the volume id of the snapshots which were not taken of a volume, e.g.
+// snapshots copied from another snapshot.
+const noSourceVolumeID = "vol-ffffffff"
+
+// snapshotOperations are the ec2 operations performed on the snapshots.
+type snapshotOperations interface {
+	describeSnapshots(logger logr.Logger, region string, ownerIDs, volumeIDs []string, tags map[string]string) ([]utils.EbsSnapshot, error)
+	describeAmiSnapshotIDs(logger logr.Logger, region string) ([]string, error)
+	deleteSnapshot(logger logr.Logger, region, snapshotID string) error
+}
+
+// awsSnapshotOperations performs the ec2 operations through the aws cli.
+type awsSnapshotOperations struct{}
+
+func (awsSnapshotOperations) describeSnapshots(logger logr.Logger, region string, ownerIDs, volumeIDs []string,
+	tags map[string]string) ([]utils.EbsSnapshot, error) {
+	return utils.DescribeEbsSnapshots(logger, region, ownerIDs, volumeIDs, tags)
+}
+
+func (awsSnapshotOperations) describeAmiSnapshotIDs(logger logr.Logger, region string) ([]string, error) {
+	return utils.DescribeAmiSnapshotIDs(logger, region)
+}
+
+func (awsSnapshotOperations) deleteSnapshot(logger logr.Logger, region, snapshotID string) error {
+	return utils.DeleteEbsSnapshot(logger, region, snapshotID)
+}
+
+// SnapshotRetentionPolicyReconciler reconciles a SnapshotRetentionPolicy object
+type SnapshotRetentionPolicyReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	logger   logr.Logger
+	// operations performed on the snapshots, the aws cli is used if not set.
+	operations snapshotOperations
+}
+
+// snapshots returns the operations performed on the snapshots.
+func (r *SnapshotRetentionPolicyReconciler) snapshots() snapshotOperations {
+	if r.operations == nil {
+		return awsSnapshotOperations{}
+	}
+	return r.operations
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SnapshotRetentionPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.SnapshotRetentionPolicy{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=snapshotretentionpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=snapshotretentionpolicies/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=snapshotretentionpolicies/finalizers,verbs=update
+
+// Reconcile applies the retention rules to the selected snapshots at the interval. Snapshots not
+// kept by the rules are listed in the status and deleted if the policy is enforced, unless they
+// back a registered ami.
+func (r *SnapshotRetentionPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling SnapshotRetentionPolicy ...")
+
+	policy := &costoptimizerv1alpha1.SnapshotRetentionPolicy{}
+	if err := r.Get(ctx, req.NamespacedName, policy); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	interval := defaultRetentionInterval
+	if policy.Spec.Interval != nil && policy.Spec.Interval.Duration > 0 {
+		interval = policy.Spec.Interval.Duration
+	}
+	if last := policy.Status.LastRunTime; last != nil && policy.Status.ObservedGeneration == policy.Generation {
+		if wait := time.Until(last.Add(interval)); wait > 0 {
+			r.logger.V(1).Info("retention is up to date", "next run after", wait.String())
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	rules := policy.Spec.Retention
+	if rules.KeepLast == 0 && rules.KeepDailyDays == 0 && rules.KeepWeeklyWeeks == 0 {
+		r.runFailed(ctx, policy, fmt.Errorf("retention requires at least one rule"))
+		return ctrl.Result{}, nil
+	}
+
+	ownerIDs := policy.Spec.Selector.OwnerIDs
+	if len(ownerIDs) == 0 {
+		ownerIDs = []string{"self"}
+	}
+	snapshots, err := r.snapshots().describeSnapshots(r.logger, policy.Spec.Region, ownerIDs, policy.Spec.Selector.VolumeIDs, policy.Spec.Selector.Tags)
+	if err != nil {
+		return r.runError(ctx, policy, interval, err)
+	}
+	amiSnapshotIDs, err := r.snapshots().describeAmiSnapshotIDs(r.logger, policy.Spec.Region)
+	if err != nil {
+		return r.runError(ctx, policy, interval, err)
+	}
+	usedByAmi := map[string]bool{}
+	for _, snapshotID := range amiSnapshotIDs {
+		usedByAmi[snapshotID] = true
+	}
+
+	now := time.Now()
+	retained := retainedSnapshots(snapshots, rules, now)
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].StartTime.Before(snapshots[j].StartTime) })
+	var expired []costoptimizerv1alpha1.ExpiredSnapshot
+	var deleted int32
+	for _, snapshot := range snapshots {
+		if retained[snapshot.SnapshotID] {
+			continue
+		}
+		entry := costoptimizerv1alpha1.ExpiredSnapshot{
+			SnapshotID:    snapshot.SnapshotID,
+			VolumeID:      snapshot.VolumeID,
+			StartTime:     metav1.NewTime(snapshot.StartTime),
+			VolumeSizeGiB: snapshot.VolumeSize,
+			Action:        snapshotActionWouldDelete,
+		}
+		switch {
+		case usedByAmi[snapshot.SnapshotID]:
+			entry.Action, entry.Message = snapshotActionSkipped, "used by a registered ami"
+		case policy.Spec.Enforce:
+			if err := r.snapshots().deleteSnapshot(r.logger, policy.Spec.Region, snapshot.SnapshotID); err != nil {
+				if _, action := utils.ClassifyError(err); action != utils.ActionSkip {
+					// the snapshots deleted so far are gone, whatever happens to the run.
+					r.recordDeleted(ctx, policy, deleted)
+					return r.runError(ctx, policy, interval, err)
+				}
+				entry.Action, entry.Message = snapshotActionFailed, err.Error()
+				break
+			}
+			entry.Action = snapshotActionDeleted
+			deleted++
+		}
+		expired = append(expired, entry)
+	}
+
+	r.recordDeleted(ctx, policy, deleted)
+	runTime := metav1.NewTime(now)
+	r.patchStatus(ctx, policy, func(status *costoptimizerv1alpha1.SnapshotRetentionPolicyStatus) {
+		status.State = complete
+		status.Message = ""
+		status.ObservedGeneration = policy.Generation
+		status.LastRunTime = &runTime
+		status.SelectedSnapshots = int32(len(snapshots))
+		status.RetainedSnapshots = int32(len(snapshots) - len(expired))
+		status.ExpiredSnapshots = expired
+	})
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// recordDeleted adds the snapshots deleted by the run to the status and reports them.
+func (r *SnapshotRetentionPolicyReconciler) recordDeleted(ctx context.Context, policy *costoptimizerv1alpha1.SnapshotRetentionPolicy,
+	deleted int32) {
+	if deleted == 0 {
+		return
+	}
+	r.Recorder.Eventf(policy, corev1.EventTypeNormal, eventReasonDeleted,
+		"Deleted %d snapshot(s) not kept by the retention rules", deleted)
+	r.patchStatus(ctx, policy, func(status *costoptimizerv1alpha1.SnapshotRetentionPolicyStatus) {
+		status.DeletedSnapshots += deleted
+	})
+}
+
+// retainedSnapshots returns the ids of the snapshots kept by the retention rules. The rules are
+// applied to the snapshots of each volume separately, snapshots which are not completed yet are
+// always kept and do not count for the rules. Snapshots which were not taken of a volume are
+// not related to each other, the rules are applied to each of them separately.
+func retainedSnapshots(snapshots []utils.EbsSnapshot, rules costoptimizerv1alpha1.RetentionRules, now time.Time) map[string]bool {
+	byVolume := map[string][]utils.EbsSnapshot{}
+	retained := map[string]bool{}
+	for _, snapshot := range snapshots {
+		if snapshot.State != "completed" {
+			retained[snapshot.SnapshotID] = true
+			continue
+		}
+		key := snapshot.VolumeID
+		if key == "" || key == noSourceVolumeID {
+			key = snapshot.SnapshotID
+		}
+		byVolume[key] = append(byVolume[key], snapshot)
+	}
+
+	dailyCutoff := now.AddDate(0, 0, -int(rules.KeepDailyDays))
+	weeklyCutoff := now.AddDate(0, 0, -7*int(rules.KeepWeeklyWeeks))
+	for _, volumeSnapshots := range byVolume {
+		// latest first, so that the latest snapshot of a day or week is kept.
+		sort.Slice(volumeSnapshots, func(i, j int) bool { return volumeSnapshots[i].StartTime.After(volumeSnapshots[j].StartTime) })
+		days := map[string]bool{}
+		weeks := map[string]bool{}
+		for i, snapshot := range volumeSnapshots {
+			startTime := snapshot.StartTime.UTC()
+			if i < int(rules.KeepLast) {
+				retained[snapshot.SnapshotID] = true
+			}
+			if day := startTime.Format("2006-01-02"); startTime.After(dailyCutoff) && !days[day] {
+				days[day] = true
+				retained[snapshot.SnapshotID] = true
+			}
+			year, week := startTime.ISOWeek()
+			if key := fmt.Sprintf("%d-%d", year, week); startTime.After(weeklyCutoff) && !weeks[key] {
+				weeks[key] = true
+				retained[snapshot.SnapshotID] = true
+			}
+		}
+	}
+	return retained
+}
+
+// runError marks the run as failed, errors which cannot be resolved by retrying are retried at
+// the next interval.
+func (r *SnapshotRetentionPolicyReconciler) runError(ctx context.Context, policy *costoptimizerv1alpha1.SnapshotRetentionPolicy,
+	interval time.Duration, err error) (ctrl.Result, error) {
+	r.logger.Error(err, "unable to apply snapshot retention")
+	r.runFailed(ctx, policy, err)
+	if _, action := utils.ClassifyError(err); action == utils.ActionFail {
+		return ctrl.Result{RequeueAfter: interval}, nil
+	}
+	return ctrl.Result{}, err
+}
+
+func (r *SnapshotRetentionPolicyReconciler) runFailed(ctx context.Context, policy *costoptimizerv1alpha1.SnapshotRetentionPolicy, err error) {
+	r.Recorder.Eventf(policy, corev1.EventTypeWarning, eventReasonOperationFailed, "Snapshot retention failed: %v", err)
+	r.patchStatus(ctx, policy, func(status *costoptimizerv1alpha1.SnapshotRetentionPolicyStatus) {
+		status.State = failed
+		status.Message = err.Error()
+		status.ObservedGeneration = policy.Generation
+	})
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *SnapshotRetentionPolicyReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.SnapshotRetentionPolicy,
+	mutate func(status *costoptimizerv1alpha1.SnapshotRetentionPolicyStatus)) {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+	}
+}
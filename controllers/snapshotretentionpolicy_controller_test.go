@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestRetainedSnapshots(t *testing.T) {
+	now := time.Date(2023, 3, 31, 12, 0, 0, 0, time.UTC)
+	var snapshots []utils.EbsSnapshot
+	for day := 1; day <= 31; day++ {
+		snapshots = append(snapshots, utils.EbsSnapshot{
+			SnapshotID: fmt.Sprintf("snap-a%02d", day),
+			VolumeID:   "vol-a",
+			State:      "completed",
+			StartTime:  time.Date(2023, 3, day, 1, 0, 0, 0, time.UTC),
+		})
+	}
+	snapshots = append(snapshots,
+		utils.EbsSnapshot{SnapshotID: "snap-a31-early", VolumeID: "vol-a", State: "completed", StartTime: time.Date(2023, 3, 31, 0, 30, 0, 0, time.UTC)},
+		utils.EbsSnapshot{SnapshotID: "snap-a-pending", VolumeID: "vol-a", State: "pending", StartTime: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		utils.EbsSnapshot{SnapshotID: "snap-b", VolumeID: "vol-b", State: "completed", StartTime: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+	)
+	// copied snapshots are not related to each other.
+	for i := 1; i <= 3; i++ {
+		snapshots = append(snapshots, utils.EbsSnapshot{SnapshotID: fmt.Sprintf("snap-copy%d", i), VolumeID: noSourceVolumeID,
+			State: "completed", StartTime: time.Date(2023, 1, i, 0, 0, 0, 0, time.UTC)})
+	}
+
+	retained := retainedSnapshots(snapshots, costoptimizerv1alpha1.RetentionRules{
+		KeepLast:        2,
+		KeepDailyDays:   3,
+		KeepWeeklyWeeks: 2,
+	}, now)
+
+	var got []string
+	for snapshotID := range retained {
+		got = append(got, snapshotID)
+	}
+	sort.Strings(got)
+	// last two, dailies of the 29th to the 31st and weeklies of the 19th, 26th and 31st.
+	want := []string{"snap-a-pending", "snap-a19", "snap-a26", "snap-a29", "snap-a30", "snap-a31", "snap-a31-early", "snap-b",
+		"snap-copy1", "snap-copy2", "snap-copy3"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected retained snapshots %v, got %v", want, got)
+	}
+}
+
+// fakeSnapshotOperations serves the snapshots, deleting the snapshots of deleteErrs fails with
+// their error. The snapshots deleted are recorded in order.
+type fakeSnapshotOperations struct {
+	snapshots  []utils.EbsSnapshot
+	deleteErrs map[string]error
+	deleted    []string
+}
+
+func (f *fakeSnapshotOperations) describeSnapshots(logr.Logger, string, []string, []string, map[string]string) ([]utils.EbsSnapshot, error) {
+	return f.snapshots, nil
+}
+
+func (f *fakeSnapshotOperations) describeAmiSnapshotIDs(logr.Logger, string) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeSnapshotOperations) deleteSnapshot(_ logr.Logger, _, snapshotID string) error {
+	if err := f.deleteErrs[snapshotID]; err != nil {
+		return err
+	}
+	f.deleted = append(f.deleted, snapshotID)
+	return nil
+}
+
+func TestReconcileRecordsDeletedSnapshotsOnFailure(t *testing.T) {
+	var snapshots []utils.EbsSnapshot
+	for day := 1; day <= 4; day++ {
+		snapshots = append(snapshots, utils.EbsSnapshot{SnapshotID: fmt.Sprintf("snap-%d", day), VolumeID: "vol-a",
+			State: "completed", StartTime: time.Date(2023, 1, day, 0, 0, 0, 0, time.UTC)})
+	}
+	// deleted oldest first, the third one fails.
+	snapshotOperations := &fakeSnapshotOperations{snapshots: snapshots, deleteErrs: map[string]error{
+		"snap-3": &utils.AWSError{Code: "UnauthorizedOperation", Operation: "DeleteSnapshot", Message: "denied"},
+	}}
+	c := &fakeClient{object: &costoptimizerv1alpha1.SnapshotRetentionPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "retention"},
+		Spec: costoptimizerv1alpha1.SnapshotRetentionPolicySpec{Enforce: true,
+			Retention: costoptimizerv1alpha1.RetentionRules{KeepLast: 1}},
+	}}
+	recorder := record.NewFakeRecorder(10)
+	r := &SnapshotRetentionPolicyReconciler{Client: c, Recorder: recorder, operations: snapshotOperations}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "retention"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	status := c.object.(*costoptimizerv1alpha1.SnapshotRetentionPolicy).Status
+	if status.State != failed || status.DeletedSnapshots != 2 {
+		t.Errorf("expected a failed run with 2 deleted snapshots, got %s with %d", status.State, status.DeletedSnapshots)
+	}
+	if events := eventReasons(recorder); strings.Join(events, ",") != eventReasonDeleted+","+eventReasonOperationFailed {
+		t.Errorf("expected the deleted snapshots to be reported before the failure, got %v", events)
+	}
+}
@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// annotations of the workloads scaled to zero.
+const (
+	// originalReplicasAnnotation stores the replicas of a workload before it got scaled to zero.
+	originalReplicasAnnotation = "kubeinbox.io/original-replicas"
+	// scaledByAnnotation is the object which scaled the workload to zero, only that object
+	// restores the workload.
+	scaledByAnnotation = "kubeinbox.io/scaled-by"
+)
+
+// WorkloadScheduleOptimizerReconciler reconciles a WorkloadScheduleOptimizer object
+type WorkloadScheduleOptimizerReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	logger   logr.Logger
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *WorkloadScheduleOptimizerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	pred := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		WithEventFilter(pred).
+		For(&costoptimizerv1alpha1.WorkloadScheduleOptimizer{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=workloadscheduleoptimizers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=workloadscheduleoptimizers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=kubeinbox.io.kubeinbox.io,resources=workloadscheduleoptimizers/finalizers,verbs=update
+//+kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=get;list;watch;patch
+
+// Reconcile scales the selected Deployments and StatefulSets to zero within the time window,
+// after storing their replicas in an annotation, and restores the replicas once the window ends.
+func (r *WorkloadScheduleOptimizerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.logger = log.FromContext(ctx).WithValues("object", req.NamespacedName)
+	r.logger.Info("Reconciling WorkloadScheduleOptimizer ...")
+
+	workloadScheduleOptimizer := &costoptimizerv1alpha1.WorkloadScheduleOptimizer{}
+	if err := r.Get(ctx, req.NamespacedName, workloadScheduleOptimizer); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.V(1).Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		r.logger.Error(err, "unable to fetch object ")
+		return ctrl.Result{}, err
+	}
+
+	inWindow := isInTimeWindow(r.logger, workloadScheduleOptimizer.Spec.StartTimeWindow, workloadScheduleOptimizer.Spec.EndTimeWindow)
+	err := r.handleWorkloads(ctx, workloadScheduleOptimizer, inWindow)
+	if err != nil {
+		r.logger.Error(err, "error processing workloads")
+	}
+	return ctrl.Result{RequeueAfter: wait.Jitter(1*time.Minute, 0.5)}, err
+}
+
+// handleWorkloads scales the workloads to zero within the time window and restores them otherwise.
+func (r *WorkloadScheduleOptimizerReconciler) handleWorkloads(ctx context.Context,
+	workloadScheduleOptimizer *costoptimizerv1alpha1.WorkloadScheduleOptimizer, inWindow bool) error {
+	state := outOfTimeWindow
+	if inWindow {
+		state = inTimeWindow
+	}
+	workloads, err := r.listWorkloads(ctx, workloadScheduleOptimizer)
+	if err != nil {
+		r.patchStatus(ctx, workloadScheduleOptimizer, func(status *costoptimizerv1alpha1.WorkloadScheduleOptimizerStatus) {
+			status.State = fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Scheduled, state)
+			status.Message = err.Error()
+		})
+		return err
+	}
+
+	owner := workloadScheduleOptimizer.Namespace + "/" + workloadScheduleOptimizer.Name
+	scaled := []costoptimizerv1alpha1.ScaledWorkload{}
+	var firstErr error
+	for _, workload := range workloads {
+		workload, err := r.handleWorkload(ctx, workloadScheduleOptimizer, workload, owner, inWindow)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if original, ok := originalReplicas(workload, owner); ok {
+			scaled = append(scaled, costoptimizerv1alpha1.ScaledWorkload{
+				Kind:             workload.GetObjectKind().GroupVersionKind().Kind,
+				Namespace:        workload.GetNamespace(),
+				Name:             workload.GetName(),
+				OriginalReplicas: original,
+			})
+		}
+	}
+
+	r.patchStatus(ctx, workloadScheduleOptimizer, func(status *costoptimizerv1alpha1.WorkloadScheduleOptimizerStatus) {
+		status.State = fmt.Sprintf("%s/%s", costoptimizerv1alpha1.Scheduled, state)
+		status.ScaledWorkloads = scaled
+		status.Message = ""
+		if firstErr != nil {
+			status.Message = firstErr.Error()
+		}
+	})
+	return firstErr
+}
+
+// handleWorkload scales the workload to zero or restores it, depending on inWindow. It returns
+// the workload as it is in the cluster, which is unchanged if the operation failed.
+func (r *WorkloadScheduleOptimizerReconciler) handleWorkload(ctx context.Context,
+	workloadScheduleOptimizer *costoptimizerv1alpha1.WorkloadScheduleOptimizer, workload client.Object, owner string,
+	inWindow bool) (client.Object, error) {
+	kind := workload.GetObjectKind().GroupVersionKind().Kind
+	original := workload.DeepCopyObject().(client.Object)
+	patch := client.MergeFrom(original)
+	var changed bool
+	var err error
+	if inWindow {
+		changed = scaleDownWorkload(workload, owner)
+	} else if changed, err = restoreWorkload(workload, owner); err != nil {
+		r.Recorder.Eventf(workloadScheduleOptimizer, corev1.EventTypeWarning, eventReasonOperationFailed,
+			"Unable to restore %s %s/%s: %v", kind, workload.GetNamespace(), workload.GetName(), err)
+		return original, err
+	}
+	if !changed {
+		return workload, nil
+	}
+
+	if err := r.Patch(ctx, workload, patch); err != nil {
+		r.Recorder.Eventf(workloadScheduleOptimizer, corev1.EventTypeWarning, eventReasonOperationFailed,
+			"Failed to scale %s %s/%s: %v", kind, workload.GetNamespace(), workload.GetName(), err)
+		return original, err
+	}
+	if inWindow {
+		r.Recorder.Eventf(workloadScheduleOptimizer, corev1.EventTypeNormal, eventReasonScaledDown,
+			"Scaled %s %s/%s to zero", kind, workload.GetNamespace(), workload.GetName())
+	} else {
+		r.Recorder.Eventf(workloadScheduleOptimizer, corev1.EventTypeNormal, eventReasonRestored,
+			"Restored %s %s/%s to %d replicas", kind, workload.GetNamespace(), workload.GetName(), *workloadReplicas(workload))
+	}
+	return workload, nil
+}
+
+// listWorkloads returns the Deployments and StatefulSets matching the selector of the object, in
+// the namespace of the object only so that an object cannot scale the workloads of other
+// namespaces.
+func (r *WorkloadScheduleOptimizerReconciler) listWorkloads(ctx context.Context,
+	workloadScheduleOptimizer *costoptimizerv1alpha1.WorkloadScheduleOptimizer) ([]client.Object, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&workloadScheduleOptimizer.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector: %w", err)
+	}
+
+	var workloads []client.Object
+	opts := []client.ListOption{client.InNamespace(workloadScheduleOptimizer.Namespace), client.MatchingLabelsSelector{Selector: selector}}
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, opts...); err != nil {
+		return nil, err
+	}
+	for i := range deployments.Items {
+		deployments.Items[i].SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("Deployment"))
+		workloads = append(workloads, &deployments.Items[i])
+	}
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.List(ctx, statefulSets, opts...); err != nil {
+		return nil, err
+	}
+	for i := range statefulSets.Items {
+		statefulSets.Items[i].SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("StatefulSet"))
+		workloads = append(workloads, &statefulSets.Items[i])
+	}
+	return workloads, nil
+}
+
+// scaleDownWorkload scales the workload to zero and stores its replicas in an annotation, it
+// returns true if the workload got changed. Workloads which are already at zero replicas or
+// scaled down by another object are left alone, workloads scaled up again within the time
+// window are scaled down again.
+func scaleDownWorkload(workload client.Object, owner string) bool {
+	replicas := workloadReplicas(workload)
+	if *replicas == 0 {
+		return false
+	}
+	annotations := workload.GetAnnotations()
+	if scaledBy, ok := annotations[scaledByAnnotation]; ok {
+		if scaledBy != owner {
+			return false
+		}
+	} else {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[originalReplicasAnnotation] = strconv.Itoa(int(*replicas))
+		annotations[scaledByAnnotation] = owner
+		workload.SetAnnotations(annotations)
+	}
+	*replicas = 0
+	return true
+}
+
+// restoreWorkload restores the replicas of the workload scaled down by owner and removes the
+// annotations, it returns true if the workload got changed.
+func restoreWorkload(workload client.Object, owner string) (bool, error) {
+	annotations := workload.GetAnnotations()
+	if annotations[scaledByAnnotation] != owner {
+		return false, nil
+	}
+	original, err := strconv.ParseInt(annotations[originalReplicasAnnotation], 10, 32)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s annotation: %w", originalReplicasAnnotation, err)
+	}
+	*workloadReplicas(workload) = int32(original)
+	delete(annotations, originalReplicasAnnotation)
+	delete(annotations, scaledByAnnotation)
+	workload.SetAnnotations(annotations)
+	return true, nil
+}
+
+// originalReplicas returns the replicas stored in the annotation of the workload, if it is
+// scaled down by owner.
+func originalReplicas(workload client.Object, owner string) (int32, bool) {
+	annotations := workload.GetAnnotations()
+	if annotations[scaledByAnnotation] != owner {
+		return 0, false
+	}
+	original, err := strconv.ParseInt(annotations[originalReplicasAnnotation], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int32(original), true
+}
+
+// workloadReplicas returns the replicas of the Deployment or StatefulSet, defaulting them to one
+// if they are not set.
+func workloadReplicas(workload client.Object) *int32 {
+	var replicas **int32
+	switch w := workload.(type) {
+	case *appsv1.Deployment:
+		replicas = &w.Spec.Replicas
+	case *appsv1.StatefulSet:
+		replicas = &w.Spec.Replicas
+	default:
+		panic(fmt.Sprintf("unsupported workload %T", workload))
+	}
+	if *replicas == nil {
+		one := int32(1)
+		*replicas = &one
+	}
+	return *replicas
+}
+
+// patchStatus applies mutate on the status of the object and patches the status if it got changed.
+func (r *WorkloadScheduleOptimizerReconciler) patchStatus(ctx context.Context, obj *costoptimizerv1alpha1.WorkloadScheduleOptimizer,
+	mutate func(status *costoptimizerv1alpha1.WorkloadScheduleOptimizerStatus)) {
+	statusPatch := client.MergeFrom(obj.DeepCopy())
+	mutate(&obj.Status)
+	data, err := statusPatch.Data(obj)
+	if err != nil || len(data) <= 2 {
+		return
+	}
+	if err := r.Status().Patch(ctx, obj, statusPatch); err != nil {
+		r.logger.Error(err, "failed to update status")
+	}
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestScaleDownAndRestoreWorkload(t *testing.T) {
+	replicas := int32(3)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "dev", Name: "web"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+
+	if !scaleDownWorkload(deployment, "kubeinbox/nightly") {
+		t.Fatal("expected the deployment to be scaled down")
+	}
+	if *deployment.Spec.Replicas != 0 {
+		t.Errorf("expected zero replicas, got %d", *deployment.Spec.Replicas)
+	}
+	if original, ok := originalReplicas(deployment, "kubeinbox/nightly"); !ok || original != 3 {
+		t.Errorf("expected original replicas 3, got %d (%t)", original, ok)
+	}
+	if scaleDownWorkload(deployment, "kubeinbox/nightly") {
+		t.Error("expected an already scaled down deployment to be left alone")
+	}
+
+	// scaled up within the window, the original replicas are kept.
+	*deployment.Spec.Replicas = 1
+	if !scaleDownWorkload(deployment, "kubeinbox/nightly") || *deployment.Spec.Replicas != 0 {
+		t.Error("expected the deployment to be scaled down again")
+	}
+	if changed, _ := restoreWorkload(deployment, "kubeinbox/other"); changed {
+		t.Error("expected the deployment not to be restored by another object")
+	}
+
+	changed, err := restoreWorkload(deployment, "kubeinbox/nightly")
+	if err != nil || !changed {
+		t.Fatalf("expected the deployment to be restored, got %v", err)
+	}
+	if *deployment.Spec.Replicas != 3 {
+		t.Errorf("expected 3 replicas, got %d", *deployment.Spec.Replicas)
+	}
+	if len(deployment.Annotations) != 0 {
+		t.Errorf("expected the annotations to be removed, got %v", deployment.Annotations)
+	}
+}
+
+func TestScaleDownWorkloadDefaultReplicas(t *testing.T) {
+	statefulSet := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: "dev", Name: "db"}}
+	if !scaleDownWorkload(statefulSet, "kubeinbox/nightly") {
+		t.Fatal("expected the statefulset to be scaled down")
+	}
+	if original, _ := originalReplicas(statefulSet, "kubeinbox/nightly"); original != 1 {
+		t.Errorf("expected original replicas 1, got %d", original)
+	}
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	costoptimizerv1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+
+	"github.com/go-logr/logr"
+)
+
+func init() {
+	registerDriver(workSpacesDriver{})
+}
+
+// workSpacesDriver drives amazon workspaces, only workspaces in AutoStop running mode can be
+// started and stopped.
+type workSpacesDriver struct{}
+
+func (workSpacesDriver) kind() string {
+	return string(costoptimizerv1alpha1.WorkSpaces)
+}
+
+func (workSpacesDriver) resolveTargets(logger logr.Logger, region string, ids []string, tags map[string]string) ([]string, error) {
+	workspaces, err := utils.DescribeWorkSpaces(logger, region, ids)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, workspace := range workspaces {
+		if len(tags) > 0 {
+			// describe-workspaces does not report the tags, they are fetched for each workspace.
+			workspaceTags, err := utils.DescribeWorkSpaceTags(logger, region, workspace.WorkspaceID)
+			if err != nil {
+				return nil, err
+			}
+			if !workspaceTags.Matches(tags) {
+				continue
+			}
+		}
+		targets = append(targets, workspace.WorkspaceID)
+	}
+	return targets, nil
+}
+
+func (workSpacesDriver) getState(logger logr.Logger, region string, ids []string) (map[string]string, error) {
+	workspaces, err := utils.DescribeWorkSpaces(logger, region, ids)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]string, len(workspaces))
+	for _, workspace := range workspaces {
+		states[workspace.WorkspaceID] = workspace.State
+	}
+	return states, nil
+}
+
+func (workSpacesDriver) applyAction(logger logr.Logger, region string, operation costoptimizerv1alpha1.Ec2OperationType,
+	ids []string) ([]utils.InstanceError, error) {
+	if operation == costoptimizerv1alpha1.Start {
+		return applyEach(logger, region, ids, utils.StartWorkSpace)
+	}
+	return applyEach(logger, region, ids, utils.StopWorkSpace)
+}
+
+func (workSpacesDriver) states() (string, string) {
+	return "AVAILABLE", "STOPPED"
+}
+
+// snapshotConfig returns nothing, the running mode of a workspace is left to its owner.
+func (workSpacesDriver) snapshotConfig(logr.Logger, string, []string) (map[string]string, error) {
+	return nil, nil
+}
+
+func (workSpacesDriver) restoreConfig(logr.Logger, string, string, string) error {
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -21,10 +22,16 @@ import (
 
 	kubeinboxiov1alpha1 "github.com/KubeInBox/aws-utility-controller/api/v1alpha1"
 	"github.com/KubeInBox/aws-utility-controller/controllers"
+	"github.com/KubeInBox/aws-utility-controller/pkg/metrics"
+	"github.com/KubeInBox/aws-utility-controller/pkg/pricing"
+	"github.com/KubeInBox/aws-utility-controller/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	//+kubebuilder:scaffold:imports
 )
@@ -45,8 +52,18 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var priceTable string
+	var priceCatalogConfigMap string
+	var defaultRegion string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&priceTable, "price-table", "",
+		"Path to a price table (.yaml) or an ec2 offer file (.json, .csv) of the aws price list, used to estimate savings.")
+	flag.StringVar(&priceCatalogConfigMap, "price-catalog-configmap", "",
+		"ConfigMap as namespace/name, with price tables or ec2 offer files of the aws price list, used to estimate savings.")
+	flag.StringVar(&defaultRegion, "default-region", "",
+		"Region of the objects which do not specify one, if neither AWS_REGION nor AWS_DEFAULT_REGION is set. "+
+			"The region of the aws cli configuration is used if empty.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -65,6 +82,8 @@ func main() {
 	flag.Parse()
 
 	ctrl.SetLogger(runtimezap.New(runtimezap.UseFlagOptions(&opts)))
+	utils.DefaultRegion = defaultRegion
+	utils.APILatencyObserver = metrics.ObserveAPILatency
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
@@ -90,13 +109,155 @@ func main() {
 		os.Exit(1)
 	}
 
+	prices, err := loadPrices(mgr.GetAPIReader(), priceTable, priceCatalogConfigMap)
+	if err != nil {
+		setupLog.Error(err, "unable to load on-demand prices")
+		os.Exit(1)
+	}
+
 	if err = (&controllers.Ec2CostOptimizerReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("ec2costoptimizer-controller"),
+		Prices:   prices,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Ec2CostOptimizer")
 		os.Exit(1)
 	}
+	if err = (&controllers.Ec2RightsizingReportReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("ec2rightsizingreport-controller"),
+		Prices:   prices,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Ec2RightsizingReport")
+		os.Exit(1)
+	}
+	if err = (&controllers.RdsCostOptimizerReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("rdscostoptimizer-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RdsCostOptimizer")
+		os.Exit(1)
+	}
+	if err = (&controllers.AsgCostOptimizerReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("asgcostoptimizer-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AsgCostOptimizer")
+		os.Exit(1)
+	}
+	if err = (&controllers.EksCostOptimizerReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Recorder:  mgr.GetEventRecorderFor("ekscostoptimizer-controller"),
+		APIReader: mgr.GetAPIReader(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "EksCostOptimizer")
+		os.Exit(1)
+	}
+	if err = (&controllers.WorkloadScheduleOptimizerReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("workloadscheduleoptimizer-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "WorkloadScheduleOptimizer")
+		os.Exit(1)
+	}
+	if err = (&controllers.EbsVolumeJanitorReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("ebsvolumejanitor-controller"),
+		Prices:   prices,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "EbsVolumeJanitor")
+		os.Exit(1)
+	}
+	if err = (&controllers.SnapshotRetentionPolicyReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("snapshotretentionpolicy-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SnapshotRetentionPolicy")
+		os.Exit(1)
+	}
+	if err = (&controllers.AmiJanitorReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("amijanitor-controller"),
+		Prices:   prices,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AmiJanitor")
+		os.Exit(1)
+	}
+	if err = (&controllers.ElasticIpJanitorReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("elasticipjanitor-controller"),
+		Prices:   prices,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ElasticIpJanitor")
+		os.Exit(1)
+	}
+	if err = (&controllers.NatGatewayCostOptimizerReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("natgatewaycostoptimizer-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "NatGatewayCostOptimizer")
+		os.Exit(1)
+	}
+	if err = (&controllers.RedshiftCostOptimizerReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("redshiftcostoptimizer-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RedshiftCostOptimizer")
+		os.Exit(1)
+	}
+	if err = (&controllers.SageMakerCostOptimizerReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("sagemakercostoptimizer-controller"),
+		Prices:   prices,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SageMakerCostOptimizer")
+		os.Exit(1)
+	}
+	if err = (&controllers.EcsServiceOptimizerReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("ecsserviceoptimizer-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "EcsServiceOptimizer")
+		os.Exit(1)
+	}
+	if err = (&controllers.DownscaleWindowReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("downscalewindow-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DownscaleWindow")
+		os.Exit(1)
+	}
+	if err = (&controllers.DynamoDbCostOptimizerReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("dynamodbcostoptimizer-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DynamoDbCostOptimizer")
+		os.Exit(1)
+	}
+	if err = (&controllers.ResourceCostOptimizerReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("resourcecostoptimizer-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ResourceCostOptimizer")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -115,6 +276,36 @@ func main() {
 	}
 }
 
+// loadPrices loads the on-demand prices from the price file and the ConfigMap on top of the
+// default list prices.
+func loadPrices(reader client.Reader, path, configMapName string) (pricing.Catalog, error) {
+	table := pricing.DefaultTable()
+	if path != "" {
+		fileTable, err := pricing.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		table.Merge(fileTable)
+	}
+	if configMapName != "" {
+		namespace, name, found := strings.Cut(configMapName, "/")
+		if !found {
+			return nil, fmt.Errorf("invalid configmap %q, expected namespace/name", configMapName)
+		}
+		configMap := &corev1.ConfigMap{}
+		if err := reader.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, configMap); err != nil {
+			return nil, err
+		}
+		configMapTable, err := pricing.LoadConfigMap(configMap)
+		if err != nil {
+			return nil, err
+		}
+		table.Merge(configMapTable)
+	}
+	setupLog.Info("loaded on-demand prices", "count", table.Len())
+	return table, nil
+}
+
 func newLogger() *logrus.Logger {
 	const filePathPrefix = "/workspace/"
 	logger := logrus.StandardLogger()
@@ -0,0 +1,102 @@
+package instancetypes
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+//go:embed catalog.yaml
+var bundledCatalog []byte
+
+// InstanceType are the specs of an ec2 instance type.
+type InstanceType struct {
+	// Name of the instance type, e.g. m5.large.
+	Name string `json:"name"`
+	// VCPUs is the number of virtual cpus.
+	VCPUs int32 `json:"vcpus"`
+	// MemoryMiB is the memory in MiB.
+	MemoryMiB int64 `json:"memory_mib"`
+	// NetworkGbps is the baseline network bandwidth in Gbps.
+	NetworkGbps float64 `json:"network_gbps"`
+}
+
+// Family returns the family of the instance type, e.g. m5 for m5.large.
+func (t InstanceType) Family() string {
+	family, _, _ := strings.Cut(t.Name, ".")
+	return family
+}
+
+// Catalog is a catalog of instance type specs.
+type Catalog struct {
+	types map[string]InstanceType
+}
+
+// Default returns the catalog bundled with the controller.
+func Default() *Catalog {
+	catalog, err := Load(bytes.NewReader(bundledCatalog))
+	if err != nil {
+		panic(fmt.Sprintf("invalid bundled instance type catalog: %v", err))
+	}
+	return catalog
+}
+
+// Load loads a catalog from a yaml or json list of instance types.
+func Load(r io.Reader) (*Catalog, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var types []InstanceType
+	if err := yaml.Unmarshal(data, &types); err != nil {
+		return nil, fmt.Errorf("unable to parse instance type catalog: %w", err)
+	}
+	catalog := &Catalog{types: make(map[string]InstanceType, len(types))}
+	for _, instanceType := range types {
+		if instanceType.Name == "" || instanceType.VCPUs <= 0 || instanceType.MemoryMiB <= 0 {
+			return nil, fmt.Errorf("invalid instance type %+v", instanceType)
+		}
+		catalog.types[instanceType.Name] = instanceType
+	}
+	return catalog, nil
+}
+
+// Lookup returns the specs of the instance type.
+func (c *Catalog) Lookup(name string) (InstanceType, bool) {
+	instanceType, ok := c.types[name]
+	return instanceType, ok
+}
+
+// Smaller returns the instance types of the same family with fewer vcpus or less memory and
+// neither more vcpus nor more memory, smallest first.
+func (c *Catalog) Smaller(name string) []InstanceType {
+	current, ok := c.types[name]
+	if !ok {
+		return nil
+	}
+	var smaller []InstanceType
+	for _, instanceType := range c.types {
+		if instanceType.Family() != current.Family() || instanceType.Name == current.Name ||
+			instanceType.VCPUs > current.VCPUs || instanceType.MemoryMiB > current.MemoryMiB {
+			continue
+		}
+		if instanceType.VCPUs < current.VCPUs || instanceType.MemoryMiB < current.MemoryMiB {
+			smaller = append(smaller, instanceType)
+		}
+	}
+	sort.Slice(smaller, func(i, j int) bool {
+		if smaller[i].VCPUs != smaller[j].VCPUs {
+			return smaller[i].VCPUs < smaller[j].VCPUs
+		}
+		if smaller[i].MemoryMiB != smaller[j].MemoryMiB {
+			return smaller[i].MemoryMiB < smaller[j].MemoryMiB
+		}
+		return smaller[i].Name < smaller[j].Name
+	})
+	return smaller
+}
@@ -0,0 +1,34 @@
+package instancetypes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultCatalog(t *testing.T) {
+	catalog := Default()
+	instanceType, ok := catalog.Lookup("m5.xlarge")
+	if !ok || instanceType.VCPUs != 4 || instanceType.MemoryMiB != 16384 || instanceType.Family() != "m5" {
+		t.Fatalf("unexpected m5.xlarge specs %+v", instanceType)
+	}
+
+	var names []string
+	for _, smaller := range catalog.Smaller("m5.2xlarge") {
+		names = append(names, smaller.Name)
+	}
+	if got := strings.Join(names, ","); got != "m5.large,m5.xlarge" {
+		t.Errorf("expected m5.large,m5.xlarge, got %s", got)
+	}
+	if smaller := catalog.Smaller("t3.nano"); len(smaller) != 0 {
+		t.Errorf("expected no smaller type for t3.nano, got %+v", smaller)
+	}
+	if smaller := catalog.Smaller("x1.unknown"); smaller != nil {
+		t.Errorf("expected no smaller type for unknown type, got %+v", smaller)
+	}
+}
+
+func TestLoadInvalidCatalog(t *testing.T) {
+	if _, err := Load(strings.NewReader("- {name: m5.large, vcpus: 0, memory_mib: 8192}")); err == nil {
+		t.Error("expected error for instance type without vcpus")
+	}
+}
@@ -0,0 +1,179 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const metricsNamespace = "aws_utility_controller"
+
+// Ec2Kind is the kind of the resources whose operations are also recorded in the ec2 metrics.
+const Ec2Kind = "EC2"
+
+// ec2InstanceStates are the states an ec2 instance can be in, see
+// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/ec2-instance-lifecycle.html
+var ec2InstanceStates = []string{"pending", "running", "stopping", "stopped", "shutting-down", "terminated"}
+
+var (
+	operationsAttempted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "ec2",
+		Name:      "operations_attempted_total",
+		Help:      "Number of ec2 operations attempted by operation and region.",
+	}, []string{"operation", "region"})
+
+	operationsSucceeded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "ec2",
+		Name:      "operations_succeeded_total",
+		Help:      "Number of ec2 operations succeeded by operation and region.",
+	}, []string{"operation", "region"})
+
+	operationsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "ec2",
+		Name:      "operations_failed_total",
+		Help:      "Number of ec2 operations failed by operation, region and error reason.",
+	}, []string{"operation", "region", "reason"})
+
+	resourceOperationsAttempted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "resource",
+		Name:      "operations_attempted_total",
+		Help:      "Number of operations attempted by resource kind, operation and region.",
+	}, []string{"kind", "operation", "region"})
+
+	resourceOperationsSucceeded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "resource",
+		Name:      "operations_succeeded_total",
+		Help:      "Number of operations succeeded by resource kind, operation and region.",
+	}, []string{"kind", "operation", "region"})
+
+	resourceOperationsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "resource",
+		Name:      "operations_failed_total",
+		Help:      "Number of operations failed by resource kind, operation, region and error reason.",
+	}, []string{"kind", "operation", "region", "reason"})
+
+	apiLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "aws",
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of aws api calls made through the aws cli, by service and operation.",
+		Buckets:   prometheus.ExponentialBuckets(0.25, 2, 8),
+	}, []string{"service", "operation"})
+
+	managedInstances = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "ec2",
+		Name:      "managed_instances",
+		Help:      "Number of instances managed by an Ec2CostOptimizer by instance state.",
+	}, []string{"namespace", "name", "state"})
+
+	nextScheduledAction = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "ec2",
+		Name:      "next_scheduled_action_seconds",
+		Help:      "Seconds until the next scheduled action of an Ec2CostOptimizer, i.e. the start or end of its time window.",
+	}, []string{"namespace", "name"})
+
+	estimatedHoursSaved = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "ec2",
+		Name:      "estimated_hours_saved_total",
+		Help:      "Estimated instance hours saved by keeping instances stopped.",
+	}, []string{"namespace", "name"})
+
+	estimatedSavings = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "ec2",
+		Name:      "estimated_savings_dollars",
+		Help:      "Cumulative estimated savings of an Ec2CostOptimizer in USD, based on on-demand prices.",
+	}, []string{"namespace", "name"})
+)
+
+func init() {
+	// register custom metrics with the global registry of controller-runtime, which is
+	// served by the metrics endpoint of the manager.
+	metrics.Registry.MustRegister(
+		operationsAttempted,
+		operationsSucceeded,
+		operationsFailed,
+		resourceOperationsAttempted,
+		resourceOperationsSucceeded,
+		resourceOperationsFailed,
+		apiLatency,
+		managedInstances,
+		nextScheduledAction,
+		estimatedHoursSaved,
+		estimatedSavings,
+	)
+}
+
+// OperationAttempted records an attempt of the operation on resources of the kind, the ec2
+// metrics are kept for ec2 operations so that existing dashboards keep working.
+func OperationAttempted(kind, operation, region string) {
+	resourceOperationsAttempted.WithLabelValues(kind, operation, region).Inc()
+	if kind == Ec2Kind {
+		operationsAttempted.WithLabelValues(operation, region).Inc()
+	}
+}
+
+// OperationSucceeded records a successful operation on resources of the kind.
+func OperationSucceeded(kind, operation, region string) {
+	resourceOperationsSucceeded.WithLabelValues(kind, operation, region).Inc()
+	if kind == Ec2Kind {
+		operationsSucceeded.WithLabelValues(operation, region).Inc()
+	}
+}
+
+// OperationFailed records a failed operation on resources of the kind along with the reason
+// of the failure.
+func OperationFailed(kind, operation, region, reason string) {
+	resourceOperationsFailed.WithLabelValues(kind, operation, region, reason).Inc()
+	if kind == Ec2Kind {
+		operationsFailed.WithLabelValues(operation, region, reason).Inc()
+	}
+}
+
+// ObserveAPILatency records the duration of an aws api call.
+func ObserveAPILatency(service, operation string, duration time.Duration) {
+	apiLatency.WithLabelValues(service, operation).Observe(duration.Seconds())
+}
+
+// SetManagedInstances records the number of instances by state for an object, states
+// missing in counts are reported as zero.
+func SetManagedInstances(namespace, name string, counts map[string]int) {
+	for _, state := range ec2InstanceStates {
+		managedInstances.WithLabelValues(namespace, name, state).Set(float64(counts[state]))
+	}
+}
+
+// SetNextScheduledAction records the time until the next scheduled action of an object.
+func SetNextScheduledAction(namespace, name string, after time.Duration) {
+	nextScheduledAction.WithLabelValues(namespace, name).Set(after.Seconds())
+}
+
+// AddHoursSaved adds to the estimated instance hours saved by an object.
+func AddHoursSaved(namespace, name string, hours float64) {
+	estimatedHoursSaved.WithLabelValues(namespace, name).Add(hours)
+}
+
+// SetEstimatedSavings records the cumulative estimated savings of an object.
+func SetEstimatedSavings(namespace, name string, dollars float64) {
+	estimatedSavings.WithLabelValues(namespace, name).Set(dollars)
+}
+
+// DeleteObjectMetrics removes the metrics reported for a deleted object.
+func DeleteObjectMetrics(namespace, name string) {
+	for _, state := range ec2InstanceStates {
+		managedInstances.DeleteLabelValues(namespace, name, state)
+	}
+	nextScheduledAction.DeleteLabelValues(namespace, name)
+	estimatedHoursSaved.DeleteLabelValues(namespace, name)
+	estimatedSavings.DeleteLabelValues(namespace, name)
+}
@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// gather returns the metrics of the family from the controller-runtime registry.
+func gather(t *testing.T, name string) []*dto.Metric {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatalf("unable to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()
+		}
+	}
+	return nil
+}
+
+// find returns the metric matching all the given labels.
+func find(metrics []*dto.Metric, labels map[string]string) *dto.Metric {
+	for _, metric := range metrics {
+		matched := 0
+		for _, label := range metric.GetLabel() {
+			if value, ok := labels[label.GetName()]; ok && value == label.GetValue() {
+				matched++
+			}
+		}
+		if matched == len(labels) {
+			return metric
+		}
+	}
+	return nil
+}
+
+func TestOperationMetrics(t *testing.T) {
+	OperationAttempted(Ec2Kind, "Stop", "ap-south-1")
+	OperationAttempted(Ec2Kind, "Stop", "ap-south-1")
+	OperationSucceeded(Ec2Kind, "Stop", "ap-south-1")
+	OperationFailed(Ec2Kind, "Stop", "ap-south-1", "Throttled")
+	OperationAttempted("Lightsail", "Stop", "ap-south-1")
+
+	labels := map[string]string{"operation": "Stop", "region": "ap-south-1"}
+	if metric := find(gather(t, "aws_utility_controller_ec2_operations_attempted_total"), labels); metric.GetCounter().GetValue() != 2 {
+		t.Errorf("expected 2 attempted operations, got %v", metric)
+	}
+	if metric := find(gather(t, "aws_utility_controller_ec2_operations_succeeded_total"), labels); metric.GetCounter().GetValue() != 1 {
+		t.Errorf("expected 1 succeeded operation, got %v", metric)
+	}
+	labels["reason"] = "Throttled"
+	if metric := find(gather(t, "aws_utility_controller_ec2_operations_failed_total"), labels); metric.GetCounter().GetValue() != 1 {
+		t.Errorf("expected 1 failed operation, got %v", metric)
+	}
+
+	labels = map[string]string{"kind": "Lightsail", "operation": "Stop", "region": "ap-south-1"}
+	if metric := find(gather(t, "aws_utility_controller_resource_operations_attempted_total"), labels); metric.GetCounter().GetValue() != 1 {
+		t.Errorf("expected 1 attempted lightsail operation, got %v", metric)
+	}
+	labels["kind"] = Ec2Kind
+	if metric := find(gather(t, "aws_utility_controller_resource_operations_attempted_total"), labels); metric.GetCounter().GetValue() != 2 {
+		t.Errorf("expected 2 attempted ec2 operations, got %v", metric)
+	}
+}
+
+func TestAPILatency(t *testing.T) {
+	ObserveAPILatency("ec2", "describe-instances", 300*time.Millisecond)
+
+	labels := map[string]string{"service": "ec2", "operation": "describe-instances"}
+	metric := find(gather(t, "aws_utility_controller_aws_api_request_duration_seconds"), labels)
+	if metric.GetHistogram().GetSampleCount() != 1 || metric.GetHistogram().GetSampleSum() != 0.3 {
+		t.Errorf("expected a single observation of 0.3s, got %v", metric)
+	}
+}
+
+func TestObjectMetrics(t *testing.T) {
+	SetManagedInstances("kubeinbox", "sample", map[string]int{"stopped": 2, "running": 1})
+	SetNextScheduledAction("kubeinbox", "sample", 90*time.Second)
+	AddHoursSaved("kubeinbox", "sample", 1.5)
+	AddHoursSaved("kubeinbox", "sample", 0.5)
+	SetEstimatedSavings("kubeinbox", "sample", 12.5)
+
+	object := map[string]string{"namespace": "kubeinbox", "name": "sample"}
+	for state, expected := range map[string]float64{"stopped": 2, "running": 1, "pending": 0} {
+		labels := map[string]string{"namespace": "kubeinbox", "name": "sample", "state": state}
+		if metric := find(gather(t, "aws_utility_controller_ec2_managed_instances"), labels); metric.GetGauge().GetValue() != expected {
+			t.Errorf("expected %v %s instances, got %v", expected, state, metric)
+		}
+	}
+	if metric := find(gather(t, "aws_utility_controller_ec2_next_scheduled_action_seconds"), object); metric.GetGauge().GetValue() != 90 {
+		t.Errorf("expected next scheduled action in 90s, got %v", metric)
+	}
+	if metric := find(gather(t, "aws_utility_controller_ec2_estimated_hours_saved_total"), object); metric.GetCounter().GetValue() != 2 {
+		t.Errorf("expected 2 hours saved, got %v", metric)
+	}
+	if metric := find(gather(t, "aws_utility_controller_ec2_estimated_savings_dollars"), object); metric.GetGauge().GetValue() != 12.5 {
+		t.Errorf("expected 12.5 dollars saved, got %v", metric)
+	}
+
+	DeleteObjectMetrics("kubeinbox", "sample")
+	if metric := find(gather(t, "aws_utility_controller_ec2_managed_instances"), object); metric != nil {
+		t.Errorf("expected managed instances to be deleted, got %v", metric)
+	}
+	if metric := find(gather(t, "aws_utility_controller_ec2_estimated_hours_saved_total"), object); metric != nil {
+		t.Errorf("expected hours saved to be deleted, got %v", metric)
+	}
+	if metric := find(gather(t, "aws_utility_controller_ec2_estimated_savings_dollars"), object); metric != nil {
+		t.Errorf("expected estimated savings to be deleted, got %v", metric)
+	}
+}
@@ -0,0 +1,19 @@
+package pricing
+
+// elasticIPHourlyPrice is the hourly list price of a public ipv4 address in USD, charged for
+// elastic ips whether they are associated or not.
+const elasticIPHourlyPrice = 0.005
+
+func elasticIPListPrices() map[Key]float64 {
+	return map[Key]float64{{Product: ProductElasticIP}: elasticIPHourlyPrice}
+}
+
+// ElasticIPMonthlyPrice returns the estimated monthly price of an elastic ip address in the region
+// in USD.
+func ElasticIPMonthlyPrice(catalog Catalog, region string) (float64, bool) {
+	if catalog == nil {
+		return 0, false
+	}
+	price, ok := catalog.Price(Key{Product: ProductElasticIP, Region: region})
+	return price * HoursPerMonth, ok
+}
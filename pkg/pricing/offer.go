@@ -0,0 +1,331 @@
+package pricing
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// locationRegions maps the locations used by older offer files, which do not have a region
+// code attribute, to the region code.
+var locationRegions = map[string]string{
+	"US East (N. Virginia)":      "us-east-1",
+	"US East (Ohio)":             "us-east-2",
+	"US West (N. California)":    "us-west-1",
+	"US West (Oregon)":           "us-west-2",
+	"Africa (Cape Town)":         "af-south-1",
+	"Asia Pacific (Hong Kong)":   "ap-east-1",
+	"Asia Pacific (Mumbai)":      "ap-south-1",
+	"Asia Pacific (Osaka)":       "ap-northeast-3",
+	"Asia Pacific (Seoul)":       "ap-northeast-2",
+	"Asia Pacific (Singapore)":   "ap-southeast-1",
+	"Asia Pacific (Sydney)":      "ap-southeast-2",
+	"Asia Pacific (Jakarta)":     "ap-southeast-3",
+	"Asia Pacific (Tokyo)":       "ap-northeast-1",
+	"Canada (Central)":           "ca-central-1",
+	"EU (Frankfurt)":             "eu-central-1",
+	"EU (Ireland)":               "eu-west-1",
+	"EU (London)":                "eu-west-2",
+	"EU (Milan)":                 "eu-south-1",
+	"EU (Paris)":                 "eu-west-3",
+	"EU (Stockholm)":             "eu-north-1",
+	"Middle East (Bahrain)":      "me-south-1",
+	"South America (Sao Paulo)":  "sa-east-1",
+	"AWS GovCloud (US-East)":     "us-gov-east-1",
+	"AWS GovCloud (US-West)":     "us-gov-west-1",
+	"Middle East (UAE)":          "me-central-1",
+	"Asia Pacific (Hyderabad)":   "ap-south-2",
+	"EU (Spain)":                 "eu-south-2",
+	"EU (Zurich)":                "eu-central-2",
+	"Asia Pacific (Melbourne)":   "ap-southeast-4",
+	"Israel (Tel Aviv)":          "il-central-1",
+	"Canada West (Calgary)":      "ca-west-1",
+	"Asia Pacific (Malaysia)":    "ap-southeast-5",
+	"Asia Pacific (Thailand)":    "ap-southeast-7",
+	"Mexico (Central)":           "mx-central-1",
+	"Asia Pacific (Taipei)":      "ap-east-2",
+	"Asia Pacific (New Zealand)": "ap-southeast-6",
+}
+
+// csvColumns maps the columns of the csv offer file to the product attributes of the json
+// offer file, so that both formats are indexed the same way.
+var csvColumns = map[string]string{
+	"SKU":               "sku",
+	"TermType":          "termType",
+	"Unit":              "unit",
+	"PricePerUnit":      "pricePerUnit",
+	"Currency":          "currency",
+	"Product Family":    "productFamily",
+	"Location":          "location",
+	"Region Code":       "regionCode",
+	"Instance Type":     "instanceType",
+	"Tenancy":           "tenancy",
+	"Operating System":  "operatingSystem",
+	"Pre Installed S/W": "preInstalledSw",
+	"License Model":     "licenseModel",
+	"CapacityStatus":    "capacitystatus",
+	"MarketOption":      "marketoption",
+}
+
+// offerProduct is a product of the json offer file of the aws price list, see
+// https://docs.aws.amazon.com/awsaccountbilling/latest/aboutv2/reading-an-offer.html
+type offerProduct struct {
+	SKU           string            `json:"sku"`
+	ProductFamily string            `json:"productFamily"`
+	Attributes    map[string]string `json:"attributes"`
+}
+
+type offerTerm struct {
+	PriceDimensions map[string]struct {
+		Unit         string            `json:"unit"`
+		PricePerUnit map[string]string `json:"pricePerUnit"`
+	} `json:"priceDimensions"`
+}
+
+// LoadOfferJSON loads the on-demand compute prices from an ec2 offer file in json format. A
+// regional offer file is hundreds of MB, it is streamed and only the hourly on-demand prices of
+// the compute instances are kept.
+func LoadOfferJSON(r io.Reader) (*Table, error) {
+	decoder := json.NewDecoder(r)
+	// the products precede the terms in the offer files, the prices of the other products are
+	// skipped once the products are known.
+	keys := map[string]Key{}
+	productsRead := false
+	prices := map[string]string{}
+	err := readObject(decoder, func(field string) error {
+		switch field {
+		case "offerCode":
+			var offerCode string
+			if err := decoder.Decode(&offerCode); err != nil {
+				return err
+			}
+			if offerCode != "" && offerCode != "AmazonEC2" {
+				return fmt.Errorf("unsupported offer %s", offerCode)
+			}
+			return nil
+		case "products":
+			productsRead = true
+			return readProducts(decoder, keys)
+		case "terms":
+			return readOnDemandPrices(decoder, prices, func(sku string) bool {
+				_, ok := keys[sku]
+				return ok || !productsRead
+			})
+		}
+		return skipValue(decoder)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse offer file: %w", err)
+	}
+
+	table := &Table{prices: map[Key]float64{}}
+	for sku, price := range prices {
+		key, ok := keys[sku]
+		if !ok {
+			continue
+		}
+		if err := table.add(key, price); err != nil {
+			return nil, fmt.Errorf("sku %s: %w", sku, err)
+		}
+	}
+	return table, nil
+}
+
+// readProducts reads the products of the offer file and records the key of the compute instances
+// by sku.
+func readProducts(decoder *json.Decoder, keys map[string]Key) error {
+	return readObject(decoder, func(sku string) error {
+		var product offerProduct
+		if err := decoder.Decode(&product); err != nil {
+			return err
+		}
+		if product.ProductFamily != "Compute Instance" {
+			return nil
+		}
+		if key, ok := computeInstanceKey(product.Attributes); ok {
+			keys[sku] = key
+		}
+		return nil
+	})
+}
+
+// readOnDemandPrices reads the terms of the offer file and records the hourly on-demand price in
+// USD of the wanted skus, the other terms are skipped.
+func readOnDemandPrices(decoder *json.Decoder, prices map[string]string, wanted func(sku string) bool) error {
+	return readObject(decoder, func(termType string) error {
+		if termType != "OnDemand" {
+			return skipValue(decoder)
+		}
+		return readObject(decoder, func(sku string) error {
+			if !wanted(sku) {
+				return skipValue(decoder)
+			}
+			var terms map[string]offerTerm
+			if err := decoder.Decode(&terms); err != nil {
+				return err
+			}
+			for _, term := range terms {
+				for _, dimension := range term.PriceDimensions {
+					if dimension.Unit == "Hrs" {
+						prices[sku] = dimension.PricePerUnit["USD"]
+					}
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// readObject reads a json object, field is called for every field of the object and has to read
+// its value.
+func readObject(decoder *json.Decoder, field func(name string) error) error {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		name, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("unexpected token %v", token)
+		}
+		if err := field(name); err != nil {
+			return err
+		}
+	}
+	return expectDelim(decoder, '}')
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+// skipValue skips the next value token by token, so that large values are not kept in memory.
+func skipValue(decoder *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// LoadOfferCSV loads the on-demand compute prices from an ec2 offer file in csv format, the
+// metadata rows preceding the header row are skipped.
+func LoadOfferCSV(r io.Reader) (*Table, error) {
+	reader := csv.NewReader(r)
+	// metadata rows have fewer fields than the price rows.
+	reader.FieldsPerRecord = -1
+
+	var columns map[string]int
+	table := &Table{prices: map[Key]float64{}}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse offer file: %w", err)
+		}
+		if columns == nil {
+			if len(record) > 0 && record[0] == "SKU" {
+				columns = map[string]int{}
+				for i, column := range record {
+					if attribute, ok := csvColumns[column]; ok {
+						columns[attribute] = i
+					}
+				}
+			}
+			continue
+		}
+
+		attributes := make(map[string]string, len(columns))
+		for attribute, i := range columns {
+			if i < len(record) {
+				attributes[attribute] = record[i]
+			}
+		}
+		if attributes["termType"] != "OnDemand" || attributes["productFamily"] != "Compute Instance" ||
+			attributes["unit"] != "Hrs" || attributes["currency"] != "USD" {
+			continue
+		}
+		key, ok := computeInstanceKey(attributes)
+		if !ok {
+			continue
+		}
+		if err := table.add(key, attributes["pricePerUnit"]); err != nil {
+			return nil, fmt.Errorf("sku %s: %w", attributes["sku"], err)
+		}
+	}
+	if columns == nil {
+		return nil, errors.New("unable to parse offer file: header row not found")
+	}
+	return table, nil
+}
+
+// computeInstanceKey returns the key for the product attributes of a compute instance, products
+// which are not plain on-demand instances, e.g. with pre installed software, bring your own
+// license or capacity reservations, are ignored.
+func computeInstanceKey(attributes map[string]string) (Key, bool) {
+	if attributes["instanceType"] == "" || attributes["operatingSystem"] == "" {
+		return Key{}, false
+	}
+	if software := attributes["preInstalledSw"]; software != "" && software != "NA" {
+		return Key{}, false
+	}
+	if attributes["licenseModel"] == "Bring your own license" {
+		return Key{}, false
+	}
+	if capacity := attributes["capacitystatus"]; capacity != "" && capacity != "Used" {
+		return Key{}, false
+	}
+	if market := attributes["marketoption"]; market != "" && market != "OnDemand" {
+		return Key{}, false
+	}
+
+	region := attributes["regionCode"]
+	if region == "" {
+		region = locationRegions[attributes["location"]]
+	}
+	if region == "" {
+		return Key{}, false
+	}
+	return Key{
+		InstanceType:    attributes["instanceType"],
+		Region:          region,
+		Tenancy:         attributes["tenancy"],
+		OperatingSystem: attributes["operatingSystem"],
+	}, true
+}
+
+func (t *Table) add(key Key, pricePerUnit string) error {
+	price, err := strconv.ParseFloat(strings.TrimSpace(pricePerUnit), 64)
+	if err != nil {
+		return fmt.Errorf("invalid price %q: %w", pricePerUnit, err)
+	}
+	t.prices[key.withDefaults()] = price
+	return nil
+}
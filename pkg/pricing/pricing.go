@@ -0,0 +1,231 @@
+package pricing
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultTenancy is the tenancy of instances running on shared hardware.
+const DefaultTenancy = "Shared"
+
+// HoursPerMonth is the number of hours of a month used by aws to compute monthly prices.
+const HoursPerMonth = 730
+
+// Products priced by the catalog besides ec2 instances, the prices of ec2 instances have no
+// product.
+const (
+	// ProductEbsVolume is the storage of ebs volumes, the InstanceType of the key is the volume
+	// type and the price is per GiB-month.
+	ProductEbsVolume = "EbsVolume"
+	// ProductEbsSnapshot is the storage of ebs snapshots, the price is per GiB-month.
+	ProductEbsSnapshot = "EbsSnapshot"
+	// ProductElasticIP is a public ipv4 address, the price is per hour.
+	ProductElasticIP = "ElasticIP"
+	// ProductSageMaker are the sagemaker notebook and studio instances, the InstanceType of the
+	// key is the ml instance type and the price is per hour.
+	ProductSageMaker = "SageMaker"
+)
+
+// Catalog answers on-demand price lookups, prices are in USD per hour for instances and per
+// unit of the product otherwise.
+type Catalog interface {
+	Price(key Key) (float64, bool)
+}
+
+// Key identifies an on-demand price.
+type Key struct {
+	// Product of the price, empty for ec2 instances.
+	Product string
+	// InstanceType e.g. t3.micro.
+	InstanceType string
+	// Region code, e.g. us-east-1. Prices without region apply to all the regions which have
+	// no price of their own.
+	Region string
+	// Tenancy as used by the aws price list, i.e. Shared, Dedicated or Host. Defaults to Shared.
+	Tenancy string
+	// OperatingSystem as used by the aws price list, e.g. Linux, Windows, RHEL.
+	OperatingSystem string
+}
+
+// Table is an offline table of hourly on-demand prices.
+type Table struct {
+	prices map[Key]float64
+}
+
+// TableEntry is an entry of the price table file.
+type TableEntry struct {
+	// Product of the price, empty for ec2 instances.
+	Product      string `json:"product,omitempty"`
+	InstanceType string `json:"instance_type,omitempty"`
+	// Region defaults to all the regions.
+	Region string `json:"region,omitempty"`
+	// Tenancy defaults to Shared.
+	Tenancy         string `json:"tenancy,omitempty"`
+	OperatingSystem string `json:"operating_system,omitempty"`
+	// HourlyPrice in USD, e.g. "0.0104", per unit of the product for the products which are not
+	// charged by the hour.
+	HourlyPrice string `json:"hourly_price"`
+}
+
+// NewTable returns a price table for the given entries.
+func NewTable(entries []TableEntry) (*Table, error) {
+	table := &Table{prices: make(map[Key]float64, len(entries))}
+	for _, entry := range entries {
+		key := Key{Product: entry.Product, InstanceType: entry.InstanceType, Region: entry.Region, Tenancy: entry.Tenancy,
+			OperatingSystem: entry.OperatingSystem}
+		if err := table.add(key, entry.HourlyPrice); err != nil {
+			return nil, fmt.Errorf("%s in %s: %w", entry.InstanceType, entry.Region, err)
+		}
+	}
+	return table, nil
+}
+
+// LoadTable loads the price table from a yaml or json list of entries.
+func LoadTable(r io.Reader) (*Table, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var entries []TableEntry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("unable to parse price table: %w", err)
+	}
+	return NewTable(entries)
+}
+
+// Load loads the prices from a file, the format is chosen by the file extension: offer files of
+// the aws price list are expected to be .json or .csv files, and price tables .yaml files.
+func Load(path string) (*Table, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	table, err := load(filepath.Base(path), file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return table, nil
+}
+
+// LoadConfigMap loads the prices from all the files of a ConfigMap, the format of each file is
+// chosen by its extension like in Load.
+func LoadConfigMap(configMap *corev1.ConfigMap) (*Table, error) {
+	files := map[string][]byte{}
+	for name, data := range configMap.Data {
+		files[name] = []byte(data)
+	}
+	for name, data := range configMap.BinaryData {
+		files[name] = data
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	merged := &Table{prices: map[Key]float64{}}
+	for _, name := range names {
+		table, err := load(name, bytes.NewReader(files[name]))
+		if err != nil {
+			return nil, fmt.Errorf("configmap %s/%s, key %s: %w", configMap.Namespace, configMap.Name, name, err)
+		}
+		merged.Merge(table)
+	}
+	return merged, nil
+}
+
+func load(name string, r io.Reader) (*Table, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return LoadOfferJSON(r)
+	case ".csv":
+		return LoadOfferCSV(r)
+	case ".yaml", ".yml":
+		return LoadTable(r)
+	}
+	return nil, fmt.Errorf("unsupported price file %s", name)
+}
+
+// Merge adds the prices of other to the table, prices of other take precedence.
+func (t *Table) Merge(other *Table) {
+	for key, price := range other.prices {
+		t.prices[key] = price
+	}
+}
+
+// Len returns the number of prices in the table.
+func (t *Table) Len() int {
+	return len(t.prices)
+}
+
+// Price returns the on-demand price for the key, the price of all the regions is returned if the
+// region of the key has no price of its own.
+func (t *Table) Price(key Key) (float64, bool) {
+	key = key.withDefaults()
+	if price, ok := t.prices[key]; ok {
+		return price, true
+	}
+	key.Region = ""
+	price, ok := t.prices[key]
+	return price, ok
+}
+
+func (k Key) withDefaults() Key {
+	if k.Product == "" && k.Tenancy == "" {
+		k.Tenancy = DefaultTenancy
+	}
+	return k
+}
+
+// DefaultTable returns a table with the list prices of the products other than ec2 instances for
+// all the regions. They are the us-east-1 prices, other regions differ by a few percent at most
+// which is accurate enough for estimates. Price files override them like any other price.
+func DefaultTable() *Table {
+	table := &Table{prices: map[Key]float64{}}
+	for _, prices := range []map[Key]float64{ebsListPrices(), elasticIPListPrices(), sageMakerListPrices()} {
+		for key, price := range prices {
+			table.prices[key.withDefaults()] = price
+		}
+	}
+	return table
+}
+
+// OperatingSystem maps the platform details reported by ec2, e.g. Linux/UNIX, to the
+// operating system used by the aws price list, e.g. Linux.
+func OperatingSystem(platformDetails string) string {
+	switch {
+	case platformDetails == "" || platformDetails == "Linux/UNIX":
+		return "Linux"
+	case strings.HasPrefix(platformDetails, "Windows"):
+		return "Windows"
+	case strings.HasPrefix(platformDetails, "Red Hat Enterprise Linux with HA"):
+		return "Red Hat Enterprise Linux with HA"
+	case strings.HasPrefix(platformDetails, "Red Hat Enterprise Linux"):
+		return "RHEL"
+	case strings.HasPrefix(platformDetails, "SUSE"):
+		return "SUSE"
+	}
+	return platformDetails
+}
+
+// Tenancy maps the placement tenancy reported by ec2, e.g. default, to the tenancy used by the
+// aws price list, e.g. Shared.
+func Tenancy(placementTenancy string) string {
+	switch placementTenancy {
+	case "dedicated":
+		return "Dedicated"
+	case "host":
+		return "Host"
+	}
+	return DefaultTenancy
+}
@@ -0,0 +1,192 @@
+package pricing
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// offerPrices are the prices expected from the offer file fixtures, the fixtures also contain
+// products which must be ignored: reserved terms, pre installed software, bring your own license,
+// unused capacity reservations and storage.
+var offerPrices = map[Key]float64{
+	{InstanceType: "t3.micro", Region: "us-east-1", OperatingSystem: "Linux"}:                       0.0104,
+	{InstanceType: "t3.micro", Region: "us-east-1", Tenancy: "Dedicated", OperatingSystem: "Linux"}: 0.0114,
+	{InstanceType: "m5.large", Region: "ap-south-1", OperatingSystem: "Windows"}:                    0.193,
+}
+
+func expectPrices(t *testing.T, table *Table, expected map[Key]float64) {
+	t.Helper()
+	if table.Len() != len(expected) {
+		t.Errorf("expected %d prices, got %d: %v", len(expected), table.Len(), table.prices)
+	}
+	for key, want := range expected {
+		if got, ok := table.Price(key); !ok || got != want {
+			t.Errorf("%+v: expected price %v, got %v (found: %v)", key, want, got, ok)
+		}
+	}
+}
+
+func TestLoadOfferFiles(t *testing.T) {
+	for _, name := range []string{"offer.json", "offer.csv"} {
+		t.Run(name, func(t *testing.T) {
+			table, err := Load(filepath.Join("testdata", name))
+			if err != nil {
+				t.Fatalf("unable to load offer file: %v", err)
+			}
+			expectPrices(t, table, offerPrices)
+			if _, ok := table.Price(Key{InstanceType: "t3.micro", Region: "us-east-1", OperatingSystem: "Windows"}); ok {
+				t.Errorf("expected no price for an unknown operating system")
+			}
+		})
+	}
+}
+
+func TestLoadOfferJSONStreaming(t *testing.T) {
+	// the terms precede the products and unknown fields hold values of any type.
+	table, err := LoadOfferJSON(strings.NewReader(`{
+  "offerCode": "AmazonEC2",
+  "attributesList": {"instanceType": ["t3.micro", {"nested": [1, 2, null, true]}]},
+  "terms": {
+    "Reserved": {"SKU1": {"SKU1.R": {"priceDimensions": {"SKU1.R.1": {"unit": "Hrs", "pricePerUnit": {"USD": "0.0060"}}}}}},
+    "OnDemand": {
+      "SKU1": {"SKU1.OD": {"priceDimensions": {"SKU1.OD.1": {"unit": "Hrs", "pricePerUnit": {"USD": "0.0104"}}}}},
+      "SKU2": {"SKU2.OD": {"priceDimensions": {"SKU2.OD.1": {"unit": "GB-Mo", "pricePerUnit": {"USD": "0.08"}}}}}
+    }
+  },
+  "products": {
+    "SKU1": {"sku": "SKU1", "productFamily": "Compute Instance",
+      "attributes": {"instanceType": "t3.micro", "operatingSystem": "Linux", "tenancy": "Shared", "regionCode": "us-east-1"}},
+    "SKU2": {"sku": "SKU2", "productFamily": "Storage", "attributes": {"volumeApiName": "gp3"}}
+  }
+}`))
+	if err != nil {
+		t.Fatalf("unable to load offer file: %v", err)
+	}
+	expectPrices(t, table, map[Key]float64{{InstanceType: "t3.micro", Region: "us-east-1", OperatingSystem: "Linux"}: 0.0104})
+
+	for name, data := range map[string]string{
+		"other offer": `{"offerCode": "AmazonRDS", "products": {}}`,
+		"truncated":   `{"offerCode": "AmazonEC2", "products": {"SKU1": {"sku": "SKU1"`,
+		"not a json":  `offerCode`,
+	} {
+		if _, err := LoadOfferJSON(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestDefaultTable(t *testing.T) {
+	table := DefaultTable()
+	if price, ok := VolumeMonthlyPrice(table, "ap-south-1", "gp3", 100); !ok || price != 8 {
+		t.Errorf("expected the list price of a 100GiB gp3 volume, got %v (found: %v)", price, ok)
+	}
+	if _, ok := VolumeMonthlyPrice(table, "ap-south-1", "unknown", 100); ok {
+		t.Errorf("expected no price for an unknown volume type")
+	}
+	if price, ok := ElasticIPMonthlyPrice(table, "us-east-1"); !ok || price != elasticIPHourlyPrice*HoursPerMonth {
+		t.Errorf("expected the list price of an elastic ip, got %v (found: %v)", price, ok)
+	}
+
+	// regional prices of a price file take precedence over the list prices.
+	regional, err := NewTable([]TableEntry{
+		{Product: ProductSageMaker, InstanceType: "ml.t3.medium", Region: "ap-south-1", HourlyPrice: "0.06"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table.Merge(regional)
+	if price, ok := SageMakerHourlyPrice(table, "ap-south-1", "ml.t3.medium"); !ok || price != 0.06 {
+		t.Errorf("expected the regional price, got %v (found: %v)", price, ok)
+	}
+	if price, ok := SageMakerHourlyPrice(table, "us-east-1", "ml.t3.medium"); !ok || price != 0.05 {
+		t.Errorf("expected the list price in other regions, got %v (found: %v)", price, ok)
+	}
+	if _, ok := SnapshotMonthlyPrice(nil, "us-east-1", 10); ok {
+		t.Errorf("expected no price without catalog")
+	}
+}
+
+func TestLoadTable(t *testing.T) {
+	table, err := LoadTable(strings.NewReader(`
+- instance_type: t3.micro
+  region: us-east-1
+  operating_system: Linux
+  hourly_price: "0.0104"
+- instance_type: t3.micro
+  region: us-east-1
+  tenancy: Dedicated
+  operating_system: Linux
+  hourly_price: "0.0114"
+`))
+	if err != nil {
+		t.Fatalf("unable to load price table: %v", err)
+	}
+	// entries without tenancy are prices of instances on shared hardware.
+	expectPrices(t, table, map[Key]float64{
+		{InstanceType: "t3.micro", Region: "us-east-1", Tenancy: "Shared", OperatingSystem: "Linux"}:    0.0104,
+		{InstanceType: "t3.micro", Region: "us-east-1", Tenancy: "Dedicated", OperatingSystem: "Linux"}: 0.0114,
+	})
+
+	for name, data := range map[string]string{
+		"invalid price": "- {instance_type: t3.micro, region: us-east-1, operating_system: Linux, hourly_price: free}",
+		"invalid yaml":  "instance_type: t3.micro",
+	} {
+		if _, err := LoadTable(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLoadConfigMap(t *testing.T) {
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "kubeinbox", Name: "prices"}, Data: map[string]string{}}
+	for _, name := range []string{"offer.json", "price-table.yaml"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("unable to read fixture: %v", err)
+		}
+		configMap.Data[name] = string(data)
+	}
+
+	table, err := LoadConfigMap(configMap)
+	if err != nil {
+		t.Fatalf("unable to load configmap: %v", err)
+	}
+	// files are merged in the order of their names, the price table overrides the offer file.
+	expectPrices(t, table, map[Key]float64{
+		{InstanceType: "t3.micro", Region: "us-east-1", OperatingSystem: "Linux"}:                       0.0110,
+		{InstanceType: "t3.micro", Region: "us-east-1", Tenancy: "Dedicated", OperatingSystem: "Linux"}: 0.0114,
+		{InstanceType: "m5.large", Region: "ap-south-1", OperatingSystem: "Windows"}:                    0.193,
+		{InstanceType: "c5.xlarge", Region: "eu-west-1", Tenancy: "Dedicated", OperatingSystem: "RHEL"}: 0.272,
+	})
+
+	configMap.Data["prices.txt"] = "t3.micro 0.0104"
+	if _, err := LoadConfigMap(configMap); err == nil {
+		t.Errorf("expected an error for an unsupported file")
+	}
+}
+
+func TestInstanceAttributes(t *testing.T) {
+	for platform, os := range map[string]string{
+		"Linux/UNIX":                       "Linux",
+		"Windows":                          "Windows",
+		"Windows with SQL Server Standard": "Windows",
+		"Red Hat Enterprise Linux":         "RHEL",
+		"SUSE Linux":                       "SUSE",
+		"Red Hat Enterprise Linux with HA": "Red Hat Enterprise Linux with HA",
+		"Ubuntu Pro":                       "Ubuntu Pro",
+	} {
+		if got := OperatingSystem(platform); got != os {
+			t.Errorf("%s: expected operating system %s, got %s", platform, os, got)
+		}
+	}
+	for placement, tenancy := range map[string]string{"default": "Shared", "dedicated": "Dedicated", "host": "Host"} {
+		if got := Tenancy(placement); got != tenancy {
+			t.Errorf("%s: expected tenancy %s, got %s", placement, tenancy, got)
+		}
+	}
+}
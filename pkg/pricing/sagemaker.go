@@ -0,0 +1,39 @@
+package pricing
+
+// sageMakerHourlyPrices are the hourly list prices of the sagemaker notebook and studio instance
+// types in USD.
+var sageMakerHourlyPrices = map[string]float64{
+	"ml.t3.medium":   0.05,
+	"ml.t3.large":    0.10,
+	"ml.t3.xlarge":   0.20,
+	"ml.t3.2xlarge":  0.399,
+	"ml.m5.large":    0.115,
+	"ml.m5.xlarge":   0.23,
+	"ml.m5.2xlarge":  0.461,
+	"ml.m5.4xlarge":  0.922,
+	"ml.m5.12xlarge": 2.765,
+	"ml.c5.large":    0.102,
+	"ml.c5.xlarge":   0.204,
+	"ml.c5.2xlarge":  0.408,
+	"ml.c5.4xlarge":  0.816,
+	"ml.g4dn.xlarge": 0.7364,
+	"ml.g5.xlarge":   1.408,
+	"ml.p3.2xlarge":  3.825,
+}
+
+func sageMakerListPrices() map[Key]float64 {
+	prices := make(map[Key]float64, len(sageMakerHourlyPrices))
+	for instanceType, price := range sageMakerHourlyPrices {
+		prices[Key{Product: ProductSageMaker, InstanceType: instanceType}] = price
+	}
+	return prices
+}
+
+// SageMakerHourlyPrice returns the hourly price of the sagemaker instance type in the region in
+// USD.
+func SageMakerHourlyPrice(catalog Catalog, region, instanceType string) (float64, bool) {
+	if catalog == nil {
+		return 0, false
+	}
+	return catalog.Price(Key{Product: ProductSageMaker, InstanceType: instanceType, Region: region})
+}
@@ -0,0 +1,45 @@
+package pricing
+
+// ebsVolumeMonthlyPricePerGiB are the monthly list prices per GiB of provisioned storage by volume
+// type, in USD. Provisioned IOPS and throughput are not included.
+var ebsVolumeMonthlyPricePerGiB = map[string]float64{
+	"gp2":      0.10,
+	"gp3":      0.08,
+	"io1":      0.125,
+	"io2":      0.125,
+	"st1":      0.045,
+	"sc1":      0.015,
+	"standard": 0.05,
+}
+
+// ebsSnapshotMonthlyPricePerGiB is the monthly list price per GiB of standard snapshot storage in
+// USD.
+const ebsSnapshotMonthlyPricePerGiB = 0.05
+
+func ebsListPrices() map[Key]float64 {
+	prices := map[Key]float64{{Product: ProductEbsSnapshot}: ebsSnapshotMonthlyPricePerGiB}
+	for volumeType, price := range ebsVolumeMonthlyPricePerGiB {
+		prices[Key{Product: ProductEbsVolume, InstanceType: volumeType}] = price
+	}
+	return prices
+}
+
+// VolumeMonthlyPrice returns the estimated monthly price of an ebs volume of the given type and
+// size in the region in USD.
+func VolumeMonthlyPrice(catalog Catalog, region, volumeType string, sizeGiB int32) (float64, bool) {
+	if catalog == nil {
+		return 0, false
+	}
+	price, ok := catalog.Price(Key{Product: ProductEbsVolume, InstanceType: volumeType, Region: region})
+	return price * float64(sizeGiB), ok
+}
+
+// SnapshotMonthlyPrice returns the estimated monthly price of storing sizeGiB of snapshot data in
+// the region in USD, snapshots are incremental so this is an upper bound for a single snapshot.
+func SnapshotMonthlyPrice(catalog Catalog, region string, sizeGiB int32) (float64, bool) {
+	if catalog == nil {
+		return 0, false
+	}
+	price, ok := catalog.Price(Key{Product: ProductEbsSnapshot, Region: region})
+	return price * float64(sizeGiB), ok
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Ami is the description of an ami.
+type Ami struct {
+	ImageID      string    `json:"ImageId"`
+	Name         string    `json:"Name"`
+	CreationDate time.Time `json:"CreationDate"`
+	// SnapshotIDs are the ids of the snapshots backing the ebs volumes of the ami.
+	SnapshotIDs []string `json:"SnapshotIds"`
+	// VolumeSizes are the sizes of the ebs volumes of the ami in GiB.
+	VolumeSizes []int32 `json:"VolumeSizes"`
+	Tags        Tags    `json:"Tags"`
+}
+
+// DescribeOwnedAmis returns the amis owned by the account having all the given tags.
+func DescribeOwnedAmis(logger logr.Logger, region string, tags map[string]string) ([]Ami, error) {
+	args := []string{"ec2", "describe-images", "--region", ResolveRegion(region), "--owners", "self"}
+	if filters := tagFilters(tags); len(filters) > 0 {
+		args = append(append(args, "--filters"), filters...)
+	}
+	out, err := runCMD(logger, append(args,
+		"--query", "Images[].{ImageId: ImageId, Name: Name, CreationDate: CreationDate, SnapshotIds: BlockDeviceMappings[].Ebs.SnapshotId, VolumeSizes: BlockDeviceMappings[].Ebs.VolumeSize, Tags: Tags}",
+		"--output", "json")...)
+	if err != nil {
+		return nil, err
+	}
+	var amis []Ami
+	if err := json.Unmarshal(out, &amis); err != nil {
+		return nil, fmt.Errorf("unable to parse describe-images output: %w", err)
+	}
+	return amis, nil
+}
+
+// DescribeUsedAmiIDs returns the ids of the amis used by instances which are not terminated, by
+// the default and latest versions of the launch templates, by the launch template versions
+// referenced by auto scaling groups and by the launch configurations.
+func DescribeUsedAmiIDs(logger logr.Logger, region string) (map[string]bool, error) {
+	region = ResolveRegion(region)
+	queries := [][]string{
+		{"ec2", "describe-instances", "--region", region,
+			"--filters", "Name=instance-state-name,Values=pending,running,stopping,stopped",
+			"--query", "Reservations[].Instances[].ImageId"},
+		{"ec2", "describe-launch-template-versions", "--region", region, "--versions", "$Latest", "$Default",
+			"--query", "LaunchTemplateVersions[].LaunchTemplateData.ImageId"},
+		{"autoscaling", "describe-launch-configurations", "--region", region,
+			"--query", "LaunchConfigurations[].ImageId"},
+	}
+	// auto scaling groups may pin any version of a launch template.
+	versions, err := describeAsgLaunchTemplateVersions(logger, region)
+	if err != nil {
+		return nil, err
+	}
+	for _, templateVersions := range versions {
+		queries = append(queries, append(append([]string{"ec2", "describe-launch-template-versions", "--region", region,
+			"--launch-template-id", templateVersions.templateID, "--versions"}, templateVersions.versions...),
+			"--query", "LaunchTemplateVersions[].LaunchTemplateData.ImageId"))
+	}
+
+	used := map[string]bool{}
+	for _, query := range queries {
+		out, err := runCMD(logger, append(query, "--output", "json")...)
+		if err != nil {
+			return nil, err
+		}
+		var imageIDs []string
+		if err := json.Unmarshal(out, &imageIDs); err != nil {
+			return nil, fmt.Errorf("unable to parse %s output: %w", query[1], err)
+		}
+		for _, imageID := range imageIDs {
+			used[imageID] = true
+		}
+	}
+	return used, nil
+}
+
+// launchTemplateVersions are versions of a launch template.
+type launchTemplateVersions struct {
+	templateID string
+	versions   []string
+}
+
+// launchTemplateSpecification references a launch template version from an auto scaling group.
+type launchTemplateSpecification struct {
+	LaunchTemplateID string `json:"LaunchTemplateId"`
+	Version          string `json:"Version"`
+}
+
+// describeAsgLaunchTemplateVersions returns the launch template versions referenced by the auto
+// scaling groups, directly or through their mixed instances policy.
+func describeAsgLaunchTemplateVersions(logger logr.Logger, region string) ([]launchTemplateVersions, error) {
+	out, err := runCMD(logger, "autoscaling", "describe-auto-scaling-groups", "--region", region,
+		"--query", "AutoScalingGroups[].{LaunchTemplate: LaunchTemplate, MixedInstancesPolicy: MixedInstancesPolicy.LaunchTemplate}",
+		"--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	return parseAsgLaunchTemplateVersions(out)
+}
+
+func parseAsgLaunchTemplateVersions(out []byte) ([]launchTemplateVersions, error) {
+	var groups []struct {
+		LaunchTemplate       *launchTemplateSpecification `json:"LaunchTemplate"`
+		MixedInstancesPolicy *struct {
+			LaunchTemplateSpecification *launchTemplateSpecification `json:"LaunchTemplateSpecification"`
+			Overrides                   []struct {
+				LaunchTemplateSpecification *launchTemplateSpecification `json:"LaunchTemplateSpecification"`
+			} `json:"Overrides"`
+		} `json:"MixedInstancesPolicy"`
+	}
+	if err := json.Unmarshal(out, &groups); err != nil {
+		return nil, fmt.Errorf("unable to parse describe-auto-scaling-groups output: %w", err)
+	}
+
+	var result []launchTemplateVersions
+	indexes := map[string]int{}
+	seen := map[launchTemplateSpecification]bool{}
+	add := func(spec *launchTemplateSpecification) {
+		if spec == nil || spec.LaunchTemplateID == "" {
+			return
+		}
+		version := *spec
+		if version.Version == "" {
+			// auto scaling uses the default version if none is specified.
+			version.Version = "$Default"
+		}
+		if seen[version] {
+			return
+		}
+		seen[version] = true
+		index, ok := indexes[version.LaunchTemplateID]
+		if !ok {
+			index = len(result)
+			indexes[version.LaunchTemplateID] = index
+			result = append(result, launchTemplateVersions{templateID: version.LaunchTemplateID})
+		}
+		result[index].versions = append(result[index].versions, version.Version)
+	}
+	for _, group := range groups {
+		add(group.LaunchTemplate)
+		if policy := group.MixedInstancesPolicy; policy != nil {
+			add(policy.LaunchTemplateSpecification)
+			for _, override := range policy.Overrides {
+				add(override.LaunchTemplateSpecification)
+			}
+		}
+	}
+	return result, nil
+}
+
+// DeregisterAmi deregisters the ami, its backing snapshots are left untouched.
+func DeregisterAmi(logger logr.Logger, region, imageID string) error {
+	if _, err := runCMD(logger, "ec2", "deregister-image", "--region", ResolveRegion(region), "--image-id", imageID); err != nil {
+		return err
+	}
+	logger.Info("successfully deregistered ami", "image", imageID)
+	return nil
+}
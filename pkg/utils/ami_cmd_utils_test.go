@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAsgLaunchTemplateVersions(t *testing.T) {
+	out := []byte(`[
+		{"LaunchTemplate": {"LaunchTemplateId": "lt-1", "LaunchTemplateName": "web", "Version": "3"}, "MixedInstancesPolicy": null},
+		{"LaunchTemplate": null, "MixedInstancesPolicy": {
+			"LaunchTemplateSpecification": {"LaunchTemplateId": "lt-2", "Version": "$Latest"},
+			"Overrides": [
+				{"InstanceType": "m5.large"},
+				{"InstanceType": "m6g.large", "LaunchTemplateSpecification": {"LaunchTemplateId": "lt-1", "Version": "1"}}
+			]
+		}},
+		{"LaunchTemplate": {"LaunchTemplateId": "lt-1", "Version": "3"}, "MixedInstancesPolicy": null},
+		{"LaunchTemplate": {"LaunchTemplateId": "lt-3"}, "MixedInstancesPolicy": null},
+		{"LaunchTemplate": null, "MixedInstancesPolicy": null}
+	]`)
+	versions, err := parseAsgLaunchTemplateVersions(out)
+	if err != nil {
+		t.Fatalf("unable to parse launch templates: %v", err)
+	}
+	expected := []launchTemplateVersions{
+		{templateID: "lt-1", versions: []string{"3", "1"}},
+		{templateID: "lt-2", versions: []string{"$Latest"}},
+		{templateID: "lt-3", versions: []string{"$Default"}},
+	}
+	if !reflect.DeepEqual(versions, expected) {
+		t.Errorf("expected %+v, got %+v", expected, versions)
+	}
+
+	if _, err := parseAsgLaunchTemplateVersions([]byte(`not json`)); err == nil {
+		t.Errorf("expected an error for invalid output")
+	}
+}
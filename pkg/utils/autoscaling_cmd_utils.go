@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+)
+
+// AutoScalingGroup is the description of an auto scaling group.
+type AutoScalingGroup struct {
+	Name            string `json:"Name"`
+	MinSize         int32  `json:"MinSize"`
+	MaxSize         int32  `json:"MaxSize"`
+	DesiredCapacity int32  `json:"DesiredCapacity"`
+	// SuspendedProcesses are the names of the suspended scaling processes, e.g. HealthCheck.
+	SuspendedProcesses []string `json:"SuspendedProcesses"`
+}
+
+// DescribeAutoScalingGroups returns the description of the given auto scaling groups, groups which
+// do not exist are left out.
+func DescribeAutoScalingGroups(logger logr.Logger, region string, names []string) ([]AutoScalingGroup, error) {
+	args := append([]string{"autoscaling", "describe-auto-scaling-groups", "--region", ResolveRegion(region),
+		"--auto-scaling-group-names"}, names...)
+	out, err := runCMD(logger, append(args,
+		"--query", "AutoScalingGroups[].{Name: AutoScalingGroupName, MinSize: MinSize, MaxSize: MaxSize, DesiredCapacity: DesiredCapacity, SuspendedProcesses: SuspendedProcesses[].ProcessName}",
+		"--output", "json")...)
+	if err != nil {
+		return nil, err
+	}
+	var groups []AutoScalingGroup
+	if err := json.Unmarshal(out, &groups); err != nil {
+		return nil, fmt.Errorf("unable to parse describe-auto-scaling-groups output: %w", err)
+	}
+	return groups, nil
+}
+
+// UpdateAutoScalingGroupCapacity sets the capacity of the auto scaling group.
+func UpdateAutoScalingGroupCapacity(logger logr.Logger, region, name string, minSize, maxSize, desiredCapacity int32) error {
+	_, err := runCMD(logger, "autoscaling", "update-auto-scaling-group", "--region", ResolveRegion(region),
+		"--auto-scaling-group-name", name,
+		"--min-size", strconv.Itoa(int(minSize)), "--max-size", strconv.Itoa(int(maxSize)),
+		"--desired-capacity", strconv.Itoa(int(desiredCapacity)))
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully updated auto scaling group capacity", "group", name,
+		"min", minSize, "max", maxSize, "desired", desiredCapacity)
+	return nil
+}
+
+// SuspendAutoScalingProcesses suspends the scaling processes of the auto scaling group.
+func SuspendAutoScalingProcesses(logger logr.Logger, region, name string, processes []string) error {
+	return runAutoScalingProcessesOperation(logger, "suspend-processes", region, name, processes)
+}
+
+// ResumeAutoScalingProcesses resumes the scaling processes of the auto scaling group.
+func ResumeAutoScalingProcesses(logger logr.Logger, region, name string, processes []string) error {
+	return runAutoScalingProcessesOperation(logger, "resume-processes", region, name, processes)
+}
+
+func runAutoScalingProcessesOperation(logger logr.Logger, operation, region, name string, processes []string) error {
+	if len(processes) == 0 {
+		return nil
+	}
+	args := append([]string{"autoscaling", operation, "--region", ResolveRegion(region),
+		"--auto-scaling-group-name", name, "--scaling-processes"}, processes...)
+	if _, err := runCMD(logger, args...); err != nil {
+		return err
+	}
+	logger.Info("successfully issued auto scaling operation", "operation", operation, "group", name, "processes", processes)
+	return nil
+}
@@ -1,7 +1,11 @@
 package utils
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
@@ -9,37 +13,169 @@ import (
 	"github.com/go-logr/logr"
 )
 
-func runCMD(logger logr.Logger, executeCMD string) error {
+// DefaultRegion is the region used if neither the object nor the aws environment variables
+// specify one. If empty, --region is left off and the aws cli resolves the region from its
+// own configuration.
+var DefaultRegion string
+
+// APILatencyObserver is called with the duration of every aws cli call, e.g. to record it in
+// the metrics. It is not called if nil.
+var APILatencyObserver func(service, operation string, duration time.Duration)
+
+// ResolveRegion returns the given region, or the region from the aws environment variables
+// if the given region is empty, or DefaultRegion.
+func ResolveRegion(region string) string {
+	if region != "" {
+		return region
+	}
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region = os.Getenv(env); region != "" {
+			return region
+		}
+	}
+	return DefaultRegion
+}
+
+// omitEmptyRegion removes the --region argument if its value is empty, so that the aws cli
+// falls back to the region of its configuration.
+func omitEmptyRegion(args []string) []string {
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == "--region" && args[i+1] == "" {
+			return append(append([]string(nil), args[:i]...), args[i+2:]...)
+		}
+	}
+	return args
+}
+
+// runCMD runs the aws cli with the given arguments and returns its stdout, aws errors
+// reported by the cli are returned as *AWSError. The first two arguments are expected to
+// be the service and the operation, e.g. ec2 stop-instances.
+func runCMD(logger logr.Logger, args ...string) ([]byte, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	logger.V(1).Info("running command", "cmd", executeCMD)
-	cmd := exec.CommandContext(ctx, "sh", "-c", executeCMD)
-	out, err := cmd.CombinedOutput()
+	args = omitEmptyRegion(args)
+	logger.V(1).Info("running command", "cmd", "aws "+strings.Join(args, " "))
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "aws", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	start := time.Now()
+	err := cmd.Run()
+	if APILatencyObserver != nil && len(args) > 1 {
+		APILatencyObserver(args[0], args[1], time.Since(start))
+	}
+	if err != nil {
+		logger.Error(err, "aws command failed", "stderr", stderr.String())
+		if awsErr := parseAWSError(stderr.String()); awsErr != nil {
+			return nil, awsErr
+		}
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	logger.V(2).Info("cmd output", "stdout", stdout.String())
+	return stdout.Bytes(), nil
+}
+
+// runEc2InstanceOperation runs the ec2 operation for all the instances at once. If aws
+// rejects the request because of a single instance, the operation is repeated for each
+// instance so that the remaining instances are still processed, the instances which had
+// to be skipped are returned.
+func runEc2InstanceOperation(logger logr.Logger, operation, region string, instanceIDs []string) ([]InstanceError, error) {
+	args := append([]string{"ec2", operation, "--region", ResolveRegion(region), "--instance-ids"}, instanceIDs...)
+	_, err := runCMD(logger, args...)
+	if err == nil {
+		return nil, nil
+	}
+	if _, action := ClassifyError(err); action != ActionSkip {
+		return nil, err
+	}
+
+	var skipped []InstanceError
+	for _, instanceID := range instanceIDs {
+		if _, err := runCMD(logger, "ec2", operation, "--region", ResolveRegion(region), "--instance-ids", instanceID); err != nil {
+			if _, action := ClassifyError(err); action != ActionSkip {
+				return skipped, err
+			}
+			logger.Info("skipping instance", "instance", instanceID, "error", err.Error())
+			skipped = append(skipped, InstanceError{InstanceID: instanceID, Err: err})
+		}
+	}
+	return skipped, nil
+}
+
+// StartEc2Instance starts the given instances, instances which could not be started
+// because of instance specific errors are returned as skipped.
+func StartEc2Instance(logger logr.Logger, region string, instanceIDs []string) ([]InstanceError, error) {
+	skipped, err := runEc2InstanceOperation(logger, "start-instances", region, instanceIDs)
 	if err != nil {
-		logger.Error(err, "unable to start instances", "stderr", cmd.Stderr)
-		return err
+		return skipped, err
 	}
-	logger.V(2).Info("cmd output", "stdout", string(out))
-	return nil
+	logger.Info("successfully started ec2 instances", "skipped", len(skipped))
+	return skipped, nil
 }
 
-func StartEc2Instance(logger logr.Logger, instanceIDs []string) error {
-	toBeExecuted := "aws ec2 start-instances --instance-ids " + strings.Join(instanceIDs, ",")
-	if err := runCMD(logger, toBeExecuted); err != nil {
-		return err
+// StopEc2Instance stops the given instances, instances which could not be stopped
+// because of instance specific errors are returned as skipped.
+func StopEc2Instance(logger logr.Logger, region string, instanceIDs []string) ([]InstanceError, error) {
+	skipped, err := runEc2InstanceOperation(logger, "stop-instances", region, instanceIDs)
+	if err != nil {
+		return skipped, err
 	}
-	logger.Info("successfully started ec2 instances")
-	return nil
+	logger.Info("successfully stopped ec2 instances", "skipped", len(skipped))
+	return skipped, nil
+}
+
+// Ec2Instance is the description of an ec2 instance.
+type Ec2Instance struct {
+	InstanceID   string `json:"InstanceId"`
+	InstanceType string `json:"InstanceType"`
+	State        string `json:"State"`
+	// Platform is the platform details of the instance, e.g. Linux/UNIX.
+	Platform string `json:"Platform"`
+	// Tenancy is the placement tenancy of the instance, e.g. default, dedicated or host.
+	Tenancy string `json:"Tenancy"`
+	// Architecture of the instance, e.g. x86_64 or arm64.
+	Architecture string `json:"Architecture"`
+	// EnaSupport reports whether enhanced networking with ENA is enabled.
+	EnaSupport bool `json:"EnaSupport"`
+	// EbsOptimized reports whether the instance is optimized for EBS I/O.
+	EbsOptimized bool `json:"EbsOptimized"`
+	// StateReason is the reason of the last state change, e.g. why an instance failed to start.
+	StateReason string `json:"StateReason"`
 }
 
-func StopEc2Instance(logger logr.Logger, instanceIDs []string) error {
-	toBeExecuted := "aws ec2 stop-instances --instance-ids " + strings.Join(instanceIDs, ",")
-	if err := runCMD(logger, toBeExecuted); err != nil {
-		return err
+// DescribeEc2Instances returns the description of the given instances, instances which do not
+// exist are left out.
+func DescribeEc2Instances(logger logr.Logger, region string, instanceIDs []string) ([]Ec2Instance, error) {
+	return describeEc2Instances(logger, region, "Name=instance-id,Values="+strings.Join(instanceIDs, ","))
+}
+
+// DescribeEc2InstancesByTags returns the description of the instances having all the given tags,
+// restricted to the given instances if any.
+func DescribeEc2InstancesByTags(logger logr.Logger, region string, instanceIDs []string, tags map[string]string) ([]Ec2Instance, error) {
+	var filters []string
+	if len(instanceIDs) > 0 {
+		filters = append(filters, "Name=instance-id,Values="+strings.Join(instanceIDs, ","))
+	}
+	filters = append(filters, tagFilters(tags)...)
+	// terminated instances stay visible for a while, they are of no interest.
+	filters = append(filters, "Name=instance-state-name,Values=pending,running,stopping,stopped")
+	return describeEc2Instances(logger, region, filters...)
+}
+
+func describeEc2Instances(logger logr.Logger, region string, filters ...string) ([]Ec2Instance, error) {
+	args := append([]string{"ec2", "describe-instances", "--region", ResolveRegion(region), "--filters"}, filters...)
+	out, err := runCMD(logger, append(args,
+		"--query", "Reservations[].Instances[].{InstanceId: InstanceId, InstanceType: InstanceType, State: State.Name, Platform: PlatformDetails, Tenancy: Placement.Tenancy, Architecture: Architecture, EnaSupport: EnaSupport, EbsOptimized: EbsOptimized, StateReason: StateReason.Message}",
+		"--output", "json")...)
+	if err != nil {
+		return nil, err
+	}
+	var instances []Ec2Instance
+	if err := json.Unmarshal(out, &instances); err != nil {
+		return nil, fmt.Errorf("unable to parse describe-instances output: %w", err)
 	}
-	logger.Info("successfully stopped ec2 instances")
-	return nil
+	return instances, nil
 }
 
 // TODO:
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveRegion(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	if region := ResolveRegion(""); region != "" {
+		t.Errorf("expected no region without configuration, got %q", region)
+	}
+
+	DefaultRegion = "eu-west-1"
+	defer func() { DefaultRegion = "" }()
+	if region := ResolveRegion(""); region != "eu-west-1" {
+		t.Errorf("expected the default region, got %q", region)
+	}
+	t.Setenv("AWS_DEFAULT_REGION", "ap-south-1")
+	if region := ResolveRegion(""); region != "ap-south-1" {
+		t.Errorf("expected the region of the environment, got %q", region)
+	}
+	if region := ResolveRegion("us-west-2"); region != "us-west-2" {
+		t.Errorf("expected the given region, got %q", region)
+	}
+}
+
+func TestOmitEmptyRegion(t *testing.T) {
+	args := []string{"ec2", "stop-instances", "--region", "", "--instance-ids", "i-1"}
+	if got := omitEmptyRegion(args); !reflect.DeepEqual(got, []string{"ec2", "stop-instances", "--instance-ids", "i-1"}) {
+		t.Errorf("expected --region to be left off, got %v", got)
+	}
+	args = []string{"ec2", "stop-instances", "--region", "eu-west-1", "--instance-ids", "i-1"}
+	if got := omitEmptyRegion(args); !reflect.DeepEqual(got, args) {
+		t.Errorf("expected the arguments to be kept, got %v", got)
+	}
+}
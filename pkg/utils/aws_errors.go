@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrorAction tells the caller how to react to a failed aws operation.
+type ErrorAction string
+
+const (
+	// ActionRetry indicates a transient failure, the operation should be retried later.
+	ActionRetry ErrorAction = "Retry"
+	// ActionSkip indicates that the failure is specific to an instance, the instance
+	// should be skipped and the remaining instances processed.
+	ActionSkip ErrorAction = "Skip"
+	// ActionFail indicates a permanent failure, retrying will not help.
+	ActionFail ErrorAction = "Fail"
+)
+
+// condition reasons derived from aws errors.
+const (
+	ReasonUnauthorized           = "Unauthorized"
+	ReasonInstanceNotFound       = "InstanceNotFound"
+	ReasonIncorrectInstanceState = "IncorrectInstanceState"
+	ReasonInsufficientCapacity   = "InsufficientCapacity"
+	ReasonThrottled              = "Throttled"
+	ReasonNotSettled             = "NotSettled"
+	ReasonAWSError               = "AWSError"
+	ReasonCommandFailed          = "CommandFailed"
+)
+
+var (
+	ErrUnauthorized           = errors.New("not authorized to perform the operation")
+	ErrInstanceNotFound       = errors.New("instance not found")
+	ErrIncorrectInstanceState = errors.New("instance is in an incorrect state for the operation")
+	ErrInsufficientCapacity   = errors.New("insufficient instance capacity")
+	ErrThrottled              = errors.New("request throttled by aws")
+	// ErrNotSettled is reported by the controller for resources which cannot reach the state of
+	// the operation, e.g. as they do not exist anymore or are stuck in transition.
+	ErrNotSettled = errors.New("resource did not settle in the state of the operation")
+)
+
+// errorClass groups aws error codes which are handled the same way.
+type errorClass struct {
+	err    error
+	reason string
+	action ErrorAction
+}
+
+var (
+	unauthorized     = errorClass{ErrUnauthorized, ReasonUnauthorized, ActionFail}
+	instanceNotFound = errorClass{ErrInstanceNotFound, ReasonInstanceNotFound, ActionSkip}
+	incorrectState   = errorClass{ErrIncorrectInstanceState, ReasonIncorrectInstanceState, ActionSkip}
+	noCapacity       = errorClass{ErrInsufficientCapacity, ReasonInsufficientCapacity, ActionRetry}
+	throttled        = errorClass{ErrThrottled, ReasonThrottled, ActionRetry}
+	notSettled       = errorClass{ErrNotSettled, ReasonNotSettled, ActionFail}
+)
+
+// awsErrorClasses maps aws error codes to the way they are handled.
+var awsErrorClasses = map[string]errorClass{
+	"UnauthorizedOperation":             unauthorized,
+	"AuthFailure":                       unauthorized,
+	"AccessDenied":                      unauthorized,
+	"AccessDeniedException":             unauthorized,
+	"UnrecognizedClientException":       unauthorized,
+	"InvalidClientTokenId":              unauthorized,
+	"ExpiredToken":                      unauthorized,
+	"InvalidInstanceID.NotFound":        instanceNotFound,
+	"InvalidInstanceID.Malformed":       instanceNotFound,
+	"IncorrectInstanceState":            incorrectState,
+	"InvalidVolume.NotFound":            instanceNotFound,
+	"InvalidSnapshot.NotFound":          instanceNotFound,
+	"VolumeInUse":                       incorrectState,
+	"InvalidSnapshot.InUse":             incorrectState,
+	"InvalidAMIID.NotFound":             instanceNotFound,
+	"InvalidAMIID.Unavailable":          instanceNotFound,
+	"InvalidAllocationID.NotFound":      instanceNotFound,
+	"InvalidIPAddress.InUse":            incorrectState,
+	"NatGatewayNotFound":                instanceNotFound,
+	"InvalidRoute.NotFound":             instanceNotFound,
+	"DBInstanceNotFound":                instanceNotFound,
+	"DBInstanceNotFoundFault":           instanceNotFound,
+	"DBClusterNotFoundFault":            instanceNotFound,
+	"InvalidDBInstanceState":            incorrectState,
+	"InvalidDBInstanceStateFault":       incorrectState,
+	"InvalidDBClusterStateFault":        incorrectState,
+	"ClusterNotFound":                   instanceNotFound,
+	"ClusterNotFoundFault":              instanceNotFound,
+	"InvalidClusterState":               incorrectState,
+	"InvalidClusterStateFault":          incorrectState,
+	"ServiceNotFoundException":          instanceNotFound,
+	"ClusterNotFoundException":          instanceNotFound,
+	"ServiceNotActiveException":         incorrectState,
+	"ReplicationGroupNotFoundFault":     instanceNotFound,
+	"InvalidReplicationGroupState":      incorrectState,
+	"InvalidReplicationGroupStateFault": incorrectState,
+	"NotFoundException":                 instanceNotFound,
+	"InvalidResourceStateException":     incorrectState,
+	"OperationNotSupportedException":    incorrectState,
+	"ResourceNotFoundException":         instanceNotFound,
+	"ResourceInUseException":            incorrectState,
+	"InsufficientInstanceCapacity":      noCapacity,
+	"Throttling":                        throttled,
+	"ThrottlingException":               throttled,
+	"RequestLimitExceeded":              throttled,
+	"RequestThrottled":                  throttled,
+	"TooManyRequestsException":          throttled,
+}
+
+// awsCLIErrorRegex matches the error line printed by the aws cli, e.g.
+// An error occurred (IncorrectInstanceState) when calling the StopInstances operation: ...
+var awsCLIErrorRegex = regexp.MustCompile(`An error occurred \(([^)]+)\) when calling the (\w+) operation(?: \([^)]*\))?: (.*)`)
+
+// AWSError is an error returned by an aws api call.
+type AWSError struct {
+	// Code is the aws error code, e.g. InvalidInstanceID.NotFound.
+	Code string
+	// Operation is the aws api operation which failed, e.g. StopInstances.
+	Operation string
+	// Message is the error message returned by aws.
+	Message string
+}
+
+func (e *AWSError) Error() string {
+	return fmt.Sprintf("%s failed with %s: %s", e.Operation, e.Code, e.Message)
+}
+
+// Unwrap returns the sentinel error for known error codes, so that callers can
+// use errors.Is(err, ErrThrottled) and alike.
+func (e *AWSError) Unwrap() error {
+	if class, ok := awsErrorClasses[e.Code]; ok {
+		return class.err
+	}
+	return nil
+}
+
+// parseAWSError extracts the aws error from the cli output, returns nil if the
+// output does not contain an aws error.
+func parseAWSError(output string) *AWSError {
+	matches := awsCLIErrorRegex.FindStringSubmatch(output)
+	if matches == nil {
+		return nil
+	}
+	return &AWSError{
+		Code:      matches[1],
+		Operation: matches[2],
+		Message:   strings.TrimSpace(matches[3]),
+	}
+}
+
+// ClassifyError returns the condition reason for the given error and the action
+// that should be taken by the caller, for aws errors as well as for the sentinel errors.
+func ClassifyError(err error) (string, ErrorAction) {
+	var awsErr *AWSError
+	if !errors.As(err, &awsErr) {
+		// sentinel errors reported by the controller itself, e.g. for instances not found.
+		for _, class := range []errorClass{unauthorized, instanceNotFound, incorrectState, noCapacity, throttled, notSettled} {
+			if errors.Is(err, class.err) {
+				return class.reason, class.action
+			}
+		}
+		return ReasonCommandFailed, ActionRetry
+	}
+	if class, ok := awsErrorClasses[awsErr.Code]; ok {
+		return class.reason, class.action
+	}
+	return ReasonAWSError, ActionRetry
+}
+
+// InstanceError is an error which occurred while operating on a single instance.
+type InstanceError struct {
+	InstanceID string
+	Err        error
+}
+
+func (e InstanceError) Error() string {
+	return fmt.Sprintf("instance %s: %v", e.InstanceID, e.Err)
+}
+
+func (e InstanceError) Unwrap() error {
+	return e.Err
+}
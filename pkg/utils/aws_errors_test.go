@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseAWSError(t *testing.T) {
+	tests := []struct {
+		output    string
+		code      string
+		operation string
+		reason    string
+		action    ErrorAction
+		sentinel  error
+	}{
+		{
+			output:    "\nAn error occurred (UnauthorizedOperation) when calling the StopInstances operation: You are not authorized to perform this operation.\n",
+			code:      "UnauthorizedOperation",
+			operation: "StopInstances",
+			reason:    ReasonUnauthorized,
+			action:    ActionFail,
+			sentinel:  ErrUnauthorized,
+		},
+		{
+			output:    "An error occurred (InvalidInstanceID.NotFound) when calling the StartInstances operation: The instance ID 'i-0b7ff2259ac5f2d9e' does not exist",
+			code:      "InvalidInstanceID.NotFound",
+			operation: "StartInstances",
+			reason:    ReasonInstanceNotFound,
+			action:    ActionSkip,
+			sentinel:  ErrInstanceNotFound,
+		},
+		{
+			output:    "An error occurred (IncorrectInstanceState) when calling the StopInstances operation: The instance 'i-0b7ff2259ac5f2d9e' is not in a state from which it can be stopped.",
+			code:      "IncorrectInstanceState",
+			operation: "StopInstances",
+			reason:    ReasonIncorrectInstanceState,
+			action:    ActionSkip,
+			sentinel:  ErrIncorrectInstanceState,
+		},
+		{
+			output:    "An error occurred (InsufficientInstanceCapacity) when calling the StartInstances operation: Insufficient capacity.",
+			code:      "InsufficientInstanceCapacity",
+			operation: "StartInstances",
+			reason:    ReasonInsufficientCapacity,
+			action:    ActionRetry,
+			sentinel:  ErrInsufficientCapacity,
+		},
+		{
+			output:    "An error occurred (RequestLimitExceeded) when calling the StopInstances operation (reached max retries: 2): Request limit exceeded.",
+			code:      "RequestLimitExceeded",
+			operation: "StopInstances",
+			reason:    ReasonThrottled,
+			action:    ActionRetry,
+			sentinel:  ErrThrottled,
+		},
+		{
+			output:    "An error occurred (InvalidDBInstanceState) when calling the StopDBInstance operation: Instance dev-postgres is not in available state.",
+			code:      "InvalidDBInstanceState",
+			operation: "StopDBInstance",
+			reason:    ReasonIncorrectInstanceState,
+			action:    ActionSkip,
+			sentinel:  ErrIncorrectInstanceState,
+		},
+		{
+			output:    "An error occurred (InternalError) when calling the StopInstances operation: An internal error has occurred.",
+			code:      "InternalError",
+			operation: "StopInstances",
+			reason:    ReasonAWSError,
+			action:    ActionRetry,
+		},
+	}
+
+	for _, test := range tests {
+		awsErr := parseAWSError(test.output)
+		if awsErr == nil {
+			t.Fatalf("expected aws error to be parsed from %q", test.output)
+		}
+		if awsErr.Code != test.code || awsErr.Operation != test.operation {
+			t.Errorf("expected %s/%s, got %s/%s", test.code, test.operation, awsErr.Code, awsErr.Operation)
+		}
+		// errors are usually wrapped by the callers.
+		err := fmt.Errorf("wrapped: %w", awsErr)
+		if reason, action := ClassifyError(err); reason != test.reason || action != test.action {
+			t.Errorf("%s: expected %s/%s, got %s/%s", test.code, test.reason, test.action, reason, action)
+		}
+		if test.sentinel != nil && !errors.Is(err, test.sentinel) {
+			t.Errorf("%s: expected error to match %v", test.code, test.sentinel)
+		}
+	}
+}
+
+func TestClassifyNonAWSError(t *testing.T) {
+	if awsErr := parseAWSError("aws: command not found"); awsErr != nil {
+		t.Fatalf("expected no aws error, got %v", awsErr)
+	}
+	if reason, action := ClassifyError(errors.New("signal: killed")); reason != ReasonCommandFailed || action != ActionRetry {
+		t.Errorf("expected %s/%s, got %s/%s", ReasonCommandFailed, ActionRetry, reason, action)
+	}
+}
+
+func TestClassifySentinelError(t *testing.T) {
+	err := InstanceError{InstanceID: "i-1", Err: ErrInstanceNotFound}
+	if reason, action := ClassifyError(err); reason != ReasonInstanceNotFound || action != ActionSkip {
+		t.Errorf("expected %s/%s, got %s/%s", ReasonInstanceNotFound, ActionSkip, reason, action)
+	}
+	notSettled := fmt.Errorf("1 cluster(s) did not settle: %w", ErrNotSettled)
+	if reason, action := ClassifyError(notSettled); reason != ReasonNotSettled || action != ActionFail {
+		t.Errorf("expected %s/%s, got %s/%s", ReasonNotSettled, ActionFail, reason, action)
+	}
+}
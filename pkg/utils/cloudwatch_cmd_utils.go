@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// cloudwatch statistics supported by GetEc2MetricStatistics.
+const (
+	StatisticAverage = "Average"
+	StatisticSum     = "Sum"
+	StatisticMaximum = "Maximum"
+)
+
+// cloudwatch namespaces of instance metrics, memory metrics are only published by the
+// cloudwatch agent.
+const (
+	NamespaceEc2     = "AWS/EC2"
+	NamespaceCWAgent = "CWAgent"
+)
+
+// Datapoint is a single datapoint of a cloudwatch metric.
+type Datapoint struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// Dimension is a dimension of a cloudwatch metric.
+type Dimension struct {
+	Name  string `json:"Name"`
+	Value string `json:"Value"`
+}
+
+// ListInstanceMetricDimensions returns the dimension sets the metric of the instance is published
+// with in the namespace. The cloudwatch agent adds dimensions configured by append_dimensions to
+// the InstanceId dimension, e.g. ImageId, InstanceType and AutoScalingGroupName, statistics are
+// only returned when queried with the exact dimension set.
+func ListInstanceMetricDimensions(logger logr.Logger, region, namespace, instanceID, metricName string) ([][]Dimension, error) {
+	out, err := runCMD(logger, "cloudwatch", "list-metrics", "--region", ResolveRegion(region),
+		"--namespace", namespace, "--metric-name", metricName,
+		"--dimensions", "Name=InstanceId,Value="+instanceID, "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	return parseMetricDimensions(out)
+}
+
+func parseMetricDimensions(out []byte) ([][]Dimension, error) {
+	var result struct {
+		Metrics []struct {
+			Dimensions []Dimension `json:"Dimensions"`
+		} `json:"Metrics"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("unable to parse list-metrics output: %w", err)
+	}
+	dimensionSets := make([][]Dimension, 0, len(result.Metrics))
+	for _, metric := range result.Metrics {
+		dimensionSets = append(dimensionSets, metric.Dimensions)
+	}
+	return dimensionSets, nil
+}
+
+// GetEc2MetricStatistics returns the datapoints of an ec2 instance metric of the AWS/EC2 namespace,
+// e.g. CPUUtilization, between start and end, sorted by their timestamp.
+func GetEc2MetricStatistics(logger logr.Logger, region, instanceID, metricName, statistic string,
+	start, end time.Time, period time.Duration) ([]Datapoint, error) {
+	return GetInstanceMetricStatistics(logger, region, NamespaceEc2, instanceID, metricName, statistic, start, end, period)
+}
+
+// GetInstanceMetricStatistics returns the datapoints of an instance metric of the namespace, the
+// metric has to be published with the InstanceId dimension only.
+func GetInstanceMetricStatistics(logger logr.Logger, region, namespace, instanceID, metricName, statistic string,
+	start, end time.Time, period time.Duration) ([]Datapoint, error) {
+	return GetMetricStatistics(logger, region, namespace, metricName, statistic,
+		[]Dimension{{Name: "InstanceId", Value: instanceID}}, start, end, period)
+}
+
+// GetMetricStatistics returns the datapoints of the metric of the namespace published with the
+// dimensions, between start and end, sorted by their timestamp.
+func GetMetricStatistics(logger logr.Logger, region, namespace, metricName, statistic string, dimensions []Dimension,
+	start, end time.Time, period time.Duration) ([]Datapoint, error) {
+	args := []string{"cloudwatch", "get-metric-statistics", "--region", ResolveRegion(region),
+		"--namespace", namespace, "--metric-name", metricName, "--dimensions"}
+	out, err := runCMD(logger, append(append(args, formatDimensions(dimensions)...),
+		"--start-time", start.UTC().Format(time.RFC3339), "--end-time", end.UTC().Format(time.RFC3339),
+		"--period", strconv.Itoa(int(period.Seconds())), "--statistics", statistic,
+		"--output", "json")...)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Datapoints []map[string]interface{} `json:"Datapoints"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("unable to parse get-metric-statistics output: %w", err)
+	}
+	datapoints := make([]Datapoint, 0, len(result.Datapoints))
+	for _, raw := range result.Datapoints {
+		timestamp, _ := raw["Timestamp"].(string)
+		parsed, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid datapoint timestamp %q: %w", timestamp, err)
+		}
+		value, ok := raw[statistic].(float64)
+		if !ok {
+			return nil, fmt.Errorf("datapoint at %s has no %s", timestamp, statistic)
+		}
+		datapoints = append(datapoints, Datapoint{Timestamp: parsed, Value: value})
+	}
+	sort.Slice(datapoints, func(i, j int) bool {
+		return datapoints[i].Timestamp.Before(datapoints[j].Timestamp)
+	})
+	return datapoints, nil
+}
+
+// formatDimensions formats the dimensions in the shorthand syntax of the aws cli, one argument
+// per dimension.
+func formatDimensions(dimensions []Dimension) []string {
+	formatted := make([]string, 0, len(dimensions))
+	for _, dimension := range dimensions {
+		formatted = append(formatted, fmt.Sprintf("Name=%s,Value=%s", dimension.Name, dimension.Value))
+	}
+	return formatted
+}
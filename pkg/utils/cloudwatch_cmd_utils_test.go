@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMetricDimensions(t *testing.T) {
+	out := []byte(`{"Metrics": [
+		{"Namespace": "CWAgent", "MetricName": "mem_used_percent", "Dimensions": [
+			{"Name": "InstanceId", "Value": "i-1"},
+			{"Name": "ImageId", "Value": "ami-1"},
+			{"Name": "InstanceType", "Value": "m5.large"}
+		]},
+		{"Namespace": "CWAgent", "MetricName": "mem_used_percent", "Dimensions": [
+			{"Name": "InstanceId", "Value": "i-1"},
+			{"Name": "ImageId", "Value": "ami-1"},
+			{"Name": "InstanceType", "Value": "m5.xlarge"}
+		]}
+	]}`)
+	dimensionSets, err := parseMetricDimensions(out)
+	if err != nil {
+		t.Fatalf("unable to parse dimensions: %v", err)
+	}
+	if len(dimensionSets) != 2 || dimensionSets[1][2] != (Dimension{Name: "InstanceType", Value: "m5.xlarge"}) {
+		t.Errorf("expected a dimension set per metric, got %v", dimensionSets)
+	}
+
+	if dimensionSets, err := parseMetricDimensions([]byte(`{"Metrics": []}`)); err != nil || len(dimensionSets) != 0 {
+		t.Errorf("expected no dimension sets for an unpublished metric, got %v (error: %v)", dimensionSets, err)
+	}
+	if _, err := parseMetricDimensions([]byte(`not json`)); err == nil {
+		t.Errorf("expected an error for invalid output")
+	}
+}
+
+func TestFormatDimensions(t *testing.T) {
+	got := formatDimensions([]Dimension{{Name: "InstanceId", Value: "i-1"}, {Name: "AutoScalingGroupName", Value: "workers"}})
+	want := []string{"Name=InstanceId,Value=i-1", "Name=AutoScalingGroupName,Value=workers"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// billing modes of dynamodb tables.
+const (
+	DynamoDbProvisioned   = "PROVISIONED"
+	DynamoDbPayPerRequest = "PAY_PER_REQUEST"
+)
+
+// DynamoDbTable is the description of a dynamodb table.
+type DynamoDbTable struct {
+	TableName string `json:"TableName"`
+	// TableStatus is ACTIVE once the table is not being updated.
+	TableStatus string `json:"TableStatus"`
+	// BillingMode is PROVISIONED or PAY_PER_REQUEST, empty for tables which were always provisioned.
+	BillingMode string `json:"BillingMode"`
+	// LastUpdateToPayPerRequest is the last time the table switched to on-demand mode, if ever.
+	LastUpdateToPayPerRequest *time.Time      `json:"LastUpdateToPayPerRequestDateTime"`
+	ReadCapacityUnits         int64           `json:"ReadCapacityUnits"`
+	WriteCapacityUnits        int64           `json:"WriteCapacityUnits"`
+	Indexes                   []DynamoDbIndex `json:"Indexes"`
+}
+
+// DynamoDbIndex is the description of a global secondary index.
+type DynamoDbIndex struct {
+	IndexName string `json:"IndexName"`
+	// IndexStatus is ACTIVE once the index is not being updated.
+	IndexStatus        string `json:"IndexStatus"`
+	ReadCapacityUnits  int64  `json:"ReadCapacityUnits"`
+	WriteCapacityUnits int64  `json:"WriteCapacityUnits"`
+}
+
+// Active returns true if neither the table nor one of its indexes is being updated.
+func (t DynamoDbTable) Active() bool {
+	for _, index := range t.Indexes {
+		if index.IndexStatus != "ACTIVE" {
+			return false
+		}
+	}
+	return t.TableStatus == "ACTIVE"
+}
+
+// DescribeDynamoDbTable returns the description of the table.
+func DescribeDynamoDbTable(logger logr.Logger, region, tableName string) (DynamoDbTable, error) {
+	var table DynamoDbTable
+	out, err := runCMD(logger, "dynamodb", "describe-table", "--region", ResolveRegion(region), "--table-name", tableName,
+		"--query", "Table.{TableName: TableName, TableStatus: TableStatus, BillingMode: BillingModeSummary.BillingMode, LastUpdateToPayPerRequestDateTime: BillingModeSummary.LastUpdateToPayPerRequestDateTime, ReadCapacityUnits: ProvisionedThroughput.ReadCapacityUnits, WriteCapacityUnits: ProvisionedThroughput.WriteCapacityUnits, Indexes: GlobalSecondaryIndexes[].{IndexName: IndexName, IndexStatus: IndexStatus, ReadCapacityUnits: ProvisionedThroughput.ReadCapacityUnits, WriteCapacityUnits: ProvisionedThroughput.WriteCapacityUnits}}",
+		"--output", "json")
+	if err != nil {
+		return table, err
+	}
+	if err := json.Unmarshal(out, &table); err != nil {
+		return table, fmt.Errorf("unable to parse describe-table output: %w", err)
+	}
+	if table.BillingMode == "" {
+		table.BillingMode = DynamoDbProvisioned
+	}
+	return table, nil
+}
+
+// DynamoDbCapacity is the capacity configuration of a table and its global secondary indexes.
+type DynamoDbCapacity struct {
+	BillingMode        string
+	ReadCapacityUnits  int64
+	WriteCapacityUnits int64
+	// Indexes are the capacities of the global secondary indexes, ignored in on-demand mode.
+	Indexes []DynamoDbIndex
+}
+
+// UpdateDynamoDbTableCapacity changes the billing mode and the provisioned capacity of the table
+// and its global secondary indexes to the capacity. Only the settings which differ from the
+// described table are sent, as dynamodb rejects updates to the current throughput.
+func UpdateDynamoDbTableCapacity(logger logr.Logger, region string, table DynamoDbTable, capacity DynamoDbCapacity) error {
+	updates, err := dynamoDbCapacityUpdates(table, capacity)
+	if err != nil {
+		return err
+	}
+	if len(updates) == 0 {
+		logger.V(1).Info("table capacity is up to date", "table", table.TableName)
+		return nil
+	}
+	args := append([]string{"dynamodb", "update-table", "--region", ResolveRegion(region), "--table-name", table.TableName}, updates...)
+	if _, err := runCMD(logger, append(args, "--query", "TableDescription.TableStatus", "--output", "text")...); err != nil {
+		return err
+	}
+	logger.Info("successfully started table capacity update", "table", table.TableName, "billingMode", capacity.BillingMode)
+	return nil
+}
+
+// dynamoDbCapacityUpdates returns the update-table arguments changing the table to the capacity,
+// none if the table has the capacity already.
+func dynamoDbCapacityUpdates(table DynamoDbTable, capacity DynamoDbCapacity) ([]string, error) {
+	var args []string
+	if capacity.BillingMode != table.BillingMode {
+		args = append(args, "--billing-mode", capacity.BillingMode)
+	}
+	if capacity.BillingMode != DynamoDbProvisioned {
+		return args, nil
+	}
+	if capacity.ReadCapacityUnits != table.ReadCapacityUnits || capacity.WriteCapacityUnits != table.WriteCapacityUnits {
+		args = append(args, "--provisioned-throughput",
+			fmt.Sprintf("ReadCapacityUnits=%d,WriteCapacityUnits=%d", capacity.ReadCapacityUnits, capacity.WriteCapacityUnits))
+	}
+
+	current := map[string]DynamoDbIndex{}
+	for _, index := range table.Indexes {
+		current[index.IndexName] = index
+	}
+	type throughput struct {
+		ReadCapacityUnits  int64
+		WriteCapacityUnits int64
+	}
+	type update struct {
+		Update struct {
+			IndexName             string
+			ProvisionedThroughput throughput
+		}
+	}
+	var updates []update
+	for _, index := range capacity.Indexes {
+		if existing, ok := current[index.IndexName]; ok && existing.ReadCapacityUnits == index.ReadCapacityUnits &&
+			existing.WriteCapacityUnits == index.WriteCapacityUnits {
+			continue
+		}
+		var indexUpdate update
+		indexUpdate.Update.IndexName = index.IndexName
+		indexUpdate.Update.ProvisionedThroughput = throughput{index.ReadCapacityUnits, index.WriteCapacityUnits}
+		updates = append(updates, indexUpdate)
+	}
+	if len(updates) > 0 {
+		indexUpdates, err := json.Marshal(updates)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--global-secondary-index-updates", string(indexUpdates))
+	}
+	return args, nil
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDynamoDbCapacityUpdates(t *testing.T) {
+	table := DynamoDbTable{
+		TableName: "orders", BillingMode: DynamoDbProvisioned, ReadCapacityUnits: 100, WriteCapacityUnits: 50,
+		Indexes: []DynamoDbIndex{
+			{IndexName: "by-customer", ReadCapacityUnits: 20, WriteCapacityUnits: 10},
+			{IndexName: "by-date", ReadCapacityUnits: 5, WriteCapacityUnits: 5},
+		},
+	}
+	onDemand := DynamoDbTable{
+		TableName: "orders", BillingMode: DynamoDbPayPerRequest,
+		Indexes: []DynamoDbIndex{{IndexName: "by-customer"}, {IndexName: "by-date"}},
+	}
+	indexes := []DynamoDbIndex{
+		{IndexName: "by-customer", ReadCapacityUnits: 20, WriteCapacityUnits: 10},
+		{IndexName: "by-date", ReadCapacityUnits: 1, WriteCapacityUnits: 1},
+	}
+
+	tests := []struct {
+		name     string
+		table    DynamoDbTable
+		capacity DynamoDbCapacity
+		expected []string
+	}{
+		{
+			name:     "up to date",
+			table:    table,
+			capacity: DynamoDbCapacity{BillingMode: DynamoDbProvisioned, ReadCapacityUnits: 100, WriteCapacityUnits: 50, Indexes: table.Indexes},
+		},
+		{
+			name:     "only an index changed",
+			table:    table,
+			capacity: DynamoDbCapacity{BillingMode: DynamoDbProvisioned, ReadCapacityUnits: 100, WriteCapacityUnits: 50, Indexes: indexes},
+			expected: []string{"--global-secondary-index-updates",
+				`[{"Update":{"IndexName":"by-date","ProvisionedThroughput":{"ReadCapacityUnits":1,"WriteCapacityUnits":1}}}]`},
+		},
+		{
+			name:     "only the table changed",
+			table:    table,
+			capacity: DynamoDbCapacity{BillingMode: DynamoDbProvisioned, ReadCapacityUnits: 10, WriteCapacityUnits: 5, Indexes: table.Indexes},
+			expected: []string{"--provisioned-throughput", "ReadCapacityUnits=10,WriteCapacityUnits=5"},
+		},
+		{
+			name:     "switch to on-demand",
+			table:    table,
+			capacity: DynamoDbCapacity{BillingMode: DynamoDbPayPerRequest},
+			expected: []string{"--billing-mode", DynamoDbPayPerRequest},
+		},
+		{
+			name:     "switch to provisioned",
+			table:    onDemand,
+			capacity: DynamoDbCapacity{BillingMode: DynamoDbProvisioned, ReadCapacityUnits: 100, WriteCapacityUnits: 50, Indexes: indexes},
+			expected: []string{"--billing-mode", DynamoDbProvisioned,
+				"--provisioned-throughput", "ReadCapacityUnits=100,WriteCapacityUnits=50",
+				"--global-secondary-index-updates",
+				`[{"Update":{"IndexName":"by-customer","ProvisionedThroughput":{"ReadCapacityUnits":20,"WriteCapacityUnits":10}}},` +
+					`{"Update":{"IndexName":"by-date","ProvisionedThroughput":{"ReadCapacityUnits":1,"WriteCapacityUnits":1}}}]`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			updates, err := dynamoDbCapacityUpdates(test.table, test.capacity)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(updates, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, updates)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Tag is an ec2 resource tag.
+type Tag struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+// Tags maps the tags of an ec2 resource by key.
+type Tags []Tag
+
+// Get returns the value of the tag, and whether the tag is set.
+func (t Tags) Get(key string) (string, bool) {
+	for _, tag := range t {
+		if tag.Key == key {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+// Matches returns true if all the given tags are set with the same value.
+func (t Tags) Matches(tags map[string]string) bool {
+	for key, value := range tags {
+		if v, ok := t.Get(key); !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// EbsVolume is the description of an ebs volume.
+type EbsVolume struct {
+	VolumeID string `json:"VolumeId"`
+	// Size in GiB.
+	Size       int32     `json:"Size"`
+	VolumeType string    `json:"VolumeType"`
+	State      string    `json:"State"`
+	CreateTime time.Time `json:"CreateTime"`
+	Tags       Tags      `json:"Tags"`
+}
+
+// DescribeAvailableEbsVolumes returns the volumes which are not attached to an instance and have
+// all the given tags.
+func DescribeAvailableEbsVolumes(logger logr.Logger, region string, tags map[string]string) ([]EbsVolume, error) {
+	return describeEbsVolumes(logger, region, append(tagFilters(tags), "Name=status,Values=available")...)
+}
+
+// DescribeEbsVolumesByTagKey returns the volumes in the given state having a tag with the key.
+func DescribeEbsVolumesByTagKey(logger logr.Logger, region, key, state string) ([]EbsVolume, error) {
+	return describeEbsVolumes(logger, region, "Name=tag-key,Values="+key, "Name=status,Values="+state)
+}
+
+func describeEbsVolumes(logger logr.Logger, region string, filters ...string) ([]EbsVolume, error) {
+	args := append([]string{"ec2", "describe-volumes", "--region", ResolveRegion(region), "--filters"}, filters...)
+	out, err := runCMD(logger, append(args,
+		"--query", "Volumes[].{VolumeId: VolumeId, Size: Size, VolumeType: VolumeType, State: State, CreateTime: CreateTime, Tags: Tags}",
+		"--output", "json")...)
+	if err != nil {
+		return nil, err
+	}
+	var volumes []EbsVolume
+	if err := json.Unmarshal(out, &volumes); err != nil {
+		return nil, fmt.Errorf("unable to parse describe-volumes output: %w", err)
+	}
+	return volumes, nil
+}
+
+// DeleteEbsVolume deletes the volume, it has to be detached.
+func DeleteEbsVolume(logger logr.Logger, region, volumeID string) error {
+	if _, err := runCMD(logger, "ec2", "delete-volume", "--region", ResolveRegion(region), "--volume-id", volumeID); err != nil {
+		return err
+	}
+	logger.Info("successfully deleted volume", "volume", volumeID)
+	return nil
+}
+
+// CreateEbsSnapshot starts a snapshot of the volume and returns its id, the snapshot gets the
+// given tags.
+func CreateEbsSnapshot(logger logr.Logger, region, volumeID, description string, tags map[string]string) (string, error) {
+	args := []string{"ec2", "create-snapshot", "--region", ResolveRegion(region), "--volume-id", volumeID,
+		"--description", description}
+	if len(tags) > 0 {
+		args = append(args, "--tag-specifications", "ResourceType=snapshot,Tags=["+tagList(tags)+"]")
+	}
+	out, err := runCMD(logger, append(args, "--query", "SnapshotId", "--output", "text")...)
+	if err != nil {
+		return "", err
+	}
+	snapshotID := strings.TrimSpace(string(out))
+	logger.Info("successfully started snapshot", "volume", volumeID, "snapshot", snapshotID)
+	return snapshotID, nil
+}
+
+// DescribeEbsSnapshotState returns the state of the snapshot, i.e. pending, completed or error.
+func DescribeEbsSnapshotState(logger logr.Logger, region, snapshotID string) (string, error) {
+	out, err := runCMD(logger, "ec2", "describe-snapshots", "--region", ResolveRegion(region),
+		"--snapshot-ids", snapshotID, "--query", "Snapshots[0].State", "--output", "text")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CreateEc2Tags adds or overwrites the tags of the ec2 resources.
+func CreateEc2Tags(logger logr.Logger, region string, resourceIDs []string, tags map[string]string) error {
+	args := append([]string{"ec2", "create-tags", "--region", ResolveRegion(region), "--resources"}, resourceIDs...)
+	args = append(args, "--tags")
+	for _, key := range sortedKeys(tags) {
+		args = append(args, fmt.Sprintf("Key=%s,Value=%s", key, tags[key]))
+	}
+	_, err := runCMD(logger, args...)
+	return err
+}
+
+// DeleteEc2Tags removes the tags with the given keys from the ec2 resources.
+func DeleteEc2Tags(logger logr.Logger, region string, resourceIDs []string, keys ...string) error {
+	args := append([]string{"ec2", "delete-tags", "--region", ResolveRegion(region), "--resources"}, resourceIDs...)
+	args = append(args, "--tags")
+	for _, key := range keys {
+		args = append(args, "Key="+key)
+	}
+	_, err := runCMD(logger, args...)
+	return err
+}
+
+// tagFilters returns the describe filters matching resources having all the tags.
+func tagFilters(tags map[string]string) []string {
+	filters := make([]string, 0, len(tags))
+	for _, key := range sortedKeys(tags) {
+		filters = append(filters, fmt.Sprintf("Name=tag:%s,Values=%s", key, tags[key]))
+	}
+	return filters
+}
+
+// tagList returns the tags in the shorthand syntax of the aws cli, e.g. {Key=a,Value=b}.
+func tagList(tags map[string]string) string {
+	list := make([]string, 0, len(tags))
+	for _, key := range sortedKeys(tags) {
+		list = append(list, fmt.Sprintf("{Key=%s,Value=%s}", key, tags[key]))
+	}
+	return strings.Join(list, ",")
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// EbsSnapshot is the description of an ebs snapshot.
+type EbsSnapshot struct {
+	SnapshotID string `json:"SnapshotId"`
+	VolumeID   string `json:"VolumeId"`
+	// VolumeSize is the size of the volume the snapshot was taken of in GiB.
+	VolumeSize int32     `json:"VolumeSize"`
+	State      string    `json:"State"`
+	StartTime  time.Time `json:"StartTime"`
+	Tags       Tags      `json:"Tags"`
+}
+
+// DescribeEbsSnapshots returns the snapshots owned by the given owners, e.g. self or an account
+// id, restricted to the snapshots of the given volumes and having all the given tags.
+func DescribeEbsSnapshots(logger logr.Logger, region string, ownerIDs, volumeIDs []string, tags map[string]string) ([]EbsSnapshot, error) {
+	args := append([]string{"ec2", "describe-snapshots", "--region", ResolveRegion(region), "--owner-ids"}, ownerIDs...)
+	filters := tagFilters(tags)
+	if len(volumeIDs) > 0 {
+		filters = append(filters, "Name=volume-id,Values="+strings.Join(volumeIDs, ","))
+	}
+	if len(filters) > 0 {
+		args = append(append(args, "--filters"), filters...)
+	}
+	out, err := runCMD(logger, append(args,
+		"--query", "Snapshots[].{SnapshotId: SnapshotId, VolumeId: VolumeId, VolumeSize: VolumeSize, State: State, StartTime: StartTime, Tags: Tags}",
+		"--output", "json")...)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []EbsSnapshot
+	if err := json.Unmarshal(out, &snapshots); err != nil {
+		return nil, fmt.Errorf("unable to parse describe-snapshots output: %w", err)
+	}
+	return snapshots, nil
+}
+
+// DeleteEbsSnapshot deletes the snapshot, snapshots used by a registered ami cannot be deleted.
+func DeleteEbsSnapshot(logger logr.Logger, region, snapshotID string) error {
+	if _, err := runCMD(logger, "ec2", "delete-snapshot", "--region", ResolveRegion(region), "--snapshot-id", snapshotID); err != nil {
+		return err
+	}
+	logger.Info("successfully deleted snapshot", "snapshot", snapshotID)
+	return nil
+}
+
+// DescribeAmiSnapshotIDs returns the ids of the snapshots backing the amis owned by the account.
+func DescribeAmiSnapshotIDs(logger logr.Logger, region string) ([]string, error) {
+	out, err := runCMD(logger, "ec2", "describe-images", "--region", ResolveRegion(region), "--owners", "self",
+		"--query", "Images[].BlockDeviceMappings[].Ebs.SnapshotId", "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var snapshotIDs []string
+	if err := json.Unmarshal(out, &snapshotIDs); err != nil {
+		return nil, fmt.Errorf("unable to parse describe-images output: %w", err)
+	}
+	return snapshotIDs, nil
+}
@@ -0,0 +1,26 @@
+package utils
+
+import "testing"
+
+func TestTagsMatches(t *testing.T) {
+	tags := Tags{{Key: "env", Value: "dev"}, {Key: "team", Value: "data"}}
+	tests := []struct {
+		name     string
+		selector map[string]string
+		expected bool
+	}{
+		{name: "no selector", expected: true},
+		{name: "subset", selector: map[string]string{"env": "dev"}, expected: true},
+		{name: "all", selector: map[string]string{"env": "dev", "team": "data"}, expected: true},
+		{name: "different value", selector: map[string]string{"env": "prod"}},
+		{name: "missing tag", selector: map[string]string{"owner": "data"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if matches := tags.Matches(test.selector); matches != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+)
+
+// ErrIncompatibleInstanceType is returned if an instance cannot be resized to an instance type.
+var ErrIncompatibleInstanceType = errors.New("incompatible instance type")
+
+// instanceTypeStartErrorCodes are the codes of the errors returned when starting an instance which
+// cannot run on its instance type.
+var instanceTypeStartErrorCodes = map[string]bool{
+	"Unsupported":                 true,
+	"UnsupportedOperation":        true,
+	"InvalidParameterCombination": true,
+	"InvalidInstanceType":         true,
+}
+
+// IsInstanceTypeStartError returns true if the instance failed to start because of its instance
+// type, i.e. there is no capacity for the type or the instance is not compatible with it. Other
+// errors, e.g. missing permissions, are not solved by changing the instance type.
+func IsInstanceTypeStartError(err error) bool {
+	if errors.Is(err, ErrInsufficientCapacity) || errors.Is(err, ErrIncompatibleInstanceType) {
+		return true
+	}
+	var awsErr *AWSError
+	return errors.As(err, &awsErr) && instanceTypeStartErrorCodes[awsErr.Code]
+}
+
+// Ec2InstanceType is the description of an ec2 instance type.
+type Ec2InstanceType struct {
+	InstanceType string `json:"InstanceType"`
+	// Architectures supported by the instance type, e.g. x86_64.
+	Architectures []string `json:"Architectures"`
+	// EnaSupport is unsupported, supported or required.
+	EnaSupport string `json:"EnaSupport"`
+	// EbsOptimizedSupport is unsupported, supported or default.
+	EbsOptimizedSupport string `json:"EbsOptimizedSupport"`
+}
+
+// DescribeEc2InstanceType returns the description of the instance type.
+func DescribeEc2InstanceType(logger logr.Logger, region, instanceType string) (Ec2InstanceType, error) {
+	out, err := runCMD(logger, "ec2", "describe-instance-types", "--region", ResolveRegion(region),
+		"--instance-types", instanceType,
+		"--query", "InstanceTypes[].{InstanceType: InstanceType, Architectures: ProcessorInfo.SupportedArchitectures, EnaSupport: NetworkInfo.EnaSupport, EbsOptimizedSupport: EbsInfo.EbsOptimizedSupport}",
+		"--output", "json")
+	if err != nil {
+		return Ec2InstanceType{}, err
+	}
+	var instanceTypes []Ec2InstanceType
+	if err := json.Unmarshal(out, &instanceTypes); err != nil {
+		return Ec2InstanceType{}, fmt.Errorf("unable to parse describe-instance-types output: %w", err)
+	}
+	if len(instanceTypes) == 0 {
+		return Ec2InstanceType{}, fmt.Errorf("instance type %s not found", instanceType)
+	}
+	return instanceTypes[0], nil
+}
+
+// CheckInstanceTypeCompatibility returns an error wrapping ErrIncompatibleInstanceType if the
+// instance cannot run on the instance type, i.e. the architecture, ENA support or EBS
+// optimization of the instance is not supported by the instance type.
+func CheckInstanceTypeCompatibility(instance Ec2Instance, instanceType Ec2InstanceType) error {
+	supported := false
+	for _, architecture := range instanceType.Architectures {
+		supported = supported || architecture == instance.Architecture
+	}
+	if !supported {
+		return fmt.Errorf("%w: %s does not support the %s architecture", ErrIncompatibleInstanceType,
+			instanceType.InstanceType, instance.Architecture)
+	}
+	if instance.EnaSupport && instanceType.EnaSupport == "unsupported" {
+		return fmt.Errorf("%w: %s does not support ENA", ErrIncompatibleInstanceType, instanceType.InstanceType)
+	}
+	if !instance.EnaSupport && instanceType.EnaSupport == "required" {
+		return fmt.Errorf("%w: %s requires ENA, which is not enabled for the instance", ErrIncompatibleInstanceType,
+			instanceType.InstanceType)
+	}
+	if instance.EbsOptimized && instanceType.EbsOptimizedSupport == "unsupported" {
+		return fmt.Errorf("%w: %s does not support EBS optimization", ErrIncompatibleInstanceType, instanceType.InstanceType)
+	}
+	return nil
+}
+
+// ModifyEc2InstanceType changes the instance type of a stopped instance.
+func ModifyEc2InstanceType(logger logr.Logger, region, instanceID, instanceType string) error {
+	_, err := runCMD(logger, "ec2", "modify-instance-attribute", "--region", ResolveRegion(region),
+		"--instance-id", instanceID, "--instance-type", "Value="+instanceType)
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully modified instance type", "instance", instanceID, "type", instanceType)
+	return nil
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckInstanceTypeCompatibility(t *testing.T) {
+	instance := Ec2Instance{InstanceID: "i-1", InstanceType: "m5.xlarge", Architecture: "x86_64", EnaSupport: true, EbsOptimized: true}
+	tests := []struct {
+		name         string
+		instance     Ec2Instance
+		instanceType Ec2InstanceType
+		compatible   bool
+	}{
+		{name: "compatible", instance: instance, compatible: true,
+			instanceType: Ec2InstanceType{InstanceType: "m5.large", Architectures: []string{"i386", "x86_64"}, EnaSupport: "required", EbsOptimizedSupport: "default"}},
+		{name: "architecture", instance: instance,
+			instanceType: Ec2InstanceType{InstanceType: "m6g.large", Architectures: []string{"arm64"}, EnaSupport: "required", EbsOptimizedSupport: "default"}},
+		{name: "ena unsupported", instance: instance,
+			instanceType: Ec2InstanceType{InstanceType: "t2.large", Architectures: []string{"x86_64"}, EnaSupport: "unsupported", EbsOptimizedSupport: "unsupported"}},
+		{name: "ena required", instance: Ec2Instance{InstanceType: "t2.large", Architecture: "x86_64"},
+			instanceType: Ec2InstanceType{InstanceType: "m5.large", Architectures: []string{"x86_64"}, EnaSupport: "required", EbsOptimizedSupport: "default"}},
+		{name: "ebs optimized", instance: Ec2Instance{InstanceType: "m4.large", Architecture: "x86_64", EbsOptimized: true},
+			instanceType: Ec2InstanceType{InstanceType: "t2.large", Architectures: []string{"x86_64"}, EnaSupport: "unsupported", EbsOptimizedSupport: "unsupported"}},
+	}
+	for _, test := range tests {
+		err := CheckInstanceTypeCompatibility(test.instance, test.instanceType)
+		if test.compatible && err != nil {
+			t.Errorf("%s: expected compatible, got %v", test.name, err)
+		}
+		if !test.compatible && !errors.Is(err, ErrIncompatibleInstanceType) {
+			t.Errorf("%s: expected ErrIncompatibleInstanceType, got %v", test.name, err)
+		}
+	}
+}
+
+func TestIsInstanceTypeStartError(t *testing.T) {
+	for output, expected := range map[string]bool{
+		"An error occurred (InsufficientInstanceCapacity) when calling the StartInstances operation: no capacity": true,
+		"An error occurred (Unsupported) when calling the StartInstances operation: not supported in the zone":    true,
+		"An error occurred (InvalidParameterCombination) when calling the StartInstances operation: ENA":          true,
+		"An error occurred (UnauthorizedOperation) when calling the StartInstances operation: denied":             false,
+		"An error occurred (ExpiredToken) when calling the StartInstances operation: expired":                     false,
+		"An error occurred (RequestLimitExceeded) when calling the StartInstances operation: slow down":           false,
+	} {
+		if got := IsInstanceTypeStartError(parseAWSError(output)); got != expected {
+			t.Errorf("%s: expected %v, got %v", output, expected, got)
+		}
+	}
+	if IsInstanceTypeStartError(errors.New("exit status 255")) {
+		t.Errorf("expected no rollback for unknown errors")
+	}
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+)
+
+// ecsDescribeServicesLimit is the maximum number of services describe-services accepts at once.
+const ecsDescribeServicesLimit = 10
+
+// EcsService is the description of an ecs service.
+type EcsService struct {
+	ServiceArn   string `json:"ServiceArn"`
+	ServiceName  string `json:"ServiceName"`
+	DesiredCount int32  `json:"DesiredCount"`
+	// LaunchType is FARGATE, EC2 or EXTERNAL, empty for services using a capacity provider strategy.
+	LaunchType string `json:"LaunchType"`
+	Tags       Tags   `json:"Tags"`
+}
+
+// DescribeEcsServices returns the active services of the cluster having all the given tags.
+func DescribeEcsServices(logger logr.Logger, region, cluster string, tags map[string]string) ([]EcsService, error) {
+	out, err := runCMD(logger, "ecs", "list-services", "--region", ResolveRegion(region), "--cluster", cluster,
+		"--query", "serviceArns", "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var serviceArns []string
+	if err := json.Unmarshal(out, &serviceArns); err != nil {
+		return nil, fmt.Errorf("unable to parse list-services output: %w", err)
+	}
+
+	var services []EcsService
+	for start := 0; start < len(serviceArns); start += ecsDescribeServicesLimit {
+		end := start + ecsDescribeServicesLimit
+		if end > len(serviceArns) {
+			end = len(serviceArns)
+		}
+		args := append([]string{"ecs", "describe-services", "--region", ResolveRegion(region), "--cluster", cluster,
+			"--include", "TAGS", "--services"}, serviceArns[start:end]...)
+		out, err := runCMD(logger, append(args,
+			"--query", "services[?status=='ACTIVE'].{ServiceArn: serviceArn, ServiceName: serviceName, DesiredCount: desiredCount, LaunchType: launchType, Tags: tags[].{Key: key, Value: value}}",
+			"--output", "json")...)
+		if err != nil {
+			return nil, err
+		}
+		var described []EcsService
+		if err := json.Unmarshal(out, &described); err != nil {
+			return nil, fmt.Errorf("unable to parse describe-services output: %w", err)
+		}
+		for _, service := range described {
+			if service.Tags.Matches(tags) {
+				services = append(services, service)
+			}
+		}
+	}
+	return services, nil
+}
+
+// UpdateEcsServiceDesiredCount sets the desired count of the service.
+func UpdateEcsServiceDesiredCount(logger logr.Logger, region, cluster, service string, desiredCount int32) error {
+	_, err := runCMD(logger, "ecs", "update-service", "--region", ResolveRegion(region), "--cluster", cluster,
+		"--service", service, "--desired-count", strconv.Itoa(int(desiredCount)), "--query", "service.serviceName", "--output", "text")
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully updated desired count of ecs service", "cluster", cluster, "service", service, "desiredCount", desiredCount)
+	return nil
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+)
+
+// ElasticIP is the description of an elastic ip address.
+type ElasticIP struct {
+	AllocationID string `json:"AllocationId"`
+	PublicIP     string `json:"PublicIp"`
+	// AssociationID is empty if the address is not associated with an instance or a network interface.
+	AssociationID string `json:"AssociationId"`
+	Tags          Tags   `json:"Tags"`
+}
+
+// DescribeElasticIPs returns the elastic ip addresses of the region having all the given tags.
+func DescribeElasticIPs(logger logr.Logger, region string, tags map[string]string) ([]ElasticIP, error) {
+	args := []string{"ec2", "describe-addresses", "--region", ResolveRegion(region)}
+	if filters := tagFilters(tags); len(filters) > 0 {
+		args = append(append(args, "--filters"), filters...)
+	}
+	out, err := runCMD(logger, append(args,
+		"--query", "Addresses[].{AllocationId: AllocationId, PublicIp: PublicIp, AssociationId: AssociationId, Tags: Tags}",
+		"--output", "json")...)
+	if err != nil {
+		return nil, err
+	}
+	var addresses []ElasticIP
+	if err := json.Unmarshal(out, &addresses); err != nil {
+		return nil, fmt.Errorf("unable to parse describe-addresses output: %w", err)
+	}
+	return addresses, nil
+}
+
+// ReleaseElasticIP releases the elastic ip address, it has to be unassociated.
+func ReleaseElasticIP(logger logr.Logger, region, allocationID string) error {
+	if _, err := runCMD(logger, "ec2", "release-address", "--region", ResolveRegion(region), "--allocation-id", allocationID); err != nil {
+		return err
+	}
+	logger.Info("successfully released elastic ip", "allocation", allocationID)
+	return nil
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+)
+
+// EksNodeGroup is the description of an eks managed node group.
+type EksNodeGroup struct {
+	Name string `json:"Name"`
+	// Status of the node group, e.g. ACTIVE or UPDATING.
+	Status      string `json:"Status"`
+	MinSize     int32  `json:"MinSize"`
+	MaxSize     int32  `json:"MaxSize"`
+	DesiredSize int32  `json:"DesiredSize"`
+}
+
+// DescribeEksNodeGroup returns the description of the managed node group of the cluster.
+func DescribeEksNodeGroup(logger logr.Logger, region, clusterName, name string) (EksNodeGroup, error) {
+	out, err := runCMD(logger, "eks", "describe-nodegroup", "--region", ResolveRegion(region),
+		"--cluster-name", clusterName, "--nodegroup-name", name,
+		"--query", "nodegroup.{Name: nodegroupName, Status: status, MinSize: scalingConfig.minSize, MaxSize: scalingConfig.maxSize, DesiredSize: scalingConfig.desiredSize}",
+		"--output", "json")
+	if err != nil {
+		return EksNodeGroup{}, err
+	}
+	var nodeGroup EksNodeGroup
+	if err := json.Unmarshal(out, &nodeGroup); err != nil {
+		return EksNodeGroup{}, fmt.Errorf("unable to parse describe-nodegroup output: %w", err)
+	}
+	return nodeGroup, nil
+}
+
+// UpdateEksNodeGroupScaling sets the scaling config of the managed node group, eks requires
+// a max size of at least one.
+func UpdateEksNodeGroupScaling(logger logr.Logger, region, clusterName, name string, minSize, maxSize, desiredSize int32) error {
+	_, err := runCMD(logger, "eks", "update-nodegroup-config", "--region", ResolveRegion(region),
+		"--cluster-name", clusterName, "--nodegroup-name", name,
+		"--scaling-config", fmt.Sprintf("minSize=%d,maxSize=%d,desiredSize=%d", minSize, maxSize, desiredSize))
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully updated node group scaling config", "cluster", clusterName, "nodegroup", name,
+		"min", minSize, "max", maxSize, "desired", desiredSize)
+	return nil
+}
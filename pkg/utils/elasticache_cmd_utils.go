@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+)
+
+// ElastiCacheReplicationGroup is the description of an elasticache replication group.
+type ElastiCacheReplicationGroup struct {
+	ReplicationGroupID string `json:"ReplicationGroupId"`
+	// Status of the replication group, e.g. available or modifying.
+	Status string `json:"Status"`
+	// NodeGroupMembers is the number of nodes of each node group, primary included.
+	NodeGroupMembers []int32 `json:"NodeGroupMembers"`
+}
+
+// ReplicasPerNodeGroup returns the number of replicas of the first node group.
+func (g ElastiCacheReplicationGroup) ReplicasPerNodeGroup() int32 {
+	if len(g.NodeGroupMembers) == 0 || g.NodeGroupMembers[0] == 0 {
+		return 0
+	}
+	return g.NodeGroupMembers[0] - 1
+}
+
+// DescribeElastiCacheReplicationGroup returns the description of the replication group.
+func DescribeElastiCacheReplicationGroup(logger logr.Logger, region, replicationGroupID string) (ElastiCacheReplicationGroup, error) {
+	var group ElastiCacheReplicationGroup
+	out, err := runCMD(logger, "elasticache", "describe-replication-groups", "--region", ResolveRegion(region),
+		"--replication-group-id", replicationGroupID,
+		"--query", "ReplicationGroups[0].{ReplicationGroupId: ReplicationGroupId, Status: Status, NodeGroupMembers: NodeGroups[].length(NodeGroupMembers)}",
+		"--output", "json")
+	if err != nil {
+		return group, err
+	}
+	if err := json.Unmarshal(out, &group); err != nil {
+		return group, fmt.Errorf("unable to parse describe-replication-groups output: %w", err)
+	}
+	return group, nil
+}
+
+// ModifyElastiCacheReplicaCount changes the number of replicas of each node group of the
+// replication group from current to replicas, the change is applied immediately.
+func ModifyElastiCacheReplicaCount(logger logr.Logger, region, replicationGroupID string, current, replicas int32) error {
+	operation := "decrease-replica-count"
+	if replicas > current {
+		operation = "increase-replica-count"
+	}
+	_, err := runCMD(logger, "elasticache", operation, "--region", ResolveRegion(region), "--replication-group-id", replicationGroupID,
+		"--new-replica-count", strconv.Itoa(int(replicas)), "--apply-immediately",
+		"--query", "ReplicationGroup.Status", "--output", "text")
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started replica count change", "replicationGroup", replicationGroupID, "replicas", replicas)
+	return nil
+}
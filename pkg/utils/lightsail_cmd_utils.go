@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+)
+
+// LightsailInstance is the description of a lightsail instance.
+type LightsailInstance struct {
+	Name string `json:"Name"`
+	// State of the instance, e.g. running, stopping or stopped.
+	State string `json:"State"`
+	Tags  Tags   `json:"Tags"`
+}
+
+// DescribeLightsailInstances returns the description of the given instances, instances which do
+// not exist are left out. All the instances of the region are returned if no names are given.
+func DescribeLightsailInstances(logger logr.Logger, region string, names []string) ([]LightsailInstance, error) {
+	// get-instance fails for unknown instances, filter all the instances instead.
+	out, err := runCMD(logger, "lightsail", "get-instances", "--region", ResolveRegion(region),
+		"--query", "instances[].{Name: name, State: state.name, Tags: tags[].{Key: key, Value: value}}", "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var instances []LightsailInstance
+	if err := json.Unmarshal(out, &instances); err != nil {
+		return nil, fmt.Errorf("unable to parse get-instances output: %w", err)
+	}
+	if len(names) == 0 {
+		return instances, nil
+	}
+	selected := map[string]bool{}
+	for _, name := range names {
+		selected[name] = true
+	}
+	filtered := instances[:0]
+	for _, instance := range instances {
+		if selected[instance.Name] {
+			filtered = append(filtered, instance)
+		}
+	}
+	return filtered, nil
+}
+
+// StartLightsailInstance starts a stopped lightsail instance.
+func StartLightsailInstance(logger logr.Logger, region, name string) error {
+	return runLightsailOperation(logger, "start-instance", region, name)
+}
+
+// StopLightsailInstance stops a running lightsail instance, a stopped instance is not billed for
+// its bundle but for its disk only.
+func StopLightsailInstance(logger logr.Logger, region, name string) error {
+	return runLightsailOperation(logger, "stop-instance", region, name)
+}
+
+func runLightsailOperation(logger logr.Logger, operation, region, name string) error {
+	if _, err := runCMD(logger, "lightsail", operation, "--region", ResolveRegion(region), "--instance-name", name); err != nil {
+		return err
+	}
+	logger.Info("successfully issued lightsail operation", "operation", operation, "instance", name)
+	return nil
+}
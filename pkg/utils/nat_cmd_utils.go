@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// NatGateway is the description of a nat gateway.
+type NatGateway struct {
+	NatGatewayID string `json:"NatGatewayId"`
+	SubnetID     string `json:"SubnetId"`
+	// State of the gateway, i.e. pending, available, deleting, deleted or failed.
+	State string `json:"State"`
+	// ConnectivityType is public or private, private gateways have no elastic ip.
+	ConnectivityType string `json:"ConnectivityType"`
+	// AllocationID of the elastic ip of a public gateway.
+	AllocationID string `json:"AllocationId"`
+	Tags         Tags   `json:"Tags"`
+}
+
+// NatGatewayRoute is a route of a route table targeting a nat gateway, only one of the
+// destinations is set.
+type NatGatewayRoute struct {
+	RouteTableID             string `json:"RouteTableId"`
+	DestinationCidrBlock     string `json:"DestinationCidrBlock"`
+	DestinationIpv6CidrBlock string `json:"DestinationIpv6CidrBlock"`
+	DestinationPrefixListID  string `json:"DestinationPrefixListId"`
+}
+
+// DescribeNatGateways returns the description of the given nat gateways, gateways which do not
+// exist are left out. Deleted gateways stay visible for about an hour.
+func DescribeNatGateways(logger logr.Logger, region string, natGatewayIDs []string) ([]NatGateway, error) {
+	out, err := runCMD(logger, "ec2", "describe-nat-gateways", "--region", ResolveRegion(region),
+		"--filter", "Name=nat-gateway-id,Values="+strings.Join(natGatewayIDs, ","),
+		"--query", "NatGateways[].{NatGatewayId: NatGatewayId, SubnetId: SubnetId, State: State, ConnectivityType: ConnectivityType, AllocationId: NatGatewayAddresses[0].AllocationId, Tags: Tags}",
+		"--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var gateways []NatGateway
+	if err := json.Unmarshal(out, &gateways); err != nil {
+		return nil, fmt.Errorf("unable to parse describe-nat-gateways output: %w", err)
+	}
+	return gateways, nil
+}
+
+// DescribeNatGatewayRoutes returns the routes of all the route tables targeting the nat gateway.
+func DescribeNatGatewayRoutes(logger logr.Logger, region, natGatewayID string) ([]NatGatewayRoute, error) {
+	out, err := runCMD(logger, "ec2", "describe-route-tables", "--region", ResolveRegion(region),
+		"--filters", "Name=route.nat-gateway-id,Values="+natGatewayID,
+		"--query", "RouteTables[].{RouteTableId: RouteTableId, Routes: Routes}", "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var tables []struct {
+		RouteTableID string `json:"RouteTableId"`
+		Routes       []struct {
+			NatGatewayRoute
+			NatGatewayID string `json:"NatGatewayId"`
+		} `json:"Routes"`
+	}
+	if err := json.Unmarshal(out, &tables); err != nil {
+		return nil, fmt.Errorf("unable to parse describe-route-tables output: %w", err)
+	}
+	var routes []NatGatewayRoute
+	for _, table := range tables {
+		for _, route := range table.Routes {
+			if route.NatGatewayID != natGatewayID {
+				continue
+			}
+			route.NatGatewayRoute.RouteTableID = table.RouteTableID
+			routes = append(routes, route.NatGatewayRoute)
+		}
+	}
+	return routes, nil
+}
+
+// DeleteNatGateway starts the deletion of the nat gateway, its elastic ip is disassociated but
+// not released. Routes targeting the gateway become blackhole routes.
+func DeleteNatGateway(logger logr.Logger, region, natGatewayID string) error {
+	if _, err := runCMD(logger, "ec2", "delete-nat-gateway", "--region", ResolveRegion(region), "--nat-gateway-id", natGatewayID); err != nil {
+		return err
+	}
+	logger.Info("successfully started deletion of nat gateway", "natGateway", natGatewayID)
+	return nil
+}
+
+// CreateNatGateway starts the creation of a nat gateway in the subnet and returns its id. The
+// gateway is public if an elastic ip allocation is given, private otherwise. Retrying with the
+// same client token returns the gateway created by the first attempt instead of a second one.
+func CreateNatGateway(logger logr.Logger, region, subnetID, allocationID, clientToken string, tags map[string]string) (string, error) {
+	args := []string{"ec2", "create-nat-gateway", "--region", ResolveRegion(region), "--subnet-id", subnetID}
+	if clientToken != "" {
+		args = append(args, "--client-token", clientToken)
+	}
+	if allocationID != "" {
+		args = append(args, "--allocation-id", allocationID)
+	} else {
+		args = append(args, "--connectivity-type", "private")
+	}
+	if len(tags) > 0 {
+		args = append(args, "--tag-specifications", "ResourceType=natgateway,Tags=["+tagList(tags)+"]")
+	}
+	out, err := runCMD(logger, append(args, "--query", "NatGateway.NatGatewayId", "--output", "text")...)
+	if err != nil {
+		return "", err
+	}
+	natGatewayID := strings.TrimSpace(string(out))
+	logger.Info("successfully started creation of nat gateway", "subnet", subnetID, "natGateway", natGatewayID)
+	return natGatewayID, nil
+}
+
+// ReplaceNatGatewayRoute points the route to the nat gateway, the route is created if it does
+// not exist anymore.
+func ReplaceNatGatewayRoute(logger logr.Logger, region string, route NatGatewayRoute, natGatewayID string) error {
+	args := []string{"--region", ResolveRegion(region), "--route-table-id", route.RouteTableID, "--nat-gateway-id", natGatewayID}
+	switch {
+	case route.DestinationCidrBlock != "":
+		args = append(args, "--destination-cidr-block", route.DestinationCidrBlock)
+	case route.DestinationIpv6CidrBlock != "":
+		args = append(args, "--destination-ipv6-cidr-block", route.DestinationIpv6CidrBlock)
+	default:
+		args = append(args, "--destination-prefix-list-id", route.DestinationPrefixListID)
+	}
+	_, err := runCMD(logger, append([]string{"ec2", "replace-route"}, args...)...)
+	if reason, _ := ClassifyError(err); err != nil && reason == ReasonInstanceNotFound {
+		_, err = runCMD(logger, append([]string{"ec2", "create-route"}, args...)...)
+	}
+	return err
+}
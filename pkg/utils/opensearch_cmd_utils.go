@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// OpenSearchDomain is the description of an opensearch domain.
+type OpenSearchDomain struct {
+	DomainName string `json:"DomainName"`
+	// Processing is true while a configuration change is in progress.
+	Processing bool `json:"Processing"`
+	// InstanceType of the data nodes.
+	InstanceType string `json:"InstanceType"`
+	// InstanceCount is the number of data nodes.
+	InstanceCount int32 `json:"InstanceCount"`
+}
+
+// DescribeOpenSearchDomain returns the description of the domain.
+func DescribeOpenSearchDomain(logger logr.Logger, region, domainName string) (OpenSearchDomain, error) {
+	var domain OpenSearchDomain
+	out, err := runCMD(logger, "opensearch", "describe-domain", "--region", ResolveRegion(region), "--domain-name", domainName,
+		"--query", "DomainStatus.{DomainName: DomainName, Processing: Processing, InstanceType: ClusterConfig.InstanceType, InstanceCount: ClusterConfig.InstanceCount}",
+		"--output", "json")
+	if err != nil {
+		return domain, err
+	}
+	if err := json.Unmarshal(out, &domain); err != nil {
+		return domain, fmt.Errorf("unable to parse describe-domain output: %w", err)
+	}
+	return domain, nil
+}
+
+// UpdateOpenSearchDataNodes changes the type and the number of the data nodes of the domain,
+// empty or zero values are left unchanged. The change is applied by a blue/green deployment.
+func UpdateOpenSearchDataNodes(logger logr.Logger, region, domainName, instanceType string, instanceCount int32) error {
+	var config []string
+	if instanceType != "" {
+		config = append(config, "InstanceType="+instanceType)
+	}
+	if instanceCount > 0 {
+		config = append(config, fmt.Sprintf("InstanceCount=%d", instanceCount))
+	}
+	if len(config) == 0 {
+		return nil
+	}
+	_, err := runCMD(logger, "opensearch", "update-domain-config", "--region", ResolveRegion(region), "--domain-name", domainName,
+		"--cluster-config", strings.Join(config, ","), "--query", "DomainConfig.ClusterConfig.Status.State", "--output", "text")
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started data nodes change", "domain", domainName, "instanceType", instanceType, "instanceCount", instanceCount)
+	return nil
+}
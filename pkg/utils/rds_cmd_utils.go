@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// kinds of rds databases.
+const (
+	RdsDBInstance = "DBInstance"
+	RdsDBCluster  = "DBCluster"
+)
+
+// RdsDatabase is the description of a DB instance or an Aurora cluster.
+type RdsDatabase struct {
+	Identifier string `json:"Identifier"`
+	// Status of the database, e.g. available, stopping or stopped.
+	Status string `json:"Status"`
+}
+
+// DescribeRdsDatabases returns the description of the given DB instances or clusters, depending
+// on kind, databases which do not exist are left out.
+func DescribeRdsDatabases(logger logr.Logger, region, kind string, identifiers []string) ([]RdsDatabase, error) {
+	args := []string{"rds", "describe-db-instances", "--region", ResolveRegion(region),
+		"--filters", "Name=db-instance-id,Values=" + strings.Join(identifiers, ","),
+		"--query", "DBInstances[].{Identifier: DBInstanceIdentifier, Status: DBInstanceStatus}"}
+	if kind == RdsDBCluster {
+		args = []string{"rds", "describe-db-clusters", "--region", ResolveRegion(region),
+			"--filters", "Name=db-cluster-id,Values=" + strings.Join(identifiers, ","),
+			"--query", "DBClusters[].{Identifier: DBClusterIdentifier, Status: Status}"}
+	}
+	out, err := runCMD(logger, append(args, "--output", "json")...)
+	if err != nil {
+		return nil, err
+	}
+	var databases []RdsDatabase
+	if err := json.Unmarshal(out, &databases); err != nil {
+		return nil, fmt.Errorf("unable to parse %s output: %w", args[1], err)
+	}
+	return databases, nil
+}
+
+// StartRdsDatabase starts a stopped DB instance or cluster.
+func StartRdsDatabase(logger logr.Logger, region, kind, identifier string) error {
+	return runRdsOperation(logger, "start", region, kind, identifier)
+}
+
+// StopRdsDatabase stops an available DB instance or cluster, rds starts it again automatically
+// after seven days.
+func StopRdsDatabase(logger logr.Logger, region, kind, identifier string) error {
+	return runRdsOperation(logger, "stop", region, kind, identifier)
+}
+
+func runRdsOperation(logger logr.Logger, operation, region, kind, identifier string) error {
+	args := []string{"rds", operation + "-db-instance", "--region", ResolveRegion(region), "--db-instance-identifier", identifier}
+	if kind == RdsDBCluster {
+		args = []string{"rds", operation + "-db-cluster", "--region", ResolveRegion(region), "--db-cluster-identifier", identifier}
+	}
+	if _, err := runCMD(logger, args...); err != nil {
+		return err
+	}
+	logger.Info("successfully issued rds operation", "operation", args[1], "identifier", identifier)
+	return nil
+}
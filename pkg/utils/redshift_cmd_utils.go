@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+)
+
+// RedshiftCluster is the description of a provisioned redshift cluster.
+type RedshiftCluster struct {
+	Identifier string `json:"Identifier"`
+	// Status of the cluster, e.g. available, pausing, paused or resuming.
+	Status string `json:"Status"`
+}
+
+// DescribeRedshiftClusters returns the description of the given clusters, clusters which do not
+// exist are left out.
+func DescribeRedshiftClusters(logger logr.Logger, region string, identifiers []string) ([]RedshiftCluster, error) {
+	// describe-clusters accepts a single identifier only, filter all the clusters instead.
+	out, err := runCMD(logger, "redshift", "describe-clusters", "--region", ResolveRegion(region),
+		"--query", "Clusters[].{Identifier: ClusterIdentifier, Status: ClusterStatus}", "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var clusters []RedshiftCluster
+	if err := json.Unmarshal(out, &clusters); err != nil {
+		return nil, fmt.Errorf("unable to parse describe-clusters output: %w", err)
+	}
+	selected := map[string]bool{}
+	for _, identifier := range identifiers {
+		selected[identifier] = true
+	}
+	filtered := clusters[:0]
+	for _, cluster := range clusters {
+		if selected[cluster.Identifier] {
+			filtered = append(filtered, cluster)
+		}
+	}
+	return filtered, nil
+}
+
+// PauseRedshiftCluster pauses an available cluster, only the storage is billed while it is paused.
+func PauseRedshiftCluster(logger logr.Logger, region, identifier string) error {
+	return runRedshiftOperation(logger, "pause-cluster", region, identifier)
+}
+
+// ResumeRedshiftCluster resumes a paused cluster.
+func ResumeRedshiftCluster(logger logr.Logger, region, identifier string) error {
+	return runRedshiftOperation(logger, "resume-cluster", region, identifier)
+}
+
+func runRedshiftOperation(logger logr.Logger, operation, region, identifier string) error {
+	if _, err := runCMD(logger, "redshift", operation, "--region", ResolveRegion(region), "--cluster-identifier", identifier); err != nil {
+		return err
+	}
+	logger.Info("successfully issued redshift operation", "operation", operation, "identifier", identifier)
+	return nil
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// SageMakerNotebookInstance is the description of a sagemaker notebook instance.
+type SageMakerNotebookInstance struct {
+	Name string `json:"Name"`
+	// Status of the notebook instance, e.g. InService, Stopping or Stopped.
+	Status       string `json:"Status"`
+	InstanceType string `json:"InstanceType"`
+}
+
+// DescribeSageMakerNotebookInstances returns the description of the given notebook instances,
+// notebook instances which do not exist are left out.
+func DescribeSageMakerNotebookInstances(logger logr.Logger, region string, names []string) ([]SageMakerNotebookInstance, error) {
+	out, err := runCMD(logger, "sagemaker", "list-notebook-instances", "--region", ResolveRegion(region),
+		"--query", "NotebookInstances[].{Name: NotebookInstanceName, Status: NotebookInstanceStatus, InstanceType: InstanceType}",
+		"--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var notebooks []SageMakerNotebookInstance
+	if err := json.Unmarshal(out, &notebooks); err != nil {
+		return nil, fmt.Errorf("unable to parse list-notebook-instances output: %w", err)
+	}
+	selected := map[string]bool{}
+	for _, name := range names {
+		selected[name] = true
+	}
+	filtered := notebooks[:0]
+	for _, notebook := range notebooks {
+		if selected[notebook.Name] {
+			filtered = append(filtered, notebook)
+		}
+	}
+	return filtered, nil
+}
+
+// StopSageMakerNotebookInstance stops a running notebook instance, its storage volume is kept.
+func StopSageMakerNotebookInstance(logger logr.Logger, region, name string) error {
+	if _, err := runCMD(logger, "sagemaker", "stop-notebook-instance", "--region", ResolveRegion(region), "--notebook-instance-name", name); err != nil {
+		return err
+	}
+	logger.Info("successfully stopped notebook instance", "notebookInstance", name)
+	return nil
+}
+
+// StartSageMakerNotebookInstance starts a stopped notebook instance.
+func StartSageMakerNotebookInstance(logger logr.Logger, region, name string) error {
+	if _, err := runCMD(logger, "sagemaker", "start-notebook-instance", "--region", ResolveRegion(region), "--notebook-instance-name", name); err != nil {
+		return err
+	}
+	logger.Info("successfully started notebook instance", "notebookInstance", name)
+	return nil
+}
+
+// SageMakerApp is the description of a sagemaker studio app.
+type SageMakerApp struct {
+	DomainID string `json:"DomainId"`
+	// UserProfileName of the app, empty for apps of shared spaces.
+	UserProfileName string `json:"UserProfileName"`
+	// SpaceName of the app, empty for apps of user profiles.
+	SpaceName    string    `json:"SpaceName"`
+	AppType      string    `json:"AppType"`
+	AppName      string    `json:"AppName"`
+	Status       string    `json:"Status"`
+	CreationTime time.Time `json:"CreationTime"`
+	// LastUserActivityTime is nil if the app was never used. SageMaker also updates it on the
+	// health checks of the app, e.g. while a studio browser tab is left open, hence it may be
+	// more recent than the last activity of the user but never older.
+	LastUserActivityTime *time.Time `json:"LastUserActivityTimestamp"`
+	InstanceType         string     `json:"InstanceType"`
+}
+
+// DescribeSageMakerKernelGatewayApps returns the running KernelGateway apps of the domain, or of
+// all the domains if no domain is given.
+func DescribeSageMakerKernelGatewayApps(logger logr.Logger, region, domainID string) ([]SageMakerApp, error) {
+	args := []string{"sagemaker", "list-apps", "--region", ResolveRegion(region)}
+	if domainID != "" {
+		args = append(args, "--domain-id-equals", domainID)
+	}
+	out, err := runCMD(logger, append(args,
+		"--query", "Apps[?AppType=='KernelGateway' && Status=='InService'].{DomainId: DomainId, UserProfileName: UserProfileName, SpaceName: SpaceName, AppType: AppType, AppName: AppName, Status: Status, CreationTime: CreationTime}",
+		"--output", "json")...)
+	if err != nil {
+		return nil, err
+	}
+	var apps []SageMakerApp
+	if err := json.Unmarshal(out, &apps); err != nil {
+		return nil, fmt.Errorf("unable to parse list-apps output: %w", err)
+	}
+	// the last activity is only reported by describe-app.
+	for i := range apps {
+		args := append([]string{"sagemaker", "describe-app", "--region", ResolveRegion(region)}, appArgs(apps[i])...)
+		out, err := runCMD(logger, append(args,
+			"--query", "{LastUserActivityTimestamp: LastUserActivityTimestamp, InstanceType: ResourceSpec.InstanceType}",
+			"--output", "json")...)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(out, &apps[i]); err != nil {
+			return nil, fmt.Errorf("unable to parse describe-app output: %w", err)
+		}
+	}
+	return apps, nil
+}
+
+// DeleteSageMakerApp deletes the studio app, the notebooks of the user are kept.
+func DeleteSageMakerApp(logger logr.Logger, region string, app SageMakerApp) error {
+	if _, err := runCMD(logger, append([]string{"sagemaker", "delete-app", "--region", ResolveRegion(region)}, appArgs(app)...)...); err != nil {
+		return err
+	}
+	logger.Info("successfully deleted studio app", "domain", app.DomainID, "app", app.AppName)
+	return nil
+}
+
+// appArgs returns the arguments identifying the app.
+func appArgs(app SageMakerApp) []string {
+	args := []string{"--domain-id", app.DomainID, "--app-type", app.AppType, "--app-name", app.AppName}
+	if app.SpaceName != "" {
+		return append(args, "--space-name", app.SpaceName)
+	}
+	return append(args, "--user-profile-name", app.UserProfileName)
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// workSpacesDescribeLimit is the maximum number of workspaces describe-workspaces accepts at once.
+const workSpacesDescribeLimit = 25
+
+// WorkSpace is the description of an amazon workspace.
+type WorkSpace struct {
+	WorkspaceID string `json:"WorkspaceId"`
+	// State of the workspace, e.g. AVAILABLE, STOPPING or STOPPED.
+	State string `json:"State"`
+}
+
+// DescribeWorkSpaces returns the description of the given workspaces, workspaces which do not
+// exist are left out. All the workspaces of the region are returned if no ids are given.
+func DescribeWorkSpaces(logger logr.Logger, region string, workspaceIDs []string) ([]WorkSpace, error) {
+	if len(workspaceIDs) == 0 {
+		return describeWorkSpaces(logger, region)
+	}
+	var workspaces []WorkSpace
+	for start := 0; start < len(workspaceIDs); start += workSpacesDescribeLimit {
+		end := start + workSpacesDescribeLimit
+		if end > len(workspaceIDs) {
+			end = len(workspaceIDs)
+		}
+		described, err := describeWorkSpaces(logger, region, append([]string{"--workspace-ids"}, workspaceIDs[start:end]...)...)
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, described...)
+	}
+	return workspaces, nil
+}
+
+func describeWorkSpaces(logger logr.Logger, region string, selection ...string) ([]WorkSpace, error) {
+	args := append([]string{"workspaces", "describe-workspaces", "--region", ResolveRegion(region)}, selection...)
+	out, err := runCMD(logger, append(args, "--query", "Workspaces[].{WorkspaceId: WorkspaceId, State: State}", "--output", "json")...)
+	if err != nil {
+		return nil, err
+	}
+	var workspaces []WorkSpace
+	if err := json.Unmarshal(out, &workspaces); err != nil {
+		return nil, fmt.Errorf("unable to parse describe-workspaces output: %w", err)
+	}
+	return workspaces, nil
+}
+
+// DescribeWorkSpaceTags returns the tags of the workspace, describe-workspaces does not report them.
+func DescribeWorkSpaceTags(logger logr.Logger, region, workspaceID string) (Tags, error) {
+	out, err := runCMD(logger, "workspaces", "describe-tags", "--region", ResolveRegion(region), "--resource-id", workspaceID,
+		"--query", "TagList", "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var tags Tags
+	if err := json.Unmarshal(out, &tags); err != nil {
+		return nil, fmt.Errorf("unable to parse describe-tags output: %w", err)
+	}
+	return tags, nil
+}
+
+// StartWorkSpace starts a stopped workspace, only workspaces in AutoStop running mode can be
+// started and stopped.
+func StartWorkSpace(logger logr.Logger, region, workspaceID string) error {
+	return runWorkSpaceOperation(logger, "start-workspaces", "StartWorkspaces", "--start-workspace-requests", region, workspaceID)
+}
+
+// StopWorkSpace stops an available workspace.
+func StopWorkSpace(logger logr.Logger, region, workspaceID string) error {
+	return runWorkSpaceOperation(logger, "stop-workspaces", "StopWorkspaces", "--stop-workspace-requests", region, workspaceID)
+}
+
+// runWorkSpaceOperation runs the operation for the workspace, the operations report failures as
+// failed requests instead of errors, which are returned as *AWSError.
+func runWorkSpaceOperation(logger logr.Logger, command, operation, requestsFlag, region, workspaceID string) error {
+	out, err := runCMD(logger, "workspaces", command, "--region", ResolveRegion(region), requestsFlag, "WorkspaceId="+workspaceID,
+		"--query", "FailedRequests", "--output", "json")
+	if err != nil {
+		return err
+	}
+	if err := parseFailedWorkSpaceRequests(operation, out); err != nil {
+		return err
+	}
+	logger.Info("successfully issued workspaces operation", "operation", command, "workspace", workspaceID)
+	return nil
+}
+
+// parseFailedWorkSpaceRequests returns the first failed request of the output as *AWSError.
+func parseFailedWorkSpaceRequests(operation string, out []byte) error {
+	var failed []struct {
+		WorkspaceID  string `json:"WorkspaceId"`
+		ErrorCode    string `json:"ErrorCode"`
+		ErrorMessage string `json:"ErrorMessage"`
+	}
+	if trimmed := strings.TrimSpace(string(out)); trimmed == "" || trimmed == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(out, &failed); err != nil {
+		return fmt.Errorf("unable to parse %s output: %w", operation, err)
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &AWSError{Code: failed[0].ErrorCode, Operation: operation, Message: failed[0].ErrorMessage}
+}
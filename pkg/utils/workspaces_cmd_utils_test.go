@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseFailedWorkSpaceRequests(t *testing.T) {
+	for _, out := range []string{"", "null", "[]"} {
+		if err := parseFailedWorkSpaceRequests("StopWorkspaces", []byte(out)); err != nil {
+			t.Errorf("expected no error for %q, got %v", out, err)
+		}
+	}
+
+	err := parseFailedWorkSpaceRequests("StopWorkspaces",
+		[]byte(`[{"WorkspaceId": "ws-1", "ErrorCode": "InvalidResourceStateException", "ErrorMessage": "The workspace is in AlwaysOn mode."}]`))
+	var awsErr *AWSError
+	if !errors.As(err, &awsErr) || awsErr.Code != "InvalidResourceStateException" || awsErr.Operation != "StopWorkspaces" {
+		t.Fatalf("expected an aws error, got %v", err)
+	}
+	if reason, action := ClassifyError(err); reason != ReasonIncorrectInstanceState || action != ActionSkip {
+		t.Errorf("expected the workspace to be skipped, got %s (action: %s)", reason, action)
+	}
+}